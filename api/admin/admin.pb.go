@@ -0,0 +1,1475 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.0
+// source: admin.proto
+
+package admin
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type NodeGroup struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MinSize    int32                  `protobuf:"varint,2,opt,name=min_size,json=minSize,proto3" json:"min_size,omitempty"`
+	MaxSize    int32                  `protobuf:"varint,3,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`
+	TargetSize int32                  `protobuf:"varint,4,opt,name=target_size,json=targetSize,proto3" json:"target_size,omitempty"`
+	// Bumped on every update, including configuration reloads changing the node group.
+	ResourceVersion uint64            `protobuf:"varint,5,opt,name=resource_version,json=resourceVersion,proto3" json:"resource_version,omitempty"`
+	Labels          map[string]string `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Flavor          string            `protobuf:"bytes,7,opt,name=flavor,proto3" json:"flavor,omitempty"`
+	Image           string            `protobuf:"bytes,8,opt,name=image,proto3" json:"image,omitempty"`
+	// Why scale-ups and scale-downs are paused, empty when they are allowed.
+	Quarantine    string `protobuf:"bytes,9,opt,name=quarantine,proto3" json:"quarantine,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeGroup) Reset() {
+	*x = NodeGroup{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeGroup) ProtoMessage() {}
+
+func (x *NodeGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeGroup.ProtoReflect.Descriptor instead.
+func (*NodeGroup) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NodeGroup) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NodeGroup) GetMinSize() int32 {
+	if x != nil {
+		return x.MinSize
+	}
+	return 0
+}
+
+func (x *NodeGroup) GetMaxSize() int32 {
+	if x != nil {
+		return x.MaxSize
+	}
+	return 0
+}
+
+func (x *NodeGroup) GetTargetSize() int32 {
+	if x != nil {
+		return x.TargetSize
+	}
+	return 0
+}
+
+func (x *NodeGroup) GetResourceVersion() uint64 {
+	if x != nil {
+		return x.ResourceVersion
+	}
+	return 0
+}
+
+func (x *NodeGroup) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *NodeGroup) GetFlavor() string {
+	if x != nil {
+		return x.Flavor
+	}
+	return ""
+}
+
+func (x *NodeGroup) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *NodeGroup) GetQuarantine() string {
+	if x != nil {
+		return x.Quarantine
+	}
+	return ""
+}
+
+type ListNodeGroupsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodeGroupsRequest) Reset() {
+	*x = ListNodeGroupsRequest{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodeGroupsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodeGroupsRequest) ProtoMessage() {}
+
+func (x *ListNodeGroupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodeGroupsRequest.ProtoReflect.Descriptor instead.
+func (*ListNodeGroupsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+type ListNodeGroupsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeGroups    []*NodeGroup           `protobuf:"bytes,1,rep,name=node_groups,json=nodeGroups,proto3" json:"node_groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodeGroupsResponse) Reset() {
+	*x = ListNodeGroupsResponse{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodeGroupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodeGroupsResponse) ProtoMessage() {}
+
+func (x *ListNodeGroupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodeGroupsResponse.ProtoReflect.Descriptor instead.
+func (*ListNodeGroupsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListNodeGroupsResponse) GetNodeGroups() []*NodeGroup {
+	if x != nil {
+		return x.NodeGroups
+	}
+	return nil
+}
+
+type UpdateNodeGroupRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Resource version the update is based on.
+	ResourceVersion uint64 `protobuf:"varint,2,opt,name=resource_version,json=resourceVersion,proto3" json:"resource_version,omitempty"`
+	MinSize         *int32 `protobuf:"varint,3,opt,name=min_size,json=minSize,proto3,oneof" json:"min_size,omitempty"`
+	MaxSize         *int32 `protobuf:"varint,4,opt,name=max_size,json=maxSize,proto3,oneof" json:"max_size,omitempty"`
+	// Replaces the node labels when replace_labels is set.
+	Labels        map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ReplaceLabels bool              `protobuf:"varint,6,opt,name=replace_labels,json=replaceLabels,proto3" json:"replace_labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateNodeGroupRequest) Reset() {
+	*x = UpdateNodeGroupRequest{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateNodeGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateNodeGroupRequest) ProtoMessage() {}
+
+func (x *UpdateNodeGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateNodeGroupRequest.ProtoReflect.Descriptor instead.
+func (*UpdateNodeGroupRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateNodeGroupRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateNodeGroupRequest) GetResourceVersion() uint64 {
+	if x != nil {
+		return x.ResourceVersion
+	}
+	return 0
+}
+
+func (x *UpdateNodeGroupRequest) GetMinSize() int32 {
+	if x != nil && x.MinSize != nil {
+		return *x.MinSize
+	}
+	return 0
+}
+
+func (x *UpdateNodeGroupRequest) GetMaxSize() int32 {
+	if x != nil && x.MaxSize != nil {
+		return *x.MaxSize
+	}
+	return 0
+}
+
+func (x *UpdateNodeGroupRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *UpdateNodeGroupRequest) GetReplaceLabels() bool {
+	if x != nil {
+		return x.ReplaceLabels
+	}
+	return false
+}
+
+type UpdateNodeGroupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeGroup     *NodeGroup             `protobuf:"bytes,1,opt,name=node_group,json=nodeGroup,proto3" json:"node_group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateNodeGroupResponse) Reset() {
+	*x = UpdateNodeGroupResponse{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateNodeGroupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateNodeGroupResponse) ProtoMessage() {}
+
+func (x *UpdateNodeGroupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateNodeGroupResponse.ProtoReflect.Descriptor instead.
+func (*UpdateNodeGroupResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateNodeGroupResponse) GetNodeGroup() *NodeGroup {
+	if x != nil {
+		return x.NodeGroup
+	}
+	return nil
+}
+
+type CleanupServersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "orphans" selects the servers of node groups that are no longer configured, "all" every
+	// server the autoscaler created for the cluster.
+	Mode string `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Deletes the selected servers, otherwise they are only listed.
+	Execute bool `protobuf:"varint,2,opt,name=execute,proto3" json:"execute,omitempty"`
+	// Why the operator cleans up, logged with every deletion.
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CleanupServersRequest) Reset() {
+	*x = CleanupServersRequest{}
+	mi := &file_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CleanupServersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleanupServersRequest) ProtoMessage() {}
+
+func (x *CleanupServersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleanupServersRequest.ProtoReflect.Descriptor instead.
+func (*CleanupServersRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CleanupServersRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *CleanupServersRequest) GetExecute() bool {
+	if x != nil {
+		return x.Execute
+	}
+	return false
+}
+
+func (x *CleanupServersRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CleanupServer struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	NodeGroup string                 `protobuf:"bytes,3,opt,name=node_group,json=nodeGroup,proto3" json:"node_group,omitempty"`
+	Deleted   bool                   `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// Why deleting the server failed.
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CleanupServer) Reset() {
+	*x = CleanupServer{}
+	mi := &file_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CleanupServer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleanupServer) ProtoMessage() {}
+
+func (x *CleanupServer) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleanupServer.ProtoReflect.Descriptor instead.
+func (*CleanupServer) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CleanupServer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CleanupServer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CleanupServer) GetNodeGroup() string {
+	if x != nil {
+		return x.NodeGroup
+	}
+	return ""
+}
+
+func (x *CleanupServer) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *CleanupServer) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type CleanupServersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Servers       []*CleanupServer       `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CleanupServersResponse) Reset() {
+	*x = CleanupServersResponse{}
+	mi := &file_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CleanupServersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleanupServersResponse) ProtoMessage() {}
+
+func (x *CleanupServersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleanupServersResponse.ProtoReflect.Descriptor instead.
+func (*CleanupServersResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CleanupServersResponse) GetServers() []*CleanupServer {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+type PlannedServer struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty for servers a scale-up would create.
+	Id               string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	AvailabilityZone string `protobuf:"bytes,3,opt,name=availability_zone,json=availabilityZone,proto3" json:"availability_zone,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PlannedServer) Reset() {
+	*x = PlannedServer{}
+	mi := &file_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedServer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedServer) ProtoMessage() {}
+
+func (x *PlannedServer) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedServer.ProtoReflect.Descriptor instead.
+func (*PlannedServer) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PlannedServer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlannedServer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PlannedServer) GetAvailabilityZone() string {
+	if x != nil {
+		return x.AvailabilityZone
+	}
+	return ""
+}
+
+type PlanRejection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanRejection) Reset() {
+	*x = PlanRejection{}
+	mi := &file_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanRejection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanRejection) ProtoMessage() {}
+
+func (x *PlanRejection) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanRejection.ProtoReflect.Descriptor instead.
+func (*PlanRejection) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PlanRejection) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PlanRejection) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ScalePlan struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	NodeGroup   string                 `protobuf:"bytes,1,opt,name=node_group,json=nodeGroup,proto3" json:"node_group,omitempty"`
+	CurrentSize int32                  `protobuf:"varint,2,opt,name=current_size,json=currentSize,proto3" json:"current_size,omitempty"`
+	Create      []*PlannedServer       `protobuf:"bytes,3,rep,name=create,proto3" json:"create,omitempty"`
+	Delete      []*PlannedServer       `protobuf:"bytes,4,rep,name=delete,proto3" json:"delete,omitempty"`
+	Rejected    []*PlanRejection       `protobuf:"bytes,5,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	// Why the operation would be refused as a whole, empty when it would go ahead.
+	Refusal       string `protobuf:"bytes,6,opt,name=refusal,proto3" json:"refusal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScalePlan) Reset() {
+	*x = ScalePlan{}
+	mi := &file_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScalePlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScalePlan) ProtoMessage() {}
+
+func (x *ScalePlan) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScalePlan.ProtoReflect.Descriptor instead.
+func (*ScalePlan) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ScalePlan) GetNodeGroup() string {
+	if x != nil {
+		return x.NodeGroup
+	}
+	return ""
+}
+
+func (x *ScalePlan) GetCurrentSize() int32 {
+	if x != nil {
+		return x.CurrentSize
+	}
+	return 0
+}
+
+func (x *ScalePlan) GetCreate() []*PlannedServer {
+	if x != nil {
+		return x.Create
+	}
+	return nil
+}
+
+func (x *ScalePlan) GetDelete() []*PlannedServer {
+	if x != nil {
+		return x.Delete
+	}
+	return nil
+}
+
+func (x *ScalePlan) GetRejected() []*PlanRejection {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+func (x *ScalePlan) GetRefusal() string {
+	if x != nil {
+		return x.Refusal
+	}
+	return ""
+}
+
+type PlanIncreaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Delta         int32                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanIncreaseRequest) Reset() {
+	*x = PlanIncreaseRequest{}
+	mi := &file_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanIncreaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanIncreaseRequest) ProtoMessage() {}
+
+func (x *PlanIncreaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanIncreaseRequest.ProtoReflect.Descriptor instead.
+func (*PlanIncreaseRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PlanIncreaseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlanIncreaseRequest) GetDelta() int32 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+type PlanIncreaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plan          *ScalePlan             `protobuf:"bytes,1,opt,name=plan,proto3" json:"plan,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanIncreaseResponse) Reset() {
+	*x = PlanIncreaseResponse{}
+	mi := &file_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanIncreaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanIncreaseResponse) ProtoMessage() {}
+
+func (x *PlanIncreaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanIncreaseResponse.ProtoReflect.Descriptor instead.
+func (*PlanIncreaseResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PlanIncreaseResponse) GetPlan() *ScalePlan {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+type PlanDeleteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Names of the nodes to delete, resolved to servers by name.
+	NodeNames     []string `protobuf:"bytes,2,rep,name=node_names,json=nodeNames,proto3" json:"node_names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanDeleteRequest) Reset() {
+	*x = PlanDeleteRequest{}
+	mi := &file_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanDeleteRequest) ProtoMessage() {}
+
+func (x *PlanDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanDeleteRequest.ProtoReflect.Descriptor instead.
+func (*PlanDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PlanDeleteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlanDeleteRequest) GetNodeNames() []string {
+	if x != nil {
+		return x.NodeNames
+	}
+	return nil
+}
+
+type PlanDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plan          *ScalePlan             `protobuf:"bytes,1,opt,name=plan,proto3" json:"plan,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanDeleteResponse) Reset() {
+	*x = PlanDeleteResponse{}
+	mi := &file_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanDeleteResponse) ProtoMessage() {}
+
+func (x *PlanDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanDeleteResponse.ProtoReflect.Descriptor instead.
+func (*PlanDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PlanDeleteResponse) GetPlan() *ScalePlan {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+type EvacuateNodeGroupRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SourceId string                 `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	TargetId string                 `protobuf:"bytes,2,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	// Number of servers replaced and removed at a time, 1 when unset.
+	BatchSize int32 `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	// Only plans the batches without changing anything.
+	DryRun bool `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Why the operator evacuates, recorded in the created_by_reason metadata of the replacements
+	// and logged with every deletion.
+	Reason        string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvacuateNodeGroupRequest) Reset() {
+	*x = EvacuateNodeGroupRequest{}
+	mi := &file_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvacuateNodeGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvacuateNodeGroupRequest) ProtoMessage() {}
+
+func (x *EvacuateNodeGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvacuateNodeGroupRequest.ProtoReflect.Descriptor instead.
+func (*EvacuateNodeGroupRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *EvacuateNodeGroupRequest) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *EvacuateNodeGroupRequest) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *EvacuateNodeGroupRequest) GetBatchSize() int32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+func (x *EvacuateNodeGroupRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *EvacuateNodeGroupRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type EvacuationBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerIds     []string               `protobuf:"bytes,1,rep,name=server_ids,json=serverIds,proto3" json:"server_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvacuationBatch) Reset() {
+	*x = EvacuationBatch{}
+	mi := &file_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvacuationBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvacuationBatch) ProtoMessage() {}
+
+func (x *EvacuationBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvacuationBatch.ProtoReflect.Descriptor instead.
+func (*EvacuationBatch) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *EvacuationBatch) GetServerIds() []string {
+	if x != nil {
+		return x.ServerIds
+	}
+	return nil
+}
+
+type EvacuateNodeGroupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Batches       []*EvacuationBatch     `protobuf:"bytes,1,rep,name=batches,proto3" json:"batches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvacuateNodeGroupResponse) Reset() {
+	*x = EvacuateNodeGroupResponse{}
+	mi := &file_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvacuateNodeGroupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvacuateNodeGroupResponse) ProtoMessage() {}
+
+func (x *EvacuateNodeGroupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvacuateNodeGroupResponse.ProtoReflect.Descriptor instead.
+func (*EvacuateNodeGroupResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *EvacuateNodeGroupResponse) GetBatches() []*EvacuationBatch {
+	if x != nil {
+		return x.Batches
+	}
+	return nil
+}
+
+type CancelEvacuationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelEvacuationRequest) Reset() {
+	*x = CancelEvacuationRequest{}
+	mi := &file_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelEvacuationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelEvacuationRequest) ProtoMessage() {}
+
+func (x *CancelEvacuationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelEvacuationRequest.ProtoReflect.Descriptor instead.
+func (*CancelEvacuationRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CancelEvacuationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CancelEvacuationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelEvacuationResponse) Reset() {
+	*x = CancelEvacuationResponse{}
+	mi := &file_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelEvacuationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelEvacuationResponse) ProtoMessage() {}
+
+func (x *CancelEvacuationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelEvacuationResponse.ProtoReflect.Descriptor instead.
+func (*CancelEvacuationResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{19}
+}
+
+type SetQuarantineRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// "forced" pauses mutations until reset, "disabled" never quarantines the node group and
+	// "auto" quarantines it based on its failure rate.
+	Override      string `protobuf:"bytes,2,opt,name=override,proto3" json:"override,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetQuarantineRequest) Reset() {
+	*x = SetQuarantineRequest{}
+	mi := &file_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetQuarantineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetQuarantineRequest) ProtoMessage() {}
+
+func (x *SetQuarantineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetQuarantineRequest.ProtoReflect.Descriptor instead.
+func (*SetQuarantineRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SetQuarantineRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetQuarantineRequest) GetOverride() string {
+	if x != nil {
+		return x.Override
+	}
+	return ""
+}
+
+type SetQuarantineResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeGroup     *NodeGroup             `protobuf:"bytes,1,opt,name=node_group,json=nodeGroup,proto3" json:"node_group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetQuarantineResponse) Reset() {
+	*x = SetQuarantineResponse{}
+	mi := &file_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetQuarantineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetQuarantineResponse) ProtoMessage() {}
+
+func (x *SetQuarantineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetQuarantineResponse.ProtoReflect.Descriptor instead.
+func (*SetQuarantineResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SetQuarantineResponse) GetNodeGroup() *NodeGroup {
+	if x != nil {
+		return x.NodeGroup
+	}
+	return nil
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\x1copenstackautoscaler.admin.v1\"\xf3\x02\n" +
+	"\tNodeGroup\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bmin_size\x18\x02 \x01(\x05R\aminSize\x12\x19\n" +
+	"\bmax_size\x18\x03 \x01(\x05R\amaxSize\x12\x1f\n" +
+	"\vtarget_size\x18\x04 \x01(\x05R\n" +
+	"targetSize\x12)\n" +
+	"\x10resource_version\x18\x05 \x01(\x04R\x0fresourceVersion\x12K\n" +
+	"\x06labels\x18\x06 \x03(\v23.openstackautoscaler.admin.v1.NodeGroup.LabelsEntryR\x06labels\x12\x16\n" +
+	"\x06flavor\x18\a \x01(\tR\x06flavor\x12\x14\n" +
+	"\x05image\x18\b \x01(\tR\x05image\x12\x1e\n" +
+	"\n" +
+	"quarantine\x18\t \x01(\tR\n" +
+	"quarantine\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x17\n" +
+	"\x15ListNodeGroupsRequest\"b\n" +
+	"\x16ListNodeGroupsResponse\x12H\n" +
+	"\vnode_groups\x18\x01 \x03(\v2'.openstackautoscaler.admin.v1.NodeGroupR\n" +
+	"nodeGroups\"\xe9\x02\n" +
+	"\x16UpdateNodeGroupRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
+	"\x10resource_version\x18\x02 \x01(\x04R\x0fresourceVersion\x12\x1e\n" +
+	"\bmin_size\x18\x03 \x01(\x05H\x00R\aminSize\x88\x01\x01\x12\x1e\n" +
+	"\bmax_size\x18\x04 \x01(\x05H\x01R\amaxSize\x88\x01\x01\x12X\n" +
+	"\x06labels\x18\x05 \x03(\v2@.openstackautoscaler.admin.v1.UpdateNodeGroupRequest.LabelsEntryR\x06labels\x12%\n" +
+	"\x0ereplace_labels\x18\x06 \x01(\bR\rreplaceLabels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\v\n" +
+	"\t_min_sizeB\v\n" +
+	"\t_max_size\"a\n" +
+	"\x17UpdateNodeGroupResponse\x12F\n" +
+	"\n" +
+	"node_group\x18\x01 \x01(\v2'.openstackautoscaler.admin.v1.NodeGroupR\tnodeGroup\"]\n" +
+	"\x15CleanupServersRequest\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x18\n" +
+	"\aexecute\x18\x02 \x01(\bR\aexecute\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\x82\x01\n" +
+	"\rCleanupServer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"node_group\x18\x03 \x01(\tR\tnodeGroup\x12\x18\n" +
+	"\adeleted\x18\x04 \x01(\bR\adeleted\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"_\n" +
+	"\x16CleanupServersResponse\x12E\n" +
+	"\aservers\x18\x01 \x03(\v2+.openstackautoscaler.admin.v1.CleanupServerR\aservers\"`\n" +
+	"\rPlannedServer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12+\n" +
+	"\x11availability_zone\x18\x03 \x01(\tR\x10availabilityZone\";\n" +
+	"\rPlanRejection\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xba\x02\n" +
+	"\tScalePlan\x12\x1d\n" +
+	"\n" +
+	"node_group\x18\x01 \x01(\tR\tnodeGroup\x12!\n" +
+	"\fcurrent_size\x18\x02 \x01(\x05R\vcurrentSize\x12C\n" +
+	"\x06create\x18\x03 \x03(\v2+.openstackautoscaler.admin.v1.PlannedServerR\x06create\x12C\n" +
+	"\x06delete\x18\x04 \x03(\v2+.openstackautoscaler.admin.v1.PlannedServerR\x06delete\x12G\n" +
+	"\brejected\x18\x05 \x03(\v2+.openstackautoscaler.admin.v1.PlanRejectionR\brejected\x12\x18\n" +
+	"\arefusal\x18\x06 \x01(\tR\arefusal\";\n" +
+	"\x13PlanIncreaseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x05R\x05delta\"S\n" +
+	"\x14PlanIncreaseResponse\x12;\n" +
+	"\x04plan\x18\x01 \x01(\v2'.openstackautoscaler.admin.v1.ScalePlanR\x04plan\"B\n" +
+	"\x11PlanDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"node_names\x18\x02 \x03(\tR\tnodeNames\"Q\n" +
+	"\x12PlanDeleteResponse\x12;\n" +
+	"\x04plan\x18\x01 \x01(\v2'.openstackautoscaler.admin.v1.ScalePlanR\x04plan\"\xa4\x01\n" +
+	"\x18EvacuateNodeGroupRequest\x12\x1b\n" +
+	"\tsource_id\x18\x01 \x01(\tR\bsourceId\x12\x1b\n" +
+	"\ttarget_id\x18\x02 \x01(\tR\btargetId\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x03 \x01(\x05R\tbatchSize\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"0\n" +
+	"\x0fEvacuationBatch\x12\x1d\n" +
+	"\n" +
+	"server_ids\x18\x01 \x03(\tR\tserverIds\"d\n" +
+	"\x19EvacuateNodeGroupResponse\x12G\n" +
+	"\abatches\x18\x01 \x03(\v2-.openstackautoscaler.admin.v1.EvacuationBatchR\abatches\")\n" +
+	"\x17CancelEvacuationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1a\n" +
+	"\x18CancelEvacuationResponse\"B\n" +
+	"\x14SetQuarantineRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\boverride\x18\x02 \x01(\tR\boverride\"_\n" +
+	"\x15SetQuarantineResponse\x12F\n" +
+	"\n" +
+	"node_group\x18\x01 \x01(\v2'.openstackautoscaler.admin.v1.NodeGroupR\tnodeGroup2\xff\a\n" +
+	"\x05Admin\x12}\n" +
+	"\x0eListNodeGroups\x123.openstackautoscaler.admin.v1.ListNodeGroupsRequest\x1a4.openstackautoscaler.admin.v1.ListNodeGroupsResponse\"\x00\x12\x80\x01\n" +
+	"\x0fUpdateNodeGroup\x124.openstackautoscaler.admin.v1.UpdateNodeGroupRequest\x1a5.openstackautoscaler.admin.v1.UpdateNodeGroupResponse\"\x00\x12}\n" +
+	"\x0eCleanupServers\x123.openstackautoscaler.admin.v1.CleanupServersRequest\x1a4.openstackautoscaler.admin.v1.CleanupServersResponse\"\x00\x12w\n" +
+	"\fPlanIncrease\x121.openstackautoscaler.admin.v1.PlanIncreaseRequest\x1a2.openstackautoscaler.admin.v1.PlanIncreaseResponse\"\x00\x12q\n" +
+	"\n" +
+	"PlanDelete\x12/.openstackautoscaler.admin.v1.PlanDeleteRequest\x1a0.openstackautoscaler.admin.v1.PlanDeleteResponse\"\x00\x12\x86\x01\n" +
+	"\x11EvacuateNodeGroup\x126.openstackautoscaler.admin.v1.EvacuateNodeGroupRequest\x1a7.openstackautoscaler.admin.v1.EvacuateNodeGroupResponse\"\x00\x12\x83\x01\n" +
+	"\x10CancelEvacuation\x125.openstackautoscaler.admin.v1.CancelEvacuationRequest\x1a6.openstackautoscaler.admin.v1.CancelEvacuationResponse\"\x00\x12z\n" +
+	"\rSetQuarantine\x122.openstackautoscaler.admin.v1.SetQuarantineRequest\x1a3.openstackautoscaler.admin.v1.SetQuarantineResponse\"\x00B;Z9github.com/bucher-brothers/openstack-autoscaler/api/adminb\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_admin_proto_goTypes = []any{
+	(*NodeGroup)(nil),                 // 0: openstackautoscaler.admin.v1.NodeGroup
+	(*ListNodeGroupsRequest)(nil),     // 1: openstackautoscaler.admin.v1.ListNodeGroupsRequest
+	(*ListNodeGroupsResponse)(nil),    // 2: openstackautoscaler.admin.v1.ListNodeGroupsResponse
+	(*UpdateNodeGroupRequest)(nil),    // 3: openstackautoscaler.admin.v1.UpdateNodeGroupRequest
+	(*UpdateNodeGroupResponse)(nil),   // 4: openstackautoscaler.admin.v1.UpdateNodeGroupResponse
+	(*CleanupServersRequest)(nil),     // 5: openstackautoscaler.admin.v1.CleanupServersRequest
+	(*CleanupServer)(nil),             // 6: openstackautoscaler.admin.v1.CleanupServer
+	(*CleanupServersResponse)(nil),    // 7: openstackautoscaler.admin.v1.CleanupServersResponse
+	(*PlannedServer)(nil),             // 8: openstackautoscaler.admin.v1.PlannedServer
+	(*PlanRejection)(nil),             // 9: openstackautoscaler.admin.v1.PlanRejection
+	(*ScalePlan)(nil),                 // 10: openstackautoscaler.admin.v1.ScalePlan
+	(*PlanIncreaseRequest)(nil),       // 11: openstackautoscaler.admin.v1.PlanIncreaseRequest
+	(*PlanIncreaseResponse)(nil),      // 12: openstackautoscaler.admin.v1.PlanIncreaseResponse
+	(*PlanDeleteRequest)(nil),         // 13: openstackautoscaler.admin.v1.PlanDeleteRequest
+	(*PlanDeleteResponse)(nil),        // 14: openstackautoscaler.admin.v1.PlanDeleteResponse
+	(*EvacuateNodeGroupRequest)(nil),  // 15: openstackautoscaler.admin.v1.EvacuateNodeGroupRequest
+	(*EvacuationBatch)(nil),           // 16: openstackautoscaler.admin.v1.EvacuationBatch
+	(*EvacuateNodeGroupResponse)(nil), // 17: openstackautoscaler.admin.v1.EvacuateNodeGroupResponse
+	(*CancelEvacuationRequest)(nil),   // 18: openstackautoscaler.admin.v1.CancelEvacuationRequest
+	(*CancelEvacuationResponse)(nil),  // 19: openstackautoscaler.admin.v1.CancelEvacuationResponse
+	(*SetQuarantineRequest)(nil),      // 20: openstackautoscaler.admin.v1.SetQuarantineRequest
+	(*SetQuarantineResponse)(nil),     // 21: openstackautoscaler.admin.v1.SetQuarantineResponse
+	nil,                               // 22: openstackautoscaler.admin.v1.NodeGroup.LabelsEntry
+	nil,                               // 23: openstackautoscaler.admin.v1.UpdateNodeGroupRequest.LabelsEntry
+}
+var file_admin_proto_depIdxs = []int32{
+	22, // 0: openstackautoscaler.admin.v1.NodeGroup.labels:type_name -> openstackautoscaler.admin.v1.NodeGroup.LabelsEntry
+	0,  // 1: openstackautoscaler.admin.v1.ListNodeGroupsResponse.node_groups:type_name -> openstackautoscaler.admin.v1.NodeGroup
+	23, // 2: openstackautoscaler.admin.v1.UpdateNodeGroupRequest.labels:type_name -> openstackautoscaler.admin.v1.UpdateNodeGroupRequest.LabelsEntry
+	0,  // 3: openstackautoscaler.admin.v1.UpdateNodeGroupResponse.node_group:type_name -> openstackautoscaler.admin.v1.NodeGroup
+	6,  // 4: openstackautoscaler.admin.v1.CleanupServersResponse.servers:type_name -> openstackautoscaler.admin.v1.CleanupServer
+	8,  // 5: openstackautoscaler.admin.v1.ScalePlan.create:type_name -> openstackautoscaler.admin.v1.PlannedServer
+	8,  // 6: openstackautoscaler.admin.v1.ScalePlan.delete:type_name -> openstackautoscaler.admin.v1.PlannedServer
+	9,  // 7: openstackautoscaler.admin.v1.ScalePlan.rejected:type_name -> openstackautoscaler.admin.v1.PlanRejection
+	10, // 8: openstackautoscaler.admin.v1.PlanIncreaseResponse.plan:type_name -> openstackautoscaler.admin.v1.ScalePlan
+	10, // 9: openstackautoscaler.admin.v1.PlanDeleteResponse.plan:type_name -> openstackautoscaler.admin.v1.ScalePlan
+	16, // 10: openstackautoscaler.admin.v1.EvacuateNodeGroupResponse.batches:type_name -> openstackautoscaler.admin.v1.EvacuationBatch
+	0,  // 11: openstackautoscaler.admin.v1.SetQuarantineResponse.node_group:type_name -> openstackautoscaler.admin.v1.NodeGroup
+	1,  // 12: openstackautoscaler.admin.v1.Admin.ListNodeGroups:input_type -> openstackautoscaler.admin.v1.ListNodeGroupsRequest
+	3,  // 13: openstackautoscaler.admin.v1.Admin.UpdateNodeGroup:input_type -> openstackautoscaler.admin.v1.UpdateNodeGroupRequest
+	5,  // 14: openstackautoscaler.admin.v1.Admin.CleanupServers:input_type -> openstackautoscaler.admin.v1.CleanupServersRequest
+	11, // 15: openstackautoscaler.admin.v1.Admin.PlanIncrease:input_type -> openstackautoscaler.admin.v1.PlanIncreaseRequest
+	13, // 16: openstackautoscaler.admin.v1.Admin.PlanDelete:input_type -> openstackautoscaler.admin.v1.PlanDeleteRequest
+	15, // 17: openstackautoscaler.admin.v1.Admin.EvacuateNodeGroup:input_type -> openstackautoscaler.admin.v1.EvacuateNodeGroupRequest
+	18, // 18: openstackautoscaler.admin.v1.Admin.CancelEvacuation:input_type -> openstackautoscaler.admin.v1.CancelEvacuationRequest
+	20, // 19: openstackautoscaler.admin.v1.Admin.SetQuarantine:input_type -> openstackautoscaler.admin.v1.SetQuarantineRequest
+	2,  // 20: openstackautoscaler.admin.v1.Admin.ListNodeGroups:output_type -> openstackautoscaler.admin.v1.ListNodeGroupsResponse
+	4,  // 21: openstackautoscaler.admin.v1.Admin.UpdateNodeGroup:output_type -> openstackautoscaler.admin.v1.UpdateNodeGroupResponse
+	7,  // 22: openstackautoscaler.admin.v1.Admin.CleanupServers:output_type -> openstackautoscaler.admin.v1.CleanupServersResponse
+	12, // 23: openstackautoscaler.admin.v1.Admin.PlanIncrease:output_type -> openstackautoscaler.admin.v1.PlanIncreaseResponse
+	14, // 24: openstackautoscaler.admin.v1.Admin.PlanDelete:output_type -> openstackautoscaler.admin.v1.PlanDeleteResponse
+	17, // 25: openstackautoscaler.admin.v1.Admin.EvacuateNodeGroup:output_type -> openstackautoscaler.admin.v1.EvacuateNodeGroupResponse
+	19, // 26: openstackautoscaler.admin.v1.Admin.CancelEvacuation:output_type -> openstackautoscaler.admin.v1.CancelEvacuationResponse
+	21, // 27: openstackautoscaler.admin.v1.Admin.SetQuarantine:output_type -> openstackautoscaler.admin.v1.SetQuarantineResponse
+	20, // [20:28] is the sub-list for method output_type
+	12, // [12:20] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	file_admin_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
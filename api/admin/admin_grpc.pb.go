@@ -0,0 +1,425 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.33.0
+// source: admin.proto
+
+package admin
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Admin_ListNodeGroups_FullMethodName    = "/openstackautoscaler.admin.v1.Admin/ListNodeGroups"
+	Admin_UpdateNodeGroup_FullMethodName   = "/openstackautoscaler.admin.v1.Admin/UpdateNodeGroup"
+	Admin_CleanupServers_FullMethodName    = "/openstackautoscaler.admin.v1.Admin/CleanupServers"
+	Admin_PlanIncrease_FullMethodName      = "/openstackautoscaler.admin.v1.Admin/PlanIncrease"
+	Admin_PlanDelete_FullMethodName        = "/openstackautoscaler.admin.v1.Admin/PlanDelete"
+	Admin_EvacuateNodeGroup_FullMethodName = "/openstackautoscaler.admin.v1.Admin/EvacuateNodeGroup"
+	Admin_CancelEvacuation_FullMethodName  = "/openstackautoscaler.admin.v1.Admin/CancelEvacuation"
+	Admin_SetQuarantine_FullMethodName     = "/openstackautoscaler.admin.v1.Admin/SetQuarantine"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Admin serves operator actions on the running autoscaler. It is only exposed when
+// --admin-address is set and is not part of the cluster autoscaler protocol.
+type AdminClient interface {
+	// ListNodeGroups returns every node group with its resource version.
+	ListNodeGroups(ctx context.Context, in *ListNodeGroupsRequest, opts ...grpc.CallOption) (*ListNodeGroupsResponse, error)
+	// UpdateNodeGroup changes the size limits or labels of a node group. The update must carry
+	// the current resource version, a stale version fails with code Aborted.
+	UpdateNodeGroup(ctx context.Context, in *UpdateNodeGroupRequest, opts ...grpc.CallOption) (*UpdateNodeGroupResponse, error)
+	// CleanupServers lists the servers the autoscaler created for this cluster that the mode
+	// selects, and deletes them when execute is set.
+	CleanupServers(ctx context.Context, in *CleanupServersRequest, opts ...grpc.CallOption) (*CleanupServersResponse, error)
+	// PlanIncrease returns the servers increasing the node group by delta would create, computed
+	// by the same checks as a real scale-up without changing anything.
+	PlanIncrease(ctx context.Context, in *PlanIncreaseRequest, opts ...grpc.CallOption) (*PlanIncreaseResponse, error)
+	// PlanDelete returns which of the named nodes deleting them from the node group would remove,
+	// computed by the same checks as a real scale-down without changing anything.
+	PlanDelete(ctx context.Context, in *PlanDeleteRequest, opts ...grpc.CallOption) (*PlanDeleteResponse, error)
+	// EvacuateNodeGroup starts moving all servers of a node group to a target node group, batch by
+	// batch: replacements are added to the target and once ACTIVE the nodes of the batch are drained
+	// and their servers deleted. Evacuations the node groups cannot accommodate fail with code
+	// FailedPrecondition.
+	EvacuateNodeGroup(ctx context.Context, in *EvacuateNodeGroupRequest, opts ...grpc.CallOption) (*EvacuateNodeGroupResponse, error)
+	// CancelEvacuation stops a running evacuation before its next step.
+	CancelEvacuation(ctx context.Context, in *CancelEvacuationRequest, opts ...grpc.CallOption) (*CancelEvacuationResponse, error)
+	// SetQuarantine manually quarantines a node group, exempts it from quarantine or returns it to
+	// automatic quarantine. Any automatic quarantine in effect is lifted.
+	SetQuarantine(ctx context.Context, in *SetQuarantineRequest, opts ...grpc.CallOption) (*SetQuarantineResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListNodeGroups(ctx context.Context, in *ListNodeGroupsRequest, opts ...grpc.CallOption) (*ListNodeGroupsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNodeGroupsResponse)
+	err := c.cc.Invoke(ctx, Admin_ListNodeGroups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) UpdateNodeGroup(ctx context.Context, in *UpdateNodeGroupRequest, opts ...grpc.CallOption) (*UpdateNodeGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateNodeGroupResponse)
+	err := c.cc.Invoke(ctx, Admin_UpdateNodeGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CleanupServers(ctx context.Context, in *CleanupServersRequest, opts ...grpc.CallOption) (*CleanupServersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CleanupServersResponse)
+	err := c.cc.Invoke(ctx, Admin_CleanupServers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) PlanIncrease(ctx context.Context, in *PlanIncreaseRequest, opts ...grpc.CallOption) (*PlanIncreaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlanIncreaseResponse)
+	err := c.cc.Invoke(ctx, Admin_PlanIncrease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) PlanDelete(ctx context.Context, in *PlanDeleteRequest, opts ...grpc.CallOption) (*PlanDeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlanDeleteResponse)
+	err := c.cc.Invoke(ctx, Admin_PlanDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) EvacuateNodeGroup(ctx context.Context, in *EvacuateNodeGroupRequest, opts ...grpc.CallOption) (*EvacuateNodeGroupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EvacuateNodeGroupResponse)
+	err := c.cc.Invoke(ctx, Admin_EvacuateNodeGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CancelEvacuation(ctx context.Context, in *CancelEvacuationRequest, opts ...grpc.CallOption) (*CancelEvacuationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelEvacuationResponse)
+	err := c.cc.Invoke(ctx, Admin_CancelEvacuation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SetQuarantine(ctx context.Context, in *SetQuarantineRequest, opts ...grpc.CallOption) (*SetQuarantineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetQuarantineResponse)
+	err := c.cc.Invoke(ctx, Admin_SetQuarantine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility.
+//
+// Admin serves operator actions on the running autoscaler. It is only exposed when
+// --admin-address is set and is not part of the cluster autoscaler protocol.
+type AdminServer interface {
+	// ListNodeGroups returns every node group with its resource version.
+	ListNodeGroups(context.Context, *ListNodeGroupsRequest) (*ListNodeGroupsResponse, error)
+	// UpdateNodeGroup changes the size limits or labels of a node group. The update must carry
+	// the current resource version, a stale version fails with code Aborted.
+	UpdateNodeGroup(context.Context, *UpdateNodeGroupRequest) (*UpdateNodeGroupResponse, error)
+	// CleanupServers lists the servers the autoscaler created for this cluster that the mode
+	// selects, and deletes them when execute is set.
+	CleanupServers(context.Context, *CleanupServersRequest) (*CleanupServersResponse, error)
+	// PlanIncrease returns the servers increasing the node group by delta would create, computed
+	// by the same checks as a real scale-up without changing anything.
+	PlanIncrease(context.Context, *PlanIncreaseRequest) (*PlanIncreaseResponse, error)
+	// PlanDelete returns which of the named nodes deleting them from the node group would remove,
+	// computed by the same checks as a real scale-down without changing anything.
+	PlanDelete(context.Context, *PlanDeleteRequest) (*PlanDeleteResponse, error)
+	// EvacuateNodeGroup starts moving all servers of a node group to a target node group, batch by
+	// batch: replacements are added to the target and once ACTIVE the nodes of the batch are drained
+	// and their servers deleted. Evacuations the node groups cannot accommodate fail with code
+	// FailedPrecondition.
+	EvacuateNodeGroup(context.Context, *EvacuateNodeGroupRequest) (*EvacuateNodeGroupResponse, error)
+	// CancelEvacuation stops a running evacuation before its next step.
+	CancelEvacuation(context.Context, *CancelEvacuationRequest) (*CancelEvacuationResponse, error)
+	// SetQuarantine manually quarantines a node group, exempts it from quarantine or returns it to
+	// automatic quarantine. Any automatic quarantine in effect is lifted.
+	SetQuarantine(context.Context, *SetQuarantineRequest) (*SetQuarantineResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServer struct{}
+
+func (UnimplementedAdminServer) ListNodeGroups(context.Context, *ListNodeGroupsRequest) (*ListNodeGroupsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodeGroups not implemented")
+}
+func (UnimplementedAdminServer) UpdateNodeGroup(context.Context, *UpdateNodeGroupRequest) (*UpdateNodeGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNodeGroup not implemented")
+}
+func (UnimplementedAdminServer) CleanupServers(context.Context, *CleanupServersRequest) (*CleanupServersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CleanupServers not implemented")
+}
+func (UnimplementedAdminServer) PlanIncrease(context.Context, *PlanIncreaseRequest) (*PlanIncreaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlanIncrease not implemented")
+}
+func (UnimplementedAdminServer) PlanDelete(context.Context, *PlanDeleteRequest) (*PlanDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlanDelete not implemented")
+}
+func (UnimplementedAdminServer) EvacuateNodeGroup(context.Context, *EvacuateNodeGroupRequest) (*EvacuateNodeGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvacuateNodeGroup not implemented")
+}
+func (UnimplementedAdminServer) CancelEvacuation(context.Context, *CancelEvacuationRequest) (*CancelEvacuationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelEvacuation not implemented")
+}
+func (UnimplementedAdminServer) SetQuarantine(context.Context, *SetQuarantineRequest) (*SetQuarantineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQuarantine not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+func (UnimplementedAdminServer) testEmbeddedByValue()               {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_ListNodeGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodeGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListNodeGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListNodeGroups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListNodeGroups(ctx, req.(*ListNodeGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_UpdateNodeGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNodeGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateNodeGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_UpdateNodeGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateNodeGroup(ctx, req.(*UpdateNodeGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CleanupServers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CleanupServersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CleanupServers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CleanupServers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CleanupServers(ctx, req.(*CleanupServersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_PlanIncrease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanIncreaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).PlanIncrease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_PlanIncrease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).PlanIncrease(ctx, req.(*PlanIncreaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_PlanDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).PlanDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_PlanDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).PlanDelete(ctx, req.(*PlanDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_EvacuateNodeGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvacuateNodeGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).EvacuateNodeGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_EvacuateNodeGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).EvacuateNodeGroup(ctx, req.(*EvacuateNodeGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CancelEvacuation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelEvacuationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CancelEvacuation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CancelEvacuation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CancelEvacuation(ctx, req.(*CancelEvacuationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetQuarantine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQuarantineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetQuarantine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_SetQuarantine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetQuarantine(ctx, req.(*SetQuarantineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "openstackautoscaler.admin.v1.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNodeGroups",
+			Handler:    _Admin_ListNodeGroups_Handler,
+		},
+		{
+			MethodName: "UpdateNodeGroup",
+			Handler:    _Admin_UpdateNodeGroup_Handler,
+		},
+		{
+			MethodName: "CleanupServers",
+			Handler:    _Admin_CleanupServers_Handler,
+		},
+		{
+			MethodName: "PlanIncrease",
+			Handler:    _Admin_PlanIncrease_Handler,
+		},
+		{
+			MethodName: "PlanDelete",
+			Handler:    _Admin_PlanDelete_Handler,
+		},
+		{
+			MethodName: "EvacuateNodeGroup",
+			Handler:    _Admin_EvacuateNodeGroup_Handler,
+		},
+		{
+			MethodName: "CancelEvacuation",
+			Handler:    _Admin_CancelEvacuation_Handler,
+		},
+		{
+			MethodName: "SetQuarantine",
+			Handler:    _Admin_SetQuarantine_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	adminpb "github.com/bucher-brothers/openstack-autoscaler/api/admin"
+)
+
+// adminCommand runs one admin subcommand with its own arguments
+type adminCommand func(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error
+
+// adminCommands are the subcommands of the admin command
+var adminCommands = map[string]adminCommand{
+	"cancel-evacuation": cancelEvacuation,
+	"cleanup-servers":   cleanupServers,
+	"evacuate":          evacuateNodeGroup,
+	"node-groups":       listNodeGroups,
+	"plan-delete":       planDelete,
+	"plan-increase":     planIncrease,
+	"quarantine":        setQuarantine,
+	"update-node-group": updateNodeGroup,
+}
+
+// runAdmin runs an admin subcommand against the admin service of a running autoscaler and
+// returns the exit code
+func runAdmin(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("admin", flag.ContinueOnError)
+	address := flags.String("address", "localhost:8087", "The address of the admin grpc service")
+	keyFile := flags.String("key-cert", "", "The path to the client certificate key file. Empty string for insecure communication")
+	certFile := flags.String("cert", "", "The path to the client certificate file. Empty string for insecure communication")
+	caFile := flags.String("ca-cert", "", "The path to the ca certificate file. Empty string for insecure communication")
+	timeout := flags.Duration("timeout", 30*time.Second, "Maximum time to wait for the admin service")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: openstack-autoscaler admin [flags] <command> [command flags]")
+		fmt.Fprintln(flags.Output(), "Commands:")
+		for _, name := range sortedAdminCommands() {
+			fmt.Fprintf(flags.Output(), "  %s\n", name)
+		}
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	name := flags.Arg(0)
+	command, exists := adminCommands[name]
+	if !exists {
+		fmt.Fprintf(flags.Output(), "unknown admin command %q\n", name)
+		flags.Usage()
+		return 2
+	}
+
+	transportCreds, err := adminTransportCredentials(*certFile, *keyFile, *caFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "admin: %v\n", err)
+		return 1
+	}
+	conn, err := grpc.NewClient(*address, grpc.WithTransportCredentials(transportCreds))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "admin: failed to connect to %s: %v\n", *address, err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := command(ctx, adminpb.NewAdminClient(conn), flags.Args()[1:], out); err != nil {
+		fmt.Fprintf(os.Stderr, "admin %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// sortedAdminCommands returns the names of the admin subcommands in order
+func sortedAdminCommands() []string {
+	names := make([]string, 0, len(adminCommands))
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// adminTransportCredentials returns mutual TLS credentials when all certificate files are set,
+// insecure credentials otherwise, matching how the server is configured
+func adminTransportCredentials(certFile, keyFile, caFile string) (credentials.TransportCredentials, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate files: %w", err)
+	}
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to append CA certificate")
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      certPool,
+	}), nil
+}
+
+// listNodeGroups prints the node groups as a table
+func listNodeGroups(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("node-groups", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	resp, err := client.ListNodeGroups(ctx, &adminpb.ListNodeGroupsRequest{})
+	if err != nil {
+		return err
+	}
+	return printNodeGroups(out, resp.NodeGroups)
+}
+
+// updateNodeGroup changes the size limits or labels of a node group and prints the result
+func updateNodeGroup(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("update-node-group", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the node group")
+	version := flags.Uint64("resource-version", 0, "Resource version the update is based on, as listed by node-groups")
+	minSize := flags.Int("min", -1, "New minimum size, unchanged when negative")
+	maxSize := flags.Int("max", -1, "New maximum size, unchanged when negative")
+	labels := map[string]string{}
+	replaceLabels := false
+	flags.Func("label", "Node label as key=value replacing all labels, repeat for several", func(value string) error {
+		key, labelValue, found := strings.Cut(value, "=")
+		if !found || key == "" {
+			return fmt.Errorf("label %q is not of the form key=value", value)
+		}
+		labels[key] = labelValue
+		replaceLabels = true
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" || *version == 0 {
+		return fmt.Errorf("--id and --resource-version are required")
+	}
+
+	req := &adminpb.UpdateNodeGroupRequest{
+		Id:              *id,
+		ResourceVersion: *version,
+		Labels:          labels,
+		ReplaceLabels:   replaceLabels,
+	}
+	if *minSize >= 0 {
+		req.MinSize = proto.Int32(int32(*minSize))
+	}
+	if *maxSize >= 0 {
+		req.MaxSize = proto.Int32(int32(*maxSize))
+	}
+
+	resp, err := client.UpdateNodeGroup(ctx, req)
+	if err != nil {
+		return err
+	}
+	return printNodeGroups(out, []*adminpb.NodeGroup{resp.NodeGroup})
+}
+
+// cleanupServers lists the autoscaler-created servers a cleanup selects and deletes them with --execute
+func cleanupServers(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("cleanup-servers", flag.ContinueOnError)
+	mode := flags.String("mode", "orphans", "Servers to select: orphans of node groups no longer configured, or all")
+	execute := flags.Bool("execute", false, "Delete the selected servers instead of listing them")
+	reason := flags.String("reason", "", "Why the servers are cleaned up, logged with every deletion")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	resp, err := client.CleanupServers(ctx, &adminpb.CleanupServersRequest{Mode: *mode, Execute: *execute, Reason: *reason})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tNODE GROUP\tSTATUS")
+	failed := 0
+	for _, server := range resp.Servers {
+		state := "would delete"
+		switch {
+		case server.Error != "":
+			state = "failed: " + server.Error
+			failed++
+		case server.Deleted:
+			state = "deleted"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", server.Id, server.Name, server.NodeGroup, state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d servers", failed, len(resp.Servers))
+	}
+	return nil
+}
+
+// planIncrease prints the servers a scale-up would create
+func planIncrease(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("plan-increase", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the node group")
+	delta := flags.Int("delta", 1, "Number of servers to add")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return fmt.Errorf("--id is required")
+	}
+
+	resp, err := client.PlanIncrease(ctx, &adminpb.PlanIncreaseRequest{Id: *id, Delta: int32(*delta)})
+	if err != nil {
+		return err
+	}
+	return printScalePlan(out, resp.Plan)
+}
+
+// planDelete prints which of the nodes given as arguments a scale-down would delete
+func planDelete(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("plan-delete", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the node group")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" || flags.NArg() == 0 {
+		return fmt.Errorf("--id and at least one node name are required")
+	}
+
+	resp, err := client.PlanDelete(ctx, &adminpb.PlanDeleteRequest{Id: *id, NodeNames: flags.Args()})
+	if err != nil {
+		return err
+	}
+	return printScalePlan(out, resp.Plan)
+}
+
+// evacuateNodeGroup starts an evacuation of a node group and prints its batches
+func evacuateNodeGroup(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("evacuate", flag.ContinueOnError)
+	source := flags.String("source", "", "ID of the node group to evacuate")
+	target := flags.String("target", "", "ID of the node group receiving the replacement capacity")
+	batchSize := flags.Int("batch-size", 1, "Number of servers replaced and removed at a time")
+	dryRun := flags.Bool("dry-run", false, "Print the batches without evacuating")
+	reason := flags.String("reason", "", "Why the node group is evacuated, recorded on the replacement servers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *source == "" || *target == "" {
+		return fmt.Errorf("--source and --target are required")
+	}
+
+	resp, err := client.EvacuateNodeGroup(ctx, &adminpb.EvacuateNodeGroupRequest{
+		SourceId:  *source,
+		TargetId:  *target,
+		BatchSize: int32(*batchSize),
+		DryRun:    *dryRun,
+		Reason:    *reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BATCH\tSERVERS")
+	for i, batch := range resp.Batches {
+		fmt.Fprintf(w, "%d\t%s\n", i+1, strings.Join(batch.ServerIds, ","))
+	}
+	return w.Flush()
+}
+
+// cancelEvacuation stops the running evacuation of a node group
+func cancelEvacuation(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("cancel-evacuation", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the node group being evacuated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return fmt.Errorf("--id is required")
+	}
+
+	if _, err := client.CancelEvacuation(ctx, &adminpb.CancelEvacuationRequest{Id: *id}); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Cancelling evacuation of node group %s\n", *id)
+	return nil
+}
+
+// setQuarantine changes the quarantine override of a node group and prints the node group
+func setQuarantine(ctx context.Context, client adminpb.AdminClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("quarantine", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the node group")
+	override := flags.String("override", "", "forced to pause scaling, disabled to never quarantine, auto to quarantine on failures")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" || *override == "" {
+		return fmt.Errorf("--id and --override are required")
+	}
+
+	resp, err := client.SetQuarantine(ctx, &adminpb.SetQuarantineRequest{Id: *id, Override: *override})
+	if err != nil {
+		return err
+	}
+	return printNodeGroups(out, []*adminpb.NodeGroup{resp.NodeGroup})
+}
+
+// printScalePlan renders a scaling plan as an aligned table, a refused plan fails the command
+func printScalePlan(out io.Writer, plan *adminpb.ScalePlan) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION	NAME	ID	ZONE	REASON")
+	for _, server := range plan.Create {
+		fmt.Fprintf(w, "create	%s	%s	%s	-\n", orDash(server.Name), orDash(server.Id), orDash(server.AvailabilityZone))
+	}
+	for _, server := range plan.Delete {
+		fmt.Fprintf(w, "delete	%s	%s	%s	-\n", orDash(server.Name), orDash(server.Id), orDash(server.AvailabilityZone))
+	}
+	for _, rejected := range plan.Rejected {
+		fmt.Fprintf(w, "keep	%s	-	-	%s\n", rejected.Name, rejected.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if plan.Refusal != "" {
+		return fmt.Errorf("node group %s would refuse the operation: %s", plan.NodeGroup, plan.Refusal)
+	}
+	return nil
+}
+
+// orDash returns value, or "-" when it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// printNodeGroups renders node groups as an aligned table
+func printNodeGroups(out io.Writer, nodeGroups []*adminpb.NodeGroup) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMIN\tMAX\tTARGET\tVERSION\tFLAVOR\tIMAGE\tQUARANTINE\tLABELS")
+	for _, ng := range nodeGroups {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n", ng.Id, ng.MinSize, ng.MaxSize, ng.TargetSize, ng.ResourceVersion, ng.Flavor, ng.Image, orDash(ng.Quarantine), formatLabels(ng.Labels))
+	}
+	return w.Flush()
+}
+
+// formatLabels renders labels as sorted key=value pairs, or "-" when there are none
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	adminpb "github.com/bucher-brothers/openstack-autoscaler/api/admin"
+)
+
+// stubAdminServer serves fixed node groups and records update requests
+type stubAdminServer struct {
+	adminpb.UnimplementedAdminServer
+	nodeGroups []*adminpb.NodeGroup
+	updates    []*adminpb.UpdateNodeGroupRequest
+}
+
+func (s *stubAdminServer) ListNodeGroups(ctx context.Context, req *adminpb.ListNodeGroupsRequest) (*adminpb.ListNodeGroupsResponse, error) {
+	return &adminpb.ListNodeGroupsResponse{NodeGroups: s.nodeGroups}, nil
+}
+
+func (s *stubAdminServer) CleanupServers(ctx context.Context, req *adminpb.CleanupServersRequest) (*adminpb.CleanupServersResponse, error) {
+	if req.Mode != "orphans" && req.Mode != "all" {
+		return nil, status.Error(codes.InvalidArgument, "unknown mode")
+	}
+	servers := []*adminpb.CleanupServer{
+		{Id: "s-1", Name: "old-1", NodeGroup: "old"},
+		{Id: "s-2", Name: "old-2", NodeGroup: "old"},
+	}
+	if req.Execute {
+		servers[0].Deleted = true
+		servers[1].Error = "conflict"
+	}
+	return &adminpb.CleanupServersResponse{Servers: servers}, nil
+}
+
+func (s *stubAdminServer) UpdateNodeGroup(ctx context.Context, req *adminpb.UpdateNodeGroupRequest) (*adminpb.UpdateNodeGroupResponse, error) {
+	s.updates = append(s.updates, req)
+	if req.ResourceVersion != s.nodeGroups[0].ResourceVersion {
+		return nil, status.Error(codes.Aborted, "stale resource version")
+	}
+	updated := proto.Clone(s.nodeGroups[0]).(*adminpb.NodeGroup)
+	updated.ResourceVersion++
+	if req.MaxSize != nil {
+		updated.MaxSize = *req.MaxSize
+	}
+	return &adminpb.UpdateNodeGroupResponse{NodeGroup: updated}, nil
+}
+
+func (s *stubAdminServer) PlanIncrease(ctx context.Context, req *adminpb.PlanIncreaseRequest) (*adminpb.PlanIncreaseResponse, error) {
+	plan := &adminpb.ScalePlan{NodeGroup: req.Id, CurrentSize: 1}
+	for i := int32(0); i < req.Delta; i++ {
+		plan.Create = append(plan.Create, &adminpb.PlannedServer{AvailabilityZone: fmt.Sprintf("az%d", i+1)})
+	}
+	if req.Delta > 3 {
+		plan.Refusal = "cannot increase size to 5, max size is 4"
+	}
+	return &adminpb.PlanIncreaseResponse{Plan: plan}, nil
+}
+
+func (s *stubAdminServer) PlanDelete(ctx context.Context, req *adminpb.PlanDeleteRequest) (*adminpb.PlanDeleteResponse, error) {
+	plan := &adminpb.ScalePlan{NodeGroup: req.Id}
+	for _, name := range req.NodeNames {
+		if name == "foreign" {
+			plan.Rejected = append(plan.Rejected, &adminpb.PlanRejection{Name: name, Reason: "not a member of the node group"})
+			continue
+		}
+		plan.Delete = append(plan.Delete, &adminpb.PlannedServer{Id: "id-" + name, Name: name, AvailabilityZone: "az1"})
+	}
+	return &adminpb.PlanDeleteResponse{Plan: plan}, nil
+}
+
+func (s *stubAdminServer) EvacuateNodeGroup(ctx context.Context, req *adminpb.EvacuateNodeGroupRequest) (*adminpb.EvacuateNodeGroupResponse, error) {
+	if req.SourceId == "gpu" {
+		return nil, status.Error(codes.FailedPrecondition, "evacuation refused: node group gpu has min size 1")
+	}
+	return &adminpb.EvacuateNodeGroupResponse{Batches: []*adminpb.EvacuationBatch{
+		{ServerIds: []string{"s-1", "s-2"}},
+		{ServerIds: []string{"s-3"}},
+	}}, nil
+}
+
+func (s *stubAdminServer) CancelEvacuation(ctx context.Context, req *adminpb.CancelEvacuationRequest) (*adminpb.CancelEvacuationResponse, error) {
+	if req.Id != "workers" {
+		return nil, status.Error(codes.NotFound, "no evacuation running")
+	}
+	return &adminpb.CancelEvacuationResponse{}, nil
+}
+
+func (s *stubAdminServer) SetQuarantine(ctx context.Context, req *adminpb.SetQuarantineRequest) (*adminpb.SetQuarantineResponse, error) {
+	if req.Override != "forced" {
+		return nil, status.Error(codes.InvalidArgument, "unknown quarantine override")
+	}
+	quarantined := proto.Clone(s.nodeGroups[0]).(*adminpb.NodeGroup)
+	quarantined.Quarantine = "manual"
+	return &adminpb.SetQuarantineResponse{NodeGroup: quarantined}, nil
+}
+
+// startStubAdminServer serves the stub on a local port and returns its address
+func startStubAdminServer(t *testing.T, stub *stubAdminServer) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	th.AssertNoErr(t, err)
+	server := grpc.NewServer()
+	adminpb.RegisterAdminServer(server, stub)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestRunAdmin(t *testing.T) {
+	nodeGroups := []*adminpb.NodeGroup{
+		{Id: "gpu", MinSize: 0, MaxSize: 4, TargetSize: 1, ResourceVersion: 3, Flavor: "g1.large", Image: "node-image", Labels: map[string]string{"pool": "gpu", "accelerator": "a100"}},
+		{Id: "workers", MinSize: 1, MaxSize: 10, TargetSize: 3, ResourceVersion: 1, Flavor: "m1.small", Image: "node-image"},
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		output string
+	}{
+		{
+			name: "list node groups",
+			args: []string{"node-groups"},
+			output: "ID       MIN  MAX  TARGET  VERSION  FLAVOR    IMAGE       QUARANTINE  LABELS\n" +
+				"gpu      0    4    1       3        g1.large  node-image  -           accelerator=a100,pool=gpu\n" +
+				"workers  1    10   3       1        m1.small  node-image  -           -\n",
+		},
+		{
+			name: "update node group",
+			args: []string{"update-node-group", "--id", "gpu", "--resource-version", "3", "--max", "8"},
+			output: "ID   MIN  MAX  TARGET  VERSION  FLAVOR    IMAGE       QUARANTINE  LABELS\n" +
+				"gpu  0    8    1       4        g1.large  node-image  -           accelerator=a100,pool=gpu\n",
+		},
+		{
+			name: "stale resource version",
+			args: []string{"update-node-group", "--id", "gpu", "--resource-version", "2", "--max", "8"},
+			code: 1,
+		},
+		{
+			name: "missing resource version",
+			args: []string{"update-node-group", "--id", "gpu"},
+			code: 1,
+		},
+		{
+			name: "report cleanup",
+			args: []string{"cleanup-servers"},
+			output: "ID   NAME   NODE GROUP  STATUS\n" +
+				"s-1  old-1  old         would delete\n" +
+				"s-2  old-2  old         would delete\n",
+		},
+		{
+			name: "execute cleanup with a failure",
+			args: []string{"cleanup-servers", "--mode", "all", "--execute"},
+			code: 1,
+			output: "ID   NAME   NODE GROUP  STATUS\n" +
+				"s-1  old-1  old         deleted\n" +
+				"s-2  old-2  old         failed: conflict\n",
+		},
+		{
+			name: "unknown cleanup mode",
+			args: []string{"cleanup-servers", "--mode", "everything"},
+			code: 1,
+		},
+		{
+			name: "plan increase",
+			args: []string{"plan-increase", "--id", "gpu", "--delta", "2"},
+			output: "ACTION  NAME  ID  ZONE  REASON\n" +
+				"create  -     -   az1   -\n" +
+				"create  -     -   az2   -\n",
+		},
+		{
+			name: "refused plan increase",
+			args: []string{"plan-increase", "--id", "gpu", "--delta", "4"},
+			code: 1,
+			output: "ACTION  NAME  ID  ZONE  REASON\n" +
+				"create  -     -   az1   -\n" +
+				"create  -     -   az2   -\n" +
+				"create  -     -   az3   -\n" +
+				"create  -     -   az4   -\n",
+		},
+		{
+			name: "plan delete",
+			args: []string{"plan-delete", "--id", "gpu", "gpu-1", "foreign"},
+			output: "ACTION  NAME     ID        ZONE  REASON\n" +
+				"delete  gpu-1    id-gpu-1  az1   -\n" +
+				"keep    foreign  -         -     not a member of the node group\n",
+		},
+		{
+			name: "plan delete without nodes",
+			args: []string{"plan-delete", "--id", "gpu"},
+			code: 1,
+		},
+		{
+			name: "evacuate",
+			args: []string{"evacuate", "--source", "workers", "--target", "gpu", "--batch-size", "2"},
+			output: "BATCH  SERVERS\n" +
+				"1      s-1,s-2\n" +
+				"2      s-3\n",
+		},
+		{
+			name: "refused evacuation",
+			args: []string{"evacuate", "--source", "gpu", "--target", "workers"},
+			code: 1,
+		},
+		{
+			name: "evacuate without target",
+			args: []string{"evacuate", "--source", "workers"},
+			code: 1,
+		},
+		{
+			name:   "cancel evacuation",
+			args:   []string{"cancel-evacuation", "--id", "workers"},
+			output: "Cancelling evacuation of node group workers\n",
+		},
+		{
+			name: "cancel missing evacuation",
+			args: []string{"cancel-evacuation", "--id", "gpu"},
+			code: 1,
+		},
+		{
+			name: "quarantine",
+			args: []string{"quarantine", "--id", "gpu", "--override", "forced"},
+			output: "ID   MIN  MAX  TARGET  VERSION  FLAVOR    IMAGE       QUARANTINE  LABELS\n" +
+				"gpu  0    4    1       3        g1.large  node-image  manual      accelerator=a100,pool=gpu\n",
+		},
+		{
+			name: "unknown quarantine override",
+			args: []string{"quarantine", "--id", "gpu", "--override", "off"},
+			code: 1,
+		},
+		{
+			name: "quarantine without override",
+			args: []string{"quarantine", "--id", "gpu"},
+			code: 1,
+		},
+		{
+			name: "unknown command",
+			args: []string{"scale"},
+			code: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startStubAdminServer(t, &stubAdminServer{nodeGroups: nodeGroups})
+
+			var out bytes.Buffer
+			code := runAdmin(append([]string{"--address", address}, tt.args...), &out)
+			th.AssertEquals(t, tt.code, code)
+			th.AssertEquals(t, tt.output, out.String())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// setFlag overrides a command line flag for the duration of the test
+func setFlag[T any](t *testing.T, value *T, override T) {
+	t.Helper()
+	previous := *value
+	*value = override
+	t.Cleanup(func() { *value = previous })
+}
+
+func TestReadConfigurationFromCloudsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clouds.yaml")
+	th.AssertNoErr(t, os.WriteFile(path, []byte(`clouds:
+  production:
+    region_name: RegionOne
+    auth:
+      auth_url: https://keystone.example.com:5000/v3
+      username: autoscaler
+      password: secret
+      project_name: kubernetes
+`), 0o600))
+
+	tests := []struct {
+		name   string
+		file   string
+		cloud  string
+		region string
+		err    string
+	}{
+		{name: "file and cloud", file: path, cloud: "production", region: "RegionOne"},
+		{name: "file without cloud", file: path, err: "requires --cloud or OS_CLOUD"},
+		{name: "unknown cloud", file: path, cloud: "staging", err: "cloud staging not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, configFile, "")
+			setFlag(t, cloudsFile, tt.file)
+			setFlag(t, cloudName, tt.cloud)
+
+			cfg, err := readConfiguration()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.region, cfg.Cloud.Region)
+			th.AssertEquals(t, "https://keystone.example.com:5000/v3", cfg.Cloud.AuthURL)
+		})
+	}
+}
+
+func TestLoadConfigurationDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	th.AssertNoErr(t, os.WriteFile(path, []byte("cloud:\n  auth_url: https://keystone.example.com:5000/v3\n"), 0o600))
+
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{name: "flag set", dryRun: true},
+		{name: "flag unset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, configFile, path)
+			setFlag(t, dryRun, tt.dryRun)
+
+			cfg, err := loadConfiguration()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.dryRun, cfg.Cloud.DryRun)
+		})
+	}
+}
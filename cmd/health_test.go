@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestHealthTransitions(t *testing.T) {
+	cloud := fakecloud.New()
+	t.Cleanup(cloud.Close)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	openstackProvider := newTestProvider(t, cloud, config.NodeGroupConfig{ID: "workers", MaxSize: 3, FlavorID: flavorID, ImageID: imageID})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	th.AssertNoErr(t, err)
+	grpcServer := grpc.NewServer()
+	healthServer := registerHealthServer(grpcServer)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	th.AssertNoErr(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+
+	steps := []struct {
+		name   string
+		action func()
+		status healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "before validation", action: func() {}, status: healthpb.HealthCheckResponse_NOT_SERVING},
+		{name: "validated", action: func() { awaitServing(healthServer, openstackProvider) }, status: healthpb.HealthCheckResponse_SERVING},
+		{name: "shutting down", action: healthServer.Shutdown, status: healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+	for _, step := range steps {
+		step.action()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		response, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		cancel()
+		th.AssertNoErr(t, err)
+		if response.Status != step.status {
+			t.Fatalf("%s: expected %s, got %s", step.name, step.status, response.Status)
+		}
+	}
+}
+
+func TestUpdateServiceHealth(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     string
+		statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name: "healthy",
+			statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				imageHealthService:    healthpb.HealthCheckResponse_SERVING,
+				identityHealthService: healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		{
+			name: "image service unavailable",
+			fail: fakecloud.ImagePath + "/v2/images",
+			statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				imageHealthService:    healthpb.HealthCheckResponse_NOT_SERVING,
+				identityHealthService: healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			openstackProvider := newTestProvider(t, cloud)
+			if tt.fail != "" {
+				cloud.Fail(http.MethodGet, tt.fail, http.StatusServiceUnavailable, -1)
+			}
+			openstackProvider.ValidationErrors(context.Background())
+
+			healthServer := health.NewServer()
+			updateServiceHealth(healthServer, openstackProvider)
+			for service, status := range tt.statuses {
+				response, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				th.AssertNoErr(t, err)
+				if response.Status != status {
+					t.Errorf("%s: expected %s, got %s", service, status, response.Status)
+				}
+			}
+		})
+	}
+}
@@ -5,19 +5,54 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"flag"
+	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 
+	adminpb "github.com/bucher-brothers/openstack-autoscaler/api/admin"
 	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
 	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
 	grpcserver "github.com/bucher-brothers/openstack-autoscaler/pkg/grpc"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/kube"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
 	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
 )
 
+const (
+	// validationRetryInitial is the first wait before retrying validation after a cloud error
+	validationRetryInitial = 5 * time.Second
+	// validationRetryMax caps the wait between validation retries
+	validationRetryMax = 5 * time.Minute
+
+	// providerIDRepairInterval is the time between passes over nodes missing a provider ID
+	providerIDRepairInterval = 5 * time.Minute
+	// providerIDRepairsPerPass caps the nodes patched per pass
+	providerIDRepairsPerPass = 5
+	// nodeDrainTimeout bounds the drain of a node before an evacuation deletes its server
+	nodeDrainTimeout = 10 * time.Minute
+
+	// imageHealthService is the health service name reporting the state of Glance
+	imageHealthService = "openstack.image"
+	// identityHealthService is the health service name reporting whether tokens are renewed
+	identityHealthService = "openstack.identity"
+	// serviceHealthInterval is how often the per-service health statuses are refreshed
+	serviceHealthInterval = 30 * time.Second
+)
+
 var (
 	// Server flags
 	address = flag.String("address", ":8086", "The address to expose the grpc service")
@@ -25,9 +60,19 @@ var (
 	cert    = flag.String("cert", "", "The path to the certificate file. Empty string for insecure communication")
 	cacert  = flag.String("ca-cert", "", "The path to the ca certificate file. Empty string for insecure communication")
 
+	adminAddress    = flag.String("admin-address", "", "The address to expose the admin grpc service on. Empty string disables it")
+	metricsAddress  = flag.String("metrics-address", "", "The address to expose Prometheus metrics on. Empty string disables metrics")
+	shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "Maximum time to wait for background loops to stop on shutdown")
+	validateOnly    = flag.Bool("validate-only", false, "Validate the configuration against the cloud, report each error and exit")
+	dryRun          = flag.Bool("dry-run", false, "Log scaling operations instead of creating and deleting servers")
+
 	// OpenStack configuration flags
 	configFile = flag.String("config", "", "Path to the OpenStack autoscaler configuration file")
 
+	// Standard clouds.yaml configuration (can be used instead of config file)
+	cloudsFile = flag.String("clouds-file", "", "Path to a clouds.yaml file, the standard locations are searched when only --cloud is set")
+	cloudName  = flag.String("cloud", os.Getenv("OS_CLOUD"), "Name of the clouds.yaml entry to use (OS_CLOUD)")
+
 	// OpenStack cloud flags (can be used instead of config file)
 	authURL     = flag.String("auth-url", "", "OpenStack authentication URL (OS_AUTH_URL)")
 	username    = flag.String("username", "", "OpenStack username (OS_USERNAME)")
@@ -35,9 +80,22 @@ var (
 	projectName = flag.String("project-name", "", "OpenStack project name (OS_PROJECT_NAME)")
 	projectID   = flag.String("project-id", "", "OpenStack project ID (OS_PROJECT_ID)")
 	region      = flag.String("region", "", "OpenStack region (OS_REGION_NAME)")
+
+	// Kubernetes client flags
+	inCluster  = flag.Bool("in-cluster", false, "Use the in-cluster Kubernetes client to watch node changes")
+	kubeconfig = flag.String("kubeconfig", "", "Path to a kubeconfig file, used instead of the in-cluster configuration when set")
+
+	repairProviderIDs = flag.Bool("repair-provider-ids", false, "Set spec.providerID on Ready nodes that registered without one and match a member server by name")
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		os.Exit(runSimulate(os.Args[2:], os.Stdout))
+	}
+
 	klog.InitFlags(nil)
 	flag.Parse()
 
@@ -48,6 +106,9 @@ func main() {
 	if err != nil {
 		klog.Fatalf("Failed to load configuration: %v", err)
 	}
+	if cfg.Cloud.DryRun {
+		klog.Warning("Dry-run mode enabled, no servers are created or deleted")
+	}
 
 	// Create OpenStack provider
 	openstackProvider, err := provider.NewOpenStackProvider(cfg)
@@ -55,9 +116,28 @@ func main() {
 		klog.Fatalf("Failed to create OpenStack provider: %v", err)
 	}
 
-	// Validate configuration
-	if err := openstackProvider.ValidateConfiguration(context.Background()); err != nil {
-		klog.Fatalf("Configuration validation failed: %v", err)
+	if *validateOnly {
+		os.Exit(reportValidation(openstackProvider, os.Stdout))
+	}
+	openstackProvider.Start()
+
+	// Watch Kubernetes nodes to keep template node info current
+	if kubeClient := createKubeClient(); kubeClient != nil {
+		watcher := kube.NewNodeWatcher(kubeClient, openstackProvider, 10*time.Minute)
+		openstackProvider.RunBackground("node-watcher", watcher.Run)
+		openstackProvider.SetNodeDrainer(kube.NewNodeDrainer(kubeClient, nodeDrainTimeout))
+
+		if *repairProviderIDs {
+			repairer := kube.NewProviderIDRepairer(kubeClient, openstackProvider, providerIDRepairInterval, providerIDRepairsPerPass)
+			openstackProvider.RunBackground("provider-id-repair", repairer.Run)
+		}
+	}
+
+	// Expose metrics
+	if *metricsAddress != "" {
+		// Exemplars link latencies to the request ID the gRPC call is logged with
+		metrics.TraceIDFromContext = grpcserver.RequestIDFromContext
+		go serveMetrics()
 	}
 
 	// Create gRPC server
@@ -67,24 +147,198 @@ func main() {
 	service := grpcserver.NewOpenStackGrpcServer(openstackProvider)
 	pb.RegisterCloudProviderServer(grpcServer, service)
 
+	// Report NOT_SERVING until the configuration has been validated
+	healthServer := registerHealthServer(grpcServer)
+
 	// Start server
 	listener, err := net.Listen("tcp", *address)
 	if err != nil {
 		klog.Fatalf("Failed to listen: %v", err)
 	}
 
+	// Serve the admin service on its own address so it is never exposed with the autoscaler one
+	var adminServer *grpc.Server
+	if *adminAddress != "" {
+		adminServer = createGRPCServer()
+		adminpb.RegisterAdminServer(adminServer, grpcserver.NewAdminServer(openstackProvider))
+		adminListener, err := net.Listen("tcp", *adminAddress)
+		if err != nil {
+			klog.Fatalf("Failed to listen on admin address: %v", err)
+		}
+		go func() {
+			klog.Infof("Admin gRPC server listening on %s", *adminAddress)
+			if err := adminServer.Serve(adminListener); err != nil {
+				klog.Fatalf("Failed to serve admin service: %v", err)
+			}
+		}()
+	}
+
+	shutdownDone := make(chan struct{})
+	go handleShutdown(grpcServer, adminServer, healthServer, openstackProvider, shutdownDone)
+	go handleReload(openstackProvider)
+
+	// Validate configuration
+	go func() {
+		awaitServing(healthServer, openstackProvider)
+		reportServiceHealth(healthServer, openstackProvider)
+	}()
+
 	klog.Infof("OpenStack Autoscaler gRPC server listening on %s", *address)
 	if err := grpcServer.Serve(listener); err != nil {
 		klog.Fatalf("Failed to serve: %v", err)
 	}
+
+	// Serve returns once GracefulStop is called, wait for the remaining shutdown steps
+	<-shutdownDone
+	klog.Info("OpenStack Autoscaler stopped")
+}
+
+// registerHealthServer registers the gRPC health service, reporting NOT_SERVING until awaitServing
+// validated the configuration
+func registerHealthServer(grpcServer *grpc.Server) *health.Server {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	return healthServer
+}
+
+// awaitServing reports SERVING once the configuration has been validated, exiting on configuration errors
+func awaitServing(healthServer *health.Server, openstackProvider *provider.OpenStackProvider) {
+	if err := waitForValidConfiguration(openstackProvider, validationRetryInitial); err != nil {
+		klog.Fatalf("Configuration validation failed: %v", err)
+	}
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	klog.Info("Configuration validated, reporting SERVING")
+}
+
+// waitForValidConfiguration validates the configuration, returning configuration errors and
+// retrying with backoff starting at wait while the cloud reports transient errors
+func waitForValidConfiguration(openstackProvider *provider.OpenStackProvider, wait time.Duration) error {
+	for {
+		err := openstackProvider.ValidateConfiguration(context.Background())
+		if err == nil {
+			return nil
+		}
+		if provider.IsConfigurationError(err) {
+			return err
+		}
+
+		klog.Warningf("Configuration validation failed with a cloud error, retrying in %s: %v", wait, err)
+		time.Sleep(wait)
+		wait = min(wait*2, validationRetryMax)
+	}
 }
 
+// reportServiceHealth publishes the health of individual OpenStack services next to the overall status
+func reportServiceHealth(healthServer *health.Server, openstackProvider *provider.OpenStackProvider) {
+	for {
+		updateServiceHealth(healthServer, openstackProvider)
+		time.Sleep(serviceHealthInterval)
+	}
+}
+
+// updateServiceHealth sets the per-service statuses from the current state of the provider
+func updateServiceHealth(healthServer *health.Server, openstackProvider *provider.OpenStackProvider) {
+	healthServer.SetServingStatus(imageHealthService, servingStatus(!openstackProvider.ImageServiceDegraded()))
+	healthServer.SetServingStatus(identityHealthService, servingStatus(!openstackProvider.Degraded()))
+}
+
+// servingStatus maps a health flag to a health service status
+func servingStatus(healthy bool) healthpb.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// reportValidation prints every validation error with its classification and returns the exit code
+func reportValidation(openstackProvider *provider.OpenStackProvider, out io.Writer) int {
+	errs := openstackProvider.ValidationErrors(context.Background())
+	if len(errs) == 0 {
+		fmt.Fprintln(out, "Configuration is valid")
+		return 0
+	}
+
+	for _, err := range errs {
+		class := "cloud error"
+		if provider.IsConfigurationError(err) {
+			class = "config error"
+		}
+		fmt.Fprintf(out, "%s: %v\n", class, err)
+	}
+	return 1
+}
+
+// handleShutdown stops the servers and background loops on SIGTERM or SIGINT
+func handleShutdown(grpcServer, adminServer *grpc.Server, healthServer *health.Server, openstackProvider *provider.OpenStackProvider, done chan<- struct{}) {
+	defer close(done)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+
+	klog.Infof("Received signal %s, shutting down", sig)
+	healthServer.Shutdown()
+	if adminServer != nil {
+		adminServer.GracefulStop()
+	}
+	grpcServer.GracefulStop()
+
+	if err := openstackProvider.Shutdown(*shutdownTimeout); err != nil {
+		klog.Warningf("Graceful shutdown incomplete: %v", err)
+	}
+	if err := openstackProvider.Cleanup(); err != nil {
+		klog.Errorf("Cleanup failed: %v", err)
+	}
+}
+
+// handleReload re-reads the configuration on SIGHUP and applies it to the provider, keeping
+// the previous configuration when the new one cannot be loaded or applied
+func handleReload(openstackProvider *provider.OpenStackProvider) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		klog.Info("Received SIGHUP, reloading configuration")
+		if err := reloadConfiguration(openstackProvider); err != nil {
+			klog.Errorf("%v, keeping the previous one", err)
+		}
+	}
+}
+
+// reloadConfiguration re-reads the configuration and applies it to the provider
+func reloadConfiguration(openstackProvider *provider.OpenStackProvider) error {
+	cfg, err := loadConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+	if err := openstackProvider.Reload(cfg); err != nil {
+		return fmt.Errorf("failed to apply reloaded configuration: %w", err)
+	}
+	return nil
+}
+
+// loadConfiguration loads the configuration and applies the flags overriding it
 func loadConfiguration() (*config.Config, error) {
+	cfg, err := readConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	if *dryRun {
+		cfg.Cloud.DryRun = true
+	}
+	return cfg, nil
+}
+
+func readConfiguration() (*config.Config, error) {
 	if *configFile != "" {
 		klog.Infof("Loading configuration from file: %s", *configFile)
 		return config.LoadConfig(*configFile)
 	}
 
+	if *cloudsFile != "" || *cloudName != "" {
+		return loadCloudsConfiguration()
+	}
+
 	// Load from environment variables or command line flags
 	cloudConfig := loadCloudConfig()
 
@@ -95,6 +349,33 @@ func loadConfiguration() (*config.Config, error) {
 	return cfg, nil
 }
 
+// loadCloudsConfiguration loads the cloud configuration from a clouds.yaml entry
+func loadCloudsConfiguration() (*config.Config, error) {
+	if *cloudName == "" {
+		return nil, fmt.Errorf("--clouds-file requires --cloud or OS_CLOUD to select an entry")
+	}
+
+	path := *cloudsFile
+	if path == "" {
+		for _, location := range config.CloudsFileLocations() {
+			if _, err := os.Stat(location); err == nil {
+				path = location
+				break
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("no clouds.yaml found in %v", config.CloudsFileLocations())
+		}
+	}
+
+	klog.Infof("Loading cloud %s from clouds file: %s", *cloudName, path)
+	cloudConfig, err := config.LoadCloudsFile(path, *cloudName)
+	if err != nil {
+		return nil, err
+	}
+	return &config.Config{Cloud: *cloudConfig}, nil
+}
+
 func loadCloudConfig() *config.CloudConfig {
 	// Load from environment variables first
 	cloudCfg := config.LoadConfigFromEnv()
@@ -122,6 +403,41 @@ func loadCloudConfig() *config.CloudConfig {
 	return cloudCfg
 }
 
+func serveMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	klog.Infof("Serving metrics on %s", *metricsAddress)
+	if err := http.ListenAndServe(*metricsAddress, mux); err != nil {
+		klog.Fatalf("Failed to serve metrics: %v", err)
+	}
+}
+
+func createKubeClient() kubernetes.Interface {
+	if !*inCluster && *kubeconfig == "" {
+		return nil
+	}
+
+	var restConfig *rest.Config
+	var err error
+	if *kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		klog.Fatalf("Failed to load Kubernetes client configuration: %v", err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		klog.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+
+	klog.Info("Kubernetes client configured")
+	return client
+}
+
 func createGRPCServer() *grpc.Server {
 	var serverOpts []grpc.ServerOption
 
@@ -161,5 +477,6 @@ func createGRPCServer() *grpc.Server {
 		klog.Warning("No TLS certificates provided, using insecure connection")
 	}
 
+	serverOpts = append(serverOpts, grpc.UnaryInterceptor(grpcserver.LoggingInterceptor))
 	return grpc.NewServer(serverOpts...)
 }
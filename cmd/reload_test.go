@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestReloadConfiguration(t *testing.T) {
+	cloud := fakecloud.New()
+	t.Cleanup(cloud.Close)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	openstackProvider := newTestProvider(t, cloud,
+		config.NodeGroupConfig{ID: "workers", MaxSize: 3, FlavorID: flavorID, ImageID: imageID},
+		config.NodeGroupConfig{ID: "batch", MaxSize: 3, FlavorID: flavorID, ImageID: imageID})
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	setFlag(t, configFile, path)
+	cloudSection := fmt.Sprintf("cloud:\n  auth_url: %s\n  username: autoscaler\n  password: secret\n  project_name: %s\n  user_domain_name: Default\n  region: %s\n  identity_api_version: \"3\"\n",
+		cloud.AuthURL(), fakecloud.ProjectID, fakecloud.Region)
+	nodeGroup := func(id string, maxSize int) string {
+		return fmt.Sprintf("- {id: %s, minSize: 0, maxSize: %d, flavorId: %s, imageId: %s, labels: {pool: %s}}\n", id, maxSize, flavorID, imageID, id)
+	}
+
+	tests := []struct {
+		name       string
+		config     string
+		err        string
+		nodeGroups map[string]int
+	}{
+		{
+			name:       "update, add and remove node groups",
+			config:     cloudSection + "nodeGroups:\n" + nodeGroup("workers", 8) + nodeGroup("gpu", 2),
+			nodeGroups: map[string]int{"workers": 8, "gpu": 2},
+		},
+		{
+			name:       "unparseable file keeps the previous configuration",
+			config:     cloudSection + "nodeGroups: [",
+			err:        "failed to reload configuration",
+			nodeGroups: map[string]int{"workers": 8, "gpu": 2},
+		},
+		{
+			name:       "invalid node group keeps the previous configuration",
+			config:     cloudSection + "nodeGroups:\n" + nodeGroup("workers", -1),
+			err:        "failed to apply reloaded configuration",
+			nodeGroups: map[string]int{"workers": 8, "gpu": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.AssertNoErr(t, os.WriteFile(path, []byte(tt.config), 0o600))
+
+			err := reloadConfiguration(openstackProvider)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+			} else {
+				th.AssertNoErr(t, err)
+			}
+
+			nodeGroups := map[string]int{}
+			for _, ng := range openstackProvider.GetNodeGroups() {
+				nodeGroups[ng.Config.ID] = ng.Config.MaxSize
+				th.AssertEquals(t, ng.Config.ID, ng.Config.Labels["pool"])
+			}
+			th.AssertDeepEquals(t, tt.nodeGroups, nodeGroups)
+		})
+	}
+}
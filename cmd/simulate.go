@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+
+	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	grpcserver "github.com/bucher-brothers/openstack-autoscaler/pkg/grpc"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+// simulationShutdownTimeout bounds waiting for the provisioning watches of a simulation
+const simulationShutdownTimeout = 10 * time.Second
+
+// scenario is a scripted sequence of cluster autoscaler calls
+type scenario struct {
+	// JSON snapshot seeding the fake cloud, relative to the scenario file
+	Snapshot string         `yaml:"snapshot"`
+	Steps    []scenarioStep `yaml:"steps"`
+}
+
+// scenarioStep is one call of a scenario, exactly one field is set
+type scenarioStep struct {
+	Increase *increaseStep  `yaml:"increase"`
+	Nodes    *nodeGroupStep `yaml:"nodes"`
+	Template *nodeGroupStep `yaml:"template"`
+	Delete   *deleteStep    `yaml:"delete"`
+}
+
+// increaseStep increases the size of a node group
+type increaseStep struct {
+	NodeGroup string `yaml:"nodeGroup"`
+	Delta     int32  `yaml:"delta"`
+}
+
+// nodeGroupStep lists the nodes or builds the template node of a node group
+type nodeGroupStep struct {
+	NodeGroup string `yaml:"nodeGroup"`
+}
+
+// deleteStep deletes the servers with the names, or the first count servers of the node group
+// by name
+type deleteStep struct {
+	NodeGroup string   `yaml:"nodeGroup"`
+	Servers   []string `yaml:"servers"`
+	Count     int      `yaml:"count"`
+}
+
+// simulation replays a scenario through the gRPC server of a provider backed by a fake cloud
+type simulation struct {
+	cloud  *fakecloud.Cloud
+	server *grpcserver.OpenStackGrpcServer
+	out    io.Writer
+}
+
+// runSimulate replays a scenario against the provider logic and an in-memory cloud, printing the
+// decisions of each step, and returns the exit code. No real cloud is contacted.
+func runSimulate(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	configPath := flags.String("config", "", "Path to the OpenStack autoscaler configuration file")
+	scenarioPath := flags.String("scenario", "", "Path to the YAML scenario file")
+	snapshotPath := flags.String("snapshot", "", "Path to a JSON cloud snapshot, overriding the snapshot of the scenario")
+	seed := flags.Int64("seed", 1, "Seed of the random server name suffixes")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: openstack-autoscaler simulate --config <file> --scenario <file> [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *configPath == "" || *scenarioPath == "" {
+		flags.Usage()
+		return 2
+	}
+
+	if err := simulate(*configPath, *scenarioPath, *snapshotPath, *seed, out); err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// simulate loads the configuration and scenario, seeds a fake cloud and replays the steps
+func simulate(configPath, scenarioPath, snapshotPath string, seed int64, out io.Writer) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	scn, err := loadScenario(scenarioPath)
+	if err != nil {
+		return err
+	}
+	if snapshotPath == "" && scn.Snapshot != "" {
+		snapshotPath = filepath.Join(filepath.Dir(scenarioPath), scn.Snapshot)
+	}
+
+	cloud := fakecloud.New()
+	defer cloud.Close()
+	if snapshotPath != "" {
+		snapshot, err := fakecloud.LoadSnapshot(snapshotPath)
+		if err != nil {
+			return err
+		}
+		cloud.Seed(snapshot)
+	}
+	useFakeCloud(&cfg.Cloud, cloud)
+
+	// Server names end in a random suffix, a fixed seed makes them reproducible
+	utilrand.Seed(seed)
+
+	p, err := provider.NewOpenStackProvider(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = p.Shutdown(simulationShutdownTimeout) }()
+
+	sim := &simulation{cloud: cloud, server: grpcserver.NewOpenStackGrpcServer(p), out: out}
+	for i, step := range scn.Steps {
+		if err := sim.run(i+1, step); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// loadScenario reads a scenario from a YAML file
+func loadScenario(path string) (*scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file %s: %w", path, err)
+	}
+	var scn scenario
+	if err := yaml.UnmarshalStrict(data, &scn); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	return &scn, nil
+}
+
+// useFakeCloud points the cloud configuration at the fake cloud. State files, dry-run and
+// concurrent creations are turned off, so a scenario is replayed the same way every time.
+func useFakeCloud(cloud *config.CloudConfig, fake *fakecloud.Cloud) {
+	cloud.AuthURL = fake.AuthURL()
+	cloud.Username = "simulation"
+	cloud.Password = "simulation"
+	cloud.ProjectName = fakecloud.ProjectID
+	cloud.ProjectID = ""
+	cloud.UserDomainName = "Default"
+	cloud.ProjectDomainName = ""
+	cloud.ApplicationCredentialID = ""
+	cloud.ApplicationCredentialName = ""
+	cloud.ApplicationCredentialSecret = ""
+	cloud.Region = fakecloud.Region
+	cloud.IdentityAPIVersion = "3"
+	cloud.Interface = ""
+	cloud.ComputeInterface = ""
+	cloud.ImageInterface = ""
+	cloud.NetworkInterface = ""
+	cloud.VolumeInterface = ""
+	cloud.CACertFile = ""
+	cloud.Insecure = false
+	cloud.StateFile = ""
+	cloud.DryRun = false
+	cloud.CreateConcurrency = 1
+	cloud.DeleteConcurrency = 1
+}
+
+// run replays one step and prints its outcome
+func (s *simulation) run(number int, step scenarioStep) error {
+	switch {
+	case step.Increase != nil:
+		fmt.Fprintf(s.out, "== %d: increase %s by %d\n", number, step.Increase.NodeGroup, step.Increase.Delta)
+		return s.increase(step.Increase)
+	case step.Nodes != nil:
+		fmt.Fprintf(s.out, "== %d: nodes %s\n", number, step.Nodes.NodeGroup)
+		return s.nodes(step.Nodes.NodeGroup)
+	case step.Template != nil:
+		fmt.Fprintf(s.out, "== %d: template %s\n", number, step.Template.NodeGroup)
+		return s.template(step.Template.NodeGroup)
+	case step.Delete != nil:
+		fmt.Fprintf(s.out, "== %d: delete from %s\n", number, step.Delete.NodeGroup)
+		return s.delete(step.Delete)
+	}
+	return fmt.Errorf("no increase, nodes, template or delete call")
+}
+
+// increase increases the size of a node group and prints the servers created
+func (s *simulation) increase(step *increaseStep) error {
+	before := s.serverIDs()
+	_, err := s.server.NodeGroupIncreaseSize(context.Background(), &pb.NodeGroupIncreaseSizeRequest{
+		Id:    step.NodeGroup,
+		Delta: step.Delta,
+	})
+	for _, server := range s.cloud.Servers() {
+		if !before[server.ID] {
+			fmt.Fprintf(s.out, "created %s in zone %q (%s)\n", server.Name, server.AvailabilityZone, server.ID)
+		}
+	}
+	s.printResult(err)
+	return s.printTargetSize(step.NodeGroup)
+}
+
+// nodes prints the servers of a node group with their instance state
+func (s *simulation) nodes(nodeGroup string) error {
+	resp, err := s.server.NodeGroupNodes(context.Background(), &pb.NodeGroupNodesRequest{Id: nodeGroup})
+	if err != nil {
+		s.printResult(err)
+		return nil
+	}
+
+	// Nodes are listed by name, the order of the provider follows its server listing
+	servers := s.serversByProviderID()
+	sort.Slice(resp.Instances, func(i, j int) bool {
+		return servers[resp.Instances[i].Id].Name < servers[resp.Instances[j].Id].Name
+	})
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tZONE\tSTATE\tPROVIDER ID")
+	for _, instance := range resp.Instances {
+		server := servers[instance.Id]
+		state := strings.TrimPrefix(instance.Status.InstanceState.String(), "instance")
+		if instance.Status.ErrorInfo != nil {
+			state += " (" + instance.Status.ErrorInfo.ErrorMessage + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", server.Name, server.AvailabilityZone, state, instance.Id)
+	}
+	return w.Flush()
+}
+
+// template prints the capacity, labels and taints of the template node of a node group
+func (s *simulation) template(nodeGroup string) error {
+	resp, err := s.server.NodeGroupTemplateNodeInfo(context.Background(), &pb.NodeGroupTemplateNodeInfoRequest{Id: nodeGroup})
+	if err != nil {
+		s.printResult(err)
+		return nil
+	}
+	var node apiv1.Node
+	if err := node.Unmarshal(resp.NodeBytes); err != nil {
+		return fmt.Errorf("failed to decode template node: %w", err)
+	}
+
+	fmt.Fprintf(s.out, "capacity: %s\n", formatResources(node.Status.Capacity))
+	fmt.Fprintf(s.out, "allocatable: %s\n", formatResources(node.Status.Allocatable))
+	labels := make([]string, 0, len(node.Labels))
+	for key, value := range node.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Fprintf(s.out, "label: %s\n", label)
+	}
+	for _, taint := range node.Spec.Taints {
+		fmt.Fprintf(s.out, "taint: %s\n", taint.ToString())
+	}
+	return nil
+}
+
+// delete deletes servers of a node group as the nodes running on them and prints the servers deleted
+func (s *simulation) delete(step *deleteStep) error {
+	names := step.Servers
+	if step.Count > 0 {
+		resp, err := s.server.NodeGroupNodes(context.Background(), &pb.NodeGroupNodesRequest{Id: step.NodeGroup})
+		if err != nil {
+			s.printResult(err)
+			return nil
+		}
+		servers := s.serversByProviderID()
+		var members []string
+		for _, instance := range resp.Instances {
+			if server, exists := servers[instance.Id]; exists {
+				members = append(members, server.Name)
+			}
+		}
+		sort.Strings(members)
+		names = members[:min(step.Count, len(members))]
+	}
+
+	byName := make(map[string]fakecloud.Server)
+	for _, server := range s.cloud.Servers() {
+		byName[server.Name] = server
+	}
+	req := &pb.NodeGroupDeleteNodesRequest{Id: step.NodeGroup}
+	for _, name := range names {
+		server, exists := byName[name]
+		if !exists {
+			return fmt.Errorf("no server named %s", name)
+		}
+		req.Nodes = append(req.Nodes, &pb.ExternalGrpcNode{Name: name, ProviderID: provider.ProviderID(server.ID)})
+	}
+
+	before := s.cloud.Servers()
+	_, err := s.server.NodeGroupDeleteNodes(context.Background(), req)
+	after := s.serverIDs()
+	for _, server := range before {
+		if !after[server.ID] {
+			fmt.Fprintf(s.out, "deleted %s in zone %q (%s)\n", server.Name, server.AvailabilityZone, server.ID)
+		}
+	}
+	s.printResult(err)
+	return s.printTargetSize(step.NodeGroup)
+}
+
+// printResult prints the status of a call, errors carry their gRPC code as the cluster
+// autoscaler sees them
+func (s *simulation) printResult(err error) {
+	if err == nil {
+		fmt.Fprintln(s.out, "result: OK")
+		return
+	}
+	st := status.Convert(err)
+	fmt.Fprintf(s.out, "result: %s: %s\n", st.Code(), st.Message())
+}
+
+// printTargetSize prints the target size of a node group
+func (s *simulation) printTargetSize(nodeGroup string) error {
+	resp, err := s.server.NodeGroupTargetSize(context.Background(), &pb.NodeGroupTargetSizeRequest{Id: nodeGroup})
+	if err != nil {
+		s.printResult(err)
+		return nil
+	}
+	fmt.Fprintf(s.out, "target size: %d\n", resp.TargetSize)
+	return nil
+}
+
+// serverIDs returns the IDs of the servers of the fake cloud
+func (s *simulation) serverIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, server := range s.cloud.Servers() {
+		ids[server.ID] = true
+	}
+	return ids
+}
+
+// serversByProviderID returns the servers of the fake cloud by their provider ID
+func (s *simulation) serversByProviderID() map[string]fakecloud.Server {
+	servers := make(map[string]fakecloud.Server)
+	for _, server := range s.cloud.Servers() {
+		servers[provider.ProviderID(server.ID)] = server
+	}
+	return servers
+}
+
+// formatResources formats resource quantities sorted by name
+func formatResources(resources apiv1.ResourceList) string {
+	formatted := make([]string, 0, len(resources))
+	for name, quantity := range resources {
+		formatted = append(formatted, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, " ")
+}
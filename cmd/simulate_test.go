@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden outputs of the simulate tests")
+
+func TestSimulateGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+	}{
+		{name: "spread across zones", scenario: "spread-zones"},
+		{name: "quota exhausted", scenario: "quota"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "simulate")
+			var out bytes.Buffer
+			code := runSimulate([]string{
+				"--config", filepath.Join(dir, "config.yaml"),
+				"--scenario", filepath.Join(dir, tt.scenario+".yaml"),
+			}, &out)
+			th.AssertEquals(t, 0, code)
+
+			golden := filepath.Join(dir, tt.scenario+".out")
+			if *updateGolden {
+				th.AssertNoErr(t, os.WriteFile(golden, out.Bytes(), 0o644))
+			}
+			expected, err := os.ReadFile(golden)
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, string(expected), out.String())
+		})
+	}
+}
+
+func TestSimulateRejectsInvalidScenarios(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		code     int
+	}{
+		{name: "unknown call", scenario: "steps:\n- resize: {nodeGroup: workers}\n", code: 1},
+		{name: "empty step", scenario: "steps:\n- {}\n", code: 1},
+		{name: "unknown server", scenario: "steps:\n- delete: {nodeGroup: workers, servers: [missing]}\n", code: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "simulate")
+			scenario := filepath.Join(t.TempDir(), "scenario.yaml")
+			th.AssertNoErr(t, os.WriteFile(scenario, []byte(tt.scenario), 0o600))
+
+			var out bytes.Buffer
+			code := runSimulate([]string{
+				"--config", filepath.Join(dir, "config.yaml"),
+				"--scenario", scenario,
+				"--snapshot", filepath.Join(dir, "cloud.json"),
+			}, &out)
+			th.AssertEquals(t, tt.code, code)
+		})
+	}
+}
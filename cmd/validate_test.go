@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+// newTestProvider returns a provider serving the node groups from the fake cloud
+func newTestProvider(t *testing.T, cloud *fakecloud.Cloud, nodeGroups ...config.NodeGroupConfig) *provider.OpenStackProvider {
+	t.Helper()
+	openstackProvider, err := provider.NewOpenStackProvider(&config.Config{
+		Cloud: config.CloudConfig{
+			AuthURL:            cloud.AuthURL(),
+			Username:           "autoscaler",
+			Password:           "secret",
+			ProjectName:        fakecloud.ProjectID,
+			UserDomainName:     "Default",
+			Region:             fakecloud.Region,
+			IdentityAPIVersion: "3",
+		},
+		NodeGroups: nodeGroups,
+	})
+	th.AssertNoErr(t, err)
+	t.Cleanup(func() { _ = openstackProvider.Shutdown(5 * time.Second) })
+	return openstackProvider
+}
+
+func TestReportValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   string
+		status int
+		code   int
+		output string
+	}{
+		{name: "valid", code: 0, output: "Configuration is valid"},
+		{name: "key pair removed", fail: fakecloud.ComputePath + "/os-keypairs/nodes", status: http.StatusNotFound, code: 1, output: "config error: node group workers validation failed"},
+		{name: "compute unavailable", fail: fakecloud.ComputePath + "/flavors/detail", status: http.StatusServiceUnavailable, code: 1, output: "cloud error: failed to validate compute client"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+			imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+			cloud.AddKeypair(fakecloud.Keypair{Name: "nodes"})
+			openstackProvider := newTestProvider(t, cloud, config.NodeGroupConfig{ID: "workers", MaxSize: 3, FlavorID: flavorID, ImageID: imageID, KeyName: "nodes"})
+			// The cloud changes after the provider was created
+			if tt.fail != "" {
+				cloud.Fail(http.MethodGet, tt.fail, tt.status, -1)
+			}
+
+			var out bytes.Buffer
+			th.AssertEquals(t, tt.code, reportValidation(openstackProvider, &out))
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			th.AssertEquals(t, 1, len(lines))
+			if !strings.HasPrefix(lines[0], tt.output) {
+				t.Errorf("expected output starting with %q, got %q", tt.output, lines[0])
+			}
+		})
+	}
+}
+
+func TestWaitForValidConfiguration(t *testing.T) {
+	tests := []struct {
+		name        string
+		fail        string
+		status      int
+		count       int
+		configError bool
+	}{
+		{name: "valid"},
+		{name: "key pair removed", fail: fakecloud.ComputePath + "/os-keypairs/nodes", status: http.StatusNotFound, count: -1, configError: true},
+		{name: "compute briefly unavailable", fail: fakecloud.ComputePath + "/flavors/detail", status: http.StatusServiceUnavailable, count: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+			imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+			cloud.AddKeypair(fakecloud.Keypair{Name: "nodes"})
+			openstackProvider := newTestProvider(t, cloud, config.NodeGroupConfig{ID: "workers", MaxSize: 3, FlavorID: flavorID, ImageID: imageID, KeyName: "nodes"})
+			if tt.fail != "" {
+				cloud.Fail(http.MethodGet, tt.fail, tt.status, tt.count)
+			}
+
+			// Configuration errors are returned at once, cloud errors are retried until they pass
+			err := waitForValidConfiguration(openstackProvider, time.Millisecond)
+			th.AssertEquals(t, tt.configError, err != nil)
+			if tt.configError {
+				th.AssertEquals(t, true, provider.IsConfigurationError(err))
+			}
+			th.AssertEquals(t, max(tt.count, 0)+1, cloud.Requests(http.MethodGet, fakecloud.ComputePath+"/flavors/detail"))
+		})
+	}
+}
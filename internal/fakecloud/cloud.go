@@ -0,0 +1,304 @@
+// Package fakecloud serves an in-memory OpenStack cloud over HTTP. It implements the parts of the
+// Keystone, Nova, Glance, Neutron and Cinder APIs the autoscaler calls, for the provider tests
+// and the simulate command.
+package fakecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const (
+	// IdentityPath is the path of the Keystone v3 endpoint, the auth URL is the cloud URL plus this path
+	IdentityPath = "/v3"
+	// ComputePath is the path of the Nova endpoint in the service catalog
+	ComputePath = "/compute/v2.1"
+	// ImagePath is the path of the Glance endpoint in the service catalog
+	ImagePath = "/image"
+	// NetworkPath is the path of the Neutron endpoint in the service catalog
+	NetworkPath = "/network"
+	// VolumePath is the path of the Cinder endpoint in the service catalog
+	VolumePath = "/volume/v3"
+
+	// Region is the region of every endpoint in the service catalog
+	Region = "RegionOne"
+	// ProjectID is the project every token is scoped to
+	ProjectID = "fake-project"
+
+	// DefaultComputeMicroversion is the newest compute microversion the cloud supports by default
+	DefaultComputeMicroversion = "2.90"
+	// defaultTokenLifetime is how long issued tokens are valid by default
+	defaultTokenLifetime = time.Hour
+)
+
+// Cloud is an in-memory OpenStack cloud. All state is guarded by one mutex, so handlers and the
+// test-facing methods can be used concurrently.
+type Cloud struct {
+	fake th.FakeServer
+	mux  *http.ServeMux
+
+	mutex    sync.Mutex
+	state    Snapshot
+	nextID   int
+	nextIP   int
+	tokens   int
+	expiries map[string]time.Time
+	expired  int
+	requests []Request
+	faults   []*fault
+
+	password            string
+	computeMicroversion string
+	tokenLifetime       time.Duration
+	createStatus        string
+	volumeBuildPolls    int
+	interfaces          map[string][]string
+	onCreate            func(*Server)
+	now                 func() time.Time
+}
+
+// Request is a request the cloud served
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+}
+
+// fault makes matching requests fail
+type fault struct {
+	method    string
+	path      string
+	status    int
+	message   string
+	remaining int
+}
+
+// New starts a cloud without any resources, Close stops it
+func New() *Cloud {
+	c := &Cloud{
+		fake:                th.SetupHTTP(),
+		mux:                 http.NewServeMux(),
+		computeMicroversion: DefaultComputeMicroversion,
+		tokenLifetime:       defaultTokenLifetime,
+		createStatus:        StatusActive,
+		expiries:            make(map[string]time.Time),
+		now:                 time.Now,
+	}
+	c.registerIdentity()
+	c.registerCompute()
+	c.registerImage()
+	c.registerNetwork()
+	c.registerVolume()
+	c.fake.Mux.HandleFunc("/", c.serveHTTP)
+	return c
+}
+
+// Close stops serving the cloud
+func (c *Cloud) Close() {
+	c.fake.Teardown()
+}
+
+// URL returns the base URL of the cloud without a trailing slash
+func (c *Cloud) URL() string {
+	return strings.TrimSuffix(c.fake.Endpoint(), "/")
+}
+
+// AuthURL returns the Keystone v3 URL to authenticate against
+func (c *Cloud) AuthURL() string {
+	return c.URL() + IdentityPath
+}
+
+// SetPassword makes authentication require the password, any password is accepted while it is empty
+func (c *Cloud) SetPassword(password string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.password = password
+}
+
+// SetComputeMicroversion sets the newest compute microversion, an empty version disables microversions
+func (c *Cloud) SetComputeMicroversion(version string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.computeMicroversion = version
+}
+
+// SetTokenLifetime sets how long tokens issued from now on are valid, requests carrying an expired
+// token are rejected with 401
+func (c *Cloud) SetTokenLifetime(lifetime time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.tokenLifetime = lifetime
+}
+
+// SetEndpointInterfaces limits the endpoints of a service type in the catalog to the interfaces,
+// every service offers public, internal and admin endpoints by default
+func (c *Cloud) SetEndpointInterfaces(serviceType string, interfaces ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.interfaces == nil {
+		c.interfaces = make(map[string][]string)
+	}
+	c.interfaces[serviceType] = interfaces
+}
+
+// SetCreateStatus sets the status new servers start in, ACTIVE by default
+func (c *Cloud) SetCreateStatus(status string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.createStatus = status
+}
+
+// SetVolumeBuildPolls sets how many times a new volume is read as creating before it is available
+func (c *Cloud) SetVolumeBuildPolls(polls int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.volumeBuildPolls = polls
+}
+
+// OnCreate registers a function called with every new server before the create request returns,
+// for example to fail servers in one availability zone. It runs under the cloud lock and must not
+// call other methods of the cloud.
+func (c *Cloud) OnCreate(fn func(*Server)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onCreate = fn
+}
+
+// Fail makes the next count requests with the method to the path fail with the HTTP status,
+// a negative count fails every one of them. The path includes the service path, such as
+// ComputePath + "/servers/<id>".
+func (c *Cloud) Fail(method, path string, status, count int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.faults = append(c.faults, &fault{
+		method:    method,
+		path:      strings.TrimSuffix(path, "/"),
+		status:    status,
+		message:   fmt.Sprintf("injected %d for %s %s", status, method, path),
+		remaining: count,
+	})
+}
+
+// Requests returns the number of requests served with the method to paths starting with the prefix
+func (c *Cloud) Requests(method, prefix string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := 0
+	for _, request := range c.requests {
+		if request.Method == method && strings.HasPrefix(request.Path, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// RequestLog returns every request served so far
+func (c *Cloud) RequestLog() []Request {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+// Authentications returns how many tokens were issued
+func (c *Cloud) Authentications() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.tokens
+}
+
+// ExpiredTokenRequests returns how many requests were rejected for carrying an expired token
+func (c *Cloud) ExpiredTokenRequests() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.expired
+}
+
+// serveHTTP records the request, applies injected faults, rejects expired tokens and dispatches it to the service handlers
+func (c *Cloud) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	c.requests = append(c.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery})
+	requestID := fmt.Sprintf("req-%08d", len(c.requests))
+	injected := c.takeFault(r.Method, r.URL.Path)
+	expiry, issued := c.expiries[r.Header.Get("X-Auth-Token")]
+	expired := issued && !c.now().Before(expiry)
+	if expired {
+		c.expired++
+	}
+	c.mutex.Unlock()
+
+	w.Header().Set("X-Openstack-Request-Id", requestID)
+	if injected != nil {
+		writeError(w, injected.status, injected.message)
+		return
+	}
+	if expired {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+	c.mux.ServeHTTP(w, r)
+}
+
+// takeFault returns the fault injected for a request, if any
+func (c *Cloud) takeFault(method, path string) *fault {
+	path = strings.TrimSuffix(path, "/")
+	for i, f := range c.faults {
+		if f.method != method || f.path != path {
+			continue
+		}
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				c.faults = append(c.faults[:i], c.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// newID returns a deterministic UUID, unique within the cloud
+func (c *Cloud) newID() string {
+	c.nextID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", c.nextID)
+}
+
+// newIP returns a fixed IP address, unique within the cloud
+func (c *Cloud) newIP(prefix string) string {
+	c.nextIP++
+	return fmt.Sprintf("%s.%d.%d", prefix, c.nextIP/250, c.nextIP%250+1)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an OpenStack style error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"code": status, "message": message},
+	})
+}
+
+// readJSON decodes a request body, writing a bad request response when it cannot
+func readJSON(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("malformed request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// timestamp formats a time the way Nova and Neutron do
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,113 @@
+package fakecloud
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// newComputeClient authenticates against the cloud and returns a compute client
+func newComputeClient(t *testing.T, cloud *Cloud) *gophercloud.ServiceClient {
+	t.Helper()
+	provider, err := openstack.NewClient(cloud.AuthURL())
+	th.AssertNoErr(t, err)
+	err = openstack.AuthenticateV3(context.Background(), provider, &gophercloud.AuthOptions{
+		IdentityEndpoint: cloud.AuthURL(),
+		Username:         "user",
+		Password:         "secret",
+		DomainName:       "Default",
+		TenantName:       ProjectID,
+	}, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	client, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: Region})
+	th.AssertNoErr(t, err)
+	return client
+}
+
+func TestCreateAndDeleteServer(t *testing.T) {
+	cloud := New()
+	defer cloud.Close()
+	flavorID := cloud.AddFlavor(Flavor{Name: "m1.small", VCPUs: 1, RAM: 1024})
+	imageID := cloud.AddImage(Image{Name: "image"})
+	cloud.AddNetwork(Network{Name: "private"})
+	client := newComputeClient(t, cloud)
+
+	created, err := servers.Create(context.Background(), client, servers.CreateOpts{
+		Name:      "node",
+		FlavorRef: flavorID,
+		ImageRef:  imageID,
+		Metadata:  map[string]string{"role": "node"},
+	}, nil).Extract()
+	th.AssertNoErr(t, err)
+
+	server, err := servers.Get(context.Background(), client, created.ID).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "node", server.Name)
+	th.AssertEquals(t, StatusActive, server.Status)
+	th.AssertEquals(t, "node", server.Metadata["role"])
+	th.AssertEquals(t, 1, len(cloud.Ports()))
+
+	th.AssertNoErr(t, servers.Delete(context.Background(), client, created.ID).ExtractErr())
+	th.AssertEquals(t, 0, len(cloud.Servers()))
+	th.AssertEquals(t, 0, len(cloud.Ports()))
+}
+
+func TestFail(t *testing.T) {
+	cloud := New()
+	defer cloud.Close()
+	id := cloud.AddServer(Server{Name: "node"})
+	client := newComputeClient(t, cloud)
+
+	cloud.Fail(http.MethodGet, ComputePath+"/servers/"+id, http.StatusServiceUnavailable, 1)
+	_, err := servers.Get(context.Background(), client, id).Extract()
+	if !gophercloud.ResponseCodeIs(err, http.StatusServiceUnavailable) {
+		t.Fatalf("expected the injected 503, got %v", err)
+	}
+
+	_, err = servers.Get(context.Background(), client, id).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, cloud.Requests(http.MethodGet, ComputePath+"/servers/"))
+}
+
+func TestExpiredToken(t *testing.T) {
+	cloud := New()
+	defer cloud.Close()
+	id := cloud.AddServer(Server{Name: "node"})
+	cloud.SetTokenLifetime(50 * time.Millisecond)
+	client := newComputeClient(t, cloud)
+
+	_, err := servers.Get(context.Background(), client, id).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, cloud.ExpiredTokenRequests())
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = servers.Get(context.Background(), client, id).Extract()
+	if !gophercloud.ResponseCodeIs(err, http.StatusUnauthorized) {
+		t.Fatalf("expected the expired token to be rejected, got %v", err)
+	}
+	th.AssertEquals(t, 1, cloud.ExpiredTokenRequests())
+}
+
+func TestQuota(t *testing.T) {
+	cloud := New()
+	defer cloud.Close()
+	flavorID := cloud.AddFlavor(Flavor{Name: "m1.small", VCPUs: 1, RAM: 1024})
+	imageID := cloud.AddImage(Image{Name: "image"})
+	cloud.SetLimits(Limits{MaxInstances: 1})
+	client := newComputeClient(t, cloud)
+
+	opts := servers.CreateOpts{Name: "node", FlavorRef: flavorID, ImageRef: imageID}
+	_, err := servers.Create(context.Background(), client, opts, nil).Extract()
+	th.AssertNoErr(t, err)
+	_, err = servers.Create(context.Background(), client, opts, nil).Extract()
+	if !gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		t.Fatalf("expected the instance quota to be enforced, got %v", err)
+	}
+}
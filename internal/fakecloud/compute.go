@@ -0,0 +1,804 @@
+package fakecloud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tagsMicroversion is the first compute microversion returning server tags
+const tagsMicroversion = 26
+
+// registerCompute registers the Nova endpoints
+func (c *Cloud) registerCompute() {
+	c.mux.HandleFunc("GET "+ComputePath+"/{$}", c.computeVersion)
+	c.mux.HandleFunc("GET "+ComputePath+"/servers/detail", c.listServers)
+	c.mux.HandleFunc("POST "+ComputePath+"/servers", c.createServer)
+	c.mux.HandleFunc("GET "+ComputePath+"/servers/{id}", c.getServer)
+	c.mux.HandleFunc("DELETE "+ComputePath+"/servers/{id}", c.deleteServer)
+	c.mux.HandleFunc("POST "+ComputePath+"/servers/{id}/metadata", c.updateMetadata)
+	c.mux.HandleFunc("PUT "+ComputePath+"/servers/{id}/tags", c.replaceTags)
+	c.mux.HandleFunc("PUT "+ComputePath+"/servers/{id}/tags/{tag}", c.addTag)
+	c.mux.HandleFunc("GET "+ComputePath+"/flavors/detail", c.listFlavors)
+	c.mux.HandleFunc("GET "+ComputePath+"/flavors/{id}", c.getFlavor)
+	c.mux.HandleFunc("GET "+ComputePath+"/flavors/{id}/os-extra_specs", c.getExtraSpecs)
+	c.mux.HandleFunc("GET "+ComputePath+"/os-keypairs/{name}", c.getKeypair)
+	c.mux.HandleFunc("GET "+ComputePath+"/limits", c.getLimits)
+	c.mux.HandleFunc("GET "+ComputePath+"/os-server-groups", c.listServerGroups)
+	c.mux.HandleFunc("POST "+ComputePath+"/os-server-groups", c.createServerGroup)
+	c.mux.HandleFunc("GET "+ComputePath+"/os-server-groups/{id}", c.getServerGroup)
+	c.mux.HandleFunc("DELETE "+ComputePath+"/os-server-groups/{id}", c.deleteServerGroup)
+}
+
+// computeVersion serves the version document of the compute endpoint
+func (c *Cloud) computeVersion(w http.ResponseWriter, _ *http.Request) {
+	c.mutex.Lock()
+	maxVersion := c.computeMicroversion
+	c.mutex.Unlock()
+
+	minVersion := ""
+	if maxVersion != "" {
+		minVersion = "2.1"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"version": map[string]string{"id": "v2.1", "status": "CURRENT", "version": maxVersion, "min_version": minVersion},
+	})
+}
+
+// requestMicroversion returns the minor compute microversion of a request, 1 when none is requested
+func requestMicroversion(r *http.Request) int {
+	version := r.Header.Get("X-OpenStack-Nova-API-Version")
+	if version == "" {
+		version = strings.TrimPrefix(r.Header.Get("OpenStack-API-Version"), "compute ")
+	}
+	_, minor, found := strings.Cut(version, ".")
+	if !found {
+		return 1
+	}
+	n, err := strconv.Atoi(minor)
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+// listServers lists servers matching the name, status, reservation and tag filters, newest first
+func (c *Cloud) listServers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var name *regexp.Regexp
+	if pattern := query.Get("name"); pattern != "" {
+		var err error
+		if name, err = regexp.Compile(pattern); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid name filter: %v", err))
+			return
+		}
+	}
+	tagsAny := splitList(query.Get("tags-any"))
+	tagsAll := splitList(query.Get("tags"))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var matched []*Server
+	for i := range c.state.Servers {
+		server := &c.state.Servers[i]
+		if name != nil && !name.MatchString(server.Name) {
+			continue
+		}
+		if status := query.Get("status"); status != "" && server.Status != status {
+			continue
+		}
+		if reservation := query.Get("reservation_id"); reservation != "" && server.ReservationID != reservation {
+			continue
+		}
+		if len(tagsAny) > 0 && !containsAny(server.Tags, tagsAny) {
+			continue
+		}
+		if len(tagsAll) > 0 && !containsAll(server.Tags, tagsAll) {
+			continue
+		}
+		matched = append(matched, server)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].Created.Equal(matched[j].Created) {
+			return matched[i].Created.After(matched[j].Created)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	withTags := requestMicroversion(r) >= tagsMicroversion
+	body := make([]map[string]interface{}, 0, len(matched))
+	for _, server := range matched {
+		body = append(body, c.novaServer(server, withTags))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"servers": body})
+}
+
+// getServer returns a server
+func (c *Cloud) getServer(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	server := c.server(r.PathValue("id"))
+	if server == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Instance %s could not be found.", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server": c.novaServer(server, requestMicroversion(r) >= tagsMicroversion)})
+}
+
+// novaServer renders a server the way Nova returns it, with the cloud lock held
+func (c *Cloud) novaServer(server *Server, withTags bool) map[string]interface{} {
+	flavor := map[string]interface{}{"id": server.FlavorID}
+	if f := c.flavor(server.FlavorID); f != nil {
+		flavor["original_name"] = f.Name
+		flavor["vcpus"] = f.VCPUs
+		flavor["ram"] = f.RAM
+		flavor["disk"] = f.Disk
+	}
+	var image interface{} = ""
+	if server.ImageID != "" {
+		image = map[string]string{"id": server.ImageID}
+	}
+
+	addresses := make(map[string][]map[string]interface{})
+	for _, port := range c.state.Ports {
+		if port.DeviceID != server.ID {
+			continue
+		}
+		networkName := port.NetworkID
+		if network := c.network(port.NetworkID); network != nil {
+			networkName = network.Name
+		}
+		for _, ip := range port.FixedIPs {
+			addresses[networkName] = append(addresses[networkName], map[string]interface{}{"addr": ip, "version": 4, "OS-EXT-IPS:type": "fixed"})
+		}
+		for _, fip := range c.state.FloatingIPs {
+			if fip.PortID == port.ID {
+				addresses[networkName] = append(addresses[networkName], map[string]interface{}{"addr": fip.Address, "version": 4, "OS-EXT-IPS:type": "floating"})
+			}
+		}
+	}
+
+	securityGroups := make([]map[string]string, 0, len(server.SecurityGroups))
+	for _, group := range server.SecurityGroups {
+		securityGroups = append(securityGroups, map[string]string{"name": group})
+	}
+	volumes := make([]map[string]interface{}, 0, len(server.Volumes))
+	for _, volume := range server.Volumes {
+		volumes = append(volumes, map[string]interface{}{"id": volume.ID, "delete_on_termination": volume.DeleteOnTermination})
+	}
+
+	var taskState interface{}
+	if server.TaskState != "" {
+		taskState = server.TaskState
+	}
+	body := map[string]interface{}{
+		"id":                                   server.ID,
+		"name":                                 server.Name,
+		"status":                               server.Status,
+		"tenant_id":                            ProjectID,
+		"user_id":                              "fake-user",
+		"metadata":                             nonNilMap(server.Metadata),
+		"created":                              timestamp(server.Created),
+		"updated":                              timestamp(server.Created),
+		"flavor":                               flavor,
+		"image":                                image,
+		"addresses":                            addresses,
+		"key_name":                             server.KeyName,
+		"security_groups":                      securityGroups,
+		"locked":                               server.Locked,
+		"OS-EXT-AZ:availability_zone":          server.AvailabilityZone,
+		"OS-EXT-STS:task_state":                taskState,
+		"OS-EXT-STS:vm_state":                  strings.ToLower(server.Status),
+		"OS-EXT-SRV-ATTR:reservation_id":       server.ReservationID,
+		"os-extended-volumes:volumes_attached": volumes,
+	}
+	if server.Fault != "" {
+		code := server.FaultCode
+		if code == 0 {
+			code = http.StatusInternalServerError
+		}
+		body["fault"] = map[string]interface{}{"code": code, "message": server.Fault, "created": timestamp(server.Created)}
+	}
+	if withTags {
+		body["tags"] = nonNilList(server.Tags)
+	}
+	return body
+}
+
+// networkRequest is a network attachment requested for a new server
+type networkRequest struct {
+	UUID    string `json:"uuid"`
+	Port    string `json:"port"`
+	FixedIP string `json:"fixed_ip"`
+}
+
+// createServer creates one server, or min_count to max_count servers as far as the quota allows
+func (c *Cloud) createServer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Server struct {
+			Name             string            `json:"name"`
+			FlavorRef        string            `json:"flavorRef"`
+			ImageRef         string            `json:"imageRef"`
+			Metadata         map[string]string `json:"metadata"`
+			AvailabilityZone string            `json:"availability_zone"`
+			UserData         string            `json:"user_data"`
+			KeyName          string            `json:"key_name"`
+			ConfigDrive      bool              `json:"config_drive"`
+			MinCount         int               `json:"min_count"`
+			MaxCount         int               `json:"max_count"`
+			Networks         json.RawMessage   `json:"networks"`
+			SecurityGroups   []struct {
+				Name string `json:"name"`
+			} `json:"security_groups"`
+			BlockDevice []struct {
+				SourceType          string `json:"source_type"`
+				DestinationType     string `json:"destination_type"`
+				UUID                string `json:"uuid"`
+				VolumeSize          int    `json:"volume_size"`
+				VolumeType          string `json:"volume_type"`
+				DeleteOnTermination bool   `json:"delete_on_termination"`
+			} `json:"block_device_mapping_v2"`
+		} `json:"server"`
+		Hints struct {
+			Group string `json:"group"`
+		} `json:"os:scheduler_hints"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	request := body.Server
+
+	var networks []networkRequest
+	if len(request.Networks) > 0 && request.Networks[0] == '[' {
+		if err := json.Unmarshal(request.Networks, &networks); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid networks: %v", err))
+			return
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	flavor := c.flavor(request.FlavorRef)
+	if flavor == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Flavor %s could not be found.", request.FlavorRef))
+		return
+	}
+	if request.ImageRef != "" && c.image(request.ImageRef) == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Image %s could not be found.", request.ImageRef))
+		return
+	}
+	if request.ImageRef == "" && len(request.BlockDevice) == 0 {
+		writeError(w, http.StatusBadRequest, "Missing imageRef attribute")
+		return
+	}
+	if request.KeyName != "" && !c.hasKeypair(request.KeyName) {
+		writeError(w, http.StatusBadRequest, "Invalid key_name provided.")
+		return
+	}
+	if request.AvailabilityZone != "" && len(c.state.Zones) > 0 && !contains(c.state.Zones, request.AvailabilityZone) {
+		writeError(w, http.StatusBadRequest, "The requested availability zone is not available")
+		return
+	}
+	var group *ServerGroup
+	if body.Hints.Group != "" {
+		if group = c.serverGroup(body.Hints.Group); group == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid server group %s", body.Hints.Group))
+			return
+		}
+	}
+
+	minCount, maxCount := max(request.MinCount, 1), max(request.MaxCount, request.MinCount, 1)
+	count := min(maxCount, c.instancesAvailable(flavor))
+	if group != nil && c.state.Limits.MaxServerGroupMembers > 0 {
+		count = min(count, c.state.Limits.MaxServerGroupMembers-len(group.Members))
+	}
+	if count < minCount {
+		if group != nil && c.state.Limits.MaxServerGroupMembers > 0 && len(group.Members)+minCount > c.state.Limits.MaxServerGroupMembers {
+			writeError(w, http.StatusForbidden, "Quota exceeded, too many servers in group")
+			return
+		}
+		writeError(w, http.StatusForbidden, "Quota exceeded for instances: Requested 1, but already used all of the quota")
+		return
+	}
+
+	userData, _ := base64.StdEncoding.DecodeString(request.UserData)
+	reservationID := "r-" + c.newID()[24:]
+	var first string
+	for i := 0; i < count; i++ {
+		name := request.Name
+		if count > 1 {
+			name = fmt.Sprintf("%s-%d", request.Name, i+1)
+		}
+		server := Server{
+			ID:               c.newID(),
+			Name:             name,
+			Status:           c.createStatus,
+			FlavorID:         flavor.ID,
+			ImageID:          request.ImageRef,
+			AvailabilityZone: request.AvailabilityZone,
+			Metadata:         copyMap(request.Metadata),
+			KeyName:          request.KeyName,
+			ConfigDrive:      request.ConfigDrive,
+			ReservationID:    reservationID,
+			Created:          c.now(),
+			UserData:         string(userData),
+		}
+		if server.Status == StatusBuild {
+			server.TaskState = "spawning"
+		}
+		for _, securityGroup := range request.SecurityGroups {
+			server.SecurityGroups = append(server.SecurityGroups, securityGroup.Name)
+		}
+		for _, device := range request.BlockDevice {
+			volumeID := device.UUID
+			if device.SourceType == "image" && device.DestinationType == "volume" {
+				volumeID = c.newID()
+				c.state.Volumes = append(c.state.Volumes, Volume{
+					ID: volumeID, Size: device.VolumeSize, Status: "in-use", VolumeType: device.VolumeType,
+					AvailabilityZone: request.AvailabilityZone, ImageID: device.UUID,
+				})
+			} else if volume := c.volume(volumeID); volume != nil {
+				volume.Status = "in-use"
+			}
+			server.Volumes = append(server.Volumes, AttachedVolume{ID: volumeID, DeleteOnTermination: device.DeleteOnTermination})
+		}
+		if group != nil {
+			server.ServerGroupID = group.ID
+			group.Members = append(group.Members, server.ID)
+		}
+
+		c.plugPorts(&server, networks)
+		if c.onCreate != nil {
+			c.onCreate(&server)
+		}
+		c.state.Servers = append(c.state.Servers, server)
+		if first == "" {
+			first = server.ID
+		}
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"server": map[string]interface{}{"id": first, "reservation_id": reservationID, "links": []interface{}{}},
+	})
+}
+
+// plugPorts attaches the requested networks and ports to a new server, the first internal network
+// when none is requested
+func (c *Cloud) plugPorts(server *Server, networks []networkRequest) {
+	if len(networks) == 0 {
+		for _, network := range c.state.Networks {
+			if !network.External {
+				networks = append(networks, networkRequest{UUID: network.ID})
+				break
+			}
+		}
+	}
+
+	for _, network := range networks {
+		if network.Port != "" {
+			if port := c.port(network.Port); port != nil {
+				port.DeviceID = server.ID
+			}
+			continue
+		}
+		fixedIP := network.FixedIP
+		if fixedIP == "" {
+			fixedIP = c.newIP("10.0")
+		}
+		c.state.Ports = append(c.state.Ports, Port{
+			ID:          c.newID(),
+			NetworkID:   network.UUID,
+			DeviceID:    server.ID,
+			FixedIPs:    []string{fixedIP},
+			NovaCreated: true,
+		})
+	}
+}
+
+// instancesAvailable returns how many more servers of a flavor the quota allows, with the cloud lock held
+func (c *Cloud) instancesAvailable(flavor *Flavor) int {
+	available := int(^uint(0) >> 1)
+	limits := c.state.Limits
+	cores, ram := 0, 0
+	for _, server := range c.state.Servers {
+		if f := c.flavor(server.FlavorID); f != nil {
+			cores += f.VCPUs
+			ram += f.RAM
+		}
+	}
+	if limits.MaxInstances > 0 {
+		available = min(available, limits.MaxInstances-len(c.state.Servers))
+	}
+	if limits.MaxCores > 0 && flavor.VCPUs > 0 {
+		available = min(available, (limits.MaxCores-cores)/flavor.VCPUs)
+	}
+	if limits.MaxRAM > 0 && flavor.RAM > 0 {
+		available = min(available, (limits.MaxRAM-ram)/flavor.RAM)
+	}
+	return max(available, 0)
+}
+
+// deleteServer deletes a server, its Nova created ports and the volumes deleted on termination
+func (c *Cloud) deleteServer(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := r.PathValue("id")
+	server := c.server(id)
+	if server == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Instance %s could not be found.", id))
+		return
+	}
+	if server.Locked {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Instance %s is locked", id))
+		return
+	}
+
+	for _, volume := range server.Volumes {
+		if volume.DeleteOnTermination {
+			c.removeVolume(volume.ID)
+		} else if v := c.volume(volume.ID); v != nil {
+			v.Status = "available"
+		}
+	}
+	if group := c.serverGroup(server.ServerGroupID); group != nil {
+		group.Members = remove(group.Members, id)
+	}
+
+	ports := c.state.Ports[:0]
+	for _, port := range c.state.Ports {
+		if port.DeviceID == id {
+			if port.NovaCreated {
+				c.disassociatePort(port.ID)
+				continue
+			}
+			port.DeviceID = ""
+		}
+		ports = append(ports, port)
+	}
+	c.state.Ports = ports
+
+	servers := c.state.Servers[:0]
+	for _, s := range c.state.Servers {
+		if s.ID != id {
+			servers = append(servers, s)
+		}
+	}
+	c.state.Servers = servers
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateMetadata merges metadata into the metadata of a server
+func (c *Cloud) updateMetadata(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Metadata map[string]string `json:"metadata"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	server := c.server(r.PathValue("id"))
+	if server == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Instance %s could not be found.", r.PathValue("id")))
+		return
+	}
+	if server.Metadata == nil {
+		server.Metadata = make(map[string]string)
+	}
+	for k, v := range body.Metadata {
+		server.Metadata[k] = v
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"metadata": server.Metadata})
+}
+
+// replaceTags replaces all tags of a server
+func (c *Cloud) replaceTags(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	server := c.server(r.PathValue("id"))
+	if server == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Instance %s could not be found.", r.PathValue("id")))
+		return
+	}
+	server.Tags = append([]string(nil), body.Tags...)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tags": nonNilList(server.Tags)})
+}
+
+// addTag adds a tag to a server
+func (c *Cloud) addTag(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	server := c.server(r.PathValue("id"))
+	if server == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Instance %s could not be found.", r.PathValue("id")))
+		return
+	}
+	if !contains(server.Tags, r.PathValue("tag")) {
+		server.Tags = append(server.Tags, r.PathValue("tag"))
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// listFlavors lists all flavors
+func (c *Cloud) listFlavors(w http.ResponseWriter, _ *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := make([]map[string]interface{}, 0, len(c.state.Flavors))
+	for i := range c.state.Flavors {
+		body = append(body, novaFlavor(&c.state.Flavors[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"flavors": body})
+}
+
+// getFlavor returns a flavor
+func (c *Cloud) getFlavor(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	flavor := c.flavor(r.PathValue("id"))
+	if flavor == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Flavor %s could not be found.", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"flavor": novaFlavor(flavor)})
+}
+
+// novaFlavor renders a flavor the way Nova returns it
+func novaFlavor(flavor *Flavor) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                         flavor.ID,
+		"name":                       flavor.Name,
+		"vcpus":                      flavor.VCPUs,
+		"ram":                        flavor.RAM,
+		"disk":                       flavor.Disk,
+		"swap":                       "",
+		"rxtx_factor":                1.0,
+		"os-flavor-access:is_public": true,
+		"OS-FLV-EXT-DATA:ephemeral":  flavor.Ephemeral,
+	}
+}
+
+// getExtraSpecs returns the extra specs of a flavor
+func (c *Cloud) getExtraSpecs(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	flavor := c.flavor(r.PathValue("id"))
+	if flavor == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Flavor %s could not be found.", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"extra_specs": nonNilMap(flavor.ExtraSpecs)})
+}
+
+// hasKeypair reports whether a key pair exists, with the cloud lock held
+func (c *Cloud) hasKeypair(name string) bool {
+	for _, keypair := range c.state.Keypairs {
+		if keypair.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getKeypair returns a key pair
+func (c *Cloud) getKeypair(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, keypair := range c.state.Keypairs {
+		if keypair.Name == r.PathValue("name") {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"keypair": map[string]string{"name": keypair.Name, "public_key": keypair.PublicKey, "fingerprint": "fake"},
+			})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Keypair %s not found", r.PathValue("name")))
+}
+
+// getLimits returns the absolute limits of the project and their usage
+func (c *Cloud) getLimits(w http.ResponseWriter, _ *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cores, ram := 0, 0
+	for _, server := range c.state.Servers {
+		if flavor := c.flavor(server.FlavorID); flavor != nil {
+			cores += flavor.VCPUs
+			ram += flavor.RAM
+		}
+	}
+	limit := func(value int) int {
+		if value <= 0 {
+			return -1
+		}
+		return value
+	}
+	limits := c.state.Limits
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"limits": map[string]interface{}{
+			"absolute": map[string]int{
+				"maxTotalInstances":     limit(limits.MaxInstances),
+				"totalInstancesUsed":    len(c.state.Servers),
+				"maxTotalCores":         limit(limits.MaxCores),
+				"totalCoresUsed":        cores,
+				"maxTotalRAMSize":       limit(limits.MaxRAM),
+				"totalRAMUsed":          ram,
+				"maxServerGroupMembers": limit(limits.MaxServerGroupMembers),
+			},
+		},
+	})
+}
+
+// listServerGroups lists all server groups
+func (c *Cloud) listServerGroups(w http.ResponseWriter, _ *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := make([]map[string]interface{}, 0, len(c.state.ServerGroups))
+	for i := range c.state.ServerGroups {
+		body = append(body, novaServerGroup(&c.state.ServerGroups[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server_groups": body})
+}
+
+// createServerGroup creates a server group
+func (c *Cloud) createServerGroup(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ServerGroup struct {
+			Name     string   `json:"name"`
+			Policies []string `json:"policies"`
+			Policy   string   `json:"policy"`
+		} `json:"server_group"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	policy := body.ServerGroup.Policy
+	if policy == "" && len(body.ServerGroup.Policies) > 0 {
+		policy = body.ServerGroup.Policies[0]
+	}
+	if strings.HasPrefix(policy, "soft-") && requestMicroversion(r) < 15 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input for field/attribute policies: %s", policy))
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	group := ServerGroup{ID: c.newID(), Name: body.ServerGroup.Name, Policy: policy}
+	c.state.ServerGroups = append(c.state.ServerGroups, group)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server_group": novaServerGroup(&group)})
+}
+
+// getServerGroup returns a server group
+func (c *Cloud) getServerGroup(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	group := c.serverGroup(r.PathValue("id"))
+	if group == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server group %s could not be found.", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server_group": novaServerGroup(group)})
+}
+
+// deleteServerGroup deletes a server group
+func (c *Cloud) deleteServerGroup(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, group := range c.state.ServerGroups {
+		if group.ID == r.PathValue("id") {
+			c.state.ServerGroups = append(c.state.ServerGroups[:i], c.state.ServerGroups[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Server group %s could not be found.", r.PathValue("id")))
+}
+
+// novaServerGroup renders a server group the way Nova returns it
+func novaServerGroup(group *ServerGroup) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       group.ID,
+		"name":     group.Name,
+		"policies": []string{group.Policy},
+		"policy":   group.Policy,
+		"members":  nonNilList(group.Members),
+		"metadata": map[string]string{},
+	}
+}
+
+// splitList splits a comma separated filter value
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// contains reports whether a list contains a value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether a list contains any of the values
+func containsAny(list, values []string) bool {
+	for _, value := range values {
+		if contains(list, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll reports whether a list contains all of the values
+func containsAll(list, values []string) bool {
+	for _, value := range values {
+		if !contains(list, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// remove returns a list without a value
+func remove(list []string, value string) []string {
+	kept := list[:0]
+	for _, item := range list {
+		if item != value {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// copyMap returns a copy of a map, never nil
+func copyMap(m map[string]string) map[string]string {
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// nonNilMap returns an empty map for nil so it encodes as an object
+func nonNilMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+// nonNilList returns an empty list for nil so it encodes as an array
+func nonNilList(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
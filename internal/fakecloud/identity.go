@@ -0,0 +1,99 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// tokenTimestampFormat is the microsecond precision format Keystone reports token timestamps in
+const tokenTimestampFormat = "2006-01-02T15:04:05.000000Z"
+
+// registerIdentity registers the Keystone v3 token endpoint
+func (c *Cloud) registerIdentity() {
+	c.mux.HandleFunc("POST "+IdentityPath+"/auth/tokens", c.createToken)
+}
+
+// createToken issues a project scoped token whose catalog points at the services of the cloud
+func (c *Cloud) createToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Auth struct {
+			Identity struct {
+				Password struct {
+					User struct {
+						Name     string `json:"name"`
+						Password string `json:"password"`
+					} `json:"user"`
+				} `json:"password"`
+				ApplicationCredential struct {
+					Secret string `json:"secret"`
+				} `json:"application_credential"`
+			} `json:"identity"`
+		} `json:"auth"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	identity := body.Auth.Identity
+	if c.password != "" && identity.Password.User.Password != c.password && identity.ApplicationCredential.Secret != c.password {
+		c.mutex.Unlock()
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+	c.tokens++
+	token := fmt.Sprintf("fake-token-%d", c.tokens)
+	issued := c.now()
+	expires := issued.Add(c.tokenLifetime)
+	c.expiries[token] = expires
+	interfaces := make(map[string][]string, len(c.interfaces))
+	for serviceType, offered := range c.interfaces {
+		interfaces[serviceType] = offered
+	}
+	c.mutex.Unlock()
+
+	base := c.URL()
+	endpoint := func(serviceType, path string) map[string]interface{} {
+		offered, limited := interfaces[serviceType]
+		if !limited {
+			offered = []string{"public", "internal", "admin"}
+		}
+		var endpoints []map[string]interface{}
+		for _, iface := range offered {
+			endpoints = append(endpoints, map[string]interface{}{
+				"id":        serviceType + "-" + iface,
+				"interface": iface,
+				"region":    Region,
+				"region_id": Region,
+				"url":       base + path + "/",
+			})
+		}
+		return map[string]interface{}{"id": serviceType, "type": serviceType, "name": serviceType, "endpoints": endpoints}
+	}
+
+	w.Header().Set("X-Subject-Token", token)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token": map[string]interface{}{
+			"methods":    []string{"password"},
+			"issued_at":  issued.UTC().Format(tokenTimestampFormat),
+			"expires_at": expires.UTC().Format(tokenTimestampFormat),
+			"project": map[string]interface{}{
+				"id":     ProjectID,
+				"name":   ProjectID,
+				"domain": map[string]string{"id": "default", "name": "Default"},
+			},
+			"user": map[string]interface{}{
+				"id":     "fake-user",
+				"name":   identity.Password.User.Name,
+				"domain": map[string]string{"id": "default", "name": "Default"},
+			},
+			"catalog": []map[string]interface{}{
+				endpoint("identity", IdentityPath),
+				endpoint("compute", ComputePath),
+				endpoint("image", ImagePath),
+				endpoint("network", NetworkPath),
+				endpoint("block-storage", VolumePath),
+			},
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// registerImage registers the Glance endpoints
+func (c *Cloud) registerImage() {
+	c.mux.HandleFunc("GET "+ImagePath+"/{$}", c.imageVersions)
+	c.mux.HandleFunc("GET "+ImagePath+"/v2/images", c.listImages)
+	c.mux.HandleFunc("GET "+ImagePath+"/v2/images/{id}", c.getImage)
+}
+
+// imageVersions serves the version discovery document of the image endpoint
+func (c *Cloud) imageVersions(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"versions": []map[string]string{{"id": "v2.16", "status": "CURRENT"}},
+	})
+}
+
+// listImages lists images matching the name, status and tag filters
+func (c *Cloud) listImages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := []map[string]interface{}{}
+	for i := range c.state.Images {
+		image := &c.state.Images[i]
+		if name := query.Get("name"); name != "" && image.Name != name {
+			continue
+		}
+		if status := query.Get("status"); status != "" && image.Status != status {
+			continue
+		}
+		if !containsAll(image.Tags, query["tag"]) {
+			continue
+		}
+		body = append(body, glanceImage(image))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"images": body})
+}
+
+// getImage returns an image
+func (c *Cloud) getImage(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	image := c.image(r.PathValue("id"))
+	if image == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No image found with ID %s", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, glanceImage(image))
+}
+
+// glanceImage renders an image the way Glance returns it, properties are top level attributes
+func glanceImage(image *Image) map[string]interface{} {
+	body := map[string]interface{}{
+		"id":               image.ID,
+		"name":             image.Name,
+		"status":           image.Status,
+		"tags":             nonNilList(image.Tags),
+		"min_ram":          image.MinRAM,
+		"min_disk":         image.MinDisk,
+		"visibility":       "public",
+		"container_format": "bare",
+		"disk_format":      "qcow2",
+		"created_at":       timestamp(image.Created),
+		"updated_at":       timestamp(image.Created),
+	}
+	for k, v := range image.Properties {
+		body[k] = v
+	}
+	return body
+}
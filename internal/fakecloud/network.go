@@ -0,0 +1,393 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// registerNetwork registers the Neutron endpoints
+func (c *Cloud) registerNetwork() {
+	c.mux.HandleFunc("GET "+NetworkPath+"/{$}", c.networkVersions)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/networks", c.listNetworks)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/networks/{id}", c.getNetwork)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/subnets/{id}", c.getSubnet)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/ports", c.listPorts)
+	c.mux.HandleFunc("POST "+NetworkPath+"/v2.0/ports", c.createPort)
+	c.mux.HandleFunc("DELETE "+NetworkPath+"/v2.0/ports/{id}", c.deletePort)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/floatingips", c.listFloatingIPs)
+	c.mux.HandleFunc("POST "+NetworkPath+"/v2.0/floatingips", c.createFloatingIP)
+	c.mux.HandleFunc("PUT "+NetworkPath+"/v2.0/floatingips/{id}", c.updateFloatingIP)
+	c.mux.HandleFunc("DELETE "+NetworkPath+"/v2.0/floatingips/{id}", c.deleteFloatingIP)
+	c.mux.HandleFunc("PUT "+NetworkPath+"/v2.0/floatingips/{id}/tags/{tag}", c.addFloatingIPTag)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/security-groups", c.listSecurityGroups)
+	c.mux.HandleFunc("GET "+NetworkPath+"/v2.0/security-groups/{id}", c.getSecurityGroup)
+}
+
+// networkVersions serves the version discovery document of the network endpoint
+func (c *Cloud) networkVersions(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"versions": []map[string]string{{"id": "v2.0", "status": "CURRENT"}},
+	})
+}
+
+// listNetworks lists networks matching the name filter
+func (c *Cloud) listNetworks(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := []map[string]interface{}{}
+	for i := range c.state.Networks {
+		if name == "" || c.state.Networks[i].Name == name {
+			body = append(body, c.neutronNetwork(&c.state.Networks[i]))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"networks": body})
+}
+
+// getNetwork returns a network
+func (c *Cloud) getNetwork(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	network := c.network(r.PathValue("id"))
+	if network == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Network %s could not be found.", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"network": c.neutronNetwork(network)})
+}
+
+// neutronNetwork renders a network the way Neutron returns it, with the cloud lock held
+func (c *Cloud) neutronNetwork(network *Network) map[string]interface{} {
+	subnets := []string{}
+	for _, subnet := range c.state.Subnets {
+		if subnet.NetworkID == network.ID {
+			subnets = append(subnets, subnet.ID)
+		}
+	}
+	return map[string]interface{}{
+		"id":              network.ID,
+		"name":            network.Name,
+		"status":          "ACTIVE",
+		"admin_state_up":  true,
+		"subnets":         subnets,
+		"router:external": network.External,
+		"tenant_id":       ProjectID,
+	}
+}
+
+// getSubnet returns a subnet
+func (c *Cloud) getSubnet(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, subnet := range c.state.Subnets {
+		if subnet.ID == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"subnet": map[string]interface{}{"id": subnet.ID, "network_id": subnet.NetworkID, "cidr": subnet.CIDR, "ip_version": 4},
+			})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Subnet %s could not be found.", r.PathValue("id")))
+}
+
+// listPorts lists ports matching the device, description, network and name filters
+func (c *Cloud) listPorts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := []map[string]interface{}{}
+	for i := range c.state.Ports {
+		port := &c.state.Ports[i]
+		if !matches(query.Get("device_id"), port.DeviceID) || !matches(query.Get("description"), port.Description) ||
+			!matches(query.Get("network_id"), port.NetworkID) || !matches(query.Get("name"), port.Name) {
+			continue
+		}
+		body = append(body, neutronPort(port))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ports": body})
+}
+
+// createPort creates a port, allocating a fixed IP on the subnet when none is requested
+func (c *Cloud) createPort(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Port struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			NetworkID   string `json:"network_id"`
+			FixedIPs    []struct {
+				SubnetID  string `json:"subnet_id"`
+				IPAddress string `json:"ip_address"`
+			} `json:"fixed_ips"`
+			SecurityGroups []string `json:"security_groups"`
+		} `json:"port"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	request := body.Port
+	if c.network(request.NetworkID) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Network %s could not be found.", request.NetworkID))
+		return
+	}
+	port := Port{
+		ID:             c.newID(),
+		Name:           request.Name,
+		Description:    request.Description,
+		NetworkID:      request.NetworkID,
+		SecurityGroups: request.SecurityGroups,
+	}
+	for _, fixedIP := range request.FixedIPs {
+		address := fixedIP.IPAddress
+		if address == "" {
+			address = c.newIP("10.0")
+		}
+		port.SubnetID = fixedIP.SubnetID
+		port.FixedIPs = append(port.FixedIPs, address)
+	}
+	if len(port.FixedIPs) == 0 {
+		port.FixedIPs = []string{c.newIP("10.0")}
+	}
+	c.state.Ports = append(c.state.Ports, port)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"port": neutronPort(&port)})
+}
+
+// deletePort deletes a port and disassociates its floating IPs
+func (c *Cloud) deletePort(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := r.PathValue("id")
+	if c.port(id) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Port %s could not be found.", id))
+		return
+	}
+	c.disassociatePort(id)
+	kept := c.state.Ports[:0]
+	for _, port := range c.state.Ports {
+		if port.ID != id {
+			kept = append(kept, port)
+		}
+	}
+	c.state.Ports = kept
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// disassociatePort disassociates the floating IPs of a port going away, with the cloud lock held
+func (c *Cloud) disassociatePort(portID string) {
+	for i := range c.state.FloatingIPs {
+		if c.state.FloatingIPs[i].PortID == portID {
+			c.state.FloatingIPs[i].PortID = ""
+		}
+	}
+}
+
+// neutronPort renders a port the way Neutron returns it
+func neutronPort(port *Port) map[string]interface{} {
+	fixedIPs := []map[string]string{}
+	for _, ip := range port.FixedIPs {
+		fixedIPs = append(fixedIPs, map[string]string{"subnet_id": port.SubnetID, "ip_address": ip})
+	}
+	return map[string]interface{}{
+		"id":              port.ID,
+		"name":            port.Name,
+		"description":     port.Description,
+		"network_id":      port.NetworkID,
+		"device_id":       port.DeviceID,
+		"fixed_ips":       fixedIPs,
+		"security_groups": nonNilList(port.SecurityGroups),
+		"status":          "ACTIVE",
+		"admin_state_up":  true,
+	}
+}
+
+// listFloatingIPs lists floating IPs matching the network, port, description and tag filters
+func (c *Cloud) listFloatingIPs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := []map[string]interface{}{}
+	for i := range c.state.FloatingIPs {
+		fip := &c.state.FloatingIPs[i]
+		if !matches(query.Get("floating_network_id"), fip.NetworkID) || !matches(query.Get("port_id"), fip.PortID) ||
+			!matches(query.Get("description"), fip.Description) || !containsAll(fip.Tags, splitList(query.Get("tags"))) {
+			continue
+		}
+		if tagsAny := splitList(query.Get("tags-any")); len(tagsAny) > 0 && !containsAny(fip.Tags, tagsAny) {
+			continue
+		}
+		body = append(body, c.neutronFloatingIP(fip))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"floatingips": body})
+}
+
+// createFloatingIP allocates a floating IP from a pool, failing with a conflict once the quota is used up
+func (c *Cloud) createFloatingIP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		FloatingIP struct {
+			FloatingNetworkID string `json:"floating_network_id"`
+			PortID            string `json:"port_id"`
+			Description       string `json:"description"`
+		} `json:"floatingip"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	request := body.FloatingIP
+	if network := c.network(request.FloatingNetworkID); network == nil || !network.External {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("External network %s could not be found.", request.FloatingNetworkID))
+		return
+	}
+	if limit := c.state.Limits.MaxFloatingIPs; limit > 0 && len(c.state.FloatingIPs) >= limit {
+		writeError(w, http.StatusConflict, "Quota exceeded for resources: ['floatingip'].")
+		return
+	}
+	fip := FloatingIP{
+		ID:          c.newID(),
+		Address:     c.newIP("203.0"),
+		NetworkID:   request.FloatingNetworkID,
+		PortID:      request.PortID,
+		Description: request.Description,
+	}
+	c.state.FloatingIPs = append(c.state.FloatingIPs, fip)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"floatingip": c.neutronFloatingIP(&fip)})
+}
+
+// updateFloatingIP associates a floating IP with a port, or disassociates it
+func (c *Cloud) updateFloatingIP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		FloatingIP struct {
+			PortID *string `json:"port_id"`
+		} `json:"floatingip"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fip := c.floatingIP(r.PathValue("id"))
+	if fip == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Floating IP %s could not be found.", r.PathValue("id")))
+		return
+	}
+	portID := ""
+	if body.FloatingIP.PortID != nil {
+		portID = *body.FloatingIP.PortID
+	}
+	if portID != "" && fip.PortID != "" && fip.PortID != portID {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Floating IP %s is associated with port %s", fip.ID, fip.PortID))
+		return
+	}
+	fip.PortID = portID
+	writeJSON(w, http.StatusOK, map[string]interface{}{"floatingip": c.neutronFloatingIP(fip)})
+}
+
+// deleteFloatingIP releases a floating IP
+func (c *Cloud) deleteFloatingIP(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, fip := range c.state.FloatingIPs {
+		if fip.ID == r.PathValue("id") {
+			c.state.FloatingIPs = append(c.state.FloatingIPs[:i], c.state.FloatingIPs[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Floating IP %s could not be found.", r.PathValue("id")))
+}
+
+// addFloatingIPTag adds a tag to a floating IP
+func (c *Cloud) addFloatingIPTag(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fip := c.floatingIP(r.PathValue("id"))
+	if fip == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Floating IP %s could not be found.", r.PathValue("id")))
+		return
+	}
+	if !contains(fip.Tags, r.PathValue("tag")) {
+		fip.Tags = append(fip.Tags, r.PathValue("tag"))
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// neutronFloatingIP renders a floating IP the way Neutron returns it, with the cloud lock held
+func (c *Cloud) neutronFloatingIP(fip *FloatingIP) map[string]interface{} {
+	var portID, fixedIP interface{}
+	if port := c.port(fip.PortID); port != nil {
+		portID = port.ID
+		if len(port.FixedIPs) > 0 {
+			fixedIP = port.FixedIPs[0]
+		}
+	}
+	status := "DOWN"
+	if portID != nil {
+		status = "ACTIVE"
+	}
+	return map[string]interface{}{
+		"id":                  fip.ID,
+		"floating_ip_address": fip.Address,
+		"floating_network_id": fip.NetworkID,
+		"port_id":             portID,
+		"fixed_ip_address":    fixedIP,
+		"description":         fip.Description,
+		"tags":                nonNilList(fip.Tags),
+		"status":              status,
+		"tenant_id":           ProjectID,
+		"project_id":          ProjectID,
+	}
+}
+
+// listSecurityGroups lists security groups matching the name filter
+func (c *Cloud) listSecurityGroups(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := []map[string]interface{}{}
+	for _, group := range c.state.SecurityGroups {
+		if name == "" || group.Name == name {
+			body = append(body, map[string]interface{}{"id": group.ID, "name": group.Name})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"security_groups": body})
+}
+
+// getSecurityGroup returns a security group
+func (c *Cloud) getSecurityGroup(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, group := range c.state.SecurityGroups {
+		if group.ID == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"security_group": map[string]interface{}{"id": group.ID, "name": group.Name}})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Security group %s could not be found.", r.PathValue("id")))
+}
+
+// matches reports whether a value passes an optional equality filter
+func matches(filter, value string) bool {
+	return filter == "" || filter == value
+}
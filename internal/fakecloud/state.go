@@ -0,0 +1,549 @@
+package fakecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// StatusActive is the status of a running server
+	StatusActive = "ACTIVE"
+	// StatusBuild is the status of a server being provisioned
+	StatusBuild = "BUILD"
+	// StatusError is the status of a server that failed
+	StatusError = "ERROR"
+)
+
+// Snapshot is the complete state of a cloud. It seeds a cloud from JSON, servers without an ID
+// get one assigned.
+type Snapshot struct {
+	Servers        []Server        `json:"servers,omitempty"`
+	Flavors        []Flavor        `json:"flavors,omitempty"`
+	Images         []Image         `json:"images,omitempty"`
+	Keypairs       []Keypair       `json:"keypairs,omitempty"`
+	ServerGroups   []ServerGroup   `json:"serverGroups,omitempty"`
+	Networks       []Network       `json:"networks,omitempty"`
+	Subnets        []Subnet        `json:"subnets,omitempty"`
+	Ports          []Port          `json:"ports,omitempty"`
+	FloatingIPs    []FloatingIP    `json:"floatingIps,omitempty"`
+	SecurityGroups []SecurityGroup `json:"securityGroups,omitempty"`
+	Volumes        []Volume        `json:"volumes,omitempty"`
+	VolumeTypes    []VolumeType    `json:"volumeTypes,omitempty"`
+	// Availability zones servers may be created in, any zone is accepted when empty
+	Zones  []string `json:"zones,omitempty"`
+	Limits Limits   `json:"limits"`
+}
+
+// Server is a Nova server
+type Server struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Status           string            `json:"status"`
+	TaskState        string            `json:"taskState,omitempty"`
+	FlavorID         string            `json:"flavorId"`
+	ImageID          string            `json:"imageId,omitempty"`
+	AvailabilityZone string            `json:"availabilityZone,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	KeyName          string            `json:"keyName,omitempty"`
+	ConfigDrive      bool              `json:"configDrive,omitempty"`
+	SecurityGroups   []string          `json:"securityGroups,omitempty"`
+	ServerGroupID    string            `json:"serverGroupId,omitempty"`
+	ReservationID    string            `json:"reservationId,omitempty"`
+	Locked           bool              `json:"locked,omitempty"`
+	// Fault message and code of a server in ERROR
+	Fault     string    `json:"fault,omitempty"`
+	FaultCode int       `json:"faultCode,omitempty"`
+	Created   time.Time `json:"created"`
+	// Decoded user data the server was created with
+	UserData string `json:"-"`
+	// Volumes attached through the block device mapping
+	Volumes []AttachedVolume `json:"volumes,omitempty"`
+}
+
+// AttachedVolume is a volume attached to a server
+type AttachedVolume struct {
+	ID                  string `json:"id"`
+	DeleteOnTermination bool   `json:"deleteOnTermination,omitempty"`
+}
+
+// Flavor is a Nova flavor
+type Flavor struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	VCPUs      int               `json:"vcpus"`
+	RAM        int               `json:"ram"`
+	Disk       int               `json:"disk"`
+	Ephemeral  int               `json:"ephemeral,omitempty"`
+	ExtraSpecs map[string]string `json:"extraSpecs,omitempty"`
+}
+
+// Image is a Glance image
+type Image struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Status     string            `json:"status,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	MinRAM     int               `json:"minRam,omitempty"`
+	MinDisk    int               `json:"minDisk,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Created    time.Time         `json:"created"`
+}
+
+// Keypair is a Nova key pair
+type Keypair struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// ServerGroup is a Nova server group
+type ServerGroup struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Policy  string   `json:"policy"`
+	Members []string `json:"members,omitempty"`
+}
+
+// Network is a Neutron network
+type Network struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	External bool   `json:"external,omitempty"`
+}
+
+// Subnet is a Neutron subnet
+type Subnet struct {
+	ID        string `json:"id"`
+	NetworkID string `json:"networkId"`
+	CIDR      string `json:"cidr,omitempty"`
+}
+
+// Port is a Neutron port
+type Port struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	NetworkID      string   `json:"networkId"`
+	DeviceID       string   `json:"deviceId,omitempty"`
+	FixedIPs       []string `json:"fixedIps,omitempty"`
+	SubnetID       string   `json:"subnetId,omitempty"`
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+	// Set on ports Nova created for a server, which are deleted together with it
+	NovaCreated bool `json:"novaCreated,omitempty"`
+}
+
+// FloatingIP is a Neutron floating IP
+type FloatingIP struct {
+	ID          string   `json:"id"`
+	Address     string   `json:"address"`
+	NetworkID   string   `json:"networkId"`
+	PortID      string   `json:"portId,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// SecurityGroup is a Neutron security group
+type SecurityGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Volume is a Cinder volume
+type Volume struct {
+	ID               string `json:"id"`
+	Name             string `json:"name,omitempty"`
+	Size             int    `json:"size"`
+	Status           string `json:"status"`
+	VolumeType       string `json:"volumeType,omitempty"`
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	ImageID          string `json:"imageId,omitempty"`
+	// Remaining reads reporting the volume as creating
+	buildPolls int
+}
+
+// VolumeType is a Cinder volume type
+type VolumeType struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Limits are the project quotas, zero or negative values are unlimited
+type Limits struct {
+	MaxInstances          int `json:"maxInstances,omitempty"`
+	MaxCores              int `json:"maxCores,omitempty"`
+	MaxRAM                int `json:"maxRam,omitempty"`
+	MaxFloatingIPs        int `json:"maxFloatingIps,omitempty"`
+	MaxServerGroupMembers int `json:"maxServerGroupMembers,omitempty"`
+}
+
+// LoadSnapshot reads a snapshot from a JSON file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	return &snapshot, nil
+}
+
+// Seed adds the resources of a snapshot to the cloud
+func (c *Cloud) Seed(snapshot *Snapshot) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, server := range snapshot.Servers {
+		c.addServer(server)
+	}
+	c.state.Flavors = append(c.state.Flavors, snapshot.Flavors...)
+	c.state.Images = append(c.state.Images, snapshot.Images...)
+	c.state.Keypairs = append(c.state.Keypairs, snapshot.Keypairs...)
+	c.state.ServerGroups = append(c.state.ServerGroups, snapshot.ServerGroups...)
+	c.state.Networks = append(c.state.Networks, snapshot.Networks...)
+	c.state.Subnets = append(c.state.Subnets, snapshot.Subnets...)
+	c.state.Ports = append(c.state.Ports, snapshot.Ports...)
+	c.state.FloatingIPs = append(c.state.FloatingIPs, snapshot.FloatingIPs...)
+	c.state.SecurityGroups = append(c.state.SecurityGroups, snapshot.SecurityGroups...)
+	c.state.Volumes = append(c.state.Volumes, snapshot.Volumes...)
+	c.state.VolumeTypes = append(c.state.VolumeTypes, snapshot.VolumeTypes...)
+	c.state.Zones = append(c.state.Zones, snapshot.Zones...)
+	if snapshot.Limits != (Limits{}) {
+		c.state.Limits = snapshot.Limits
+	}
+}
+
+// Snapshot returns a copy of the state of the cloud
+func (c *Cloud) Snapshot() Snapshot {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, _ := json.Marshal(c.state)
+	var snapshot Snapshot
+	_ = json.Unmarshal(data, &snapshot)
+	for i := range snapshot.Servers {
+		snapshot.Servers[i].UserData = c.state.Servers[i].UserData
+	}
+	return snapshot
+}
+
+// AddServer adds a server, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddServer(server Server) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.addServer(server)
+}
+
+// addServer adds a server with the cloud lock held
+func (c *Cloud) addServer(server Server) string {
+	if server.ID == "" {
+		server.ID = c.newID()
+	}
+	if server.Status == "" {
+		server.Status = StatusActive
+	}
+	if server.Created.IsZero() {
+		server.Created = c.now()
+	}
+	if server.Metadata == nil {
+		server.Metadata = make(map[string]string)
+	}
+	c.state.Servers = append(c.state.Servers, server)
+	return server.ID
+}
+
+// AddFlavor adds a flavor, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddFlavor(flavor Flavor) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if flavor.ID == "" {
+		flavor.ID = c.newID()
+	}
+	c.state.Flavors = append(c.state.Flavors, flavor)
+	return flavor.ID
+}
+
+// AddImage adds an active image, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddImage(image Image) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if image.ID == "" {
+		image.ID = c.newID()
+	}
+	if image.Status == "" {
+		image.Status = "active"
+	}
+	if image.Created.IsZero() {
+		image.Created = c.now()
+	}
+	c.state.Images = append(c.state.Images, image)
+	return image.ID
+}
+
+// AddKeypair adds a key pair
+func (c *Cloud) AddKeypair(keypair Keypair) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.state.Keypairs = append(c.state.Keypairs, keypair)
+}
+
+// AddServerGroup adds a server group, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddServerGroup(group ServerGroup) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if group.ID == "" {
+		group.ID = c.newID()
+	}
+	c.state.ServerGroups = append(c.state.ServerGroups, group)
+	return group.ID
+}
+
+// AddNetwork adds a network, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddNetwork(network Network) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if network.ID == "" {
+		network.ID = c.newID()
+	}
+	c.state.Networks = append(c.state.Networks, network)
+	return network.ID
+}
+
+// AddSubnet adds a subnet, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddSubnet(subnet Subnet) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if subnet.ID == "" {
+		subnet.ID = c.newID()
+	}
+	c.state.Subnets = append(c.state.Subnets, subnet)
+	return subnet.ID
+}
+
+// AddPort adds a port, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddPort(port Port) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if port.ID == "" {
+		port.ID = c.newID()
+	}
+	c.state.Ports = append(c.state.Ports, port)
+	return port.ID
+}
+
+// AddFloatingIP adds a floating IP, assigning an ID and address when it has none, and returns its ID
+func (c *Cloud) AddFloatingIP(fip FloatingIP) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if fip.ID == "" {
+		fip.ID = c.newID()
+	}
+	if fip.Address == "" {
+		fip.Address = c.newIP("203.0")
+	}
+	c.state.FloatingIPs = append(c.state.FloatingIPs, fip)
+	return fip.ID
+}
+
+// AddSecurityGroup adds a security group, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddSecurityGroup(group SecurityGroup) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if group.ID == "" {
+		group.ID = c.newID()
+	}
+	c.state.SecurityGroups = append(c.state.SecurityGroups, group)
+	return group.ID
+}
+
+// AddVolumeType adds a volume type, assigning an ID when it has none, and returns its ID
+func (c *Cloud) AddVolumeType(volumeType VolumeType) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if volumeType.ID == "" {
+		volumeType.ID = c.newID()
+	}
+	c.state.VolumeTypes = append(c.state.VolumeTypes, volumeType)
+	return volumeType.ID
+}
+
+// SetZones sets the availability zones servers may be created in
+func (c *Cloud) SetZones(zones ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.state.Zones = zones
+}
+
+// SetLimits sets the project quotas
+func (c *Cloud) SetLimits(limits Limits) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.state.Limits = limits
+}
+
+// Server returns a copy of a server
+func (c *Cloud) Server(id string) (Server, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if server := c.server(id); server != nil {
+		return *server, true
+	}
+	return Server{}, false
+}
+
+// Servers returns copies of all servers sorted by name
+func (c *Cloud) Servers() []Server {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	all := append([]Server(nil), c.state.Servers...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// UpdateServer changes a server in place, returning false when it does not exist
+func (c *Cloud) UpdateServer(id string, update func(*Server)) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	server := c.server(id)
+	if server == nil {
+		return false
+	}
+	update(server)
+	return true
+}
+
+// UpdateFlavor changes a flavor in place, such as renaming it, returning false when it does not exist
+func (c *Cloud) UpdateFlavor(id string, update func(*Flavor)) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	flavor := c.flavor(id)
+	if flavor == nil {
+		return false
+	}
+	update(flavor)
+	return true
+}
+
+// UpdateImage changes an image in place, such as renaming it, returning false when it does not exist
+func (c *Cloud) UpdateImage(id string, update func(*Image)) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	image := c.image(id)
+	if image == nil {
+		return false
+	}
+	update(image)
+	return true
+}
+
+// Ports returns copies of all ports
+func (c *Cloud) Ports() []Port {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Port(nil), c.state.Ports...)
+}
+
+// FloatingIPs returns copies of all floating IPs
+func (c *Cloud) FloatingIPs() []FloatingIP {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]FloatingIP(nil), c.state.FloatingIPs...)
+}
+
+// ServerGroups returns copies of all server groups
+func (c *Cloud) ServerGroups() []ServerGroup {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]ServerGroup(nil), c.state.ServerGroups...)
+}
+
+// Volumes returns copies of all volumes
+func (c *Cloud) Volumes() []Volume {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Volume(nil), c.state.Volumes...)
+}
+
+// server returns a server with the cloud lock held
+func (c *Cloud) server(id string) *Server {
+	for i := range c.state.Servers {
+		if c.state.Servers[i].ID == id {
+			return &c.state.Servers[i]
+		}
+	}
+	return nil
+}
+
+// flavor returns a flavor by ID with the cloud lock held
+func (c *Cloud) flavor(id string) *Flavor {
+	for i := range c.state.Flavors {
+		if c.state.Flavors[i].ID == id {
+			return &c.state.Flavors[i]
+		}
+	}
+	return nil
+}
+
+// image returns an image by ID with the cloud lock held
+func (c *Cloud) image(id string) *Image {
+	for i := range c.state.Images {
+		if c.state.Images[i].ID == id {
+			return &c.state.Images[i]
+		}
+	}
+	return nil
+}
+
+// serverGroup returns a server group by ID with the cloud lock held
+func (c *Cloud) serverGroup(id string) *ServerGroup {
+	for i := range c.state.ServerGroups {
+		if c.state.ServerGroups[i].ID == id {
+			return &c.state.ServerGroups[i]
+		}
+	}
+	return nil
+}
+
+// network returns a network by ID with the cloud lock held
+func (c *Cloud) network(id string) *Network {
+	for i := range c.state.Networks {
+		if c.state.Networks[i].ID == id {
+			return &c.state.Networks[i]
+		}
+	}
+	return nil
+}
+
+// port returns a port by ID with the cloud lock held
+func (c *Cloud) port(id string) *Port {
+	for i := range c.state.Ports {
+		if c.state.Ports[i].ID == id {
+			return &c.state.Ports[i]
+		}
+	}
+	return nil
+}
+
+// floatingIP returns a floating IP by ID with the cloud lock held
+func (c *Cloud) floatingIP(id string) *FloatingIP {
+	for i := range c.state.FloatingIPs {
+		if c.state.FloatingIPs[i].ID == id {
+			return &c.state.FloatingIPs[i]
+		}
+	}
+	return nil
+}
+
+// volume returns a volume by ID with the cloud lock held
+func (c *Cloud) volume(id string) *Volume {
+	for i := range c.state.Volumes {
+		if c.state.Volumes[i].ID == id {
+			return &c.state.Volumes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// registerVolume registers the Cinder endpoints
+func (c *Cloud) registerVolume() {
+	c.mux.HandleFunc("POST "+VolumePath+"/volumes", c.createVolume)
+	c.mux.HandleFunc("GET "+VolumePath+"/volumes/{id}", c.getVolume)
+	c.mux.HandleFunc("DELETE "+VolumePath+"/volumes/{id}", c.deleteVolume)
+	c.mux.HandleFunc("GET "+VolumePath+"/types", c.listVolumeTypes)
+}
+
+// createVolume creates a volume, which reads as creating for the configured number of polls
+func (c *Cloud) createVolume(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Volume struct {
+			Name             string `json:"name"`
+			Size             int    `json:"size"`
+			VolumeType       string `json:"volume_type"`
+			AvailabilityZone string `json:"availability_zone"`
+			ImageID          string `json:"imageRef"`
+		} `json:"volume"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	request := body.Volume
+	if request.ImageID != "" && c.image(request.ImageID) == nil {
+		writeError(w, http.StatusBadRequest, "Invalid image identifier or unable to access requested image.")
+		return
+	}
+	volume := Volume{
+		ID:               c.newID(),
+		Name:             request.Name,
+		Size:             request.Size,
+		Status:           "available",
+		VolumeType:       request.VolumeType,
+		AvailabilityZone: request.AvailabilityZone,
+		ImageID:          request.ImageID,
+		buildPolls:       c.volumeBuildPolls,
+	}
+	if volume.buildPolls > 0 {
+		volume.Status = "creating"
+	}
+	c.state.Volumes = append(c.state.Volumes, volume)
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"volume": cinderVolume(&volume)})
+}
+
+// getVolume returns a volume, counting down the polls of a volume being created
+func (c *Cloud) getVolume(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	volume := c.volume(r.PathValue("id"))
+	if volume == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Volume %s could not be found.", r.PathValue("id")))
+		return
+	}
+	if volume.Status == "creating" {
+		if volume.buildPolls--; volume.buildPolls <= 0 {
+			volume.Status = "available"
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"volume": cinderVolume(volume)})
+}
+
+// deleteVolume deletes a volume that is not attached
+func (c *Cloud) deleteVolume(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	volume := c.volume(r.PathValue("id"))
+	if volume == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Volume %s could not be found.", r.PathValue("id")))
+		return
+	}
+	if volume.Status == "in-use" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Volume %s is attached", volume.ID))
+		return
+	}
+	c.removeVolume(volume.ID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// removeVolume removes a volume with the cloud lock held
+func (c *Cloud) removeVolume(id string) {
+	kept := c.state.Volumes[:0]
+	for _, volume := range c.state.Volumes {
+		if volume.ID != id {
+			kept = append(kept, volume)
+		}
+	}
+	c.state.Volumes = kept
+}
+
+// listVolumeTypes lists all volume types
+func (c *Cloud) listVolumeTypes(w http.ResponseWriter, _ *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	body := []map[string]interface{}{}
+	for _, volumeType := range c.state.VolumeTypes {
+		body = append(body, map[string]interface{}{"id": volumeType.ID, "name": volumeType.Name, "is_public": true})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"volume_types": body})
+}
+
+// cinderVolume renders a volume the way Cinder returns it
+func cinderVolume(volume *Volume) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                volume.ID,
+		"name":              volume.Name,
+		"size":              volume.Size,
+		"status":            volume.Status,
+		"volume_type":       volume.VolumeType,
+		"availability_zone": volume.AvailabilityZone,
+		"attachments":       []interface{}{},
+		"metadata":          map[string]string{},
+		"bootable":          fmt.Sprintf("%t", volume.ImageID != ""),
+	}
+}
@@ -0,0 +1,81 @@
+package inventory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// GroupIndex maps server IDs to the ID of the node group they belong to, servers of no node
+// group map to an empty ID. The zero value is an empty index that is stale.
+type GroupIndex struct {
+	mutex     sync.Mutex
+	groups    map[string]string
+	refreshed time.Time
+}
+
+// Stale reports whether the index was not rebuilt within ttl
+func (i *GroupIndex) Stale(ttl time.Duration) bool {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return time.Since(i.refreshed) > ttl
+}
+
+// Rebuild maps the listed servers to the node group groupOf returns for them and returns the
+// number of servers in the index. The listing only holds node group servers, servers already
+// known to belong to no node group are kept.
+func (i *GroupIndex) Rebuild(listed []servers.Server, groupOf func(*servers.Server) string) int {
+	i.mutex.Lock()
+	groups := make(map[string]string, len(listed))
+	for serverID, groupID := range i.groups {
+		if groupID == "" {
+			groups[serverID] = ""
+		}
+	}
+	i.mutex.Unlock()
+
+	for j := range listed {
+		groups[listed[j].ID] = groupOf(&listed[j])
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.groups = groups
+	i.refreshed = time.Now()
+	return len(groups)
+}
+
+// Lookup returns the node group ID of a server, empty for servers of no node group, and
+// whether the server is in the index at all
+func (i *GroupIndex) Lookup(serverID string) (string, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	groupID, exists := i.groups[serverID]
+	return groupID, exists
+}
+
+// Record maps a server fetched after a lookup miss, until the first rebuild it is ignored
+func (i *GroupIndex) Record(serverID, groupID string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.groups == nil {
+		return
+	}
+	i.groups[serverID] = groupID
+}
+
+// Invalidate marks the index stale so the next lookup rebuilds it
+func (i *GroupIndex) Invalidate() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.refreshed = time.Time{}
+}
+
+// Len returns the number of servers in the index
+func (i *GroupIndex) Len() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return len(i.groups)
+}
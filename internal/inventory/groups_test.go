@@ -0,0 +1,85 @@
+package inventory
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// groupByPrefix maps servers to the node group their name starts with
+func groupByPrefix(server *servers.Server) string {
+	group, _, found := strings.Cut(server.Name, "-")
+	if !found {
+		return ""
+	}
+	return group
+}
+
+func TestGroupIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		record map[string]string
+		listed [][]servers.Server
+		lookup map[string]string
+		absent []string
+		size   int
+	}{
+		{name: "empty", absent: []string{"a"}},
+		{name: "records are ignored before the first rebuild", record: map[string]string{"a": "workers"}, absent: []string{"a"}},
+		{
+			name:   "rebuilt from the listing",
+			listed: [][]servers.Server{{{ID: "a", Name: "workers-a"}, {ID: "b", Name: "database"}}},
+			lookup: map[string]string{"a": "workers", "b": ""}, absent: []string{"c"}, size: 2,
+		},
+		{
+			name:   "unrelated servers survive rebuilds",
+			listed: [][]servers.Server{{{ID: "a", Name: "workers-a"}, {ID: "b", Name: "database"}}, {{ID: "c", Name: "gpu-c"}}},
+			lookup: map[string]string{"b": "", "c": "gpu"}, absent: []string{"a"}, size: 2,
+		},
+		{
+			name:   "recorded after a miss",
+			listed: [][]servers.Server{{{ID: "a", Name: "workers-a"}}},
+			record: map[string]string{"d": "gpu", "e": ""},
+			lookup: map[string]string{"a": "workers", "d": "gpu", "e": ""}, size: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var index GroupIndex
+			th.AssertEquals(t, true, index.Stale(time.Hour))
+			for _, listed := range tt.listed {
+				index.Rebuild(listed, groupByPrefix)
+			}
+			for serverID, groupID := range tt.record {
+				index.Record(serverID, groupID)
+			}
+
+			for serverID, groupID := range tt.lookup {
+				found, exists := index.Lookup(serverID)
+				th.AssertEquals(t, true, exists)
+				th.AssertEquals(t, groupID, found)
+			}
+			for _, serverID := range tt.absent {
+				_, exists := index.Lookup(serverID)
+				th.AssertEquals(t, false, exists)
+			}
+			th.AssertEquals(t, tt.size, index.Len())
+		})
+	}
+}
+
+func TestGroupIndexStaleness(t *testing.T) {
+	var index GroupIndex
+	th.AssertEquals(t, 1, index.Rebuild([]servers.Server{{ID: "a", Name: "workers-a"}}, groupByPrefix))
+	th.AssertEquals(t, false, index.Stale(time.Hour))
+
+	index.Invalidate()
+	th.AssertEquals(t, true, index.Stale(time.Hour))
+	// Invalidation only forces a rebuild, lookups keep answering meanwhile
+	groupID, exists := index.Lookup("a")
+	th.AssertEquals(t, true, exists)
+	th.AssertEquals(t, "workers", groupID)
+}
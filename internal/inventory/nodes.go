@@ -0,0 +1,92 @@
+package inventory
+
+import (
+	"sync"
+	"time"
+)
+
+// NodeState tells which servers are registered as Kubernetes nodes
+type NodeState interface {
+	// State returns whether a server is registered as a node, whether that node is marked for
+	// deletion and whether the view is complete
+	State(serverID string) (registered, markedForDeletion, synced bool)
+}
+
+// NodeRegistry mirrors which servers are registered as Kubernetes nodes, as reported by the node
+// watcher. It is only complete once the watcher synced, before that a server missing from it may
+// still be a registered node. The zero value is an empty registry.
+type NodeRegistry struct {
+	mutex  sync.Mutex
+	synced bool
+	// The registered node of each server
+	nodes map[string]registeredNode
+}
+
+// registeredNode is the state of the Kubernetes node of a server
+type registeredNode struct {
+	// markedForDeletion is whether the cluster autoscaler marked the node for deletion
+	markedForDeletion bool
+	// recorded is when the node was last reported
+	recorded time.Time
+}
+
+var _ NodeState = (*NodeRegistry)(nil)
+
+// Record records the Kubernetes node of a server
+func (r *NodeRegistry) Record(serverID string, markedForDeletion bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.nodes == nil {
+		r.nodes = make(map[string]registeredNode)
+	}
+	r.nodes[serverID] = registeredNode{markedForDeletion: markedForDeletion, recorded: time.Now()}
+}
+
+// Forget forgets the node of a server that was removed from the cluster
+func (r *NodeRegistry) Forget(serverID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.nodes, serverID)
+}
+
+// MarkSynced marks the registry complete and returns the number of registered nodes
+func (r *NodeRegistry) MarkSynced() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.synced = true
+	return len(r.nodes)
+}
+
+// State returns whether a server is registered as a node, whether that node is marked for
+// deletion and whether the registry is complete
+func (r *NodeRegistry) State(serverID string) (registered, markedForDeletion, synced bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	node, registered := r.nodes[serverID]
+	return registered, node.markedForDeletion, r.synced
+}
+
+// Prune forgets the nodes of servers that no longer exist, in case the watcher missed their
+// deletion. Nodes recorded after the servers were listed are kept, their server may be newer
+// than the listing. It returns the number of registered nodes.
+func (r *NodeRegistry) Prune(existing map[string]bool, listedAt time.Time) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for serverID, node := range r.nodes {
+		if !existing[serverID] && node.recorded.Before(listedAt) {
+			delete(r.nodes, serverID)
+		}
+	}
+	return len(r.nodes)
+}
+
+// Len returns the number of registered nodes
+func (r *NodeRegistry) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.nodes)
+}
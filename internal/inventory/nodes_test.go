@@ -0,0 +1,65 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestNodeRegistryConformance(t *testing.T) {
+	tests := []struct {
+		name              string
+		run               func(*NodeRegistry)
+		registered        bool
+		markedForDeletion bool
+		synced            bool
+	}{
+		{name: "empty"},
+		{name: "registered", run: func(r *NodeRegistry) { r.Record("a", false) }, registered: true},
+		{name: "marked for deletion", run: func(r *NodeRegistry) { r.Record("a", true) }, registered: true, markedForDeletion: true},
+		{name: "unmarked again", run: func(r *NodeRegistry) { r.Record("a", true); r.Record("a", false) }, registered: true},
+		{name: "deleted", run: func(r *NodeRegistry) { r.Record("a", true); r.Forget("a") }},
+		{name: "synced without the node", run: func(r *NodeRegistry) { r.MarkSynced() }, synced: true},
+		{name: "synced with the node", run: func(r *NodeRegistry) { r.Record("a", false); r.MarkSynced() }, registered: true, synced: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &NodeRegistry{}
+			if tt.run != nil {
+				tt.run(registry)
+			}
+
+			var state NodeState = registry
+			registered, markedForDeletion, synced := state.State("a")
+			th.AssertEquals(t, tt.registered, registered)
+			th.AssertEquals(t, tt.markedForDeletion, markedForDeletion)
+			th.AssertEquals(t, tt.synced, synced)
+		})
+	}
+}
+
+func TestNodeRegistryMarkSyncedCountsNodes(t *testing.T) {
+	var registry NodeRegistry
+	registry.Record("a", false)
+	registry.Record("b", true)
+	registry.Forget("a")
+	th.AssertEquals(t, 1, registry.MarkSynced())
+}
+
+func TestNodeRegistryPrune(t *testing.T) {
+	var registry NodeRegistry
+	registry.Record("a", false)
+	registry.Record("b", false)
+	listedAt := time.Now()
+	time.Sleep(time.Millisecond)
+	registry.Record("c", false)
+
+	// b is gone from Nova, c registered after the listing
+	th.AssertEquals(t, 2, registry.Prune(map[string]bool{"a": true}, listedAt))
+	for serverID, registered := range map[string]bool{"a": true, "b": false, "c": true} {
+		state, _, _ := registry.State(serverID)
+		th.AssertEquals(t, registered, state)
+	}
+	th.AssertEquals(t, 2, registry.Len())
+}
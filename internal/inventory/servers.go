@@ -0,0 +1,91 @@
+// Package inventory keeps the views of the cloud and the cluster the provider answers from
+// without asking OpenStack again: a shared server listing, the node group of each server and
+// the Kubernetes nodes of the servers.
+package inventory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// ServerSource visits the servers of the project page by page
+type ServerSource interface {
+	EachServer(ctx context.Context, opts servers.ListOptsBuilder, visit func(*servers.Server)) error
+}
+
+// Collect lists the servers matching opts and keeps those keep accepts, returning how many were
+// dropped. Servers are filtered page by page so busy shared projects do not hold thousands of
+// unrelated servers in memory.
+func Collect(ctx context.Context, source ServerSource, opts servers.ListOptsBuilder, keep func(*servers.Server) bool) ([]servers.Server, int, error) {
+	var kept []servers.Server
+	dropped := 0
+	err := source.EachServer(ctx, opts, func(server *servers.Server) {
+		if !keep(server) {
+			dropped++
+			return
+		}
+		kept = append(kept, *server)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return kept, dropped, nil
+}
+
+// Snapshot is the last server listing, shared by all node groups so an autoscaler loop lists
+// servers once instead of once per node group and call. The zero value is an empty snapshot.
+type Snapshot struct {
+	// listMutex serializes listings so concurrent callers wait for and reuse the same one
+	listMutex sync.Mutex
+	mutex     sync.Mutex
+	servers   []servers.Server
+	taken     time.Time
+}
+
+// Servers returns the shared listing while it is younger than ttl, otherwise it replaces the
+// listing with the result of list. Concurrent callers share one listing.
+func (s *Snapshot) Servers(ttl time.Duration, list func() ([]servers.Server, error)) ([]servers.Server, error) {
+	if snapshot, fresh := s.fresh(ttl); fresh {
+		return snapshot, nil
+	}
+
+	s.listMutex.Lock()
+	defer s.listMutex.Unlock()
+
+	// Another caller may have listed while we waited
+	if snapshot, fresh := s.fresh(ttl); fresh {
+		return snapshot, nil
+	}
+
+	listed, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.servers = listed
+	s.taken = time.Now()
+	s.mutex.Unlock()
+	return listed, nil
+}
+
+// fresh returns the shared listing and whether it is still within ttl
+func (s *Snapshot) fresh(ttl time.Duration) ([]servers.Server, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.taken.IsZero() || time.Since(s.taken) > ttl {
+		return nil, false
+	}
+	return s.servers, true
+}
+
+// Invalidate makes the next call list servers again
+func (s *Snapshot) Invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.taken = time.Time{}
+}
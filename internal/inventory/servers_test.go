@@ -0,0 +1,142 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// staticSource is a ServerSource serving a fixed list of servers
+type staticSource struct {
+	servers []servers.Server
+	err     error
+	opts    []servers.ListOptsBuilder
+}
+
+func (s *staticSource) EachServer(_ context.Context, opts servers.ListOptsBuilder, visit func(*servers.Server)) error {
+	s.opts = append(s.opts, opts)
+	for i := range s.servers {
+		visit(&s.servers[i])
+	}
+	return s.err
+}
+
+var _ ServerSource = (*staticSource)(nil)
+
+func TestCollect(t *testing.T) {
+	listed := []servers.Server{{ID: "a", Name: "workers-a"}, {ID: "b", Name: "database"}, {ID: "c", Name: "workers-c"}}
+	failure := errors.New("listing failed")
+	tests := []struct {
+		name    string
+		err     error
+		keep    func(*servers.Server) bool
+		kept    []string
+		dropped int
+	}{
+		{name: "keeps all", keep: func(*servers.Server) bool { return true }, kept: []string{"a", "b", "c"}},
+		{name: "drops unrelated", keep: func(server *servers.Server) bool { return server.Name != "database" }, kept: []string{"a", "c"}, dropped: 1},
+		{name: "drops all", keep: func(*servers.Server) bool { return false }, dropped: 3},
+		{name: "listing fails", err: failure, keep: func(*servers.Server) bool { return true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &staticSource{servers: listed, err: tt.err}
+			opts := servers.ListOpts{TagsAny: "nodegroup:workers"}
+
+			kept, dropped, err := Collect(context.Background(), source, opts, tt.keep)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				th.AssertEquals(t, 0, len(kept))
+				return
+			}
+			th.AssertNoErr(t, err)
+
+			var ids []string
+			for _, server := range kept {
+				ids = append(ids, server.ID)
+			}
+			th.AssertDeepEquals(t, tt.kept, ids)
+			th.AssertEquals(t, tt.dropped, dropped)
+			th.AssertDeepEquals(t, []servers.ListOptsBuilder{opts}, source.opts)
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	failure := errors.New("listing failed")
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		invalidate bool
+		failFirst  bool
+		listings   int32
+	}{
+		{name: "shared within the TTL", ttl: time.Hour, listings: 1},
+		{name: "listed again once expired", ttl: -time.Second, listings: 2},
+		{name: "listed again after invalidation", ttl: time.Hour, invalidate: true, listings: 2},
+		{name: "failures are not shared", ttl: time.Hour, failFirst: true, listings: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var snapshot Snapshot
+			var listings atomic.Int32
+			list := func() ([]servers.Server, error) {
+				if listings.Add(1) == 1 && tt.failFirst {
+					return nil, failure
+				}
+				return []servers.Server{{ID: "a"}}, nil
+			}
+
+			first, err := snapshot.Servers(tt.ttl, list)
+			if tt.failFirst {
+				if !errors.Is(err, failure) {
+					t.Fatalf("expected %v, got %v", failure, err)
+				}
+			} else {
+				th.AssertNoErr(t, err)
+				th.AssertEquals(t, 1, len(first))
+			}
+			if tt.invalidate {
+				snapshot.Invalidate()
+			}
+			second, err := snapshot.Servers(tt.ttl, list)
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 1, len(second))
+			th.AssertEquals(t, tt.listings, listings.Load())
+		})
+	}
+}
+
+func TestSnapshotConcurrentCallersShareOneListing(t *testing.T) {
+	var snapshot Snapshot
+	var listings atomic.Int32
+	release := make(chan struct{})
+	list := func() ([]servers.Server, error) {
+		listings.Add(1)
+		<-release
+		return []servers.Server{{ID: "a"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listed, err := snapshot.Servers(time.Hour, list)
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 1, len(listed))
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	th.AssertEquals(t, int32(1), listings.Load())
+}
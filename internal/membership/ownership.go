@@ -0,0 +1,132 @@
+// Package membership decides which servers belong to a node group, from the tags and metadata
+// the autoscaler records on the servers it creates, and tracks the servers known to be members.
+package membership
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+const (
+	// NodeGroupMetadataKey is the server metadata key recording node group ownership
+	NodeGroupMetadataKey = "nodegroup"
+	// CreatedByMetadataKey is the server metadata key marking servers created by the autoscaler
+	CreatedByMetadataKey = "created_by"
+	// CreatedByMetadataValue is the value of CreatedByMetadataKey
+	CreatedByMetadataValue = "openstack-autoscaler"
+	// ClusterMetadataKey is the server metadata key recording the cluster a server was created for
+	ClusterMetadataKey = "cluster"
+
+	// NodeGroupTagPrefix prefixes the node group ID in the server tag recording membership
+	NodeGroupTagPrefix = "nodegroup:"
+	// ManagedByTag marks every server the autoscaler created
+	ManagedByTag = "managed-by:" + CreatedByMetadataValue
+	// MaxTagLength is the maximum length of a Nova server tag
+	MaxTagLength = 60
+)
+
+// Match is how a server was attributed to a node group
+type Match int
+
+const (
+	// NoMatch means the server is not a member of the node group
+	NoMatch Match = iota
+	// MatchedByTag means the server carries the membership tag of the node group
+	MatchedByTag
+	// MatchedByMetadata means the node group metadata of the server names the node group
+	MatchedByMetadata
+	// MatchedByName means the server carries no ownership at all and its name contains the node group ID
+	MatchedByName
+)
+
+// Tag returns the server tag marking members of the node group
+func Tag(groupID string) string {
+	return NodeGroupTagPrefix + groupID
+}
+
+// HasTag reports whether the server carries the membership tag of the node group
+func HasTag(server *servers.Server, groupID string) bool {
+	if server.Tags == nil {
+		return false
+	}
+	for _, tag := range *server.Tags {
+		if tag == Tag(groupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyTag reports whether a server carries the membership tag of any node group
+func HasAnyTag(server *servers.Server) bool {
+	if server.Tags == nil {
+		return false
+	}
+	for _, tag := range *server.Tags {
+		if strings.HasPrefix(tag, NodeGroupTagPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Classify returns how a server is attributed to the node group. The membership tag wins over
+// the metadata, and servers carrying the metadata or tag of another node group never match by
+// their name.
+func Classify(server *servers.Server, groupID string) Match {
+	if HasTag(server, groupID) {
+		return MatchedByTag
+	}
+	if nodeGroupID, exists := server.Metadata[NodeGroupMetadataKey]; exists {
+		if nodeGroupID == groupID {
+			return MatchedByMetadata
+		}
+		return NoMatch
+	}
+	if HasAnyTag(server) || !strings.Contains(server.Name, groupID) {
+		return NoMatch
+	}
+	return MatchedByName
+}
+
+// Owns reports whether a server carries the membership tag or node group metadata of the node
+// group, servers attributed by their name alone are not owned
+func Owns(server *servers.Server, groupID string) bool {
+	return HasTag(server, groupID) || server.Metadata[NodeGroupMetadataKey] == groupID
+}
+
+// CreatedFor reports whether a server carries the full ownership metadata the autoscaler sets
+// on the servers it creates for the node group
+func CreatedFor(server *servers.Server, groupID string) bool {
+	return server.Metadata[NodeGroupMetadataKey] == groupID &&
+		server.Metadata[CreatedByMetadataKey] == CreatedByMetadataValue
+}
+
+// Metadata returns the ownership metadata of a server of the node group, the cluster is
+// only recorded when it is named
+func Metadata(groupID, clusterName string) map[string]string {
+	metadata := map[string]string{
+		NodeGroupMetadataKey: groupID,
+		CreatedByMetadataKey: CreatedByMetadataValue,
+	}
+	if clusterName != "" {
+		metadata[ClusterMetadataKey] = clusterName
+	}
+	return metadata
+}
+
+// ValidateTags checks the configured server tags and the membership tag of the node group
+// against the Nova tag rules
+func ValidateTags(groupID string, tags []string) error {
+	for _, tag := range tags {
+		if tag == "" || len(tag) > MaxTagLength || strings.ContainsAny(tag, "/,") {
+			return fmt.Errorf("tag %q must be 1 to %d characters without slashes or commas", tag, MaxTagLength)
+		}
+	}
+	if len(Tag(groupID)) > MaxTagLength {
+		return fmt.Errorf("node group ID is too long for the %d character membership tag", MaxTagLength)
+	}
+	return nil
+}
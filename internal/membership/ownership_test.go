@@ -0,0 +1,76 @@
+package membership
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  servers.Server
+		match   Match
+		owned   bool
+		created bool
+	}{
+		{name: "membership tag", server: servers.Server{Name: "node", Tags: &[]string{Tag("workers")}}, match: MatchedByTag, owned: true},
+		{
+			name:   "tag wins over foreign metadata",
+			server: servers.Server{Name: "node", Tags: &[]string{Tag("workers")}, Metadata: map[string]string{NodeGroupMetadataKey: "other"}},
+			match:  MatchedByTag, owned: true,
+		},
+		{
+			name:   "ownership metadata",
+			server: servers.Server{Name: "node", Metadata: map[string]string{NodeGroupMetadataKey: "workers", CreatedByMetadataKey: CreatedByMetadataValue}},
+			match:  MatchedByMetadata, owned: true, created: true,
+		},
+		{name: "node group metadata only", server: servers.Server{Name: "node", Metadata: map[string]string{NodeGroupMetadataKey: "workers"}}, match: MatchedByMetadata, owned: true},
+		{name: "foreign metadata", server: servers.Server{Name: "workers-1", Metadata: map[string]string{NodeGroupMetadataKey: "other"}}, match: NoMatch},
+		{name: "foreign tag", server: servers.Server{Name: "workers-1", Tags: &[]string{Tag("other")}}, match: NoMatch},
+		{name: "name only", server: servers.Server{Name: "cluster-workers-1"}, match: MatchedByName},
+		{name: "unrelated", server: servers.Server{Name: "database"}, match: NoMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.AssertEquals(t, tt.match, Classify(&tt.server, "workers"))
+			th.AssertEquals(t, tt.owned, Owns(&tt.server, "workers"))
+			th.AssertEquals(t, tt.created, CreatedFor(&tt.server, "workers"))
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	th.AssertDeepEquals(t, map[string]string{
+		NodeGroupMetadataKey: "workers",
+		CreatedByMetadataKey: CreatedByMetadataValue,
+	}, Metadata("workers", ""))
+	th.AssertEquals(t, "prod", Metadata("workers", "prod")[ClusterMetadataKey])
+
+	server := servers.Server{Metadata: Metadata("workers", "prod")}
+	th.AssertEquals(t, true, CreatedFor(&server, "workers"))
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		groupID string
+		tags    []string
+		valid   bool
+	}{
+		{name: "no tags", groupID: "workers", valid: true},
+		{name: "valid tags", groupID: "workers", tags: []string{"env:prod", "team-a"}, valid: true},
+		{name: "empty tag", groupID: "workers", tags: []string{""}},
+		{name: "slash", groupID: "workers", tags: []string{"a/b"}},
+		{name: "comma", groupID: "workers", tags: []string{"a,b"}},
+		{name: "too long", groupID: "workers", tags: []string{string(make([]byte, MaxTagLength+1))}},
+		{name: "node group ID too long", groupID: string(make([]byte, MaxTagLength))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTags(tt.groupID, tt.tags)
+			th.AssertEquals(t, tt.valid, err == nil)
+		})
+	}
+}
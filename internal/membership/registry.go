@@ -0,0 +1,158 @@
+package membership
+
+import (
+	"sync"
+	"time"
+)
+
+// Members is the record of known members a node group consults before trusting the ownership
+// a server carries, so servers that lost their metadata are not dropped from the node group
+type Members interface {
+	// Remember records a server as a known member
+	Remember(serverID string)
+	// Known reports whether a server was previously seen as a member
+	Known(serverID string) bool
+	// Forget removes a server from the known members
+	Forget(serverID string)
+	// Prune forgets the known members that no longer exist
+	Prune(existing map[string]bool)
+}
+
+// memberState tracks a known member of a node group
+type memberState struct {
+	// lastSeen is when the server was last confirmed as a member
+	lastSeen time.Time
+	// lastRepair is when the ownership metadata of the server was last restored
+	lastRepair time.Time
+}
+
+// Registry is the record of the known members of one node group and of the servers attributed
+// to it by their name. It is held in memory only, after a restart members are known again once
+// listed with their ownership, so a server stripped while the autoscaler was down is not adopted.
+// It is safe for concurrent use.
+type Registry struct {
+	mutex   sync.Mutex
+	members map[string]*memberState
+	// Servers attributed to the node group by their name, warned about once each
+	nameMatched map[string]bool
+}
+
+var _ Members = (*Registry)(nil)
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{members: make(map[string]*memberState)}
+}
+
+// Remember records a server as a known member of the node group
+func (r *Registry) Remember(serverID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if member, exists := r.members[serverID]; exists {
+		member.lastSeen = time.Now()
+		return
+	}
+	r.members[serverID] = &memberState{lastSeen: time.Now()}
+}
+
+// Known reports whether a server was previously seen as a member of the node group
+func (r *Registry) Known(serverID string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, exists := r.members[serverID]
+	return exists
+}
+
+// Forget removes a server from the known members
+func (r *Registry) Forget(serverID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.members, serverID)
+}
+
+// Prune forgets known members and name matches that no longer exist in Nova
+func (r *Registry) Prune(existing map[string]bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for serverID := range r.members {
+		if !existing[serverID] {
+			delete(r.members, serverID)
+		}
+	}
+	for serverID := range r.nameMatched {
+		if !existing[serverID] {
+			delete(r.nameMatched, serverID)
+		}
+	}
+}
+
+// FirstNameMatch records a server attributed to the node group by its name and reports
+// whether it was not attributed that way before
+func (r *Registry) FirstNameMatch(serverID string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.nameMatched[serverID] {
+		return false
+	}
+	if r.nameMatched == nil {
+		r.nameMatched = make(map[string]bool)
+	}
+	r.nameMatched[serverID] = true
+	return true
+}
+
+// Expire forgets known members that were not seen within the retention period
+func (r *Registry) Expire(retention time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for serverID, member := range r.members {
+		if time.Since(member.lastSeen) > retention {
+			delete(r.members, serverID)
+		}
+	}
+}
+
+// Len returns the number of known members
+func (r *Registry) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.members)
+}
+
+// ShouldRepair rate limits metadata repairs of a known member to one per interval, counting
+// the call as a sighting of the member. Unknown servers are never repaired.
+func (r *Registry) ShouldRepair(serverID string, interval time.Duration) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	member, exists := r.members[serverID]
+	if !exists {
+		return false
+	}
+	member.lastSeen = time.Now()
+	if time.Since(member.lastRepair) < interval {
+		return false
+	}
+	member.lastRepair = time.Now()
+	return true
+}
+
+// Clone returns a registry holding copies of the known members, the node group replacing
+// another on update takes them over while the replaced one may still be observing servers
+func (r *Registry) Clone() *Registry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	clone := NewRegistry()
+	for serverID, member := range r.members {
+		state := *member
+		clone.members[serverID] = &state
+	}
+	return clone
+}
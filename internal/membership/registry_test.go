@@ -0,0 +1,92 @@
+package membership
+
+import (
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// testMembers checks the behaviour every Members implementation provides
+func testMembers(t *testing.T, newMembers func() Members) {
+	tests := []struct {
+		name    string
+		run     func(Members)
+		known   []string
+		unknown []string
+	}{
+		{name: "empty", unknown: []string{"a"}},
+		{name: "remembered", run: func(m Members) { m.Remember("a"); m.Remember("a") }, known: []string{"a"}},
+		{name: "forgotten", run: func(m Members) { m.Remember("a"); m.Remember("b"); m.Forget("a") }, known: []string{"b"}, unknown: []string{"a"}},
+		{name: "forgetting an unknown server", run: func(m Members) { m.Forget("a") }, unknown: []string{"a"}},
+		{
+			name:  "pruned",
+			run:   func(m Members) { m.Remember("a"); m.Remember("b"); m.Prune(map[string]bool{"b": true}) },
+			known: []string{"b"}, unknown: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := newMembers()
+			if tt.run != nil {
+				tt.run(members)
+			}
+			for _, serverID := range tt.known {
+				th.AssertEquals(t, true, members.Known(serverID))
+			}
+			for _, serverID := range tt.unknown {
+				th.AssertEquals(t, false, members.Known(serverID))
+			}
+		})
+	}
+}
+
+func TestRegistryConformance(t *testing.T) {
+	testMembers(t, func() Members { return NewRegistry() })
+}
+
+func TestRegistryNameMatches(t *testing.T) {
+	registry := NewRegistry()
+	th.AssertEquals(t, true, registry.FirstNameMatch("a"))
+	th.AssertEquals(t, false, registry.FirstNameMatch("a"))
+
+	// Name matches are not members, pruning a vanished server reports it again
+	th.AssertEquals(t, 0, registry.Len())
+	registry.Prune(map[string]bool{})
+	th.AssertEquals(t, true, registry.FirstNameMatch("a"))
+}
+
+func TestRegistryShouldRepair(t *testing.T) {
+	registry := NewRegistry()
+	th.AssertEquals(t, false, registry.ShouldRepair("a", time.Hour))
+
+	registry.Remember("a")
+	th.AssertEquals(t, true, registry.ShouldRepair("a", time.Hour))
+	th.AssertEquals(t, false, registry.ShouldRepair("a", time.Hour))
+	th.AssertEquals(t, true, registry.ShouldRepair("a", 0))
+}
+
+func TestRegistryExpire(t *testing.T) {
+	registry := NewRegistry()
+	registry.Remember("a")
+	registry.Expire(time.Hour)
+	th.AssertEquals(t, 1, registry.Len())
+	registry.Expire(0)
+	th.AssertEquals(t, 0, registry.Len())
+}
+
+func TestRegistryClone(t *testing.T) {
+	registry := NewRegistry()
+	registry.Remember("a")
+	registry.FirstNameMatch("b")
+
+	clone := registry.Clone()
+	th.AssertEquals(t, true, clone.Known("a"))
+	th.AssertEquals(t, true, registry.ShouldRepair("a", time.Hour))
+	th.AssertEquals(t, true, clone.ShouldRepair("a", time.Hour))
+
+	clone.Forget("a")
+	th.AssertEquals(t, true, registry.Known("a"))
+	// Name matches warn once per node group and are not carried over
+	th.AssertEquals(t, true, clone.FirstNameMatch("b"))
+}
@@ -0,0 +1,65 @@
+package nodegroup
+
+import "sync"
+
+// Placement balances servers created concurrently across availability zones
+type Placement struct {
+	mutex        sync.Mutex
+	zones        []string
+	distribution map[string]int
+}
+
+// NewPlacement creates a placement starting from the current per zone server counts
+func NewPlacement(zones []string, distribution map[string]int) *Placement {
+	counts := make(map[string]int, len(zones))
+	for _, zone := range zones {
+		counts[zone] = distribution[zone]
+	}
+	return &Placement{zones: zones, distribution: counts}
+}
+
+// Reserve picks the least populated zone not in skip and counts a server in it.
+// It returns false when no zones are configured or all of them were skipped.
+func (p *Placement) Reserve(skip map[string]bool) (string, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	best := ""
+	for _, zone := range p.zones {
+		if skip[zone] {
+			continue
+		}
+		if best == "" || p.distribution[zone] < p.distribution[best] {
+			best = zone
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	p.distribution[best]++
+	return best, true
+}
+
+// Release gives back a zone reservation of a server that could not be created
+func (p *Placement) Release(zone string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.distribution[zone]--
+}
+
+// Size returns the number of zones servers are spread across
+func (p *Placement) Size() int {
+	return len(p.zones)
+}
+
+// Distribution returns a copy of the per zone server counts including the reservations
+func (p *Placement) Distribution() map[string]int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	counts := make(map[string]int, len(p.distribution))
+	for zone, count := range p.distribution {
+		counts[zone] = count
+	}
+	return counts
+}
@@ -0,0 +1,57 @@
+package nodegroup
+
+import (
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestPlacement(t *testing.T) {
+	tests := []struct {
+		name         string
+		zones        []string
+		distribution map[string]int
+		skip         map[string]bool
+		reserve      int
+		reserved     []string
+		ok           bool
+	}{
+		{name: "no zones", reserve: 1, reserved: []string{""}},
+		{name: "balanced", zones: []string{"az1", "az2", "az3"}, reserve: 4, reserved: []string{"az1", "az2", "az3", "az1"}, ok: true},
+		{name: "fills the emptiest zone first", zones: []string{"az1", "az2"}, distribution: map[string]int{"az1": 2}, reserve: 3, reserved: []string{"az2", "az2", "az1"}, ok: true},
+		{name: "unconfigured zones are ignored", zones: []string{"az1"}, distribution: map[string]int{"az9": 5}, reserve: 1, reserved: []string{"az1"}, ok: true},
+		{name: "skipped zones", zones: []string{"az1", "az2"}, skip: map[string]bool{"az1": true}, reserve: 2, reserved: []string{"az2", "az2"}, ok: true},
+		{name: "every zone skipped", zones: []string{"az1"}, skip: map[string]bool{"az1": true}, reserve: 1, reserved: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placement := NewPlacement(tt.zones, tt.distribution)
+			th.AssertEquals(t, len(tt.zones), placement.Size())
+
+			var reserved []string
+			for range tt.reserve {
+				zone, ok := placement.Reserve(tt.skip)
+				th.AssertEquals(t, tt.ok, ok)
+				reserved = append(reserved, zone)
+			}
+			th.AssertDeepEquals(t, tt.reserved, reserved)
+		})
+	}
+}
+
+func TestPlacementRelease(t *testing.T) {
+	placement := NewPlacement([]string{"az1", "az2"}, nil)
+	zone, _ := placement.Reserve(nil)
+	th.AssertEquals(t, "az1", zone)
+	placement.Release(zone)
+
+	// The released zone is the emptiest again
+	zone, _ = placement.Reserve(nil)
+	th.AssertEquals(t, "az1", zone)
+	th.AssertDeepEquals(t, map[string]int{"az1": 1, "az2": 0}, placement.Distribution())
+
+	// The distribution is a copy
+	placement.Distribution()["az2"] = 5
+	zone, _ = placement.Reserve(nil)
+	th.AssertEquals(t, "az2", zone)
+}
@@ -0,0 +1,183 @@
+package nodegroup
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultQuarantineWindow is the sliding window server operations are judged over
+	defaultQuarantineWindow = 10 * time.Minute
+	// defaultQuarantineFailureRate is the share of failed server operations that quarantines a node group
+	defaultQuarantineFailureRate = 0.8
+	// defaultQuarantineCooldown is how long a node group stays quarantined
+	defaultQuarantineCooldown = 15 * time.Minute
+	// quarantineMinOperations is the number of operations in the window before the failure rate counts
+	quarantineMinOperations = 5
+)
+
+// QuarantineOverride manually controls the quarantine of a node group
+type QuarantineOverride int
+
+const (
+	// QuarantineAuto quarantines the node group based on its failure rate
+	QuarantineAuto QuarantineOverride = iota
+	// QuarantineForced keeps the node group quarantined until the override is reset
+	QuarantineForced
+	// QuarantineDisabled never quarantines the node group
+	QuarantineDisabled
+)
+
+// String returns the name of the override
+func (o QuarantineOverride) String() string {
+	switch o {
+	case QuarantineForced:
+		return "forced"
+	case QuarantineDisabled:
+		return "disabled"
+	default:
+		return "auto"
+	}
+}
+
+// QuarantineState is the outcome of checking the quarantine before a mutation
+type QuarantineState int
+
+const (
+	// QuarantineClear allows mutations
+	QuarantineClear QuarantineState = iota
+	// QuarantineManual pauses mutations until the forced override is reset
+	QuarantineManual
+	// QuarantineActive pauses mutations until the cool-down passed
+	QuarantineActive
+	// QuarantineLifted allows mutations again, the cool-down just passed
+	QuarantineLifted
+)
+
+// QuarantinePolicy decides when repeated failures quarantine a node group
+type QuarantinePolicy struct {
+	// Window is the sliding window server operations are judged over
+	Window time.Duration
+	// FailureRate is the share of failed server operations that quarantines the node group
+	FailureRate float64
+	// Cooldown is how long the node group stays quarantined
+	Cooldown time.Duration
+}
+
+// WithDefaults returns the policy with unset values replaced by the defaults
+func (p QuarantinePolicy) WithDefaults() QuarantinePolicy {
+	if p.Window <= 0 {
+		p.Window = defaultQuarantineWindow
+	}
+	if p.FailureRate <= 0 {
+		p.FailureRate = defaultQuarantineFailureRate
+	}
+	if p.Cooldown <= 0 {
+		p.Cooldown = defaultQuarantineCooldown
+	}
+	return p
+}
+
+// operationOutcome is the result of a single server create or delete
+type operationOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// Quarantine tracks recent server operations of a node group and the quarantine they caused.
+// The zero value judges failures automatically and is not quarantined.
+type Quarantine struct {
+	mutex    sync.Mutex
+	outcomes []operationOutcome
+	until    time.Time
+	override QuarantineOverride
+}
+
+// Record records the result of a server operation and quarantines the node group when the
+// failure rate over the window of the policy exceeds its threshold. It reports whether the
+// node group was quarantined and the failures and operations that caused it.
+func (q *Quarantine) Record(failed bool, policy QuarantinePolicy) (quarantined bool, failures, operations int) {
+	policy = policy.WithDefaults()
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+	outcomes := q.outcomes[:0]
+	for _, outcome := range q.outcomes {
+		if now.Sub(outcome.at) <= policy.Window {
+			outcomes = append(outcomes, outcome)
+		}
+	}
+	outcomes = append(outcomes, operationOutcome{at: now, failed: failed})
+	q.outcomes = outcomes
+
+	if q.override != QuarantineAuto || now.Before(q.until) || len(outcomes) < quarantineMinOperations {
+		return false, 0, 0
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(outcomes)) < policy.FailureRate {
+		return false, 0, 0
+	}
+
+	q.until = now.Add(policy.Cooldown)
+	q.outcomes = nil
+	return true, failures, len(outcomes)
+}
+
+// Check returns whether mutations are paused and until when an automatic quarantine lasts,
+// lifting an automatic quarantine once its cool-down has passed
+func (q *Quarantine) Check() (QuarantineState, time.Time) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	switch q.override {
+	case QuarantineForced:
+		return QuarantineManual, time.Time{}
+	case QuarantineDisabled:
+		return QuarantineClear, time.Time{}
+	}
+
+	if q.until.IsZero() {
+		return QuarantineClear, time.Time{}
+	}
+	if time.Now().Before(q.until) {
+		return QuarantineActive, q.until
+	}
+
+	q.until = time.Time{}
+	return QuarantineLifted, time.Time{}
+}
+
+// SetOverride manually quarantines the node group, exempts it from quarantine or returns it to
+// automatic quarantine. Any automatic quarantine in effect is lifted.
+func (q *Quarantine) SetOverride(override QuarantineOverride) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.override = override
+	q.until = time.Time{}
+	q.outcomes = nil
+}
+
+// Status describes the quarantine, empty when mutations are allowed
+func (q *Quarantine) Status() string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	switch {
+	case q.override == QuarantineForced:
+		return "manual"
+	case q.override == QuarantineDisabled:
+		return ""
+	case time.Now().Before(q.until):
+		return "until " + q.until.Format(time.RFC3339)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,93 @@
+package nodegroup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestQuarantine(t *testing.T) {
+	policy := QuarantinePolicy{Window: time.Minute, FailureRate: 0.5, Cooldown: time.Hour}
+	tests := []struct {
+		name        string
+		override    QuarantineOverride
+		outcomes    []bool
+		policy      QuarantinePolicy
+		quarantined bool
+		state       QuarantineState
+		status      string
+	}{
+		{name: "no operations", policy: policy, state: QuarantineClear},
+		{name: "too few operations", outcomes: []bool{true, true, true, true}, policy: policy, state: QuarantineClear},
+		{name: "failure rate reached", outcomes: []bool{false, false, true, true, true}, policy: policy, quarantined: true, state: QuarantineActive, status: "until "},
+		{name: "failure rate not reached", outcomes: []bool{false, false, false, true, true}, policy: policy, state: QuarantineClear},
+		{name: "default failure rate", outcomes: []bool{false, true, true, true, true}, quarantined: true, state: QuarantineActive, status: "until "},
+		{name: "cool-down passed", outcomes: []bool{true, true, true, true, true}, policy: QuarantinePolicy{Window: time.Minute, Cooldown: time.Nanosecond}, quarantined: true, state: QuarantineLifted},
+		{name: "disabled", override: QuarantineDisabled, outcomes: []bool{true, true, true, true, true}, policy: policy, state: QuarantineClear},
+		{name: "forced", override: QuarantineForced, policy: policy, state: QuarantineManual, status: "manual"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var quarantine Quarantine
+			quarantine.SetOverride(tt.override)
+
+			quarantined := false
+			for _, failed := range tt.outcomes {
+				tripped, failures, operations := quarantine.Record(failed, tt.policy)
+				if tripped {
+					quarantined = true
+					th.AssertEquals(t, true, failures > 0 && failures <= operations)
+				}
+			}
+			th.AssertEquals(t, tt.quarantined, quarantined)
+
+			time.Sleep(time.Millisecond)
+			state, until := quarantine.Check()
+			th.AssertEquals(t, tt.state, state)
+			th.AssertEquals(t, tt.state == QuarantineActive, !until.IsZero())
+			if !strings.HasPrefix(quarantine.Status(), tt.status) || (tt.status == "") != (quarantine.Status() == "") {
+				t.Errorf("expected status %q, got %q", tt.status, quarantine.Status())
+			}
+		})
+	}
+}
+
+func TestQuarantineLiftedOnce(t *testing.T) {
+	var quarantine Quarantine
+	for range 5 {
+		quarantine.Record(true, QuarantinePolicy{Cooldown: time.Nanosecond})
+	}
+	time.Sleep(time.Millisecond)
+
+	state, _ := quarantine.Check()
+	th.AssertEquals(t, QuarantineLifted, state)
+	state, _ = quarantine.Check()
+	th.AssertEquals(t, QuarantineClear, state)
+}
+
+func TestQuarantineOverrideResetsQuarantine(t *testing.T) {
+	var quarantine Quarantine
+	for range 5 {
+		quarantine.Record(true, QuarantinePolicy{})
+	}
+	state, _ := quarantine.Check()
+	th.AssertEquals(t, QuarantineActive, state)
+
+	quarantine.SetOverride(QuarantineAuto)
+	state, _ = quarantine.Check()
+	th.AssertEquals(t, QuarantineClear, state)
+
+	// Earlier outcomes were dropped, four more failures are not enough
+	for range 4 {
+		tripped, _, _ := quarantine.Record(true, QuarantinePolicy{})
+		th.AssertEquals(t, false, tripped)
+	}
+}
+
+func TestQuarantinePolicyWithDefaults(t *testing.T) {
+	th.AssertEquals(t, QuarantinePolicy{Window: defaultQuarantineWindow, FailureRate: defaultQuarantineFailureRate, Cooldown: defaultQuarantineCooldown}, QuarantinePolicy{}.WithDefaults())
+	custom := QuarantinePolicy{Window: time.Second, FailureRate: 0.1, Cooldown: time.Minute}
+	th.AssertEquals(t, custom, custom.WithDefaults())
+}
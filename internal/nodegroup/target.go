@@ -0,0 +1,217 @@
+// Package nodegroup holds the lifecycle state of a node group that does not depend on the cloud:
+// its target size and where it is persisted, the quarantine after repeated failures and the
+// placement of new servers across availability zones.
+package nodegroup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// SizeStore persists the target sizes of node groups so a restart keeps scale-up intents
+type SizeStore interface {
+	// StoredSize returns the persisted target size of a node group, false when none is stored
+	StoredSize(groupID string) (int, bool)
+	// StoreSize persists the target size of a node group
+	StoreSize(groupID string, size int)
+}
+
+// Target is the target size of a node group. It is unknown until a scaling operation sets it or
+// it is restored from the store or observed in the cloud, every change is persisted in the store.
+type Target struct {
+	groupID string
+	store   SizeStore
+	mutex   sync.Mutex
+	size    int
+	known   bool
+}
+
+// NewTarget returns the unknown target size of a node group persisted in store
+func NewTarget(groupID string, store SizeStore) *Target {
+	return &Target{groupID: groupID, store: store}
+}
+
+// Get returns the target size and whether it is known
+func (t *Target) Get() (int, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.size, t.known
+}
+
+// Restore takes the target size from the store unless it is known already, returning the
+// target size in effect and false when the store holds none
+func (t *Target) Restore() (int, bool) {
+	stored, exists := t.store.StoredSize(t.groupID)
+	if !exists {
+		return 0, false
+	}
+	return t.Init(stored), true
+}
+
+// Init records the target size unless a scaling operation set it in the meantime, returning
+// the target size in effect
+func (t *Target) Init(size int) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.known {
+		return t.size
+	}
+	t.size, t.known = size, true
+	t.store.StoreSize(t.groupID, size)
+	return size
+}
+
+// Set records and persists the target size. It is persisted under the lock so concurrent
+// changes reach the store in the order they were made.
+func (t *Target) Set(size int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.size, t.known = size, true
+	t.store.StoreSize(t.groupID, size)
+}
+
+// Adjust changes a known target size by delta, never below zero
+func (t *Target) Adjust(delta int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.known {
+		return
+	}
+	t.size = max(t.size+delta, 0)
+	t.store.StoreSize(t.groupID, t.size)
+}
+
+// FileSizeStore persists target sizes in a JSON state file. The path is looked up on every call
+// so a configuration reload takes effect, without a path nothing is persisted.
+type FileSizeStore struct {
+	path   func() string
+	mutex  sync.Mutex
+	sizes  map[string]int
+	loaded bool
+}
+
+var _ SizeStore = (*FileSizeStore)(nil)
+
+// NewFileSizeStore returns a store persisting to the state file path returns
+func NewFileSizeStore(path func() string) *FileSizeStore {
+	return &FileSizeStore{path: path}
+}
+
+// StoredSize returns the persisted target size of a node group, false when none is stored or
+// no state file is configured
+func (s *FileSizeStore) StoredSize(groupID string) (int, bool) {
+	path := s.path()
+	if path == "" {
+		return 0, false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(path); err != nil {
+		klog.Errorf("Failed to load desired sizes from %s: %v", path, err)
+		return 0, false
+	}
+	size, exists := s.sizes[groupID]
+	return size, exists
+}
+
+// StoreSize persists the target size of a node group when a state file is configured
+func (s *FileSizeStore) StoreSize(groupID string, size int) {
+	path := s.path()
+	if path == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(path); err != nil {
+		klog.Errorf("Failed to load desired sizes from %s: %v", path, err)
+		return
+	}
+	s.sizes[groupID] = size
+	if err := writeSizes(path, s.sizes); err != nil {
+		klog.Errorf("Failed to persist desired size of node group %s: %v", groupID, err)
+	}
+}
+
+// Prune drops the persisted target sizes of node groups that are no longer configured
+func (s *FileSizeStore) Prune(groupIDs map[string]bool) {
+	path := s.path()
+	if path == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(path); err != nil {
+		klog.Errorf("Failed to load desired sizes from %s: %v", path, err)
+		return
+	}
+	pruned := 0
+	for groupID := range s.sizes {
+		if !groupIDs[groupID] {
+			delete(s.sizes, groupID)
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return
+	}
+	if err := writeSizes(path, s.sizes); err != nil {
+		klog.Errorf("Failed to persist desired sizes after dropping %d removed node groups: %v", pruned, err)
+		return
+	}
+	klog.Infof("Dropped the desired sizes of %d removed node groups", pruned)
+}
+
+// load reads the state file once, a missing file is an empty store
+func (s *FileSizeStore) load(path string) error {
+	if s.loaded {
+		return nil
+	}
+
+	sizes := make(map[string]int)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &sizes); err != nil {
+			return fmt.Errorf("failed to parse state file: %w", err)
+		}
+	}
+
+	s.sizes = sizes
+	s.loaded = true
+	return nil
+}
+
+// writeSizes replaces the state file atomically so a crash never leaves it half written
+func writeSizes(path string, sizes map[string]int) error {
+	data, err := json.Marshal(sizes)
+	if err != nil {
+		return fmt.Errorf("failed to encode desired sizes: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package nodegroup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// memoryStore is a SizeStore keeping the sizes in memory and counting the writes
+type memoryStore struct {
+	mutex  sync.Mutex
+	sizes  map[string]int
+	writes int
+}
+
+func (s *memoryStore) StoredSize(groupID string) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	size, exists := s.sizes[groupID]
+	return size, exists
+}
+
+func (s *memoryStore) StoreSize(groupID string, size int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.sizes == nil {
+		s.sizes = make(map[string]int)
+	}
+	s.sizes[groupID] = size
+	s.writes++
+}
+
+// testSizeStore checks the behaviour every SizeStore implementation provides
+func testSizeStore(t *testing.T, newStore func(t *testing.T) SizeStore) {
+	tests := []struct {
+		name   string
+		store  map[string]int
+		size   int
+		exists bool
+	}{
+		{name: "nothing stored"},
+		{name: "stored", store: map[string]int{"workers": 3}, size: 3, exists: true},
+		{name: "overwritten", store: map[string]int{"workers": 3, "gpu": 1}, size: 3, exists: true},
+		{name: "zero is a size", store: map[string]int{"workers": 0}, exists: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore(t)
+			for groupID, size := range tt.store {
+				store.StoreSize(groupID, size+1)
+				store.StoreSize(groupID, size)
+			}
+
+			size, exists := store.StoredSize("workers")
+			th.AssertEquals(t, tt.exists, exists)
+			th.AssertEquals(t, tt.size, size)
+		})
+	}
+}
+
+func TestMemoryStoreConformance(t *testing.T) {
+	testSizeStore(t, func(*testing.T) SizeStore { return &memoryStore{} })
+}
+
+func TestFileSizeStoreConformance(t *testing.T) {
+	testSizeStore(t, func(t *testing.T) SizeStore {
+		path := filepath.Join(t.TempDir(), "state.json")
+		return NewFileSizeStore(func() string { return path })
+	})
+}
+
+func TestFileSizeStore(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		prune    map[string]bool
+		stored   map[string]int
+	}{
+		{name: "new state file", stored: map[string]int{"workers": 4}},
+		{name: "existing state file", existing: `{"gpu": 2}`, stored: map[string]int{"gpu": 2, "workers": 4}},
+		{name: "removed node groups pruned", existing: `{"gpu": 2, "removed": 1}`, prune: map[string]bool{"gpu": true, "workers": true}, stored: map[string]int{"gpu": 2, "workers": 4}},
+		{name: "unparseable state file", existing: `{`, stored: map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if tt.existing != "" {
+				th.AssertNoErr(t, os.WriteFile(path, []byte(tt.existing), 0o600))
+			}
+			store := NewFileSizeStore(func() string { return path })
+			store.StoreSize("workers", 4)
+			if tt.prune != nil {
+				store.Prune(tt.prune)
+			}
+
+			data, err := os.ReadFile(path)
+			th.AssertNoErr(t, err)
+			stored := map[string]int{}
+			if tt.existing != "{" {
+				th.AssertNoErr(t, json.Unmarshal(data, &stored))
+			}
+			th.AssertDeepEquals(t, tt.stored, stored)
+		})
+	}
+}
+
+func TestFileSizeStoreWithoutPath(t *testing.T) {
+	store := NewFileSizeStore(func() string { return "" })
+	store.StoreSize("workers", 4)
+	store.Prune(map[string]bool{})
+	_, exists := store.StoredSize("workers")
+	th.AssertEquals(t, false, exists)
+}
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored map[string]int
+		run    func(*Target)
+		size   int
+		known  bool
+		writes int
+	}{
+		{name: "unknown", run: func(*Target) {}},
+		{name: "adjusting an unknown size", run: func(target *Target) { target.Adjust(2) }},
+		{name: "set", run: func(target *Target) { target.Set(3) }, size: 3, known: true, writes: 1},
+		{name: "adjusted", run: func(target *Target) { target.Set(3); target.Adjust(2) }, size: 5, known: true, writes: 2},
+		{name: "never below zero", run: func(target *Target) { target.Set(1); target.Adjust(-3) }, known: true, writes: 2},
+		{name: "initialized", run: func(target *Target) { target.Init(2) }, size: 2, known: true, writes: 1},
+		{name: "set before initialized", run: func(target *Target) { target.Set(4); target.Init(2) }, size: 4, known: true, writes: 1},
+		{name: "restored", stored: map[string]int{"workers": 6}, run: func(target *Target) { target.Restore() }, size: 6, known: true, writes: 1},
+		{name: "nothing to restore", run: func(target *Target) { target.Restore() }},
+		{name: "set before restored", stored: map[string]int{"workers": 6}, run: func(target *Target) { target.Set(1); target.Restore() }, size: 1, known: true, writes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{sizes: tt.stored}
+			target := NewTarget("workers", store)
+			tt.run(target)
+
+			size, known := target.Get()
+			th.AssertEquals(t, tt.known, known)
+			th.AssertEquals(t, tt.size, size)
+			th.AssertEquals(t, tt.writes, store.writes)
+			if tt.known {
+				th.AssertEquals(t, tt.size, store.sizes["workers"])
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package osclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/utils"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// parseAPIVersion parses an API version of the form major or major.minor, a missing minor
+// version is returned as -1
+func parseAPIVersion(version string) (int, int, error) {
+	majorPart, minorPart, hasMinor := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	major, err := strconv.Atoi(majorPart)
+	if err != nil {
+		return 0, 0, ConfigErrorf("invalid API version %q", version)
+	}
+	if !hasMinor {
+		return major, -1, nil
+	}
+	minor, err := strconv.Atoi(minorPart)
+	if err != nil {
+		return 0, 0, ConfigErrorf("invalid API version %q", version)
+	}
+	return major, minor, nil
+}
+
+// validateAPIVersions checks the configured API versions are parseable and name a major version
+// the clients support, unset versions are left to discovery and the client defaults
+func validateAPIVersions(cloud *config.CloudConfig) error {
+	checks := []struct {
+		service string
+		version string
+		majors  []int
+	}{
+		{"identity", cloud.IdentityAPIVersion, []int{2, 3}},
+		{"compute", cloud.ComputeAPIVersion, []int{2}},
+		{"network", cloud.NetworkAPIVersion, []int{2}},
+		{"volume", cloud.VolumeAPIVersion, []int{3}},
+	}
+	for _, check := range checks {
+		if check.version == "" {
+			continue
+		}
+		major, _, err := parseAPIVersion(check.version)
+		if err != nil {
+			return fmt.Errorf("%s API version: %w", check.service, err)
+		}
+		supported := false
+		for _, m := range check.majors {
+			supported = supported || m == major
+		}
+		if !supported {
+			return ConfigErrorf("%s API version %s is not supported", check.service, check.version)
+		}
+	}
+	return nil
+}
+
+// microversion returns the microversion to request from a configured API version, or an empty
+// string when only a major version is configured
+func microversion(version string) string {
+	if _, minor, err := parseAPIVersion(version); err != nil || minor < 0 {
+		return ""
+	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// authenticate authenticates the provider client against the identity API version, discovering
+// the newest version the endpoint offers when none is configured
+func authenticate(ctx context.Context, client *gophercloud.ProviderClient, options gophercloud.AuthOptions, version string) error {
+	if version == "" {
+		return openstack.Authenticate(ctx, client, options)
+	}
+
+	major, _, err := parseAPIVersion(version)
+	if err != nil {
+		return err
+	}
+	if major == 2 {
+		return openstack.AuthenticateV2(ctx, client, options, gophercloud.EndpointOpts{})
+	}
+	return openstack.AuthenticateV3(ctx, client, &options, gophercloud.EndpointOpts{})
+}
+
+// ValidateComputeMicroversion checks the cloud supports the configured compute microversion
+func (c *Clients) ValidateComputeMicroversion(ctx context.Context) error {
+	version := c.compute.Microversion
+	if version == "" {
+		return nil
+	}
+
+	supported, err := utils.GetSupportedMicroversions(ctx, c.compute)
+	if err != nil {
+		return fmt.Errorf("failed to discover compute microversions: %w", WrapError(err))
+	}
+	if ok, err := supported.IsSupported(version); err != nil || !ok {
+		return ConfigErrorf("compute API does not support microversion %s", version)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package osclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		version      string
+		major, minor int
+		microversion string
+		err          bool
+	}{
+		{version: "3", major: 3, minor: -1},
+		{version: "v3", major: 3, minor: -1},
+		{version: "2.1", major: 2, minor: 1, microversion: "2.1"},
+		{version: "v2.60", major: 2, minor: 60, microversion: "2.60"},
+		{version: "3.50", major: 3, minor: 50, microversion: "3.50"},
+		{version: "", err: true},
+		{version: "latest", err: true},
+		{version: "2.x", err: true},
+		{version: "2.", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, minor, err := parseAPIVersion(tt.version)
+			th.AssertEquals(t, tt.microversion, microversion(tt.version))
+			if tt.err {
+				var configErr *ConfigurationError
+				if !errors.As(err, &configErr) {
+					t.Fatalf("expected a configuration error, got %v", err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.major, major)
+			th.AssertEquals(t, tt.minor, minor)
+		})
+	}
+}
+
+func TestValidateAPIVersions(t *testing.T) {
+	tests := []struct {
+		name  string
+		cloud config.CloudConfig
+		err   string
+	}{
+		{name: "unset"},
+		{name: "supported", cloud: config.CloudConfig{IdentityAPIVersion: "3", ComputeAPIVersion: "2.60", NetworkAPIVersion: "2.0", VolumeAPIVersion: "3.50"}},
+		{name: "identity v2", cloud: config.CloudConfig{IdentityAPIVersion: "2.0"}},
+		{name: "identity v4", cloud: config.CloudConfig{IdentityAPIVersion: "4"}, err: "identity API version 4 is not supported"},
+		{name: "compute v3", cloud: config.CloudConfig{ComputeAPIVersion: "3"}, err: "compute API version 3 is not supported"},
+		{name: "network v1", cloud: config.CloudConfig{NetworkAPIVersion: "1.0"}, err: "network API version 1.0 is not supported"},
+		{name: "volume v2", cloud: config.CloudConfig{VolumeAPIVersion: "2"}, err: "volume API version 2 is not supported"},
+		{name: "unparseable volume version", cloud: config.CloudConfig{VolumeAPIVersion: "three"}, err: `volume API version: invalid API version "three"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAPIVersions(&tt.cloud)
+			if tt.err == "" {
+				th.AssertNoErr(t, err)
+				return
+			}
+			var configErr *ConfigurationError
+			if !errors.As(err, &configErr) || err.Error() != tt.err {
+				t.Fatalf("expected configuration error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestNewAppliesServiceMicroversions(t *testing.T) {
+	tests := []struct {
+		name                string
+		compute, volume     string
+		computeMicroversion string
+		volumeMicroversion  string
+		supported           bool
+	}{
+		{name: "major versions only", compute: "2", volume: "3", supported: true},
+		{name: "pinned microversions", compute: "2.60", volume: "3.50", computeMicroversion: "2.60", volumeMicroversion: "3.50", supported: true},
+		{name: "compute microversion newer than the cloud", compute: "2.95", computeMicroversion: "2.95"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			cfg := testConfig(cloud)
+			cfg.Cloud.ComputeAPIVersion = tt.compute
+			cfg.Cloud.VolumeAPIVersion = tt.volume
+
+			clients, err := New(context.Background(), cfg)
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.computeMicroversion, clients.Compute().Microversion)
+			th.AssertEquals(t, tt.volumeMicroversion, clients.Volume().Microversion)
+
+			err = clients.ValidateComputeMicroversion(context.Background())
+			if tt.supported {
+				th.AssertNoErr(t, err)
+				return
+			}
+			var configErr *ConfigurationError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("expected a configuration error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Package osclient authenticates against OpenStack and hands out the service clients the
+// provider talks to the cloud with.
+package osclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/v2/openstack/utils"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+// TagsMicroversion is the first compute microversion supporting server tags and the tags filter
+const TagsMicroversion = "2.26"
+
+// Clients is one authenticated set of service clients. Renewing the token or changing the
+// credentials builds a new set and swaps it in whole, so a request never sees a client while
+// its token or endpoints are being replaced.
+type Clients struct {
+	provider *gophercloud.ProviderClient
+	compute  *gophercloud.ServiceClient
+	tags     *gophercloud.ServiceClient
+	image    *gophercloud.ServiceClient
+	volume   *gophercloud.ServiceClient
+	network  *gophercloud.ServiceClient
+}
+
+// New authenticates with the credentials of the configuration and creates the service clients
+func New(ctx context.Context, cfg *config.Config) (*Clients, error) {
+	cloud := &cfg.Cloud
+
+	// Validate authentication configuration
+	if err := cloud.ValidateAuth(); err != nil {
+		return nil, fmt.Errorf("authentication validation failed: %w", err)
+	}
+	if err := validateAPIVersions(cloud); err != nil {
+		return nil, err
+	}
+
+	providerClient, err := openstack.NewClient(cloud.AuthURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider client: %w", err)
+	}
+	httpClient, err := newHTTPClient(cloud)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	providerClient.HTTPClient = httpClient
+	providerClient.HTTPClient.Transport = metrics.InstrumentRoundTripper(providerClient.HTTPClient.Transport)
+	providerClient.RetryBackoffFunc = retryAfterBackoff
+	providerClient.MaxBackoffRetries = maxRateLimitRetries
+
+	if err := authenticate(ctx, providerClient, authOptions(cloud), cloud.IdentityAPIVersion); err != nil {
+		return nil, fmt.Errorf("failed to create authenticated client: %w", err)
+	}
+	if providerClient.ReauthFunc == nil {
+		return nil, fmt.Errorf("provider client cannot re-authenticate with the configured credentials")
+	}
+	clients := &Clients{provider: providerClient}
+
+	// Create compute client
+	clients.compute, err = openstack.NewComputeV2(providerClient, endpointOpts(cloud, cloud.ComputeInterface))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compute client: %w", err)
+	}
+	clients.compute.Microversion = microversion(cloud.ComputeAPIVersion)
+
+	// Track node group membership with server tags where the cloud supports them
+	clients.tags = newTagsClient(ctx, clients.compute)
+
+	// Create image client
+	clients.image, err = openstack.NewImageV2(providerClient, endpointOpts(cloud, cloud.ImageInterface))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image client: %w", err)
+	}
+
+	// Create block storage client, only required by boot-from-volume node groups
+	clients.volume, err = openstack.NewBlockStorageV3(providerClient, endpointOpts(cloud, cloud.VolumeInterface))
+	if err != nil {
+		klog.Warningf("Block storage client unavailable, boot-from-volume node groups will fail: %v", err)
+		clients.volume = nil
+	} else {
+		clients.volume.Microversion = microversion(cloud.VolumeAPIVersion)
+	}
+
+	// Create network client, only required for floating IPs and subnet ports
+	clients.network, err = openstack.NewNetworkV2(providerClient, endpointOpts(cloud, cloud.NetworkInterface))
+	if err != nil {
+		klog.Warningf("Network client unavailable, floating IPs and subnet ports cannot be managed: %v", err)
+		clients.network = nil
+	}
+
+	return clients, nil
+}
+
+// authOptions returns the options authenticating with the credentials of the cloud, re-authenticating
+// whenever a request is rejected with an expired token
+func authOptions(cloud *config.CloudConfig) gophercloud.AuthOptions {
+	options := gophercloud.AuthOptions{
+		IdentityEndpoint: cloud.AuthURL,
+		AllowReauth:      true,
+	}
+
+	// Use application credentials if available, otherwise fall back to username/password
+	if cloud.ApplicationCredentialID != "" && cloud.ApplicationCredentialSecret != "" {
+		klog.V(2).Info("Using OpenStack application credentials for authentication")
+		options.ApplicationCredentialID = cloud.ApplicationCredentialID
+		options.ApplicationCredentialSecret = cloud.ApplicationCredentialSecret
+		// When using application credentials, we don't need username/password or domain info
+	} else if cloud.ApplicationCredentialName != "" && cloud.ApplicationCredentialSecret != "" {
+		klog.V(2).Info("Using OpenStack application credentials with name for authentication")
+		options.ApplicationCredentialName = cloud.ApplicationCredentialName
+		options.ApplicationCredentialSecret = cloud.ApplicationCredentialSecret
+		// For application credential name, we need username and user domain
+		options.Username = cloud.Username
+		options.DomainName = cloud.UserDomainName
+	} else {
+		klog.V(2).Info("Using OpenStack username/password authentication")
+		options.Username = cloud.Username
+		options.Password = cloud.Password
+		options.TenantName = cloud.ProjectName
+		options.TenantID = cloud.ProjectID
+		options.DomainName = cloud.UserDomainName
+		options.DomainID = cloud.ProjectDomainName
+	}
+	return options
+}
+
+// endpointOpts returns the endpoint options of a service client, the service interface
+// falls back to the cloud wide interface and public
+func endpointOpts(cloud *config.CloudConfig, serviceInterface string) gophercloud.EndpointOpts {
+	opts := gophercloud.EndpointOpts{
+		Region:       cloud.Region,
+		Availability: gophercloud.AvailabilityPublic,
+	}
+
+	iface := serviceInterface
+	if iface == "" {
+		iface = cloud.Interface
+	}
+	switch strings.ToLower(iface) {
+	case "internal":
+		opts.Availability = gophercloud.AvailabilityInternal
+	case "admin":
+		opts.Availability = gophercloud.AvailabilityAdmin
+	}
+	return opts
+}
+
+// newTagsClient returns a compute client pinned to the tags microversion, or nil when the cloud does not support it
+func newTagsClient(ctx context.Context, computeClient *gophercloud.ServiceClient) *gophercloud.ServiceClient {
+	supported, err := utils.GetSupportedMicroversions(ctx, computeClient)
+	if err != nil {
+		klog.Warningf("Failed to discover compute microversions, using metadata for node group membership: %v", err)
+		return nil
+	}
+
+	if ok, err := supported.IsSupported(TagsMicroversion); err != nil || !ok {
+		klog.Infof("Compute API does not support microversion %s, using metadata for node group membership", TagsMicroversion)
+		return nil
+	}
+
+	tagsClient := *computeClient
+	tagsClient.Microversion = TagsMicroversion
+	return &tagsClient
+}
+
+// Provider returns the authenticated provider client shared by the service clients
+func (c *Clients) Provider() *gophercloud.ProviderClient {
+	return c.provider
+}
+
+// Compute returns the client of the compute API
+func (c *Clients) Compute() *gophercloud.ServiceClient {
+	return c.compute
+}
+
+// Tags returns the compute client requesting the tags microversion, nil when the cloud does not support it
+func (c *Clients) Tags() *gophercloud.ServiceClient {
+	return c.tags
+}
+
+// Image returns the client of the image API
+func (c *Clients) Image() *gophercloud.ServiceClient {
+	return c.image
+}
+
+// Volume returns the client of the block storage API, nil when the cloud offers none
+func (c *Clients) Volume() *gophercloud.ServiceClient {
+	return c.volume
+}
+
+// Network returns the client of the network API, nil when the cloud offers none
+func (c *Clients) Network() *gophercloud.ServiceClient {
+	return c.network
+}
+
+// TokenExpiry returns the expiry of the token the clients authenticated with, or the zero time if unknown
+func (c *Clients) TokenExpiry() time.Time {
+	var token *tokens.Token
+	var err error
+
+	switch result := c.provider.GetAuthResult().(type) {
+	case tokens.CreateResult:
+		token, err = result.ExtractToken()
+	case tokens.GetResult:
+		token, err = result.ExtractToken()
+	default:
+		return time.Time{}
+	}
+	if err != nil {
+		klog.V(4).Infof("Failed to extract token expiry: %v", err)
+		return time.Time{}
+	}
+
+	return token.ExpiresAt
+}
+
+// EachServer visits the servers matching opts page by page. Servers carry their tags when the
+// compute API supports them.
+func (c *Clients) EachServer(ctx context.Context, opts servers.ListOptsBuilder, visit func(*servers.Server)) error {
+	client := c.compute
+	if c.tags != nil {
+		client = c.tags
+	}
+
+	err := servers.List(client, opts).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		pageServers, err := servers.ExtractServers(page)
+		if err != nil {
+			return false, fmt.Errorf("failed to extract servers: %w", err)
+		}
+		for i := range pageServers {
+			visit(&pageServers[i])
+		}
+		return true, nil
+	})
+	return WrapError(err)
+}
@@ -0,0 +1,326 @@
+package osclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/inventory"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// captureLogs redirects klog output into a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	_ = flags.Set("logtostderr", "false")
+	_ = flags.Set("alsologtostderr", "false")
+	_ = flags.Set("stderrthreshold", "FATAL")
+
+	var buf bytes.Buffer
+	// Every severity is also written to the lower ones, the INFO output sees each line once
+	klog.SetOutput(io.Discard)
+	klog.SetOutputBySeverity("INFO", &buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		_ = flags.Set("logtostderr", "true")
+		klog.SetOutput(nil)
+	})
+	return &buf
+}
+
+// testConfig returns a configuration authenticating against the fake cloud
+func testConfig(cloud *fakecloud.Cloud) *config.Config {
+	return &config.Config{Cloud: config.CloudConfig{
+		AuthURL:            cloud.AuthURL(),
+		Username:           "autoscaler",
+		Password:           "secret",
+		ProjectName:        fakecloud.ProjectID,
+		UserDomainName:     "Default",
+		Region:             fakecloud.Region,
+		IdentityAPIVersion: "3",
+	}}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		microversion string
+		configure    func(*config.CloudConfig)
+		tags         bool
+		configErr    bool
+	}{
+		{name: "tags supported", microversion: "2.90", tags: true},
+		{name: "tags unsupported", microversion: "2.20"},
+		{name: "no microversions"},
+		{name: "pinned compute microversion", microversion: "2.90", configure: func(cloud *config.CloudConfig) { cloud.ComputeAPIVersion = "2.60" }, tags: true},
+		{name: "unsupported identity version", microversion: "2.90", configure: func(cloud *config.CloudConfig) { cloud.IdentityAPIVersion = "4" }, configErr: true},
+		{name: "unparseable compute version", microversion: "2.90", configure: func(cloud *config.CloudConfig) { cloud.ComputeAPIVersion = "2.x" }, configErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			cloud.SetComputeMicroversion(tt.microversion)
+			cfg := testConfig(cloud)
+			if tt.configure != nil {
+				tt.configure(&cfg.Cloud)
+			}
+
+			clients, err := New(context.Background(), cfg)
+			if tt.configErr {
+				var configErr *ConfigurationError
+				if !errors.As(err, &configErr) {
+					t.Fatalf("expected a configuration error, got %v", err)
+				}
+				th.AssertEquals(t, 0, cloud.Authentications())
+				return
+			}
+			th.AssertNoErr(t, err)
+
+			th.AssertEquals(t, 1, cloud.Authentications())
+			if clients.Provider() == nil || clients.Compute() == nil || clients.Image() == nil {
+				t.Fatal("expected provider, compute and image clients")
+			}
+			th.AssertEquals(t, tt.tags, clients.Tags() != nil)
+			if tt.tags {
+				th.AssertEquals(t, TagsMicroversion, clients.Tags().Microversion)
+			}
+			th.AssertEquals(t, microversion(cfg.Cloud.ComputeAPIVersion), clients.Compute().Microversion)
+			if clients.TokenExpiry().IsZero() {
+				t.Error("expected the token expiry to be known")
+			}
+		})
+	}
+}
+
+func TestEachServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		microversion string
+		fail         bool
+		tags         bool
+	}{
+		{name: "with tags", microversion: "2.90", tags: true},
+		{name: "without tags"},
+		{name: "listing fails", microversion: "2.90", fail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			cloud.SetComputeMicroversion(tt.microversion)
+			tagged := cloud.AddServer(fakecloud.Server{Name: "workers-a", Tags: []string{"nodegroup:workers"}})
+			cloud.AddServer(fakecloud.Server{Name: "other"})
+			clients, err := New(context.Background(), testConfig(cloud))
+			th.AssertNoErr(t, err)
+			if tt.fail {
+				cloud.Fail(http.MethodGet, fakecloud.ComputePath+"/servers/detail", http.StatusInternalServerError, 1)
+			}
+
+			var names []string
+			var tags []string
+			err = clients.EachServer(context.Background(), servers.ListOpts{}, func(server *servers.Server) {
+				names = append(names, server.Name)
+				if server.ID == tagged && server.Tags != nil {
+					tags = *server.Tags
+				}
+			})
+			if tt.fail {
+				var osErr *OpenStackError
+				if !errors.As(err, &osErr) || osErr.RequestID == "" {
+					t.Fatalf("expected an error carrying the request ID, got %v", err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 2, len(names))
+			th.AssertEquals(t, tt.tags, len(tags) == 1)
+		})
+	}
+}
+
+// Clients serve the shared server listing of the inventory
+var _ inventory.ServerSource = (*Clients)(nil)
+
+func TestClientsCollectServers(t *testing.T) {
+	cloud := fakecloud.New()
+	t.Cleanup(cloud.Close)
+	member := cloud.AddServer(fakecloud.Server{Name: "workers-a", Tags: []string{"nodegroup:workers"}})
+	cloud.AddServer(fakecloud.Server{Name: "other", Tags: []string{"nodegroup:other"}})
+	clients, err := New(context.Background(), testConfig(cloud))
+	th.AssertNoErr(t, err)
+
+	// Nova filters by tag, the inventory keeps what the node groups may claim
+	kept, dropped, err := inventory.Collect(context.Background(), clients, servers.ListOpts{TagsAny: "nodegroup:workers"}, func(server *servers.Server) bool {
+		return server.Name == "workers-a"
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(kept))
+	th.AssertEquals(t, member, kept[0].ID)
+	th.AssertEquals(t, 0, dropped)
+}
+
+func TestWrapError(t *testing.T) {
+	cloud := fakecloud.New()
+	t.Cleanup(cloud.Close)
+	clients, err := New(context.Background(), testConfig(cloud))
+	th.AssertNoErr(t, err)
+
+	_, err = servers.Get(context.Background(), clients.Compute(), "missing").Extract()
+	wrapped := WrapError(err)
+	var osErr *OpenStackError
+	if !errors.As(wrapped, &osErr) || osErr.RequestID == "" {
+		t.Fatalf("expected an error carrying the request ID, got %v", wrapped)
+	}
+	if !gophercloud.ResponseCodeIs(wrapped, http.StatusNotFound) {
+		t.Error("expected the wrapped error to unwrap to the not found response")
+	}
+	th.AssertEquals(t, nil, WrapError(nil))
+	plain := errors.New("plain")
+	th.AssertEquals(t, plain, WrapError(plain))
+}
+
+func TestReauthOnExpiredToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.CloudConfig)
+		rotate    bool
+		reauthed  bool
+	}{
+		{name: "password", reauthed: true},
+		{name: "application credential", configure: func(cloud *config.CloudConfig) {
+			cloud.Username = ""
+			cloud.Password = ""
+			cloud.ApplicationCredentialID = "cred-id"
+			cloud.ApplicationCredentialSecret = "secret"
+		}, reauthed: true},
+		{name: "credentials rejected on reauth", rotate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			cloud.SetPassword("secret")
+			cfg := testConfig(cloud)
+			if tt.configure != nil {
+				tt.configure(&cfg.Cloud)
+			}
+			clients, err := New(context.Background(), cfg)
+			th.AssertNoErr(t, err)
+			if clients.Provider().ReauthFunc == nil {
+				t.Fatal("expected the provider client to hold a reauth func")
+			}
+			previousToken := clients.Provider().Token()
+
+			// The token expired, the next request is rejected once
+			cloud.Fail(http.MethodGet, fakecloud.ComputePath+"/servers/detail", http.StatusUnauthorized, 1)
+			if tt.rotate {
+				cloud.SetPassword("rotated")
+			}
+
+			err = clients.EachServer(context.Background(), servers.ListOpts{}, func(*servers.Server) {})
+			if !tt.reauthed {
+				if err == nil {
+					t.Fatal("expected the request to fail when reauthentication is rejected")
+				}
+				th.AssertEquals(t, previousToken, clients.Provider().Token())
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 2, cloud.Authentications())
+			th.AssertEquals(t, 2, cloud.Requests(http.MethodGet, fakecloud.ComputePath+"/servers/detail"))
+			if clients.Provider().Token() == previousToken {
+				t.Error("expected the request to be retried with a new token")
+			}
+		})
+	}
+}
+
+func TestEndpointOpts(t *testing.T) {
+	tests := []struct {
+		name             string
+		cloudInterface   string
+		serviceInterface string
+		availability     gophercloud.Availability
+	}{
+		{name: "default", availability: gophercloud.AvailabilityPublic},
+		{name: "cloud wide", cloudInterface: "internal", availability: gophercloud.AvailabilityInternal},
+		{name: "service override", cloudInterface: "public", serviceInterface: "internal", availability: gophercloud.AvailabilityInternal},
+		{name: "service override to public", cloudInterface: "internal", serviceInterface: "public", availability: gophercloud.AvailabilityPublic},
+		{name: "admin", serviceInterface: "admin", availability: gophercloud.AvailabilityAdmin},
+		{name: "case insensitive", serviceInterface: "Internal", availability: gophercloud.AvailabilityInternal},
+		{name: "unknown falls back to public", cloudInterface: "private", availability: gophercloud.AvailabilityPublic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := &config.CloudConfig{Region: fakecloud.Region, Interface: tt.cloudInterface}
+			opts := endpointOpts(cloud, tt.serviceInterface)
+			th.AssertEquals(t, tt.availability, opts.Availability)
+			th.AssertEquals(t, fakecloud.Region, opts.Region)
+		})
+	}
+}
+
+func TestNewPerServiceInterface(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.CloudConfig)
+		err       string
+		network   bool
+	}{
+		{name: "public everywhere", err: "failed to create image client"},
+		{name: "image on the internal interface", configure: func(cloud *config.CloudConfig) {
+			cloud.ImageInterface = "internal"
+		}, network: true},
+		{name: "internal everywhere", configure: func(cloud *config.CloudConfig) {
+			cloud.Interface = "internal"
+		}, err: "failed to create compute client"},
+		{name: "internal everywhere but compute", configure: func(cloud *config.CloudConfig) {
+			cloud.Interface = "internal"
+			cloud.ComputeInterface = "public"
+		}},
+		{name: "internal everywhere but compute and network", configure: func(cloud *config.CloudConfig) {
+			cloud.Interface = "internal"
+			cloud.ComputeInterface = "public"
+			cloud.NetworkInterface = "public"
+		}, network: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := fakecloud.New()
+			t.Cleanup(cloud.Close)
+			// Glance is only reachable internally, Nova and Neutron only publicly
+			cloud.SetEndpointInterfaces("image", "internal")
+			cloud.SetEndpointInterfaces("compute", "public")
+			cloud.SetEndpointInterfaces("network", "public")
+			cfg := testConfig(cloud)
+			if tt.configure != nil {
+				tt.configure(&cfg.Cloud)
+			}
+
+			clients, err := New(context.Background(), cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			// Without a network endpoint only floating IPs and subnet ports are unavailable
+			th.AssertEquals(t, tt.network, clients.Network() != nil)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package osclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// requestIDHeaders are the response headers OpenStack services use to report the request ID
+var requestIDHeaders = []string{"X-Openstack-Request-Id", "X-Compute-Request-Id"}
+
+// ConfigurationError is a failure caused by the configuration, such as a reference to a
+// flavor or image that does not exist, rather than by the cloud being unavailable
+type ConfigurationError struct {
+	msg string
+}
+
+func (e *ConfigurationError) Error() string {
+	return e.msg
+}
+
+// ConfigErrorf formats a ConfigurationError
+func ConfigErrorf(format string, args ...interface{}) error {
+	return &ConfigurationError{msg: fmt.Sprintf(format, args...)}
+}
+
+// OpenStackError is a failed OpenStack API call carrying the request ID reported by the cloud
+type OpenStackError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *OpenStackError) Error() string {
+	return fmt.Sprintf("%v (request ID: %s)", e.Err, e.RequestID)
+}
+
+func (e *OpenStackError) Unwrap() error {
+	return e.Err
+}
+
+// WrapError wraps err in an OpenStackError when the response carried a request ID
+func WrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var unexpected gophercloud.ErrUnexpectedResponseCode
+	if !errors.As(err, &unexpected) || unexpected.ResponseHeader == nil {
+		return err
+	}
+
+	for _, header := range requestIDHeaders {
+		if requestID := unexpected.ResponseHeader.Get(header); requestID != "" {
+			return &OpenStackError{RequestID: requestID, Err: err}
+		}
+	}
+
+	return err
+}
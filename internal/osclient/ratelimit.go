@@ -0,0 +1,70 @@
+package osclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+const (
+	// maxRateLimitRetries is the number of times a throttled request is retried
+	maxRateLimitRetries = 5
+	// defaultRetryAfter is the wait used when a throttled response carries no usable Retry-After header
+	defaultRetryAfter = time.Second
+	// maxRetryAfter bounds the wait requested by a Retry-After header
+	maxRetryAfter = time.Minute
+)
+
+// retryAfterBackoff waits for the duration requested by the Retry-After header of a
+// throttled response before gophercloud retries the request. Gophercloud calls it for
+// both 429 and 498 responses.
+func retryAfterBackoff(ctx context.Context, respErr *gophercloud.ErrUnexpectedResponseCode, err error, retries uint) error {
+	code := strconv.Itoa(respErr.Actual)
+	metrics.ThrottledRequests.WithLabelValues(code).Inc()
+
+	wait := parseRetryAfter(respErr.ResponseHeader.Get("Retry-After"), time.Now())
+	klog.Warningf("OpenStack throttled %s %s with %s, retrying in %s (attempt %d/%d)", respErr.Method, respErr.URL, code, wait, retries, maxRateLimitRetries)
+
+	if sleepErr := retrySleep(ctx, wait); sleepErr != nil {
+		return err
+	}
+	return nil
+}
+
+// retrySleep waits for the duration unless ctx is done first, tests replace it to observe the wait
+var retrySleep = func(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date,
+// bounded by maxRetryAfter
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	wait := defaultRetryAfter
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = date.Sub(now)
+	}
+
+	if wait < 0 {
+		return 0
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
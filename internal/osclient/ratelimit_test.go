@@ -0,0 +1,98 @@
+package osclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+func TestRetryAfterBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		code       string
+		wait       time.Duration
+	}{
+		{name: "too many requests", status: http.StatusTooManyRequests, retryAfter: "3", code: "429", wait: 3 * time.Second},
+		{name: "token expired", status: 498, retryAfter: "1", code: "498", wait: time.Second},
+		{name: "no header", status: http.StatusTooManyRequests, code: "429", wait: defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			before := testutil.ToFloat64(metrics.ThrottledRequests.WithLabelValues(tt.code))
+			var waited []time.Duration
+			previous := retrySleep
+			retrySleep = func(ctx context.Context, wait time.Duration) error {
+				waited = append(waited, wait)
+				return nil
+			}
+			t.Cleanup(func() { retrySleep = previous })
+
+			respErr := &gophercloud.ErrUnexpectedResponseCode{
+				Method:         http.MethodGet,
+				URL:            "https://compute.example/servers",
+				Actual:         tt.status,
+				ResponseHeader: http.Header{"Retry-After": []string{tt.retryAfter}},
+			}
+			th.AssertNoErr(t, retryAfterBackoff(context.Background(), respErr, respErr, 1))
+
+			th.AssertDeepEquals(t, []time.Duration{tt.wait}, waited)
+			th.AssertEquals(t, before+1, testutil.ToFloat64(metrics.ThrottledRequests.WithLabelValues(tt.code)))
+			if !strings.Contains(logs.String(), "with "+tt.code) {
+				t.Errorf("expected the log to name status %s, got: %s", tt.code, logs.String())
+			}
+		})
+	}
+}
+
+func TestRetryAfterBackoffCancelled(t *testing.T) {
+	captureLogs(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	respErr := &gophercloud.ErrUnexpectedResponseCode{
+		Method:         http.MethodGet,
+		URL:            "https://compute.example/servers",
+		Actual:         http.StatusTooManyRequests,
+		ResponseHeader: http.Header{"Retry-After": []string{"60"}},
+	}
+	start := time.Now()
+	err := retryAfterBackoff(ctx, respErr, respErr, 1)
+	if err != respErr {
+		t.Fatalf("expected the throttled response error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected a cancelled request not to wait for the Retry-After, waited %s", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: defaultRetryAfter},
+		{name: "seconds", value: "7", want: 7 * time.Second},
+		{name: "date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "capped", value: "3600", want: maxRetryAfter},
+		{name: "malformed", value: "soon", want: defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.AssertEquals(t, tt.want, parseRetryAfter(tt.value, now))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package osclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// newHTTPClient returns the HTTP client used for all OpenStack requests, trusting the configured
+// CA bundle in addition to the system roots and skipping verification only when asked to
+func newHTTPClient(cloud *config.CloudConfig) (http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cloud.CACertFile != "" {
+		caCert, err := os.ReadFile(cloud.CACertFile)
+		if err != nil {
+			return http.Client{}, fmt.Errorf("failed to read CA certificate %s: %w", cloud.CACertFile, err)
+		}
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			klog.Warningf("Failed to load system certificate pool, trusting only %s: %v", cloud.CACertFile, err)
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return http.Client{}, fmt.Errorf("no PEM certificates found in %s", cloud.CACertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+
+	if cloud.Insecure {
+		klog.Warning("TLS certificate verification of the OpenStack endpoints is disabled")
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	return http.Client{Transport: transport}, nil
+}
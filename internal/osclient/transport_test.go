@@ -0,0 +1,83 @@
+package osclient
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	// The rejected handshakes are expected
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	th.AssertNoErr(t, os.WriteFile(caFile, caPEM, 0o600))
+	notPEM := filepath.Join(dir, "not-pem.txt")
+	th.AssertNoErr(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name     string
+		cloud    config.CloudConfig
+		rootCAs  bool
+		insecure bool
+		trusted  bool
+		warning  string
+		err      string
+	}{
+		{name: "system roots"},
+		{name: "custom CA", cloud: config.CloudConfig{CACertFile: caFile}, rootCAs: true, trusted: true},
+		{name: "insecure", cloud: config.CloudConfig{Insecure: true}, insecure: true, trusted: true, warning: "verification of the OpenStack endpoints is disabled"},
+		{name: "custom CA and insecure", cloud: config.CloudConfig{CACertFile: caFile, Insecure: true}, rootCAs: true, insecure: true, trusted: true},
+		{name: "missing CA file", cloud: config.CloudConfig{CACertFile: filepath.Join(dir, "missing.pem")}, err: "failed to read CA certificate"},
+		{name: "CA file without certificates", cloud: config.CloudConfig{CACertFile: notPEM}, err: "no PEM certificates found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+
+			client, err := newHTTPClient(&tt.cloud)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+
+			tlsConfig := client.Transport.(*http.Transport).TLSClientConfig
+			th.AssertEquals(t, tt.rootCAs, tlsConfig != nil && tlsConfig.RootCAs != nil)
+			th.AssertEquals(t, tt.insecure, tlsConfig != nil && tlsConfig.InsecureSkipVerify)
+
+			response, err := client.Get(server.URL)
+			if tt.trusted {
+				th.AssertNoErr(t, err)
+				_ = response.Body.Close()
+			} else if err == nil || !strings.Contains(err.Error(), "x509") {
+				t.Errorf("expected the self-signed endpoint to be rejected, got %v", err)
+			}
+
+			klog.Flush()
+			if tt.warning != "" && !strings.Contains(logs.String(), tt.warning) {
+				t.Errorf("expected a warning containing %q, got %q", tt.warning, logs.String())
+			}
+		})
+	}
+}
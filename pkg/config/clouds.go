@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+	"gopkg.in/yaml.v2"
+)
+
+// CloudsFileLocations returns the standard clouds.yaml locations searched when no file is given,
+// in the order used by the OpenStack client
+func CloudsFileLocations() []string {
+	locations := []string{"clouds.yaml"}
+	if home, err := os.UserHomeDir(); err == nil {
+		locations = append(locations, filepath.Join(home, ".config", "openstack", "clouds.yaml"))
+	}
+	return append(locations, "/etc/openstack/clouds.yaml")
+}
+
+// LoadCloudsFile loads the cloud configuration from an entry of a standard clouds.yaml file
+func LoadCloudsFile(path, cloudName string) (*CloudConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clouds file %s: %w", path, err)
+	}
+
+	var cloudsFile clouds.Clouds
+	if err := yaml.Unmarshal(data, &cloudsFile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal clouds file %s: %w", path, err)
+	}
+
+	cloud, exists := cloudsFile.Clouds[cloudName]
+	if !exists {
+		return nil, fmt.Errorf("cloud %s not found in clouds file %s", cloudName, path)
+	}
+	if cloud.AuthInfo == nil {
+		return nil, fmt.Errorf("cloud %s in clouds file %s has no auth section", cloudName, path)
+	}
+
+	auth := cloud.AuthInfo
+	cloudConfig := &CloudConfig{
+		AuthURL:                     auth.AuthURL,
+		Username:                    auth.Username,
+		Password:                    auth.Password,
+		ProjectName:                 auth.ProjectName,
+		ProjectID:                   auth.ProjectID,
+		UserDomainName:              firstNonEmpty(auth.UserDomainName, auth.DomainName, "Default"),
+		ProjectDomainName:           firstNonEmpty(auth.ProjectDomainName, auth.DomainName, "Default"),
+		ApplicationCredentialID:     auth.ApplicationCredentialID,
+		ApplicationCredentialName:   auth.ApplicationCredentialName,
+		ApplicationCredentialSecret: auth.ApplicationCredentialSecret,
+		Region:                      cloud.RegionName,
+		ClusterName:                 getEnvOrDefault("CLUSTER_NAME", ""),
+		Interface:                   firstNonEmpty(cloud.EndpointType, cloud.Interface, "public"),
+		IdentityAPIVersion:          firstNonEmpty(cloud.IdentityAPIVersion, "3"),
+		ComputeAPIVersion:           "2.1",
+		NetworkAPIVersion:           "2.0",
+		VolumeAPIVersion:            firstNonEmpty(cloud.VolumeAPIVersion, "3"),
+		CACertFile:                  cloud.CACertFile,
+		Insecure:                    cloud.Verify != nil && !*cloud.Verify,
+	}
+	return cloudConfig, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const testCloudsFile = `clouds:
+  production:
+    region_name: RegionOne
+    interface: internal
+    identity_api_version: 3
+    volume_api_version: 3.50
+    cacert: /etc/ssl/openstack-ca.pem
+    auth:
+      auth_url: https://keystone.example.com:5000/v3
+      username: autoscaler
+      password: secret
+      project_name: kubernetes
+      project_id: 0123456789abcdef
+      user_domain_name: Users
+      project_domain_name: Projects
+  appcred:
+    region_name: RegionTwo
+    verify: false
+    auth_type: v3applicationcredential
+    auth:
+      auth_url: https://keystone.example.com:5000/v3
+      application_credential_id: cred-id
+      application_credential_secret: cred-secret
+  domain:
+    auth:
+      auth_url: https://keystone.example.com:5000/v3
+      username: autoscaler
+      password: secret
+      project_name: kubernetes
+      domain_name: Shared
+  noauth:
+    region_name: RegionOne
+`
+
+func TestLoadCloudsFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		cloud  string
+		expect CloudConfig
+		err    string
+	}{
+		{
+			name:  "password",
+			cloud: "production",
+			expect: CloudConfig{
+				AuthURL:            "https://keystone.example.com:5000/v3",
+				Username:           "autoscaler",
+				Password:           "secret",
+				ProjectName:        "kubernetes",
+				ProjectID:          "0123456789abcdef",
+				UserDomainName:     "Users",
+				ProjectDomainName:  "Projects",
+				Region:             "RegionOne",
+				Interface:          "internal",
+				IdentityAPIVersion: "3",
+				ComputeAPIVersion:  "2.1",
+				NetworkAPIVersion:  "2.0",
+				VolumeAPIVersion:   "3.50",
+				CACertFile:         "/etc/ssl/openstack-ca.pem",
+			},
+		},
+		{
+			name:  "application credential",
+			cloud: "appcred",
+			expect: CloudConfig{
+				AuthURL:                     "https://keystone.example.com:5000/v3",
+				ApplicationCredentialID:     "cred-id",
+				ApplicationCredentialSecret: "cred-secret",
+				UserDomainName:              "Default",
+				ProjectDomainName:           "Default",
+				Region:                      "RegionTwo",
+				Interface:                   "public",
+				IdentityAPIVersion:          "3",
+				ComputeAPIVersion:           "2.1",
+				NetworkAPIVersion:           "2.0",
+				VolumeAPIVersion:            "3",
+				Insecure:                    true,
+			},
+		},
+		{
+			name:  "domain applies to user and project",
+			cloud: "domain",
+			expect: CloudConfig{
+				AuthURL:            "https://keystone.example.com:5000/v3",
+				Username:           "autoscaler",
+				Password:           "secret",
+				ProjectName:        "kubernetes",
+				UserDomainName:     "Shared",
+				ProjectDomainName:  "Shared",
+				Interface:          "public",
+				IdentityAPIVersion: "3",
+				ComputeAPIVersion:  "2.1",
+				NetworkAPIVersion:  "2.0",
+				VolumeAPIVersion:   "3",
+			},
+		},
+		{
+			name:  "missing auth section",
+			cloud: "noauth",
+			err:   "has no auth section",
+		},
+		{
+			name:  "unknown cloud",
+			cloud: "staging",
+			err:   "cloud staging not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CLUSTER_NAME", "")
+			path := filepath.Join(t.TempDir(), "clouds.yaml")
+			th.AssertNoErr(t, os.WriteFile(path, []byte(testCloudsFile), 0o600))
+
+			cloud, err := LoadCloudsFile(path, tt.cloud)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertDeepEquals(t, tt.expect, *cloud)
+		})
+	}
+}
+
+func TestLoadCloudsFileUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	th.AssertNoErr(t, os.WriteFile(invalid, []byte("clouds: [not, a, map]"), 0o600))
+
+	_, err := LoadCloudsFile(filepath.Join(dir, "missing.yaml"), "production")
+	if err == nil || !strings.Contains(err.Error(), "failed to read clouds file") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+	_, err = LoadCloudsFile(invalid, "production")
+	if err == nil || !strings.Contains(err.Error(), "failed to unmarshal clouds file") {
+		t.Errorf("expected an unmarshal error, got %v", err)
+	}
+}
@@ -3,13 +3,28 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 // Config represents the configuration for the OpenStack autoscaler
 type Config struct {
-	Cloud CloudConfig `yaml:"cloud"`
+	Cloud   CloudConfig    `yaml:"cloud"`
+	Pricing *PricingConfig `yaml:"pricing"`
+	// Node groups offered to the cluster autoscaler, reloading the configuration adds, updates
+	// and removes node groups to match
+	NodeGroups []NodeGroupConfig `yaml:"nodeGroups"`
+}
+
+// PricingConfig holds the hourly node prices reported to the cluster autoscaler price expander
+type PricingConfig struct {
+	// Hourly price per flavor name
+	Flavors map[string]float64 `yaml:"flavors"`
+	// Hourly price per node group ID, takes precedence over the flavor price
+	NodeGroups map[string]float64 `yaml:"nodeGroups"`
+	// Hourly price of nodes without a flavor or node group price, unknown nodes are an error when unset
+	DefaultHourlyPrice *float64 `yaml:"defaultHourlyPrice"`
 }
 
 // CloudConfig contains OpenStack cloud configuration
@@ -25,31 +40,295 @@ type CloudConfig struct {
 	ApplicationCredentialName   string `yaml:"application_credential_name"`
 	ApplicationCredentialSecret string `yaml:"application_credential_secret"`
 	Region                      string `yaml:"region"`
+	ClusterName                 string `yaml:"cluster_name"`
 	Interface                   string `yaml:"interface"`
 	IdentityAPIVersion          string `yaml:"identity_api_version"`
 	ComputeAPIVersion           string `yaml:"compute_api_version"`
 	NetworkAPIVersion           string `yaml:"network_api_version"`
+	VolumeAPIVersion            string `yaml:"volume_api_version"`
+
+	// Endpoint interface of a single service, overriding interface for it
+	ComputeInterface string `yaml:"compute_interface"`
+	ImageInterface   string `yaml:"image_interface"`
+	NetworkInterface string `yaml:"network_interface"`
+	VolumeInterface  string `yaml:"volume_interface"`
+
+	// CA bundle trusted for the OpenStack endpoints in addition to the system roots
+	CACertFile string `yaml:"ca_cert_file"`
+	// Skip TLS certificate verification of the OpenStack endpoints, for test clouds only
+	Insecure bool `yaml:"insecure"`
+
+	// Forget servers as soon as their ownership metadata disappears instead of restoring it
+	StrictOwnershipMetadata bool `yaml:"strict_ownership_metadata"`
+
+	// Share of the token lifetime after which the token is renewed proactively (default 0.8)
+	TokenRenewalFraction float64 `yaml:"token_renewal_fraction"`
+
+	// Node label marking GPU nodes, reported to the cluster autoscaler (default node.kubernetes.io/gpu)
+	GPULabel string `yaml:"gpu_label"`
+
+	// GPUs of flavors whose extra specs do not describe them in a standard way, keyed by flavor name
+	GPUFlavors map[string]GPUFlavorConfig `yaml:"gpu_flavors"`
+
+	// PCI passthrough aliases counted as GPUs besides the well-known GPU alias names, other
+	// aliases such as network cards are not GPUs
+	GPUPCIAliases []string `yaml:"gpu_pci_aliases"`
+
+	// Resources the kubelet reserves on every node, subtracted from the allocatable resources of
+	// template nodes. Keys are cpu, memory and ephemeral-storage, node groups override single keys.
+	KubeReserved   map[string]string `yaml:"kube_reserved"`
+	SystemReserved map[string]string `yaml:"system_reserved"`
+
+	// Also set the deprecated failure-domain.beta.kubernetes.io region and zone labels
+	LegacyTopologyLabels bool `yaml:"legacy_topology_labels"`
+
+	// Attach a config drive to servers of node groups that do not set configDrive
+	ConfigDrive bool `yaml:"config_drive"`
+
+	// How many servers a scale-up creates in parallel (default 5)
+	CreateConcurrency int `yaml:"create_concurrency"`
+	// How many servers a scale-down deletes in parallel (default 5)
+	DeleteConcurrency int `yaml:"delete_concurrency"`
+
+	// How long an image name keeps resolving to its last known ID while Glance is unavailable (default 24h)
+	ImageFallbackMaxAge time.Duration `yaml:"image_fallback_max_age"`
+
+	// How long a server listing is shared by all node groups before servers are listed again (default 10s)
+	ServerListTTL time.Duration `yaml:"server_list_ttl"`
+
+	// How long the server to node group mapping answers NodeGroupForNode without a refresh (default 1m)
+	NodeGroupCacheTTL time.Duration `yaml:"node_group_cache_ttl"`
+
+	// Skip checking the compute quota before a scale-up, for clouds that report limits unreliably
+	SkipQuotaCheck bool `yaml:"skip_quota_check"`
+
+	// File persisting the desired size of each node group across restarts, desired sizes are
+	// derived from the servers on startup when unset
+	StateFile string `yaml:"state_file"`
+
+	// Log scaling operations instead of creating and deleting servers, target sizes are simulated in memory
+	DryRun bool `yaml:"dry_run"`
+
+	// How long flavor and image names resolve from cache before being looked up again (default 1h)
+	LookupCacheTTL time.Duration `yaml:"lookup_cache_ttl"`
+
+	// How long template nodes are cached before being rebuilt from OpenStack (default 10m)
+	TemplateCacheTTL time.Duration `yaml:"template_cache_ttl"`
+
+	// Node groups whose server operations fail at this rate within the window are quarantined
+	// for the cool-down, pausing scale-ups and scale-downs (defaults 0.8, 10m and 15m)
+	QuarantineFailureRate float64       `yaml:"quarantine_failure_rate"`
+	QuarantineWindow      time.Duration `yaml:"quarantine_window"`
+	QuarantineCooldown    time.Duration `yaml:"quarantine_cooldown"`
+
+	// Server creates and deletes per second, shared equally between the node groups so the
+	// retries of a failing node group cannot use up the API rate limit of the project (default unlimited)
+	ServerOperationRate float64 `yaml:"server_operation_rate"`
+
+	// How often internal tracking state is garbage collected (default 10m)
+	JanitorInterval time.Duration `yaml:"janitor_interval"`
+	// How long tracking entries are kept without being refreshed (default 24h)
+	TrackingRetention time.Duration `yaml:"tracking_retention"`
+
+	// How often servers stuck in BUILD or ERROR past the maxNodeProvisionDuration of their node
+	// group (default 15m) are deleted, and how many one pass deletes at most (defaults 5m and 5)
+	StuckServerInterval   time.Duration `yaml:"stuck_server_interval"`
+	StuckServerMaxDeletes int           `yaml:"stuck_server_max_deletes"`
 }
 
 // NodeGroupConfig represents a configuration for a node group
 type NodeGroupConfig struct {
-	ID               string            `yaml:"id"`
-	Name             string            `yaml:"name"`
-	MinSize          int               `yaml:"minSize"`
-	MaxSize          int               `yaml:"maxSize"`
-	FlavorName       string            `yaml:"flavorName"`
-	ImageName        string            `yaml:"imageName"`
-	ImageID          string            `yaml:"imageId"`
-	KeyName          string            `yaml:"keyName"`
-	SecurityGroups   []string          `yaml:"securityGroups"`
-	NetworkID        string            `yaml:"networkId"`
-	SubnetID         string            `yaml:"subnetId"`
-	FloatingIPPool   string            `yaml:"floatingIpPool"`
-	AvailabilityZone string            `yaml:"availabilityZone"`
-	UserData         string            `yaml:"userData"`
-	UserDataFile     string            `yaml:"userDataFile"`
-	Metadata         map[string]string `yaml:"metadata"`
-	Labels           map[string]string `yaml:"labels"`
+	ID         string `yaml:"id"`
+	Name       string `yaml:"name"`
+	MinSize    int    `yaml:"minSize"`
+	MaxSize    int    `yaml:"maxSize"`
+	FlavorName string `yaml:"flavorName"`
+	FlavorID   string `yaml:"flavorId"`
+	ImageName  string `yaml:"imageName"`
+	ImageID    string `yaml:"imageId"`
+	// Treat imageName as a prefix so rolling image versions such as "k8s-node-2024-05" match "k8s-node-"
+	ImageNameIsPrefix bool `yaml:"imageNameIsPrefix"`
+	// Fail instead of picking the newest image when several active images match
+	ImageRequireUnique bool `yaml:"imageRequireUnique"`
+	// Only consider images carrying all of these Glance tags
+	ImageTags []string `yaml:"imageTags"`
+	// Nova tags set on every server next to the membership and managed-by tags
+	Tags             []string        `yaml:"tags"`
+	KeyName          string          `yaml:"keyName"`
+	SecurityGroups   []string        `yaml:"securityGroups"`
+	NetworkID        string          `yaml:"networkId"`
+	SubnetID         string          `yaml:"subnetId"`
+	Networks         []NetworkConfig `yaml:"networks"`
+	FloatingIPPool   string          `yaml:"floatingIpPool"`
+	AvailabilityZone string          `yaml:"availabilityZone"`
+	// Availability zones new servers are spread across, least populated first
+	AvailabilityZones []string          `yaml:"availabilityZones"`
+	UserData          string            `yaml:"userData"`
+	UserDataFile      string            `yaml:"userDataFile"`
+	Metadata          map[string]string `yaml:"metadata"`
+	// Node labels, also stamped onto the server metadata as k8s-label-<key>
+	Labels map[string]string `yaml:"labels"`
+
+	// Server name template with {{.NodeGroupID}}, {{.Suffix}} and {{.Index}}, default "{{.NodeGroupID}}-{{.Suffix}}"
+	NameTemplate string `yaml:"nameTemplate"`
+
+	// Attach a config drive for images that cannot reach the metadata service, overrides the cloud default
+	ConfigDrive *bool `yaml:"configDrive"`
+
+	// Delete the servers a scale-up created when it fails part way (default true)
+	RollbackOnFailure *bool `yaml:"rollbackOnFailure"`
+
+	// Place servers in a server group, either an existing one or one created for the node group
+	ServerGroup *ServerGroupConfig `yaml:"serverGroup"`
+
+	// Watch new servers in the background for up to this long until they are ACTIVE, reporting
+	// those ending in ERROR or still building as failed creations. Zero disables the watch.
+	ProvisionTimeout time.Duration `yaml:"provisionTimeout"`
+	// Wait for new servers to become ACTIVE within provisionTimeout before the scale-up returns
+	// instead of watching them in the background. Servers ending in ERROR or still building are
+	// deleted and fail the scale-up.
+	WaitForActive bool `yaml:"waitForActive"`
+
+	// Request the servers of a scale-up from Nova with one min_count/max_count call per
+	// availability zone. Node groups with subnet ports, zone pinned root volumes, floating IPs,
+	// waitForActive or user data templates keep creating one server per call.
+	MultiCreate bool `yaml:"multiCreate"`
+
+	// Leave servers stuck in BUILD or ERROR past maxNodeProvisionDuration in place, for debugging
+	SkipStuckServerCollection bool `yaml:"skipStuckServerCollection"`
+
+	// Disassociate floating IPs on scale-down instead of releasing them back to the pool
+	KeepFloatingIPOnDelete bool `yaml:"keepFloatingIpOnDelete"`
+
+	// Maximum number of nodes removed per DeleteNodes call, 0 means unlimited.
+	// With scaleDownTrimPolicy "trim" the excess nodes are dropped silently,
+	// otherwise ("partial") the call fails for them so the autoscaler retries later.
+	MaxScaleDownBatch   int    `yaml:"maxScaleDownBatch"`
+	ScaleDownTrimPolicy string `yaml:"scaleDownTrimPolicy"`
+
+	// Taints the kubelet registers nodes of this group with, reported on the template node for
+	// scale-from-zero
+	ExpectedTaints []TaintConfig `yaml:"expectedTaints"`
+
+	// Ephemeral storage reported for scale-from-zero, overrides the root volume and flavor disk size
+	EphemeralStorageGB int `yaml:"ephemeralStorageGB"`
+	// Ephemeral storage reserved for the system, subtracted from the allocatable ephemeral storage
+	EphemeralStorageReservedGB int `yaml:"ephemeralStorageReservedGB"`
+
+	// Kubelet reservations as quantities ("100m", "1Gi"), overriding the cloud defaults per resource
+	KubeReserved   map[string]string `yaml:"kubeReserved"`
+	SystemReserved map[string]string `yaml:"systemReserved"`
+
+	// Pods capacity reported for scale-from-zero (default 110)
+	MaxPods int `yaml:"maxPods"`
+
+	// Architecture the flavor runs, overrides the hw:architecture extra spec and covers images without an architecture property
+	Architecture string `yaml:"architecture"`
+	// Operating system of the image, used when the image has no os_type property (default linux)
+	OperatingSystem string `yaml:"operatingSystem"`
+
+	// GPU resource advertised by nodes (default nvidia.com/gpu) and a count overriding the flavor extra specs
+	GPUResourceName string `yaml:"gpuResourceName"`
+	GPUCount        int    `yaml:"gpuCount"`
+
+	// Expand userData and userDataFile as a text/template per server, user data is passed on
+	// verbatim otherwise
+	UserDataTemplate bool `yaml:"userDataTemplate"`
+
+	// Labels the kubelet registers on real nodes that the template cannot derive otherwise
+	ExpectedNodeLabels map[string]string `yaml:"expectedNodeLabels"`
+
+	// Boot from a Cinder volume created from the image instead of local disk
+	RootVolume *RootVolumeConfig `yaml:"rootVolume"`
+
+	// Per node group overrides of the cluster autoscaler options
+	AutoscalingOptions *AutoscalingOptionsConfig `yaml:"autoscalingOptions"`
+
+	// Deprecated, mapped to rootVolume by LoadConfig and rejected together with it
+	BootFromVolume            bool   `yaml:"bootFromVolume"`
+	VolumeSize                int    `yaml:"volumeSize"` // GB
+	VolumeType                string `yaml:"volumeType"`
+	DeleteVolumeOnTermination bool   `yaml:"deleteVolumeOnTermination"`
+}
+
+// AutoscalingOptionsConfig overrides cluster autoscaler options for a node group,
+// unset fields fall through to the autoscaler defaults
+type AutoscalingOptionsConfig struct {
+	ScaleDownUtilizationThreshold    *float64       `yaml:"scaleDownUtilizationThreshold"`
+	ScaleDownGpuUtilizationThreshold *float64       `yaml:"scaleDownGpuUtilizationThreshold"`
+	ScaleDownUnneededDuration        *time.Duration `yaml:"scaleDownUnneededDuration"`
+	ScaleDownUnreadyDuration         *time.Duration `yaml:"scaleDownUnreadyDuration"`
+	MaxNodeProvisionDuration         *time.Duration `yaml:"maxNodeProvisionDuration"`
+	ZeroOrMaxNodeScaling             *bool          `yaml:"zeroOrMaxNodeScaling"`
+	IgnoreDaemonSetsUtilization      *bool          `yaml:"ignoreDaemonSetsUtilization"`
+}
+
+// Validate checks the overridden options are in range
+func (o *AutoscalingOptionsConfig) Validate() error {
+	if t := o.ScaleDownUtilizationThreshold; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("scaleDownUtilizationThreshold must be between 0 and 1, got %v", *t)
+	}
+	if t := o.ScaleDownGpuUtilizationThreshold; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("scaleDownGpuUtilizationThreshold must be between 0 and 1, got %v", *t)
+	}
+	if d := o.ScaleDownUnneededDuration; d != nil && *d < 0 {
+		return fmt.Errorf("scaleDownUnneededDuration cannot be negative")
+	}
+	if d := o.ScaleDownUnreadyDuration; d != nil && *d < 0 {
+		return fmt.Errorf("scaleDownUnreadyDuration cannot be negative")
+	}
+	if d := o.MaxNodeProvisionDuration; d != nil && *d < 0 {
+		return fmt.Errorf("maxNodeProvisionDuration cannot be negative")
+	}
+	return nil
+}
+
+// GPUFlavorConfig describes the GPUs of a flavor, overriding its extra specs
+type GPUFlavorConfig struct {
+	Count int    `yaml:"count"`
+	Type  string `yaml:"type"`
+	// Extended resource the GPUs are advertised as, defaults to nvidia.com/gpu
+	ResourceName string `yaml:"resourceName"`
+}
+
+// TaintConfig describes a Kubernetes node taint
+type TaintConfig struct {
+	Key    string `yaml:"key"`
+	Value  string `yaml:"value"`
+	Effect string `yaml:"effect"`
+}
+
+// RootVolumeConfig describes the Cinder volume a server boots from
+type RootVolumeConfig struct {
+	SizeGiB    int    `yaml:"sizeGiB"`
+	VolumeType string `yaml:"volumeType"`
+
+	// Defaults to true, set to false to keep the volume after the server is deleted
+	DeleteOnTermination *bool `yaml:"deleteOnTermination"`
+
+	// Pre-create the volume in this Cinder availability zone instead of letting Nova place it
+	AvailabilityZone string `yaml:"availabilityZone"`
+}
+
+// ShouldDeleteOnTermination reports whether the volume is deleted together with its server
+func (r *RootVolumeConfig) ShouldDeleteOnTermination() bool {
+	return r.DeleteOnTermination == nil || *r.DeleteOnTermination
+}
+
+// ServerGroupConfig selects the Nova server group servers of a node group are placed in
+type ServerGroupConfig struct {
+	// UUID of an existing server group
+	ID string `yaml:"id"`
+	// Policy of a server group created for the node group, e.g. soft-anti-affinity. The group is
+	// named openstack-autoscaler-<cluster name>-<node group ID> and deleted once it is empty.
+	Policy string `yaml:"policy"`
+}
+
+// NetworkConfig describes a network attachment of node group servers
+type NetworkConfig struct {
+	NetworkID string `yaml:"networkId"`
+	SubnetID  string `yaml:"subnetId"`
+	FixedIP   string `yaml:"fixedIp"`
 }
 
 // LoadConfig loads configuration from a YAML file
@@ -63,13 +342,33 @@ func LoadConfig(filepath string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
-
-	// NodeGroups are managed dynamically by the external-grpc protocol
-	// No static configuration needed here
+	for i := range config.NodeGroups {
+		if err := config.NodeGroups[i].mapLegacyRootVolume(); err != nil {
+			return nil, fmt.Errorf("node group %s: %w", config.NodeGroups[i].ID, err)
+		}
+	}
 
 	return &config, nil
 }
 
+// mapLegacyRootVolume moves the deprecated bootFromVolume settings to rootVolume, so the provider
+// only reads rootVolume
+func (c *NodeGroupConfig) mapLegacyRootVolume() error {
+	if c.BootFromVolume {
+		if c.RootVolume != nil {
+			return fmt.Errorf("bootFromVolume cannot be combined with rootVolume")
+		}
+		deleteOnTermination := c.DeleteVolumeOnTermination
+		c.RootVolume = &RootVolumeConfig{
+			SizeGiB:             c.VolumeSize,
+			VolumeType:          c.VolumeType,
+			DeleteOnTermination: &deleteOnTermination,
+		}
+	}
+	c.BootFromVolume, c.VolumeSize, c.VolumeType, c.DeleteVolumeOnTermination = false, 0, "", false
+	return nil
+}
+
 // LoadConfigFromEnv loads configuration from environment variables
 func LoadConfigFromEnv() *CloudConfig {
 	return &CloudConfig{
@@ -84,10 +383,18 @@ func LoadConfigFromEnv() *CloudConfig {
 		ApplicationCredentialName:   getEnvOrDefault("OS_APPLICATION_CREDENTIAL_NAME", ""),
 		ApplicationCredentialSecret: getEnvOrDefault("OS_APPLICATION_CREDENTIAL_SECRET", ""),
 		Region:                      getEnvOrDefault("OS_REGION_NAME", ""),
+		ClusterName:                 getEnvOrDefault("CLUSTER_NAME", ""),
 		Interface:                   getEnvOrDefault("OS_INTERFACE", "public"),
 		IdentityAPIVersion:          getEnvOrDefault("OS_IDENTITY_API_VERSION", "3"),
 		ComputeAPIVersion:           getEnvOrDefault("OS_COMPUTE_API_VERSION", "2.1"),
 		NetworkAPIVersion:           getEnvOrDefault("OS_NETWORK_API_VERSION", "2.0"),
+		VolumeAPIVersion:            getEnvOrDefault("OS_VOLUME_API_VERSION", "3"),
+		ComputeInterface:            getEnvOrDefault("OS_COMPUTE_INTERFACE", ""),
+		ImageInterface:              getEnvOrDefault("OS_IMAGE_INTERFACE", ""),
+		NetworkInterface:            getEnvOrDefault("OS_NETWORK_INTERFACE", ""),
+		VolumeInterface:             getEnvOrDefault("OS_VOLUME_INTERFACE", ""),
+		CACertFile:                  getEnvOrDefault("OS_CACERT", ""),
+		Insecure:                    getEnvOrDefault("OS_INSECURE", "") == "true",
 	}
 }
 
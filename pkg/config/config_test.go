@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestLoadConfigMapsLegacyRootVolume(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodeGroup  string
+		rootVolume *RootVolumeConfig
+		err        string
+	}{
+		{
+			name:      "local disk",
+			nodeGroup: "{id: workers}",
+		},
+		{
+			name:       "legacy settings",
+			nodeGroup:  "{id: workers, bootFromVolume: true, volumeSize: 50, volumeType: ssd, deleteVolumeOnTermination: true}",
+			rootVolume: &RootVolumeConfig{SizeGiB: 50, VolumeType: "ssd", DeleteOnTermination: newBool(true)},
+		},
+		{
+			name:       "legacy settings keeping the volume",
+			nodeGroup:  "{id: workers, bootFromVolume: true, volumeSize: 50}",
+			rootVolume: &RootVolumeConfig{SizeGiB: 50, DeleteOnTermination: newBool(false)},
+		},
+		{
+			name:      "legacy volume size without bootFromVolume",
+			nodeGroup: "{id: workers, volumeSize: 50}",
+		},
+		{
+			name:       "rootVolume",
+			nodeGroup:  "{id: workers, rootVolume: {sizeGiB: 30}}",
+			rootVolume: &RootVolumeConfig{SizeGiB: 30},
+		},
+		{
+			name:      "legacy settings and rootVolume",
+			nodeGroup: "{id: workers, bootFromVolume: true, volumeSize: 50, rootVolume: {sizeGiB: 30}}",
+			err:       "bootFromVolume cannot be combined with rootVolume",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			th.AssertNoErr(t, os.WriteFile(path, []byte("nodeGroups:\n- "+tt.nodeGroup+"\n"), 0o600))
+
+			cfg, err := LoadConfig(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+
+			nodeGroup := cfg.NodeGroups[0]
+			th.AssertDeepEquals(t, tt.rootVolume, nodeGroup.RootVolume)
+			th.AssertEquals(t, false, nodeGroup.BootFromVolume)
+			th.AssertEquals(t, 0, nodeGroup.VolumeSize)
+		})
+	}
+}
+
+func newBool(value bool) *bool {
+	return &value
+}
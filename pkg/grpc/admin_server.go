@@ -0,0 +1,285 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	adminpb "github.com/bucher-brothers/openstack-autoscaler/api/admin"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+// AdminServer implements the Admin gRPC service operators use to inspect and change the running autoscaler
+type AdminServer struct {
+	adminpb.UnimplementedAdminServer
+	provider *provider.OpenStackProvider
+}
+
+// NewAdminServer creates a new admin gRPC server
+func NewAdminServer(p *provider.OpenStackProvider) *AdminServer {
+	return &AdminServer{
+		provider: p,
+	}
+}
+
+// ListNodeGroups returns every node group with its resource version, ordered by ID
+func (s *AdminServer) ListNodeGroups(ctx context.Context, req *adminpb.ListNodeGroupsRequest) (*adminpb.ListNodeGroupsResponse, error) {
+	nodeGroups := s.provider.GetNodeGroups()
+	sort.Slice(nodeGroups, func(i, j int) bool { return nodeGroups[i].ID() < nodeGroups[j].ID() })
+
+	resp := &adminpb.ListNodeGroupsResponse{}
+	for _, ng := range nodeGroups {
+		pbNodeGroup, err := adminNodeGroup(ng)
+		if err != nil {
+			return nil, err
+		}
+		resp.NodeGroups = append(resp.NodeGroups, pbNodeGroup)
+	}
+	return resp, nil
+}
+
+// UpdateNodeGroup changes the size limits or labels of a node group based on the resource version
+// the caller read, failing with Aborted when the node group changed since
+func (s *AdminServer) UpdateNodeGroup(ctx context.Context, req *adminpb.UpdateNodeGroupRequest) (*adminpb.UpdateNodeGroupResponse, error) {
+	ng := s.provider.GetNodeGroup(req.Id)
+	if ng == nil {
+		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
+	}
+	// The configuration is copied from the node group at the requested version, UpdateNodeGroup
+	// rejects the update if it is replaced before the update applies
+	if current := ng.ResourceVersion(); current != req.ResourceVersion {
+		return nil, status.Errorf(codes.Aborted, "node group %s has resource version %d, update was based on %d", req.Id, current, req.ResourceVersion)
+	}
+
+	cfg := *ng.Config
+	cfg.Labels = maps.Clone(ng.Config.Labels)
+	if req.MinSize != nil {
+		cfg.MinSize = int(*req.MinSize)
+	}
+	if req.MaxSize != nil {
+		cfg.MaxSize = int(*req.MaxSize)
+	}
+	if req.ReplaceLabels {
+		cfg.Labels = maps.Clone(req.Labels)
+	}
+	if cfg.MinSize < 0 || cfg.MaxSize < cfg.MinSize {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid size limits min %d, max %d", cfg.MinSize, cfg.MaxSize)
+	}
+
+	updated, err := s.provider.UpdateNodeGroup(&cfg, req.ResourceVersion)
+	if err != nil {
+		var conflict *provider.ResourceVersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			return nil, status.Error(codes.Aborted, err.Error())
+		case provider.IsConfigurationError(err):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	klog.Infof("Admin updated node group %s to resource version %d (min %d, max %d)", cfg.ID, updated.ResourceVersion(), cfg.MinSize, cfg.MaxSize)
+
+	pbNodeGroup, err := adminNodeGroup(updated)
+	if err != nil {
+		return nil, err
+	}
+	return &adminpb.UpdateNodeGroupResponse{NodeGroup: pbNodeGroup}, nil
+}
+
+// CleanupServers lists, and with execute set deletes, the servers the autoscaler created for the
+// cluster that the mode selects
+func (s *AdminServer) CleanupServers(ctx context.Context, req *adminpb.CleanupServersRequest) (*adminpb.CleanupServersResponse, error) {
+	selected, err := s.provider.CleanupServers(req.Mode, req.Execute, req.Reason)
+	if err != nil {
+		if provider.IsConfigurationError(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if req.Execute {
+		klog.Infof("Admin cleanup in mode %s selected %d servers", req.Mode, len(selected))
+	}
+
+	resp := &adminpb.CleanupServersResponse{}
+	for _, server := range selected {
+		pbServer := &adminpb.CleanupServer{
+			Id:        server.ID,
+			Name:      server.Name,
+			NodeGroup: server.NodeGroupID,
+			Deleted:   server.Deleted,
+		}
+		if server.Err != nil {
+			pbServer.Error = server.Err.Error()
+		}
+		resp.Servers = append(resp.Servers, pbServer)
+	}
+	return resp, nil
+}
+
+// PlanIncrease returns the servers a scale-up of the node group by delta would create
+func (s *AdminServer) PlanIncrease(ctx context.Context, req *adminpb.PlanIncreaseRequest) (*adminpb.PlanIncreaseResponse, error) {
+	ng := s.provider.GetNodeGroup(req.Id)
+	if ng == nil {
+		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
+	}
+	if req.Delta <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "delta must be positive, got %d", req.Delta)
+	}
+
+	plan, err := ng.PlanIncrease(int(req.Delta))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &adminpb.PlanIncreaseResponse{Plan: adminScalePlan(plan)}, nil
+}
+
+// PlanDelete returns which of the named nodes a scale-down of the node group would delete. The
+// nodes are resolved to servers by name, as DeleteNodes does for nodes without provider ID.
+func (s *AdminServer) PlanDelete(ctx context.Context, req *adminpb.PlanDeleteRequest) (*adminpb.PlanDeleteResponse, error) {
+	ng := s.provider.GetNodeGroup(req.Id)
+	if ng == nil {
+		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
+	}
+	if len(req.NodeNames) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no node names given")
+	}
+
+	nodes := make([]*apiv1.Node, len(req.NodeNames))
+	for i, name := range req.NodeNames {
+		nodes[i] = &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	return &adminpb.PlanDeleteResponse{Plan: adminScalePlan(ng.PlanDelete(nodes))}, nil
+}
+
+// EvacuateNodeGroup starts an evacuation of the source node group to the target node group and
+// returns the batches of servers it removes
+func (s *AdminServer) EvacuateNodeGroup(ctx context.Context, req *adminpb.EvacuateNodeGroupRequest) (*adminpb.EvacuateNodeGroupResponse, error) {
+	for _, id := range []string{req.SourceId, req.TargetId} {
+		if id != "" && s.provider.GetNodeGroup(id) == nil {
+			return nil, status.Errorf(codes.NotFound, "node group %s not found", id)
+		}
+	}
+	if req.SourceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "no source node group given")
+	}
+	if req.BatchSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "batch size must not be negative, got %d", req.BatchSize)
+	}
+
+	batches, err := s.provider.EvacuateNodeGroup(provider.EvacuationOptions{
+		SourceGroupID: req.SourceId,
+		TargetGroupID: req.TargetId,
+		BatchSize:     int(req.BatchSize),
+		DryRun:        req.DryRun,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, provider.ErrEvacuationRefused) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if !req.DryRun {
+		klog.Infof("Admin started evacuation of node group %s to %s", req.SourceId, req.TargetId)
+	}
+
+	resp := &adminpb.EvacuateNodeGroupResponse{}
+	for _, batch := range batches {
+		resp.Batches = append(resp.Batches, &adminpb.EvacuationBatch{ServerIds: batch})
+	}
+	return resp, nil
+}
+
+// CancelEvacuation stops the running evacuation of a node group
+func (s *AdminServer) CancelEvacuation(ctx context.Context, req *adminpb.CancelEvacuationRequest) (*adminpb.CancelEvacuationResponse, error) {
+	if err := s.provider.CancelEvacuation(req.Id); err != nil {
+		if errors.Is(err, provider.ErrNoEvacuation) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	klog.Infof("Admin cancelled evacuation of node group %s", req.Id)
+	return &adminpb.CancelEvacuationResponse{}, nil
+}
+
+// SetQuarantine changes the quarantine override of a node group and returns the node group
+func (s *AdminServer) SetQuarantine(ctx context.Context, req *adminpb.SetQuarantineRequest) (*adminpb.SetQuarantineResponse, error) {
+	ng := s.provider.GetNodeGroup(req.Id)
+	if ng == nil {
+		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
+	}
+	override, err := provider.ParseQuarantineOverride(req.Override)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ng.SetQuarantineOverride(override)
+	klog.Infof("Admin set the quarantine override of node group %s to %s", req.Id, override)
+
+	pbNodeGroup, err := adminNodeGroup(ng)
+	if err != nil {
+		return nil, err
+	}
+	return &adminpb.SetQuarantineResponse{NodeGroup: pbNodeGroup}, nil
+}
+
+// adminScalePlan converts a scaling plan to its admin API representation
+func adminScalePlan(plan *provider.ScalePlan) *adminpb.ScalePlan {
+	pbPlan := &adminpb.ScalePlan{
+		NodeGroup:   plan.NodeGroupID,
+		CurrentSize: int32(plan.CurrentSize),
+		Create:      adminPlannedServers(plan.Create),
+		Delete:      adminPlannedServers(plan.Delete),
+	}
+	for _, rejected := range plan.Rejected {
+		pbPlan.Rejected = append(pbPlan.Rejected, &adminpb.PlanRejection{Name: rejected.Name, Reason: rejected.Reason})
+	}
+	if plan.Refusal != nil {
+		pbPlan.Refusal = plan.Refusal.Error()
+	}
+	return pbPlan
+}
+
+// adminPlannedServers converts the servers of a scaling plan to their admin API representation
+func adminPlannedServers(planned []provider.PlannedServer) []*adminpb.PlannedServer {
+	pbServers := make([]*adminpb.PlannedServer, 0, len(planned))
+	for _, server := range planned {
+		pbServers = append(pbServers, &adminpb.PlannedServer{Id: server.ID, Name: server.Name, AvailabilityZone: server.AvailabilityZone})
+	}
+	return pbServers
+}
+
+// adminNodeGroup converts a node group to its admin API representation
+func adminNodeGroup(ng *provider.OpenStackNodeGroup) (*adminpb.NodeGroup, error) {
+	targetSize, err := ng.TargetSize()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to get target size of node group %s: %v", ng.ID(), err)
+	}
+
+	flavor := ng.Config.FlavorName
+	if flavor == "" {
+		flavor = ng.Config.FlavorID
+	}
+	image := ng.Config.ImageName
+	if image == "" {
+		image = ng.Config.ImageID
+	}
+	return &adminpb.NodeGroup{
+		Id:              ng.ID(),
+		MinSize:         int32(ng.MinSize()),
+		MaxSize:         int32(ng.MaxSize()),
+		TargetSize:      int32(targetSize),
+		ResourceVersion: ng.ResourceVersion(),
+		Labels:          ng.Config.Labels,
+		Flavor:          flavor,
+		Image:           image,
+		Quarantine:      ng.QuarantineStatus(),
+	}, nil
+}
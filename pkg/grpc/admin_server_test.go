@@ -0,0 +1,220 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	adminpb "github.com/bucher-brothers/openstack-autoscaler/api/admin"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+func TestAdminUpdateNodeGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *adminpb.UpdateNodeGroupRequest
+		code    codes.Code
+		version uint64
+		minSize int32
+		maxSize int32
+		labels  map[string]string
+	}{
+		{
+			name:    "sizes",
+			req:     &adminpb.UpdateNodeGroupRequest{Id: "workers", ResourceVersion: 1, MinSize: proto.Int32(1), MaxSize: proto.Int32(8)},
+			code:    codes.OK,
+			version: 2, minSize: 1, maxSize: 8, labels: map[string]string{"pool": "general"},
+		},
+		{
+			name:    "labels",
+			req:     &adminpb.UpdateNodeGroupRequest{Id: "workers", ResourceVersion: 1, Labels: map[string]string{"pool": "batch"}, ReplaceLabels: true},
+			code:    codes.OK,
+			version: 2, minSize: 0, maxSize: 5, labels: map[string]string{"pool": "batch"},
+		},
+		{
+			name: "stale resource version",
+			req:  &adminpb.UpdateNodeGroupRequest{Id: "workers", ResourceVersion: 7, MaxSize: proto.Int32(8)},
+			code: codes.Aborted,
+		},
+		{
+			name: "unknown node group",
+			req:  &adminpb.UpdateNodeGroupRequest{Id: "missing", ResourceVersion: 1},
+			code: codes.NotFound,
+		},
+		{
+			name: "max below min",
+			req:  &adminpb.UpdateNodeGroupRequest{Id: "workers", ResourceVersion: 1, MinSize: proto.Int32(4), MaxSize: proto.Int32(2)},
+			code: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, p, cloud := newTestServer(t)
+			admin := NewAdminServer(p)
+			flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+			imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+			_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: "workers", MaxSize: 5, FlavorID: flavorID, ImageID: imageID, Labels: map[string]string{"pool": "general"}})
+			th.AssertNoErr(t, err)
+
+			resp, err := admin.UpdateNodeGroup(context.Background(), tt.req)
+			th.AssertEquals(t, tt.code, status.Code(err))
+			if tt.code != codes.OK {
+				th.AssertEquals(t, uint64(1), p.GetNodeGroup("workers").ResourceVersion())
+				return
+			}
+
+			th.AssertEquals(t, tt.version, resp.NodeGroup.ResourceVersion)
+			th.AssertEquals(t, tt.minSize, resp.NodeGroup.MinSize)
+			th.AssertEquals(t, tt.maxSize, resp.NodeGroup.MaxSize)
+			th.AssertDeepEquals(t, tt.labels, resp.NodeGroup.Labels)
+			th.AssertEquals(t, int(tt.maxSize), p.GetNodeGroup("workers").MaxSize())
+		})
+	}
+}
+
+func TestAdminListNodeGroups(t *testing.T) {
+	_, p, cloud := newTestServer(t)
+	admin := NewAdminServer(p)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	for _, id := range []string{"b", "a"} {
+		_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: id, MaxSize: 3, FlavorID: flavorID, ImageName: "node-image"})
+		th.AssertNoErr(t, err)
+	}
+
+	resp, err := admin.ListNodeGroups(context.Background(), &adminpb.ListNodeGroupsRequest{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(resp.NodeGroups))
+	th.AssertEquals(t, "a", resp.NodeGroups[0].Id)
+	th.AssertEquals(t, flavorID, resp.NodeGroups[0].Flavor)
+	th.AssertEquals(t, "node-image", resp.NodeGroups[0].Image)
+}
+
+func TestAdminCleanupServersWithoutClusterName(t *testing.T) {
+	_, p, _ := newTestServer(t)
+	admin := NewAdminServer(p)
+
+	_, err := admin.CleanupServers(context.Background(), &adminpb.CleanupServersRequest{Mode: "orphans"})
+	th.AssertEquals(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAdminPlans(t *testing.T) {
+	_, p, cloud := newTestServer(t)
+	admin := NewAdminServer(p)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: "workers", MaxSize: 3, FlavorID: flavorID, ImageID: imageID})
+	th.AssertNoErr(t, err)
+
+	increase, err := admin.PlanIncrease(context.Background(), &adminpb.PlanIncreaseRequest{Id: "workers", Delta: 2})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(increase.Plan.Create))
+	th.AssertEquals(t, "", increase.Plan.Refusal)
+
+	increase, err = admin.PlanIncrease(context.Background(), &adminpb.PlanIncreaseRequest{Id: "workers", Delta: 4})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "cannot increase size to 4, max size is 3", increase.Plan.Refusal)
+
+	_, err = admin.PlanIncrease(context.Background(), &adminpb.PlanIncreaseRequest{Id: "workers"})
+	th.AssertEquals(t, codes.InvalidArgument, status.Code(err))
+
+	// A node no server is named like is rejected, nothing is deleted
+	remove, err := admin.PlanDelete(context.Background(), &adminpb.PlanDeleteRequest{Id: "workers", NodeNames: []string{"workers-missing"}})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(remove.Plan.Delete))
+	th.AssertEquals(t, 1, len(remove.Plan.Rejected))
+
+	_, err = admin.PlanDelete(context.Background(), &adminpb.PlanDeleteRequest{Id: "missing", NodeNames: []string{"a"}})
+	th.AssertEquals(t, codes.NotFound, status.Code(err))
+	th.AssertEquals(t, 0, len(cloud.Servers()))
+}
+
+func TestAdminEvacuation(t *testing.T) {
+	_, p, cloud := newTestServer(t)
+	admin := NewAdminServer(p)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	for _, id := range []string{"old", "new"} {
+		_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: id, MaxSize: 3, FlavorID: flavorID, ImageID: imageID})
+		th.AssertNoErr(t, err)
+	}
+	req := &adminpb.EvacuateNodeGroupRequest{SourceId: "old", TargetId: "new", DryRun: true}
+
+	// Nodes cannot be drained without a Kubernetes client
+	_, err := admin.EvacuateNodeGroup(context.Background(), req)
+	th.AssertEquals(t, codes.FailedPrecondition, status.Code(err))
+
+	p.SetNodeDrainer(stubDrainer{})
+	resp, err := admin.EvacuateNodeGroup(context.Background(), req)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(resp.Batches))
+
+	_, err = admin.EvacuateNodeGroup(context.Background(), &adminpb.EvacuateNodeGroupRequest{SourceId: "old", TargetId: "missing"})
+	th.AssertEquals(t, codes.NotFound, status.Code(err))
+
+	_, err = admin.EvacuateNodeGroup(context.Background(), &adminpb.EvacuateNodeGroupRequest{SourceId: "old"})
+	th.AssertEquals(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = admin.CancelEvacuation(context.Background(), &adminpb.CancelEvacuationRequest{Id: "old"})
+	th.AssertEquals(t, codes.NotFound, status.Code(err))
+}
+
+func TestAdminSetQuarantine(t *testing.T) {
+	_, p, cloud := newTestServer(t)
+	admin := NewAdminServer(p)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: "workers", MaxSize: 5, FlavorID: flavorID, ImageID: imageID})
+	th.AssertNoErr(t, err)
+
+	steps := []struct {
+		name       string
+		req        *adminpb.SetQuarantineRequest
+		code       codes.Code
+		quarantine string
+		scales     bool
+	}{
+		{name: "forced", req: &adminpb.SetQuarantineRequest{Id: "workers", Override: "forced"}, quarantine: "manual"},
+		{name: "unknown override", req: &adminpb.SetQuarantineRequest{Id: "workers", Override: "off"}, code: codes.InvalidArgument, quarantine: "manual"},
+		{name: "unknown node group", req: &adminpb.SetQuarantineRequest{Id: "missing", Override: "auto"}, code: codes.NotFound, quarantine: "manual"},
+		{name: "disabled", req: &adminpb.SetQuarantineRequest{Id: "workers", Override: "disabled"}, scales: true},
+		{name: "auto", req: &adminpb.SetQuarantineRequest{Id: "workers", Override: "auto"}, scales: true},
+	}
+	for _, step := range steps {
+		resp, err := admin.SetQuarantine(context.Background(), step.req)
+		if status.Code(err) != step.code {
+			t.Fatalf("%s: expected code %s, got %v", step.name, step.code, err)
+		}
+		if err == nil {
+			th.AssertEquals(t, step.quarantine, resp.NodeGroup.Quarantine)
+		}
+
+		// The override outlives configuration updates
+		ng := p.GetNodeGroup("workers")
+		_, err = admin.UpdateNodeGroup(context.Background(), &adminpb.UpdateNodeGroupRequest{Id: "workers", ResourceVersion: ng.ResourceVersion(), MaxSize: proto.Int32(5)})
+		th.AssertNoErr(t, err)
+		list, err := admin.ListNodeGroups(context.Background(), &adminpb.ListNodeGroupsRequest{})
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, step.quarantine, list.NodeGroups[0].Quarantine)
+
+		err = p.GetNodeGroup("workers").IncreaseSize(1)
+		if step.scales != (err == nil) || (err != nil && !errors.Is(err, provider.ErrQuarantined)) {
+			t.Fatalf("%s: expected the scale-up to succeed %t, got %v", step.name, step.scales, err)
+		}
+	}
+	th.AssertEquals(t, 2, len(cloud.Servers()))
+}
+
+// stubDrainer drains every node immediately
+type stubDrainer struct{}
+
+func (stubDrainer) DrainNode(ctx context.Context, providerID string) error {
+	return nil
+}
@@ -2,16 +2,23 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
 	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
 )
 
@@ -30,8 +37,6 @@ func NewOpenStackGrpcServer(p *provider.OpenStackProvider) *OpenStackGrpcServer
 
 // NodeGroups returns all node groups configured for this cloud provider
 func (s *OpenStackGrpcServer) NodeGroups(ctx context.Context, req *pb.NodeGroupsRequest) (*pb.NodeGroupsResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroups %v", req)
-
 	nodeGroups := s.provider.GetNodeGroups()
 	pbNodeGroups := make([]*pb.NodeGroup, len(nodeGroups))
 
@@ -40,7 +45,7 @@ func (s *OpenStackGrpcServer) NodeGroups(ctx context.Context, req *pb.NodeGroups
 			Id:      ng.ID(),
 			MinSize: int32(ng.MinSize()),
 			MaxSize: int32(ng.MaxSize()),
-			Debug:   fmt.Sprintf("NodeGroup %s: min=%d, max=%d, flavor=%s", ng.ID(), ng.MinSize(), ng.MaxSize(), ng.Config.FlavorName),
+			Debug:   nodeGroupDebug(ng),
 		}
 	}
 
@@ -51,15 +56,13 @@ func (s *OpenStackGrpcServer) NodeGroups(ctx context.Context, req *pb.NodeGroups
 
 // NodeGroupForNode returns the node group for the given node
 func (s *OpenStackGrpcServer) NodeGroupForNode(ctx context.Context, req *pb.NodeGroupForNodeRequest) (*pb.NodeGroupForNodeResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupForNode %v", req)
-
 	if req.Node == nil {
 		return nil, status.Error(codes.InvalidArgument, "node is required")
 	}
 
-	ng, err := s.provider.NodeGroupForNode(req.Node.ProviderID)
+	ng, err := s.provider.NodeGroupForNode(req.Node.ProviderID, req.Node.Name)
 	if err != nil {
-		klog.Errorf("Failed to find node group for node %s: %v", req.Node.ProviderID, err)
+		klog.Errorf("Failed to find node group for node %s (%s): %v", req.Node.Name, req.Node.ProviderID, err)
 		return &pb.NodeGroupForNodeResponse{
 			NodeGroup: &pb.NodeGroup{}, // Empty node group means not managed
 		}, nil
@@ -76,47 +79,67 @@ func (s *OpenStackGrpcServer) NodeGroupForNode(ctx context.Context, req *pb.Node
 			Id:      ng.ID(),
 			MinSize: int32(ng.MinSize()),
 			MaxSize: int32(ng.MaxSize()),
-			Debug:   fmt.Sprintf("NodeGroup %s: min=%d, max=%d, flavor=%s", ng.ID(), ng.MinSize(), ng.MaxSize(), ng.Config.FlavorName),
+			Debug:   nodeGroupDebug(ng),
 		},
 	}, nil
 }
 
-// PricingNodePrice returns pricing for a node (not implemented)
+// PricingNodePrice returns the price of running a node over the requested time range
 func (s *OpenStackGrpcServer) PricingNodePrice(ctx context.Context, req *pb.PricingNodePriceRequest) (*pb.PricingNodePriceResponse, error) {
-	klog.V(4).Infof("gRPC request: PricingNodePrice %v", req)
-	return nil, status.Error(codes.Unimplemented, "PricingNodePrice not implemented")
+	if !s.provider.PricingEnabled() {
+		return nil, status.Error(codes.Unimplemented, "PricingNodePrice not implemented")
+	}
+	if req.Node == nil || req.StartTimestamp == nil || req.EndTimestamp == nil {
+		return nil, status.Error(codes.InvalidArgument, "node, startTimestamp and endTimestamp are required")
+	}
+
+	price, err := s.provider.NodePrice(req.Node.ProviderID, req.Node.Labels, req.StartTimestamp.AsTime(), req.EndTimestamp.AsTime())
+	if err != nil {
+		if errors.Is(err, provider.ErrPriceNotFound) {
+			return nil, status.Errorf(codes.NotFound, "failed to price node %s: %v", req.Node.Name, err)
+		}
+		klog.Errorf("Failed to price node %s: %v", req.Node.Name, err)
+		return nil, openStackStatus(codes.Internal, err, "failed to price node %s: %v", req.Node.Name, err)
+	}
+
+	return &pb.PricingNodePriceResponse{Price: price}, nil
 }
 
 // PricingPodPrice returns pricing for a pod (not implemented)
 func (s *OpenStackGrpcServer) PricingPodPrice(ctx context.Context, req *pb.PricingPodPriceRequest) (*pb.PricingPodPriceResponse, error) {
-	klog.V(4).Infof("gRPC request: PricingPodPrice %v", req)
 	return nil, status.Error(codes.Unimplemented, "PricingPodPrice not implemented")
 }
 
-// GPULabel returns the label for GPU nodes (not applicable for OpenStack)
+// GPULabel returns the label for GPU nodes, empty when no node group has GPUs
 func (s *OpenStackGrpcServer) GPULabel(ctx context.Context, req *pb.GPULabelRequest) (*pb.GPULabelResponse, error) {
-	klog.V(4).Infof("gRPC request: GPULabel %v", req)
 	return &pb.GPULabelResponse{
-		Label: "", // No specific GPU label for OpenStack
+		Label: s.provider.GPULabel(),
 	}, nil
 }
 
-// GetAvailableGPUTypes returns available GPU types (not implemented)
+// GetAvailableGPUTypes returns the GPU types offered by the flavors of the node groups
 func (s *OpenStackGrpcServer) GetAvailableGPUTypes(ctx context.Context, req *pb.GetAvailableGPUTypesRequest) (*pb.GetAvailableGPUTypesResponse, error) {
-	klog.V(4).Infof("gRPC request: GetAvailableGPUTypes %v", req)
-	return &pb.GetAvailableGPUTypesResponse{
-		GpuTypes: make(map[string]*anypb.Any),
-	}, nil
+	gpuTypes, err := s.provider.AvailableGPUTypes()
+	if err != nil {
+		klog.Errorf("Failed to get available GPU types: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "failed to get available GPU types: %v", err)
+	}
+
+	response := &pb.GetAvailableGPUTypesResponse{
+		GpuTypes: make(map[string]*anypb.Any, len(gpuTypes)),
+	}
+	for gpuType := range gpuTypes {
+		response.GpuTypes[gpuType] = &anypb.Any{}
+	}
+	return response, nil
 }
 
 // Cleanup cleans up resources before shutdown
 func (s *OpenStackGrpcServer) Cleanup(ctx context.Context, req *pb.CleanupRequest) (*pb.CleanupResponse, error) {
-	klog.V(4).Infof("gRPC request: Cleanup %v", req)
-
 	err := s.provider.Cleanup()
 	if err != nil {
 		klog.Errorf("Cleanup failed: %v", err)
-		return nil, status.Errorf(codes.Internal, "cleanup failed: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "cleanup failed: %v", err)
 	}
 
 	return &pb.CleanupResponse{}, nil
@@ -124,12 +147,10 @@ func (s *OpenStackGrpcServer) Cleanup(ctx context.Context, req *pb.CleanupReques
 
 // Refresh refreshes the cloud provider state
 func (s *OpenStackGrpcServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
-	klog.V(4).Infof("gRPC request: Refresh %v", req)
-
 	err := s.provider.Refresh()
 	if err != nil {
 		klog.Errorf("Refresh failed: %v", err)
-		return nil, status.Errorf(codes.Internal, "refresh failed: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "refresh failed: %v", err)
 	}
 
 	return &pb.RefreshResponse{}, nil
@@ -137,8 +158,6 @@ func (s *OpenStackGrpcServer) Refresh(ctx context.Context, req *pb.RefreshReques
 
 // NodeGroupTargetSize returns the current target size of the node group
 func (s *OpenStackGrpcServer) NodeGroupTargetSize(ctx context.Context, req *pb.NodeGroupTargetSizeRequest) (*pb.NodeGroupTargetSizeResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupTargetSize %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
@@ -147,7 +166,7 @@ func (s *OpenStackGrpcServer) NodeGroupTargetSize(ctx context.Context, req *pb.N
 	size, err := ng.TargetSize()
 	if err != nil {
 		klog.Errorf("Failed to get target size for node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to get target size: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "failed to get target size: %v", err)
 	}
 
 	return &pb.NodeGroupTargetSizeResponse{
@@ -157,17 +176,23 @@ func (s *OpenStackGrpcServer) NodeGroupTargetSize(ctx context.Context, req *pb.N
 
 // NodeGroupIncreaseSize increases the size of the node group
 func (s *OpenStackGrpcServer) NodeGroupIncreaseSize(ctx context.Context, req *pb.NodeGroupIncreaseSizeRequest) (*pb.NodeGroupIncreaseSizeResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupIncreaseSize %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
 	}
 
-	err := ng.IncreaseSize(int(req.Delta))
+	start := time.Now()
+	err := ng.IncreaseSizeWithReason(ctx, int(req.Delta), provider.ReasonClusterAutoscaler)
+	metrics.ObserveDuration(ctx, metrics.ScaleOperationDuration.WithLabelValues("increase_size", req.Id), time.Since(start))
 	if err != nil {
 		klog.Errorf("Failed to increase size for node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to increase size: %v", err)
+		if errors.Is(err, provider.ErrQuotaExceeded) {
+			return nil, openStackStatus(codes.ResourceExhausted, err, "failed to increase size: %v", err)
+		}
+		if errors.Is(err, provider.ErrQuarantined) {
+			return nil, status.Errorf(codes.Unavailable, "failed to increase size: %v", err)
+		}
+		return nil, openStackStatus(codes.Internal, err, "failed to increase size: %v", err)
 	}
 
 	return &pb.NodeGroupIncreaseSizeResponse{}, nil
@@ -175,8 +200,6 @@ func (s *OpenStackGrpcServer) NodeGroupIncreaseSize(ctx context.Context, req *pb
 
 // NodeGroupDeleteNodes deletes nodes from the node group
 func (s *OpenStackGrpcServer) NodeGroupDeleteNodes(ctx context.Context, req *pb.NodeGroupDeleteNodesRequest) (*pb.NodeGroupDeleteNodesResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupDeleteNodes %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
@@ -197,10 +220,21 @@ func (s *OpenStackGrpcServer) NodeGroupDeleteNodes(ctx context.Context, req *pb.
 		}
 	}
 
+	start := time.Now()
 	err := ng.DeleteNodes(nodes)
+	metrics.ObserveDuration(ctx, metrics.ScaleOperationDuration.WithLabelValues("delete_nodes", req.Id), time.Since(start))
 	if err != nil {
 		klog.Errorf("Failed to delete nodes from node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to delete nodes: %v", err)
+		if errors.Is(err, provider.ErrNotMember) {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to delete nodes: %v", err)
+		}
+		if errors.Is(err, provider.ErrScaleDownBatchLimit) || errors.Is(err, provider.ErrBelowMinSize) {
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to delete nodes: %v", err)
+		}
+		if errors.Is(err, provider.ErrQuarantined) {
+			return nil, status.Errorf(codes.Unavailable, "failed to delete nodes: %v", err)
+		}
+		return nil, openStackStatus(codes.Internal, err, "failed to delete nodes: %v", err)
 	}
 
 	return &pb.NodeGroupDeleteNodesResponse{}, nil
@@ -208,8 +242,6 @@ func (s *OpenStackGrpcServer) NodeGroupDeleteNodes(ctx context.Context, req *pb.
 
 // NodeGroupDecreaseTargetSize decreases the target size of the node group
 func (s *OpenStackGrpcServer) NodeGroupDecreaseTargetSize(ctx context.Context, req *pb.NodeGroupDecreaseTargetSizeRequest) (*pb.NodeGroupDecreaseTargetSizeResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupDecreaseTargetSize %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
@@ -218,7 +250,10 @@ func (s *OpenStackGrpcServer) NodeGroupDecreaseTargetSize(ctx context.Context, r
 	err := ng.DecreaseTargetSize(int(req.Delta))
 	if err != nil {
 		klog.Errorf("Failed to decrease target size for node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to decrease target size: %v", err)
+		if errors.Is(err, provider.ErrQuarantined) {
+			return nil, status.Errorf(codes.Unavailable, "failed to decrease target size: %v", err)
+		}
+		return nil, openStackStatus(codes.Internal, err, "failed to decrease target size: %v", err)
 	}
 
 	return &pb.NodeGroupDecreaseTargetSizeResponse{}, nil
@@ -226,8 +261,6 @@ func (s *OpenStackGrpcServer) NodeGroupDecreaseTargetSize(ctx context.Context, r
 
 // NodeGroupNodes returns a list of all nodes in the node group
 func (s *OpenStackGrpcServer) NodeGroupNodes(ctx context.Context, req *pb.NodeGroupNodesRequest) (*pb.NodeGroupNodesResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupNodes %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
@@ -236,31 +269,17 @@ func (s *OpenStackGrpcServer) NodeGroupNodes(ctx context.Context, req *pb.NodeGr
 	servers, err := ng.Nodes()
 	if err != nil {
 		klog.Errorf("Failed to get nodes for node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to get nodes: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "failed to get nodes: %v", err)
 	}
 
 	instances := make([]*pb.Instance, len(servers))
 	for i, server := range servers {
-		instanceState := pb.InstanceStatus_unspecified
-		switch server.Status {
-		case "ACTIVE":
-			instanceState = pb.InstanceStatus_instanceRunning
-		case "BUILD":
-			instanceState = pb.InstanceStatus_instanceCreating
-		case "DELETED", "DELETING":
-			instanceState = pb.InstanceStatus_instanceDeleting
-		}
-
 		instances[i] = &pb.Instance{
-			Id: server.ID,
-			Status: &pb.InstanceStatus{
-				InstanceState: instanceState,
-				ErrorInfo: &pb.InstanceErrorInfo{
-					ErrorCode:          "",
-					ErrorMessage:       "",
-					InstanceErrorClass: 0,
-				},
-			},
+			Id:     provider.ProviderID(server.ID),
+			Status: InstanceStatusFromServer(&server),
+		}
+		if failure := ng.ProvisioningFailure(server.ID); failure != nil && instances[i].Status.ErrorInfo == nil {
+			instances[i].Status = provisioningFailureStatus(failure)
 		}
 	}
 
@@ -271,8 +290,6 @@ func (s *OpenStackGrpcServer) NodeGroupNodes(ctx context.Context, req *pb.NodeGr
 
 // NodeGroupTemplateNodeInfo returns template node info for scale-up simulations
 func (s *OpenStackGrpcServer) NodeGroupTemplateNodeInfo(ctx context.Context, req *pb.NodeGroupTemplateNodeInfoRequest) (*pb.NodeGroupTemplateNodeInfoResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupTemplateNodeInfo %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
@@ -281,13 +298,13 @@ func (s *OpenStackGrpcServer) NodeGroupTemplateNodeInfo(ctx context.Context, req
 	templateNode, err := ng.TemplateNodeInfo()
 	if err != nil {
 		klog.Errorf("Failed to get template node info for node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to get template node info: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "failed to get template node info: %v", err)
 	}
 
 	nodeBytes, err := templateNode.Marshal()
 	if err != nil {
 		klog.Errorf("Failed to marshal template node for node group %s: %v", req.Id, err)
-		return nil, status.Errorf(codes.Internal, "failed to marshal template node: %v", err)
+		return nil, openStackStatus(codes.Internal, err, "failed to marshal template node: %v", err)
 	}
 
 	return &pb.NodeGroupTemplateNodeInfoResponse{
@@ -297,27 +314,93 @@ func (s *OpenStackGrpcServer) NodeGroupTemplateNodeInfo(ctx context.Context, req
 
 // NodeGroupGetOptions returns autoscaling options for the node group
 func (s *OpenStackGrpcServer) NodeGroupGetOptions(ctx context.Context, req *pb.NodeGroupAutoscalingOptionsRequest) (*pb.NodeGroupAutoscalingOptionsResponse, error) {
-	klog.V(4).Infof("gRPC request: NodeGroupGetOptions %v", req)
-
 	ng := s.provider.GetNodeGroup(req.Id)
 	if ng == nil {
 		return nil, status.Errorf(codes.NotFound, "node group %s not found", req.Id)
 	}
 
-	// Use default options - can be extended in the future
 	if req.Defaults == nil {
 		return nil, status.Error(codes.InvalidArgument, "defaults are required")
 	}
 
 	return &pb.NodeGroupAutoscalingOptionsResponse{
-		NodeGroupAutoscalingOptions: &pb.NodeGroupAutoscalingOptions{
-			ScaleDownUtilizationThreshold:    req.Defaults.ScaleDownUtilizationThreshold,
-			ScaleDownGpuUtilizationThreshold: req.Defaults.ScaleDownGpuUtilizationThreshold,
-			ScaleDownUnneededDuration:        req.Defaults.ScaleDownUnneededDuration,
-			ScaleDownUnreadyDuration:         req.Defaults.ScaleDownUnreadyDuration,
-			MaxNodeProvisionDuration:         req.Defaults.MaxNodeProvisionDuration,
-			ZeroOrMaxNodeScaling:             req.Defaults.ZeroOrMaxNodeScaling,
-			IgnoreDaemonSetsUtilization:      req.Defaults.IgnoreDaemonSetsUtilization,
-		},
+		NodeGroupAutoscalingOptions: mergeAutoscalingOptions(req.Defaults, ng.Config.AutoscalingOptions),
 	}, nil
 }
+
+// mergeAutoscalingOptions applies the node group overrides on top of the autoscaler defaults
+func mergeAutoscalingOptions(defaults *pb.NodeGroupAutoscalingOptions, overrides *config.AutoscalingOptionsConfig) *pb.NodeGroupAutoscalingOptions {
+	options := &pb.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    defaults.ScaleDownUtilizationThreshold,
+		ScaleDownGpuUtilizationThreshold: defaults.ScaleDownGpuUtilizationThreshold,
+		ScaleDownUnneededDuration:        defaults.ScaleDownUnneededDuration,
+		ScaleDownUnreadyDuration:         defaults.ScaleDownUnreadyDuration,
+		MaxNodeProvisionDuration:         defaults.MaxNodeProvisionDuration,
+		ZeroOrMaxNodeScaling:             defaults.ZeroOrMaxNodeScaling,
+		IgnoreDaemonSetsUtilization:      defaults.IgnoreDaemonSetsUtilization,
+	}
+	if overrides == nil {
+		return options
+	}
+
+	if overrides.ScaleDownUtilizationThreshold != nil {
+		options.ScaleDownUtilizationThreshold = *overrides.ScaleDownUtilizationThreshold
+	}
+	if overrides.ScaleDownGpuUtilizationThreshold != nil {
+		options.ScaleDownGpuUtilizationThreshold = *overrides.ScaleDownGpuUtilizationThreshold
+	}
+	if overrides.ScaleDownUnneededDuration != nil {
+		options.ScaleDownUnneededDuration = durationpb.New(*overrides.ScaleDownUnneededDuration)
+	}
+	if overrides.ScaleDownUnreadyDuration != nil {
+		options.ScaleDownUnreadyDuration = durationpb.New(*overrides.ScaleDownUnreadyDuration)
+	}
+	if overrides.MaxNodeProvisionDuration != nil {
+		options.MaxNodeProvisionDuration = durationpb.New(*overrides.MaxNodeProvisionDuration)
+	}
+	if overrides.ZeroOrMaxNodeScaling != nil {
+		options.ZeroOrMaxNodeScaling = *overrides.ZeroOrMaxNodeScaling
+	}
+	if overrides.IgnoreDaemonSetsUtilization != nil {
+		options.IgnoreDaemonSetsUtilization = *overrides.IgnoreDaemonSetsUtilization
+	}
+	return options
+}
+
+// nodeGroupDebug returns the debug string reported for a node group
+func nodeGroupDebug(ng *provider.OpenStackNodeGroup) string {
+	flavor := ng.Config.FlavorName
+	if ng.Config.FlavorID != "" {
+		flavor = ng.Config.FlavorID
+	}
+	debug := fmt.Sprintf("NodeGroup %s: min=%d, max=%d, flavor=%s", ng.ID(), ng.MinSize(), ng.MaxSize(), flavor)
+	if ng.Config.MaxScaleDownBatch > 0 {
+		debug += fmt.Sprintf(", maxScaleDownBatch=%d", ng.Config.MaxScaleDownBatch)
+	}
+	if quarantine := ng.QuarantineStatus(); quarantine != "" {
+		debug += fmt.Sprintf(", quarantined=%s", quarantine)
+	}
+	if zones := ng.ZoneDistribution(); zones != "" {
+		debug += fmt.Sprintf(", zones=%s", zones)
+	}
+	if requestIDs := ng.FailedRequestIDs(); len(requestIDs) > 0 {
+		debug += fmt.Sprintf(", failedRequestIDs=%s", strings.Join(requestIDs, ","))
+	}
+	return debug
+}
+
+// openStackStatus builds a gRPC status error carrying the OpenStack request ID of err when present
+func openStackStatus(code codes.Code, err error, format string, args ...interface{}) error {
+	st := status.Newf(code, format, args...)
+
+	requestID := provider.RequestIDFromError(err)
+	if requestID == "" {
+		return st.Err()
+	}
+
+	detailed, detailErr := st.WithDetails(&errdetails.RequestInfo{RequestId: requestID})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+// newTestServer returns a gRPC server whose provider is authenticated against a fake cloud
+func newTestServer(t *testing.T) (*OpenStackGrpcServer, *provider.OpenStackProvider, *fakecloud.Cloud) {
+	t.Helper()
+	cloud := fakecloud.New()
+	t.Cleanup(cloud.Close)
+
+	p, err := provider.NewOpenStackProvider(&config.Config{Cloud: config.CloudConfig{
+		AuthURL:            cloud.AuthURL(),
+		Username:           "autoscaler",
+		Password:           "secret",
+		ProjectName:        fakecloud.ProjectID,
+		UserDomainName:     "Default",
+		Region:             fakecloud.Region,
+		IdentityAPIVersion: "3",
+	}})
+	th.AssertNoErr(t, err)
+	t.Cleanup(func() { _ = p.Shutdown(5 * time.Second) })
+	return NewOpenStackGrpcServer(p), p, cloud
+}
+
+func TestGetAvailableGPUTypes(t *testing.T) {
+	server, p, cloud := newTestServer(t)
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	flavors := map[string]map[string]string{
+		"cpu":  nil,
+		"vgpu": {"resources:VGPU": "1"},
+	}
+	for name, extraSpecs := range flavors {
+		flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: name, VCPUs: 4, RAM: 8192, ExtraSpecs: extraSpecs})
+		_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: name, MaxSize: 3, FlavorID: flavorID, ImageID: imageID})
+		th.AssertNoErr(t, err)
+	}
+
+	response, err := server.GetAvailableGPUTypes(context.Background(), &pb.GetAvailableGPUTypesRequest{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(response.GpuTypes))
+	if _, exists := response.GpuTypes["vgpu"]; !exists {
+		t.Errorf("expected the vgpu type, got %v", response.GpuTypes)
+	}
+}
+
+func TestRequestIDPropagatedToStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		code   codes.Code
+	}{
+		{name: "server error", status: http.StatusInternalServerError, code: codes.Internal},
+		{name: "conflict", status: http.StatusConflict, code: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, p, cloud := newTestServer(t)
+			flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+			imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+			_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: "workers", MaxSize: 3, FlavorID: flavorID, ImageID: imageID, RollbackOnFailure: new(bool)})
+			th.AssertNoErr(t, err)
+			cloud.Fail(http.MethodPost, fakecloud.ComputePath+"/servers", tt.status, -1)
+
+			_, err = server.NodeGroupIncreaseSize(context.Background(), &pb.NodeGroupIncreaseSizeRequest{Id: "workers", Delta: 1})
+			st, _ := status.FromError(err)
+			th.AssertEquals(t, tt.code, st.Code())
+
+			// The request ID is the one the cloud reported for the failed create
+			var failed string
+			for i, request := range cloud.RequestLog() {
+				if request.Method == http.MethodPost && request.Path == fakecloud.ComputePath+"/servers" {
+					failed = fmt.Sprintf("req-%08d", i+1)
+				}
+			}
+			var requestIDs []string
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.RequestInfo); ok {
+					requestIDs = append(requestIDs, info.RequestId)
+				}
+			}
+			th.AssertDeepEquals(t, []string{failed}, requestIDs)
+
+			response, err := server.NodeGroups(context.Background(), &pb.NodeGroupsRequest{})
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 1, len(response.NodeGroups))
+			if !strings.Contains(response.NodeGroups[0].Debug, "failedRequestIDs="+failed) {
+				t.Errorf("expected the debug string to list %s, got %q", failed, response.NodeGroups[0].Debug)
+			}
+		})
+	}
+}
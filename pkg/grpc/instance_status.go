@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+
+	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+const (
+	// instanceErrorClassOutOfResources is the cluster autoscaler error class for exhausted capacity,
+	// it triggers the stockout backoff of the node group
+	instanceErrorClassOutOfResources = 1
+	// instanceErrorClassOther is the cluster autoscaler error class for failures other than exhausted capacity
+	instanceErrorClassOther = 99
+)
+
+// InstanceStatusFromServer maps the instance state of a server to the instance status reported
+// to the cluster autoscaler. Servers in ERROR are reported as failed creations carrying the Nova
+// fault, only those carry error info.
+func InstanceStatusFromServer(server *servers.Server) *pb.InstanceStatus {
+	instanceStatus := &pb.InstanceStatus{
+		InstanceState: pb.InstanceStatus_unspecified,
+	}
+
+	switch provider.ServerInstanceState(server) {
+	case provider.InstanceStateRunning:
+		instanceStatus.InstanceState = pb.InstanceStatus_instanceRunning
+	case provider.InstanceStateCreating:
+		instanceStatus.InstanceState = pb.InstanceStatus_instanceCreating
+	case provider.InstanceStateDeleting:
+		instanceStatus.InstanceState = pb.InstanceStatus_instanceDeleting
+	case provider.InstanceStateFailed:
+		instanceStatus.InstanceState = pb.InstanceStatus_instanceCreating
+		instanceStatus.ErrorInfo = &pb.InstanceErrorInfo{
+			ErrorCode:          "ERROR",
+			ErrorMessage:       fmt.Sprintf("server %s is in ERROR state", server.ID),
+			InstanceErrorClass: instanceErrorClassOther,
+		}
+		if server.Fault.Code != 0 {
+			instanceStatus.ErrorInfo.ErrorCode = fmt.Sprintf("%d", server.Fault.Code)
+		}
+		if server.Fault.Message != "" {
+			instanceStatus.ErrorInfo.ErrorMessage = server.Fault.Message
+		}
+		if isOutOfResourcesFault(server.Fault.Message) {
+			instanceStatus.ErrorInfo.InstanceErrorClass = instanceErrorClassOutOfResources
+		}
+	}
+
+	return instanceStatus
+}
+
+// provisioningFailureStatus reports a server that did not become a usable node as a failed
+// creation, so the cluster autoscaler removes it and backs off the node group. Servers still
+// building after the provision timeout and servers the cloud ran out of floating IPs for are
+// reported this way, servers in ERROR already carry the Nova fault.
+func provisioningFailureStatus(failure *provider.ProvisioningFailure) *pb.InstanceStatus {
+	errorInfo := &pb.InstanceErrorInfo{
+		ErrorCode:          "PROVISION_TIMEOUT",
+		ErrorMessage:       failure.Reason,
+		InstanceErrorClass: instanceErrorClassOther,
+	}
+	if failure.OutOfResources {
+		errorInfo.ErrorCode = "FLOATING_IP_EXHAUSTED"
+		errorInfo.InstanceErrorClass = instanceErrorClassOutOfResources
+	}
+
+	return &pb.InstanceStatus{
+		InstanceState: pb.InstanceStatus_instanceCreating,
+		ErrorInfo:     errorInfo,
+	}
+}
+
+// isOutOfResourcesFault reports whether a Nova fault means the cloud had no capacity or quota for the server
+func isOutOfResourcesFault(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "no valid host") || strings.Contains(message, "quota exceeded")
+}
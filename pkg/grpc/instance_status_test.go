@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/provider"
+)
+
+func TestProvisioningFailureStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure provider.ProvisioningFailure
+		code    string
+		class   int32
+	}{
+		{
+			name:    "timed out",
+			failure: provider.ProvisioningFailure{Reason: "server is still BUILD after 10m0s", TimedOut: true},
+			code:    "PROVISION_TIMEOUT",
+			class:   instanceErrorClassOther,
+		},
+		{
+			name:    "floating IP pool exhausted",
+			failure: provider.ProvisioningFailure{Reason: "failed to attach floating IP", OutOfResources: true},
+			code:    "FLOATING_IP_EXHAUSTED",
+			class:   instanceErrorClassOutOfResources,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := provisioningFailureStatus(&tt.failure)
+			th.AssertEquals(t, pb.InstanceStatus_instanceCreating, status.InstanceState)
+			th.AssertEquals(t, tt.code, status.ErrorInfo.ErrorCode)
+			th.AssertEquals(t, tt.failure.Reason, status.ErrorInfo.ErrorMessage)
+			th.AssertEquals(t, tt.class, status.ErrorInfo.InstanceErrorClass)
+		})
+	}
+}
+
+func TestInstanceStatusFromServer(t *testing.T) {
+	tests := []struct {
+		status    string
+		taskState string
+		fault     servers.Fault
+		state     pb.InstanceStatus_InstanceState
+		code      string
+		class     int32
+	}{
+		{status: "BUILD", state: pb.InstanceStatus_instanceCreating},
+		{status: "ACTIVE", state: pb.InstanceStatus_instanceRunning},
+		{status: "REBUILD", state: pb.InstanceStatus_instanceRunning},
+		{status: "REBOOT", state: pb.InstanceStatus_instanceRunning},
+		{status: "HARD_REBOOT", state: pb.InstanceStatus_instanceRunning},
+		{status: "PASSWORD", state: pb.InstanceStatus_instanceRunning},
+		{status: "MIGRATING", state: pb.InstanceStatus_instanceRunning},
+		{status: "RESIZE", state: pb.InstanceStatus_instanceRunning},
+		{status: "VERIFY_RESIZE", state: pb.InstanceStatus_instanceRunning},
+		{status: "REVERT_RESIZE", state: pb.InstanceStatus_instanceRunning},
+		{status: "RESCUE", state: pb.InstanceStatus_instanceRunning},
+		{status: "SHUTOFF", state: pb.InstanceStatus_instanceRunning},
+		{status: "SUSPENDED", state: pb.InstanceStatus_instanceRunning},
+		{status: "PAUSED", state: pb.InstanceStatus_instanceRunning},
+		{status: "SHELVED", state: pb.InstanceStatus_instanceRunning},
+		{status: "SHELVED_OFFLOADED", state: pb.InstanceStatus_instanceRunning},
+		{status: "UNKNOWN", state: pb.InstanceStatus_instanceRunning},
+		{status: "DELETED", state: pb.InstanceStatus_instanceDeleting},
+		{status: "SOFT_DELETED", state: pb.InstanceStatus_instanceDeleting},
+		{status: "ACTIVE", taskState: "deleting", state: pb.InstanceStatus_instanceDeleting},
+		{status: "SHUTOFF", taskState: "soft-deleting", state: pb.InstanceStatus_instanceDeleting},
+		{status: "ERROR", state: pb.InstanceStatus_instanceCreating, code: "ERROR", class: instanceErrorClassOther},
+		{
+			status: "ERROR", fault: servers.Fault{Code: 500, Message: "No valid host was found. There are not enough hosts available."},
+			state: pb.InstanceStatus_instanceCreating, code: "500", class: instanceErrorClassOutOfResources,
+		},
+		{
+			status: "ERROR", fault: servers.Fault{Code: 500, Message: "Build of instance aborted: Failure prepping block device."},
+			state: pb.InstanceStatus_instanceCreating, code: "500", class: instanceErrorClassOther,
+		},
+		{status: "NEW_STATUS", state: pb.InstanceStatus_unspecified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status+"/"+tt.taskState+"/"+tt.fault.Message, func(t *testing.T) {
+			server := &servers.Server{ID: "server", Status: tt.status, TaskState: tt.taskState, Fault: tt.fault}
+
+			status := InstanceStatusFromServer(server)
+			th.AssertEquals(t, tt.state, status.InstanceState)
+			th.AssertEquals(t, tt.code != "", status.ErrorInfo != nil)
+			if status.ErrorInfo == nil {
+				return
+			}
+			th.AssertEquals(t, tt.code, status.ErrorInfo.ErrorCode)
+			th.AssertEquals(t, tt.class, status.ErrorInfo.InstanceErrorClass)
+			if tt.fault.Message != "" {
+				th.AssertEquals(t, tt.fault.Message, status.ErrorInfo.ErrorMessage)
+			}
+		})
+	}
+}
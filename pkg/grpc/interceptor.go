@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// requestIDMetadataKey is the incoming metadata key a caller may set to choose the request ID
+	requestIDMetadataKey = "x-request-id"
+	// requestIDLength is the length of generated request IDs
+	requestIDLength = 8
+)
+
+// requestIDKey is the context key holding the request ID
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID assigned by LoggingInterceptor, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// LoggingInterceptor assigns each call a request ID, attaches it to the context and logs the
+// method, duration and status code once the call completes. Successful calls are logged at
+// verbosity 4 and failed calls at verbosity 2, request payloads at verbosity 6.
+func LoggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestID := incomingRequestID(ctx)
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+
+	klog.V(6).Infof("gRPC request %s: %s %v", requestID, info.FullMethod, req)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	duration := time.Since(start)
+
+	code := status.Code(err)
+	if code == codes.OK {
+		klog.V(4).Infof("gRPC request %s: %s completed with %s in %s", requestID, info.FullMethod, code, duration)
+	} else {
+		klog.V(2).Infof("gRPC request %s: %s completed with %s in %s: %v", requestID, info.FullMethod, code, duration, err)
+	}
+	return resp, err
+}
+
+// incomingRequestID returns the request ID set by the caller, or a new one
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadataKey); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return utilrand.String(requestIDLength)
+}
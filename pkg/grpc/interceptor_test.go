@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	pb "github.com/bucher-brothers/openstack-autoscaler/api/protos"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+// exemplarTraceIDs returns the trace IDs of the exemplars of the histogram series matching labels
+func exemplarTraceIDs(t *testing.T, name string, labels map[string]string) []string {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	th.AssertNoErr(t, err)
+
+	var traceIDs []string
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if value, exists := labels[label.GetName()]; exists && value != label.GetValue() {
+					continue series
+				}
+			}
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				for _, label := range bucket.GetExemplar().GetLabel() {
+					if label.GetName() == "trace_id" {
+						traceIDs = append(traceIDs, label.GetValue())
+					}
+				}
+			}
+		}
+	}
+	return traceIDs
+}
+
+func TestRequestIDExemplars(t *testing.T) {
+	metrics.TraceIDFromContext = RequestIDFromContext
+	t.Cleanup(func() { metrics.TraceIDFromContext = nil })
+
+	server, p, cloud := newTestServer(t)
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 2, RAM: 4096})
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+	_, err := p.AddNodeGroup(&config.NodeGroupConfig{ID: "exemplars", MaxSize: 3, FlavorID: flavorID, ImageID: imageID})
+	th.AssertNoErr(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "scale-up-1"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupIncreaseSize"}
+	_, err = LoggingInterceptor(ctx, &pb.NodeGroupIncreaseSizeRequest{Id: "exemplars", Delta: 1}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.NodeGroupIncreaseSize(ctx, req.(*pb.NodeGroupIncreaseSizeRequest))
+	})
+	th.AssertNoErr(t, err)
+
+	// The scale operation and the create request it sent are linked to the request ID
+	th.AssertDeepEquals(t, []string{"scale-up-1"}, exemplarTraceIDs(t, "openstack_autoscaler_scale_operation_duration_seconds", map[string]string{"node_group": "exemplars"}))
+	found := false
+	for _, traceID := range exemplarTraceIDs(t, "openstack_autoscaler_openstack_api_request_duration_seconds", map[string]string{"method": "POST"}) {
+		found = found || traceID == "scale-up-1"
+	}
+	if !found {
+		t.Error("expected the create request latency to carry the request ID as exemplar")
+	}
+}
+
+// captureLogs redirects klog output at the verbosity into a buffer for the duration of the test
+func captureLogs(t *testing.T, verbosity string) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	_ = flags.Set("logtostderr", "false")
+	_ = flags.Set("alsologtostderr", "false")
+	_ = flags.Set("stderrthreshold", "FATAL")
+	_ = flags.Set("v", verbosity)
+
+	var buf bytes.Buffer
+	// Every severity is also written to the lower ones, the INFO output sees each line once
+	klog.SetOutput(io.Discard)
+	klog.SetOutputBySeverity("INFO", &buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		_ = flags.Set("v", "0")
+		_ = flags.Set("logtostderr", "true")
+		klog.SetOutput(nil)
+	})
+	return &buf
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	const method = "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupTargetSize"
+
+	tests := []struct {
+		name      string
+		requestID string
+		verbosity string
+		err       error
+		logged    string
+	}{
+		{name: "success", requestID: "scale-up-1", verbosity: "4", logged: "gRPC request scale-up-1: " + method + " completed with OK in "},
+		{name: "success below verbosity", requestID: "scale-up-1", verbosity: "2"},
+		{name: "failure", requestID: "scale-up-2", verbosity: "2", err: status.Error(codes.NotFound, "node group workers not found"),
+			logged: "gRPC request scale-up-2: " + method + " completed with NotFound in "},
+		{name: "plain error", verbosity: "2", err: errors.New("boom"), logged: "completed with Unknown in "},
+		{name: "generated request ID", verbosity: "4", logged: "completed with OK in "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t, tt.verbosity)
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(requestIDMetadataKey, tt.requestID))
+			}
+
+			var handlerRequestID string
+			resp, err := LoggingInterceptor(ctx, &pb.NodeGroupTargetSizeRequest{Id: "workers"}, &grpc.UnaryServerInfo{FullMethod: method},
+				func(ctx context.Context, _ interface{}) (interface{}, error) {
+					handlerRequestID = RequestIDFromContext(ctx)
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &pb.NodeGroupTargetSizeResponse{TargetSize: 2}, nil
+				})
+			th.AssertEquals(t, tt.err, err)
+			if tt.err == nil {
+				th.AssertEquals(t, int32(2), resp.(*pb.NodeGroupTargetSizeResponse).TargetSize)
+			}
+
+			// The handler sees the request ID the caller chose, or a generated one
+			if tt.requestID != "" {
+				th.AssertEquals(t, tt.requestID, handlerRequestID)
+			} else {
+				th.AssertEquals(t, requestIDLength, len(handlerRequestID))
+			}
+
+			klog.Flush()
+			output := logs.String()
+			if tt.logged == "" {
+				th.AssertEquals(t, "", output)
+				return
+			}
+			if !strings.Contains(output, tt.logged) || !strings.Contains(output, handlerRequestID) {
+				t.Errorf("expected a log line containing %q, got %q", tt.logged, output)
+			}
+			if tt.err != nil && !strings.Contains(output, tt.err.Error()) {
+				t.Errorf("expected the error to be logged, got %q", output)
+			}
+		})
+	}
+}
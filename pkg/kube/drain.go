@@ -0,0 +1,136 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// drainPollInterval is the interval between eviction retries and checks for remaining pods
+var drainPollInterval = 5 * time.Second
+
+// NodeDrainer cordons nodes and evicts their pods before their servers are deleted
+type NodeDrainer struct {
+	client kubernetes.Interface
+	// timeout bounds the drain of one node
+	timeout time.Duration
+}
+
+// NewNodeDrainer creates a drainer giving up on a node after timeout
+func NewNodeDrainer(client kubernetes.Interface, timeout time.Duration) *NodeDrainer {
+	return &NodeDrainer{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// DrainNode cordons the node with the provider ID and evicts its pods, honouring pod disruption
+// budgets. DaemonSet and mirror pods are left to go with the node. A server that never
+// registered as a node has nothing to drain.
+func (d *NodeDrainer) DrainNode(ctx context.Context, providerID string) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	node, err := d.nodeByProviderID(ctx, providerID)
+	if err != nil || node == nil {
+		return err
+	}
+
+	if !node.Spec.Unschedulable {
+		patch := []byte(`{"spec":{"unschedulable":true}}`)
+		if _, err := d.client.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to cordon node %s: %w", node.Name, err)
+		}
+		klog.Infof("Cordoned node %s", node.Name)
+	}
+
+	for {
+		pods, err := d.evictablePods(ctx, node.Name)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			klog.Infof("Drained node %s", node.Name)
+			return nil
+		}
+
+		for _, pod := range pods {
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+			err := d.client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+			switch {
+			case err == nil, apierrors.IsNotFound(err):
+			case apierrors.IsTooManyRequests(err):
+				// A pod disruption budget blocks the eviction for now
+				klog.V(2).Infof("Eviction of pod %s/%s from node %s blocked, retrying: %v", pod.Namespace, pod.Name, node.Name, err)
+			default:
+				return fmt.Errorf("failed to evict pod %s/%s from node %s: %w", pod.Namespace, pod.Name, node.Name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node %s still has %d pods to evict: %w", node.Name, len(pods), ctx.Err())
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+// nodeByProviderID returns the node with the provider ID, nil when there is none
+func (d *NodeDrainer) nodeByProviderID(ctx context.Context, providerID string) (*apiv1.Node, error) {
+	nodes, err := d.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for i := range nodes.Items {
+		if nodes.Items[i].Spec.ProviderID == providerID {
+			return &nodes.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// evictablePods returns the pods on a node that draining evicts
+func (d *NodeDrainer) evictablePods(ctx context.Context, nodeName string) ([]apiv1.Pod, error) {
+	pods, err := d.client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of node %s: %w", nodeName, err)
+	}
+
+	var evictable []apiv1.Pod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName || isDaemonSetPod(&pod) || isMirrorPod(&pod) {
+			continue
+		}
+		if pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+			continue
+		}
+		evictable = append(evictable, pod)
+	}
+	return evictable, nil
+}
+
+// isDaemonSetPod reports whether a DaemonSet controls the pod
+func isDaemonSetPod(pod *apiv1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// isMirrorPod reports whether the pod mirrors a static pod of the kubelet
+func isMirrorPod(pod *apiv1.Pod) bool {
+	_, exists := pod.Annotations[apiv1.MirrorPodAnnotationKey]
+	return exists
+}
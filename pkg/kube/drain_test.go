@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testPod returns a pod running on the node, owner optionally names the kind of its controller
+func testPod(name, nodeName, owner string) *apiv1.Pod {
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       apiv1.PodSpec{NodeName: nodeName},
+		Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+	}
+	switch owner {
+	case "":
+	case "mirror":
+		pod.Annotations = map[string]string{apiv1.MirrorPodAnnotationKey: "hash"}
+	default:
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: owner, Name: name, Controller: &controller}}
+	}
+	return pod
+}
+
+func TestDrainNode(t *testing.T) {
+	drainPollInterval = time.Millisecond
+	t.Cleanup(func() { drainPollInterval = 5 * time.Second })
+
+	tests := []struct {
+		name       string
+		providerID string
+		// blocked is the number of evictions a pod disruption budget rejects, -1 for all
+		blocked   int
+		err       string
+		cordoned  bool
+		remaining []string
+	}{
+		{name: "unknown node", providerID: "openstack:///missing", remaining: []string{"app", "logs", "static", "other"}},
+		{name: "evicts pods", providerID: "openstack:///server-1", cordoned: true, remaining: []string{"logs", "static", "other"}},
+		{name: "retries blocked evictions", providerID: "openstack:///server-1", blocked: 2, cordoned: true, remaining: []string{"logs", "static", "other"}},
+		{name: "gives up on blocked evictions", providerID: "openstack:///server-1", blocked: -1, err: "still has 1 pods to evict", cordoned: true, remaining: []string{"app", "logs", "static", "other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: apiv1.NodeSpec{ProviderID: "openstack:///server-1"}},
+				testPod("app", "node-1", "ReplicaSet"),
+				testPod("logs", "node-1", "DaemonSet"),
+				testPod("static", "node-1", "mirror"),
+				testPod("other", "node-2", ""),
+			)
+			blocked := tt.blocked
+			client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				if blocked != 0 {
+					blocked--
+					return true, nil, apierrors.NewTooManyRequests("disruption budget", 0)
+				}
+				name := action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName()
+				return true, nil, client.Tracker().Delete(schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "default", name)
+			})
+
+			err := NewNodeDrainer(client, 100*time.Millisecond).DrainNode(context.Background(), tt.providerID)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+			} else {
+				th.AssertNoErr(t, err)
+			}
+
+			node, err := client.CoreV1().Nodes().Get(context.Background(), "node-1", metav1.GetOptions{})
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.cordoned, node.Spec.Unschedulable)
+
+			pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+			th.AssertNoErr(t, err)
+			var remaining []string
+			for _, pod := range pods.Items {
+				remaining = append(remaining, pod.Name)
+			}
+			sort.Strings(remaining)
+			sort.Strings(tt.remaining)
+			th.AssertDeepEquals(t, tt.remaining, remaining)
+		})
+	}
+}
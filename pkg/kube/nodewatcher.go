@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"context"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// NodeChangeHandler is notified when a Kubernetes node is added, its advertised capacity changes
+// or it is deleted, and once every node existing at startup was delivered
+type NodeChangeHandler interface {
+	NodeChanged(node *apiv1.Node)
+	NodeDeleted(node *apiv1.Node)
+	NodesSynced()
+}
+
+// NodeWatcher watches Kubernetes nodes and forwards relevant changes to a handler
+type NodeWatcher struct {
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+	handler  NodeChangeHandler
+}
+
+// NewNodeWatcher creates a new node watcher using the given client
+func NewNodeWatcher(client kubernetes.Interface, handler NodeChangeHandler, resync time.Duration) *NodeWatcher {
+	factory := informers.NewSharedInformerFactory(client, resync)
+	w := &NodeWatcher{
+		factory:  factory,
+		informer: factory.Core().V1().Nodes().Informer(),
+		handler:  handler,
+	}
+
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.onAdd,
+		UpdateFunc: w.onUpdate,
+		DeleteFunc: w.onDelete,
+	})
+
+	return w
+}
+
+// Run starts the informer and blocks until the context is cancelled
+func (w *NodeWatcher) Run(ctx context.Context) {
+	klog.Info("Starting Kubernetes node watcher")
+
+	w.factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), w.informer.HasSynced) {
+		klog.Warning("Kubernetes node watcher stopped before cache sync")
+		return
+	}
+	w.handler.NodesSynced()
+
+	<-ctx.Done()
+	w.factory.Shutdown()
+	klog.Info("Kubernetes node watcher stopped")
+}
+
+func (w *NodeWatcher) onAdd(obj interface{}) {
+	node, ok := obj.(*apiv1.Node)
+	if !ok {
+		return
+	}
+	w.handler.NodeChanged(node)
+}
+
+func (w *NodeWatcher) onUpdate(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*apiv1.Node)
+	if !ok {
+		return
+	}
+	newNode, ok := newObj.(*apiv1.Node)
+	if !ok {
+		return
+	}
+
+	// Status heartbeats update nodes constantly, only forward changes that affect the template
+	if !nodeCapacityChanged(oldNode, newNode) {
+		return
+	}
+	w.handler.NodeChanged(newNode)
+}
+
+func (w *NodeWatcher) onDelete(obj interface{}) {
+	// A node deleted while the watch was disconnected arrives wrapped with its last known state
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	node, ok := obj.(*apiv1.Node)
+	if !ok {
+		return
+	}
+	w.handler.NodeDeleted(node)
+}
+
+// nodeCapacityChanged reports whether the parts of a node reflected in a template node differ
+func nodeCapacityChanged(oldNode, newNode *apiv1.Node) bool {
+	return !apiequality.Semantic.DeepEqual(oldNode.Status.Capacity, newNode.Status.Capacity) ||
+		!apiequality.Semantic.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) ||
+		!apiequality.Semantic.DeepEqual(oldNode.Labels, newNode.Labels) ||
+		!apiequality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) ||
+		nodeReady(oldNode) != nodeReady(newNode)
+}
+
+// nodeReady reports whether the node has a true Ready condition, only ready nodes model templates
+func nodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
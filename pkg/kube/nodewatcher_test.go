@@ -0,0 +1,175 @@
+package kube
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// recordingHandler records the node events a watcher delivers
+type recordingHandler struct {
+	mutex  sync.Mutex
+	events []string
+	synced chan struct{}
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{synced: make(chan struct{})}
+}
+
+func (h *recordingHandler) NodeChanged(node *apiv1.Node) {
+	h.record("changed " + node.Name + " " + node.Status.Capacity.Cpu().String())
+}
+
+func (h *recordingHandler) NodeDeleted(node *apiv1.Node) {
+	h.record("deleted " + node.Name)
+}
+
+func (h *recordingHandler) NodesSynced() {
+	close(h.synced)
+}
+
+func (h *recordingHandler) record(event string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.events = append(h.events, event)
+}
+
+// waitForEvents waits until the handler recorded count events and returns them
+func (h *recordingHandler) waitForEvents(t *testing.T, count int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.mutex.Lock()
+		events := append([]string(nil), h.events...)
+		h.mutex.Unlock()
+		if len(events) >= count || time.Now().After(deadline) {
+			return events
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// testWatchedNode returns a ready node advertising the CPU capacity
+func testWatchedNode(name, cpu string) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: apiv1.NodeStatus{
+			Capacity:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse(cpu)},
+			Allocatable: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse(cpu)},
+			Conditions:  []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue}},
+		},
+	}
+}
+
+func TestNodeWatcher(t *testing.T) {
+	client := fake.NewSimpleClientset(testWatchedNode("node-1", "2"))
+	handler := newRecordingHandler()
+	watcher := NewNodeWatcher(client, handler, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	select {
+	case <-handler.synced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watcher to sync")
+	}
+	th.AssertDeepEquals(t, []string{"changed node-1 2"}, handler.waitForEvents(t, 1))
+
+	nodes := client.CoreV1().Nodes()
+	steps := []struct {
+		name   string
+		change func() error
+		events []string
+	}{
+		{
+			name: "add",
+			change: func() error {
+				_, err := nodes.Create(ctx, testWatchedNode("node-2", "4"), metav1.CreateOptions{})
+				return err
+			},
+			events: []string{"changed node-2 4"},
+		},
+		{
+			name: "heartbeat leaves the capacity unchanged",
+			change: func() error {
+				node := testWatchedNode("node-2", "4")
+				node.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+				_, err := nodes.UpdateStatus(ctx, node, metav1.UpdateOptions{})
+				return err
+			},
+		},
+		{
+			name: "capacity changes",
+			change: func() error {
+				_, err := nodes.UpdateStatus(ctx, testWatchedNode("node-2", "8"), metav1.UpdateOptions{})
+				return err
+			},
+			events: []string{"changed node-2 8"},
+		},
+		{
+			name: "delete",
+			change: func() error {
+				return nodes.Delete(ctx, "node-1", metav1.DeleteOptions{})
+			},
+			events: []string{"deleted node-1"},
+		},
+	}
+	expected := []string{"changed node-1 2"}
+	for _, step := range steps {
+		th.AssertNoErr(t, step.change())
+		expected = append(expected, step.events...)
+		events := handler.waitForEvents(t, len(expected))
+		// Give an event that must not fire the chance to arrive
+		if len(step.events) == 0 {
+			time.Sleep(50 * time.Millisecond)
+			events = handler.waitForEvents(t, len(expected))
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("%s: expected events %v, got %v", step.name, expected, events)
+		}
+		th.AssertDeepEquals(t, expected, events)
+	}
+}
+
+func TestNodeWatcherDeleteTombstone(t *testing.T) {
+	tests := []struct {
+		name   string
+		obj    interface{}
+		events []string
+	}{
+		{name: "node", obj: testWatchedNode("node-1", "2"), events: []string{"deleted node-1"}},
+		{
+			name:   "tombstone",
+			obj:    cache.DeletedFinalStateUnknown{Key: "node-1", Obj: testWatchedNode("node-1", "2")},
+			events: []string{"deleted node-1"},
+		},
+		{name: "tombstone of another object", obj: cache.DeletedFinalStateUnknown{Key: "default/pod", Obj: &apiv1.Pod{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRecordingHandler()
+			watcher := NewNodeWatcher(fake.NewSimpleClientset(), handler, 0)
+
+			watcher.onDelete(tt.obj)
+			th.AssertDeepEquals(t, tt.events, handler.events)
+		})
+	}
+}
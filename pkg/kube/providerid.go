@@ -0,0 +1,116 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+// ProviderIDResolver finds the provider ID of the member server named like a node,
+// returning an empty provider ID when no member matches and an error when several do
+type ProviderIDResolver interface {
+	ProviderIDForNodeName(name string) (string, error)
+}
+
+// ProviderIDRepairer sets spec.providerID on Ready nodes that registered without one
+type ProviderIDRepairer struct {
+	client   kubernetes.Interface
+	resolver ProviderIDResolver
+	interval time.Duration
+	// maxRepairs caps the nodes patched per pass
+	maxRepairs int
+}
+
+// NewProviderIDRepairer creates a repairer patching at most maxRepairs nodes every interval
+func NewProviderIDRepairer(client kubernetes.Interface, resolver ProviderIDResolver, interval time.Duration, maxRepairs int) *ProviderIDRepairer {
+	return &ProviderIDRepairer{
+		client:     client,
+		resolver:   resolver,
+		interval:   interval,
+		maxRepairs: maxRepairs,
+	}
+}
+
+// Run repairs nodes periodically until the context is cancelled
+func (r *ProviderIDRepairer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.repair(ctx); err != nil {
+				klog.Warningf("Provider ID repair failed: %v", err)
+			}
+		}
+	}
+}
+
+// repair patches the provider ID of Ready nodes lacking one that match exactly one member server
+func (r *ProviderIDRepairer) repair(ctx context.Context) error {
+	nodes, err := r.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	repaired := 0
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if node.Spec.ProviderID != "" || !isNodeReady(node) {
+			continue
+		}
+		if repaired >= r.maxRepairs {
+			klog.V(2).Infof("Reached %d provider ID repairs, deferring the remaining nodes to the next pass", r.maxRepairs)
+			return nil
+		}
+
+		providerID, err := r.resolver.ProviderIDForNodeName(node.Name)
+		if err != nil {
+			klog.Warningf("Not repairing provider ID of node %s: %v", node.Name, err)
+			continue
+		}
+		if providerID == "" {
+			continue
+		}
+
+		// spec.providerID can only be set while it is empty
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]string{"providerID": providerID},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build provider ID patch: %w", err)
+		}
+		if _, err := r.client.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			klog.Errorf("Failed to set provider ID of node %s to %s: %v", node.Name, providerID, err)
+			continue
+		}
+
+		repaired++
+		metrics.ProviderIDRepairs.Inc()
+		// Audit record of the change made to a node on the operator's behalf
+		klog.InfoS("Audit: set missing provider ID", "node", node.Name, "providerID", providerID, "matchedBy", "serverName")
+	}
+
+	return nil
+}
+
+// isNodeReady reports whether the node has a true Ready condition
+func isNodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
+)
+
+// captureLogs redirects klog output into a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	_ = flags.Set("logtostderr", "false")
+	_ = flags.Set("alsologtostderr", "false")
+	_ = flags.Set("stderrthreshold", "FATAL")
+
+	var buf bytes.Buffer
+	// Every severity is also written to the lower ones, the INFO output sees each line once
+	klog.SetOutput(io.Discard)
+	klog.SetOutputBySeverity("INFO", &buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		_ = flags.Set("logtostderr", "true")
+		klog.SetOutput(nil)
+	})
+	return &buf
+}
+
+// stubResolver resolves node names from a fixed table, names listed as ambiguous match several servers
+type stubResolver struct {
+	providerIDs map[string]string
+	ambiguous   map[string]bool
+}
+
+func (r stubResolver) ProviderIDForNodeName(name string) (string, error) {
+	if r.ambiguous[name] {
+		return "", fmt.Errorf("2 servers are named %s", name)
+	}
+	return r.providerIDs[name], nil
+}
+
+// testNode returns a node with the provider ID and Ready condition status
+func testNode(name, providerID string, ready apiv1.ConditionStatus) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       apiv1.NodeSpec{ProviderID: providerID},
+		Status:     apiv1.NodeStatus{Conditions: []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: ready}}},
+	}
+}
+
+func TestProviderIDRepair(t *testing.T) {
+	tests := []struct {
+		name       string
+		node       *apiv1.Node
+		maxRepairs int
+		providerID string
+	}{
+		{name: "exact match", node: testNode("worker-1", "", apiv1.ConditionTrue), maxRepairs: 1, providerID: "openstack:///server-1"},
+		{name: "ambiguous match", node: testNode("worker-2", "", apiv1.ConditionTrue), maxRepairs: 1},
+		{name: "foreign node", node: testNode("laptop", "", apiv1.ConditionTrue), maxRepairs: 1},
+		{name: "node not ready", node: testNode("worker-1", "", apiv1.ConditionFalse), maxRepairs: 1},
+		{name: "provider ID already set", node: testNode("worker-1", "aws:///i-1", apiv1.ConditionTrue), maxRepairs: 1, providerID: "aws:///i-1"},
+		{name: "repair limit reached", node: testNode("worker-1", "", apiv1.ConditionTrue)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			client := fake.NewSimpleClientset(tt.node)
+			resolver := stubResolver{
+				providerIDs: map[string]string{"worker-1": "openstack:///server-1", "worker-2": "openstack:///server-2"},
+				ambiguous:   map[string]bool{"worker-2": true},
+			}
+
+			repairer := NewProviderIDRepairer(client, resolver, 0, tt.maxRepairs)
+			th.AssertNoErr(t, repairer.repair(context.Background()))
+
+			node, err := client.CoreV1().Nodes().Get(context.Background(), tt.node.Name, metav1.GetOptions{})
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.providerID, node.Spec.ProviderID)
+
+			// Every repair leaves an audit record naming the node and provider ID
+			klog.Flush()
+			repaired := tt.node.Spec.ProviderID == "" && tt.providerID != ""
+			audit := fmt.Sprintf(`"Audit: set missing provider ID" node=%q providerID=%q`, tt.node.Name, tt.providerID)
+			th.AssertEquals(t, repaired, strings.Contains(logs.String(), audit))
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+)
+
+const (
+	namespace = "openstack_autoscaler"
+
+	// clockSkewSmoothing is the weight of a new sample in the smoothed clock skew
+	clockSkewSmoothing = 0.2
+	// clockSkewWarningThreshold is the smoothed skew beyond which a warning is logged
+	clockSkewWarningThreshold = 30 * time.Second
+)
+
+// clockSkew holds the smoothed offset of the OpenStack API clock from the local clock
+var clockSkew struct {
+	sync.Mutex
+	seconds float64
+	sampled bool
+	warned  bool
+}
+
+// TraceIDFromContext returns the ID tracing the operation ctx belongs to, or an
+// empty string when there is none. It is set to the gRPC request ID lookup when
+// metrics are served, while nil durations are observed without exemplars.
+var TraceIDFromContext func(ctx context.Context) string
+
+// tokenExpiry holds the expiry of the current OpenStack token as unix seconds
+var tokenExpiry atomic.Int64
+
+var (
+	// TokenRenewalFailures counts failed proactive token renewals
+	TokenRenewalFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_renewal_failures_total",
+		Help:      "Number of failed proactive OpenStack token renewals.",
+	})
+
+	// OwnershipRepairs counts servers whose lost ownership metadata was restored
+	OwnershipRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ownership_repairs_total",
+		Help:      "Number of servers whose node group ownership metadata was restored.",
+	}, []string{"node_group"})
+
+	// ProviderIDRepairs counts Kubernetes nodes whose missing provider ID was set
+	ProviderIDRepairs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_id_repairs_total",
+		Help:      "Number of Kubernetes nodes whose missing provider ID was set.",
+	})
+
+	// ScaleOperationDuration tracks how long scale operations take
+	ScaleOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scale_operation_duration_seconds",
+		Help:      "Duration of node group scale operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	}, []string{"operation", "node_group"})
+
+	// APIRequestDuration tracks the latency of OpenStack API requests
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "openstack_api_request_duration_seconds",
+		Help:      "Latency of OpenStack API requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	// ThrottledRequests counts OpenStack requests rejected as throttled, 429 Too Many Requests or 498
+	ThrottledRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "openstack_throttled_requests_total",
+		Help:      "Number of OpenStack API requests throttled by response code, 429 or 498.",
+	}, []string{"code"})
+
+	// NodeGroupCacheLookups counts server to node group lookups answered from the cache or not
+	NodeGroupCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_group_cache_lookups_total",
+		Help:      "Number of server to node group lookups by result, hit or miss.",
+	}, []string{"result"})
+
+	clockSkewSeconds = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "clock_skew_seconds",
+		Help:      "Smoothed offset of the OpenStack API clock from the local clock, positive when the API is ahead.",
+	}, func() float64 {
+		return ClockSkew().Seconds()
+	})
+
+	// NodeGroupQuarantined reports which node groups have their mutations paused
+	NodeGroupQuarantined = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_group_quarantined",
+		Help:      "Whether mutations of the node group are paused after repeated failures.",
+	}, []string{"node_group"})
+
+	// NodeGroupBudgetWait accumulates the time server operations waited for the share of their node group
+	NodeGroupBudgetWait = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_group_budget_wait_seconds_total",
+		Help:      "Time server operations of the node group waited for its share of the server operation rate.",
+	}, []string{"node_group"})
+
+	// ProvisioningOutcomes counts how watched servers ended provisioning, active, error or timeout
+	ProvisioningOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provisioning_outcomes_total",
+		Help:      "Number of watched new servers by provisioning outcome, active, error or timeout.",
+	}, []string{"node_group", "outcome"})
+
+	// TrackedEntries reports the size of internal tracking structures
+	TrackedEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tracked_entries",
+		Help:      "Number of entries held in internal tracking structures.",
+	}, []string{"structure"})
+
+	tokenExpirySeconds = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "token_expiry_seconds",
+		Help:      "Seconds until the current OpenStack token expires.",
+	}, func() float64 {
+		expiry := tokenExpiry.Load()
+		if expiry == 0 {
+			return 0
+		}
+		return time.Until(time.Unix(expiry, 0)).Seconds()
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		TokenRenewalFailures,
+		OwnershipRepairs,
+		ProviderIDRepairs,
+		ScaleOperationDuration,
+		APIRequestDuration,
+		ThrottledRequests,
+		NodeGroupCacheLookups,
+		NodeGroupQuarantined,
+		NodeGroupBudgetWait,
+		ProvisioningOutcomes,
+		TrackedEntries,
+		clockSkewSeconds,
+		tokenExpirySeconds,
+	)
+}
+
+// SetTokenExpiry records when the current OpenStack token expires
+func SetTokenExpiry(expiresAt time.Time) {
+	tokenExpiry.Store(expiresAt.Unix())
+}
+
+// ObserveDuration records d on observer, attaching the trace ID from ctx as an
+// exemplar when a sampled trace is present
+func ObserveDuration(ctx context.Context, observer prometheus.Observer, d time.Duration) {
+	if TraceIDFromContext != nil {
+		if traceID := TraceIDFromContext(ctx); traceID != "" {
+			if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+				exemplarObserver.ObserveWithExemplar(d.Seconds(), prometheus.Labels{"trace_id": traceID})
+				return
+			}
+		}
+	}
+	observer.Observe(d.Seconds())
+}
+
+// instrumentedTransport records the latency of every request it forwards
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+// InstrumentRoundTripper wraps next so that request latencies are recorded in APIRequestDuration
+func InstrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	end := time.Now()
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+		if date, dateErr := http.ParseTime(resp.Header.Get("Date")); dateErr == nil {
+			// The Date header has second resolution, compare it with the middle of the request
+			recordClockSkew(date.Sub(start.Add(end.Sub(start) / 2)))
+		}
+	}
+	ObserveDuration(req.Context(), APIRequestDuration.WithLabelValues(req.Method, code), end.Sub(start))
+
+	return resp, err
+}
+
+// recordClockSkew folds a clock skew sample into the smoothed skew
+func recordClockSkew(sample time.Duration) {
+	clockSkew.Lock()
+	defer clockSkew.Unlock()
+
+	if clockSkew.sampled {
+		clockSkew.seconds += clockSkewSmoothing * (sample.Seconds() - clockSkew.seconds)
+	} else {
+		clockSkew.seconds = sample.Seconds()
+		clockSkew.sampled = true
+	}
+
+	exceeded := math.Abs(clockSkew.seconds) > clockSkewWarningThreshold.Seconds()
+	if exceeded && !clockSkew.warned {
+		klog.Warningf("Local clock is %.0fs off the OpenStack API clock, check NTP on this host", -clockSkew.seconds)
+	}
+	clockSkew.warned = exceeded
+}
+
+// ClockSkew returns the smoothed offset of the OpenStack API clock from the local clock,
+// positive when the API clock is ahead
+func ClockSkew() time.Duration {
+	clockSkew.Lock()
+	defer clockSkew.Unlock()
+	return time.Duration(clockSkew.seconds * float64(time.Second))
+}
+
+// Handler returns the HTTP handler serving the registered metrics, negotiating
+// the OpenMetrics format so exemplars are exposed to scrapers that accept it
+func Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: true,
+		}),
+	)
+}
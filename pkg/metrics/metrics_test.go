@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/klog/v2"
+)
+
+// captureLogs redirects klog output into a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	_ = flags.Set("logtostderr", "false")
+	_ = flags.Set("alsologtostderr", "false")
+	_ = flags.Set("stderrthreshold", "FATAL")
+
+	var buf bytes.Buffer
+	klog.SetOutput(io.Discard)
+	klog.SetOutputBySeverity("INFO", &buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		_ = flags.Set("logtostderr", "true")
+		klog.SetOutput(nil)
+	})
+	return &buf
+}
+
+// resetClockSkew forgets the skew measured by earlier tests
+func resetClockSkew() {
+	clockSkew.Lock()
+	defer clockSkew.Unlock()
+	clockSkew.seconds = 0
+	clockSkew.sampled = false
+	clockSkew.warned = false
+}
+
+func TestClockSkew(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets []time.Duration
+		skew    time.Duration
+		warned  bool
+	}{
+		{name: "in sync", offsets: []time.Duration{0, 0, 0}},
+		{name: "cloud ahead", offsets: []time.Duration{time.Minute, time.Minute}, skew: time.Minute, warned: true},
+		{name: "cloud behind", offsets: []time.Duration{-time.Minute}, skew: -time.Minute, warned: true},
+		{name: "single outlier smoothed", offsets: []time.Duration{0, 0, 0, time.Minute}, skew: 12 * time.Second},
+		{name: "small skew", offsets: []time.Duration{10 * time.Second, 10 * time.Second}, skew: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClockSkew()
+			logs := captureLogs(t)
+
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Date", time.Now().Add(tt.offsets[requests]).UTC().Format(http.TimeFormat))
+				requests++
+			}))
+			t.Cleanup(server.Close)
+
+			client := &http.Client{Transport: InstrumentRoundTripper(nil)}
+			for range tt.offsets {
+				resp, err := client.Get(server.URL)
+				th.AssertNoErr(t, err)
+				_ = resp.Body.Close()
+			}
+
+			// The Date header has second resolution
+			if diff := ClockSkew() - tt.skew; diff < -2*time.Second || diff > 2*time.Second {
+				t.Errorf("expected a skew of about %s, got %s", tt.skew, ClockSkew())
+			}
+			if diff := testutil.ToFloat64(clockSkewSeconds) - tt.skew.Seconds(); diff < -2 || diff > 2 {
+				t.Errorf("expected the gauge to report about %.0fs, got %.1fs", tt.skew.Seconds(), testutil.ToFloat64(clockSkewSeconds))
+			}
+
+			klog.Flush()
+			warnings := strings.Count(logs.String(), "off the OpenStack API clock")
+			th.AssertEquals(t, tt.warned, warnings == 1)
+			if warnings > 1 {
+				t.Errorf("expected one warning, got %d", warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultArchitecture is assumed when neither the image nor the flavor declares an architecture
+	defaultArchitecture = "amd64"
+	// defaultOperatingSystem is assumed when the image does not declare its operating system
+	defaultOperatingSystem = "linux"
+
+	// flavorArchitectureExtraSpec is the flavor extra spec declaring the expected architecture
+	flavorArchitectureExtraSpec = "hw:architecture"
+	// imageArchitectureProperty is the Glance image property declaring the image architecture
+	imageArchitectureProperty = "architecture"
+	// imageOSTypeProperty is the Glance image property declaring the image operating system
+	imageOSTypeProperty = "os_type"
+)
+
+// kubernetesArchitectures maps OpenStack architecture names to their Kubernetes equivalents
+var kubernetesArchitectures = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+}
+
+// normalizeArchitecture returns the Kubernetes name of an architecture
+func normalizeArchitecture(arch string) string {
+	if kubeArch, exists := kubernetesArchitectures[arch]; exists {
+		return kubeArch
+	}
+	return arch
+}
+
+// resolveArchitecture compares the architecture of the image with the one expected by the
+// flavor and returns it in Kubernetes notation, or an empty string when neither declares one
+func (ng *OpenStackNodeGroup) resolveArchitecture(flavor *flavors.Flavor) (string, error) {
+	imageID, err := ng.getImageID()
+	if err != nil {
+		return "", err
+	}
+
+	// Without Glance the flavor alone decides, the image was checked against it when it was last reachable
+	imageName, imageArch := imageID, ""
+	image, err := ng.Provider.getImage(imageID)
+	switch {
+	case err == nil:
+		imageName, imageArch = image.name, image.architecture
+	case IsConfigurationError(err):
+		return "", err
+	default:
+		klog.Warningf("Cannot check the image architecture of node group %s: %v", ng.Config.ID, err)
+	}
+
+	flavorArch := ng.Config.Architecture
+	if flavorArch == "" {
+		extraSpecs, err := ng.Provider.flavorExtraSpecs(flavor)
+		if err != nil {
+			return "", err
+		}
+		flavorArch = extraSpecs[flavorArchitectureExtraSpec]
+	}
+
+	imageArch, flavorArch = normalizeArchitecture(imageArch), normalizeArchitecture(flavorArch)
+	switch {
+	case imageArch != "" && flavorArch != "" && imageArch != flavorArch:
+		return "", configErrorf("image %s is built for %s but flavor %s expects %s", imageName, imageArch, flavor.Name, flavorArch)
+	case imageArch != "":
+		return imageArch, nil
+	default:
+		return flavorArch, nil
+	}
+}
+
+// resolveOperatingSystem returns the operating system of the image in Kubernetes notation,
+// falling back to the node group setting and then linux when Glance does not say
+func (ng *OpenStackNodeGroup) resolveOperatingSystem() (string, error) {
+	imageID, err := ng.getImageID()
+	if err != nil {
+		return "", err
+	}
+
+	image, err := ng.Provider.getImage(imageID)
+	switch {
+	case err == nil && image.osType != "":
+		return strings.ToLower(image.osType), nil
+	case err != nil && IsConfigurationError(err):
+		return "", err
+	case err != nil:
+		klog.Warningf("Cannot check the image operating system of node group %s: %v", ng.Config.ID, err)
+	}
+
+	if ng.Config.OperatingSystem != "" {
+		return ng.Config.OperatingSystem, nil
+	}
+	return defaultOperatingSystem, nil
+}
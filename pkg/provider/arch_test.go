@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestResolveArchitecture(t *testing.T) {
+	tests := []struct {
+		name       string
+		imageArch  string
+		flavorArch string
+		configured string
+		arch       string
+		err        string
+	}{
+		{name: "matching architectures", imageArch: "x86_64", flavorArch: "x86_64", arch: "amd64"},
+		{name: "matching in Kubernetes notation", imageArch: "aarch64", flavorArch: "arm64", arch: "arm64"},
+		{name: "image only", imageArch: "aarch64", arch: "arm64"},
+		{name: "undeclared image falls back to the flavor", flavorArch: "aarch64", arch: "arm64"},
+		{name: "undeclared image falls back to the configured architecture", configured: "arm64", flavorArch: "x86_64", arch: "arm64"},
+		{name: "neither declares one", arch: defaultArchitecture},
+		{name: "mismatch", imageArch: "aarch64", flavorArch: "x86_64", err: "image arm-image is built for arm64 but flavor m1.arch expects amd64"},
+		{name: "mismatch with the configured architecture", imageArch: "x86_64", configured: "arm64", err: "is built for amd64 but flavor m1.arch expects arm64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			flavor := fakecloud.Flavor{ID: "flavor-arch", Name: "m1.arch", VCPUs: 2, RAM: 4096, Disk: 20}
+			if tt.flavorArch != "" {
+				flavor.ExtraSpecs = map[string]string{flavorArchitectureExtraSpec: tt.flavorArch}
+			}
+			cloud.AddFlavor(flavor)
+			image := fakecloud.Image{Name: "arm-image"}
+			if tt.imageArch != "" {
+				image.Properties = map[string]string{imageArchitectureProperty: tt.imageArch}
+			}
+			imageID := cloud.AddImage(image)
+			p := newTestProvider(t, cloud)
+			cfg := testNodeGroupConfig()
+			cfg.FlavorID, cfg.ImageID, cfg.Architecture = "flavor-arch", imageID, tt.configured
+
+			node, err := addTemplateNodeGroup(p, cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) || !IsConfigurationError(err) {
+					t.Fatalf("expected a configuration error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.arch, node.Labels[apiv1.LabelArchStable])
+		})
+	}
+}
+
+// addTemplateNodeGroup adds a node group and returns its template node, or the first error
+func addTemplateNodeGroup(p *OpenStackProvider, cfg *config.NodeGroupConfig) (*apiv1.Node, error) {
+	ng, err := p.AddNodeGroup(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ng.TemplateNodeInfo()
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/osclient"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+const (
+	// defaultTokenRenewalFraction is the share of the token lifetime after which it is renewed
+	defaultTokenRenewalFraction = 0.8
+	// maxTokenRenewalFailures is the number of consecutive failures after which the provider is degraded
+	maxTokenRenewalFailures = 3
+)
+
+// tokenRenewalRetryInterval is the wait before retrying a failed renewal
+var tokenRenewalRetryInterval = 30 * time.Second
+
+// nextTokenRenewal returns how long to wait before proactively renewing the token
+func (p *OpenStackProvider) nextTokenRenewal(issuedAt, expiresAt time.Time) time.Duration {
+	fraction := p.config.Load().Cloud.TokenRenewalFraction
+	if fraction <= 0 || fraction >= 1 {
+		fraction = defaultTokenRenewalFraction
+	}
+
+	lifetime := expiresAt.Sub(issuedAt)
+	return time.Until(issuedAt.Add(time.Duration(float64(lifetime) * fraction)))
+}
+
+// renewToken re-authenticates into a new set of service clients and swaps it in, requests in
+// flight keep the clients and token they started with
+func (p *OpenStackProvider) renewToken(ctx context.Context) error {
+	clients, err := osclient.New(ctx, p.config.Load())
+	if err != nil {
+		return fmt.Errorf("failed to re-authenticate: %w", wrapOpenStackError(err))
+	}
+	p.clients.Store(clients)
+	return nil
+}
+
+// tokenRenewalLoop renews the token before it lapses so no request observes an expired token
+func (p *OpenStackProvider) tokenRenewalLoop(ctx context.Context) {
+	issuedAt := time.Now()
+	expiresAt := localTime(p.clients.Load().TokenExpiry())
+	if expiresAt.IsZero() {
+		klog.Warning("Token expiry unknown, relying on reactive re-authentication")
+		return
+	}
+	metrics.SetTokenExpiry(expiresAt)
+
+	failures := 0
+	wait := p.nextTokenRenewal(issuedAt, expiresAt)
+	for {
+		klog.V(2).Infof("Next token renewal in %s (token expires at %s)", wait.Round(time.Second), expiresAt.Format(time.RFC3339))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := p.renewToken(ctx); err != nil {
+			failures++
+			metrics.TokenRenewalFailures.Inc()
+			klog.Warningf("Proactive token renewal failed (%d consecutive): %v", failures, err)
+			if failures >= maxTokenRenewalFailures {
+				p.setDegraded(true)
+			}
+			wait = tokenRenewalRetryInterval
+			continue
+		}
+
+		failures = 0
+		p.setDegraded(false)
+		issuedAt = time.Now()
+		if renewed := localTime(p.clients.Load().TokenExpiry()); !renewed.IsZero() {
+			expiresAt = renewed
+		}
+		metrics.SetTokenExpiry(expiresAt)
+		wait = p.nextTokenRenewal(issuedAt, expiresAt)
+	}
+}
+
+// localTime converts a timestamp reported by OpenStack to the local clock, correcting the measured skew
+func localTime(cloudTime time.Time) time.Time {
+	if cloudTime.IsZero() {
+		return cloudTime
+	}
+	return cloudTime.Add(-metrics.ClockSkew())
+}
+
+// setDegraded marks the provider as degraded or healthy
+func (p *OpenStackProvider) setDegraded(degraded bool) {
+	p.degraded.Store(degraded)
+}
+
+// Degraded reports whether the provider is operating in a degraded state
+func (p *OpenStackProvider) Degraded() bool {
+	return p.degraded.Load()
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestRenewTokenSwapsClients(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+
+	previous := p.clients.Load()
+	previousToken := previous.Provider().Token()
+
+	th.AssertNoErr(t, p.renewToken(context.Background()))
+
+	renewed := p.clients.Load()
+	if renewed == previous {
+		t.Fatal("expected renewal to swap in new clients")
+	}
+	th.AssertEquals(t, previousToken, previous.Provider().Token())
+	if renewed.Provider().Token() == previousToken {
+		t.Errorf("expected the renewed clients to carry a new token")
+	}
+	th.AssertEquals(t, 2, cloud.Authentications())
+}
+
+func TestRenewTokenConcurrentRequests(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	addMember(cloud, testGroupID, "workers-a")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				_, err := servers.List(p.computeClient(), servers.ListOpts{}).AllPages(context.Background())
+				th.AssertNoErr(t, err)
+			}
+		}()
+	}
+	for range 5 {
+		th.AssertNoErr(t, p.renewToken(context.Background()))
+	}
+	wg.Wait()
+}
+
+func TestRenewTokenKeepsClientsOnFailure(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	previous := p.clients.Load()
+
+	cloud.SetPassword("rotated")
+	if err := p.renewToken(context.Background()); err == nil {
+		t.Fatal("expected renewal with rejected credentials to fail")
+	}
+	if p.clients.Load() != previous {
+		t.Error("expected the previous clients to stay in use after a failed renewal")
+	}
+}
+
+func TestTokenRenewalLoop(t *testing.T) {
+	cloud := newTestCloud(t)
+	cloud.SetPassword("secret")
+	cloud.SetTokenLifetime(time.Second)
+	// The clock skew measured from the second resolution Date header may stretch the
+	// expiry by up to a second, renew early enough to stay ahead of it
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.TokenRenewalFraction = 0.25
+	})
+	addMember(cloud, testGroupID, "workers-a")
+	p.RunBackground("token-renewal", p.tokenRenewalLoop)
+
+	// Requests across several token lifetimes never carry an expired token
+	for deadline := time.Now().Add(2500 * time.Millisecond); time.Now().Before(deadline); {
+		_, err := servers.List(p.computeClient(), servers.ListOpts{}).AllPages(context.Background())
+		th.AssertNoErr(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	th.AssertEquals(t, 0, cloud.ExpiredTokenRequests())
+	if cloud.Authentications() < 4 {
+		t.Errorf("expected the token to be renewed before every expiry, got %d authentications", cloud.Authentications())
+	}
+	th.AssertEquals(t, false, p.Degraded())
+}
+
+func TestTokenRenewalLoopDegraded(t *testing.T) {
+	previous := tokenRenewalRetryInterval
+	tokenRenewalRetryInterval = 10 * time.Millisecond
+	t.Cleanup(func() { tokenRenewalRetryInterval = previous })
+
+	cloud := newTestCloud(t)
+	cloud.SetTokenLifetime(time.Second)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.TokenRenewalFraction = 0.1
+	})
+	cloud.SetPassword("rotated")
+	p.RunBackground("token-renewal", p.tokenRenewalLoop)
+
+	waitFor := func(degraded bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); p.Degraded() != degraded; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected degraded to become %t", degraded)
+			}
+		}
+	}
+	waitFor(true)
+
+	cloud.SetPassword("secret")
+	waitFor(false)
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+// waitForBudget waits until the node group may issue another server create or delete. The
+// configured server operation rate is shared equally between the node groups, so a node group
+// whose operations keep failing and being retried cannot slow down the others.
+func (ng *OpenStackNodeGroup) waitForBudget(ctx context.Context) error {
+	share := rate.Inf
+	if total := ng.Provider.config.Load().Cloud.ServerOperationRate; total > 0 {
+		share = rate.Limit(total / float64(max(len(ng.Provider.GetNodeGroups()), 1)))
+	}
+	// Node groups added or removed change the share
+	if ng.budget.Limit() != share {
+		ng.budget.SetLimit(share)
+	}
+
+	start := time.Now()
+	if err := ng.budget.Wait(ctx); err != nil {
+		return fmt.Errorf("waiting for the server operation budget of node group %s: %w", ng.Config.ID, err)
+	}
+	if waited := time.Since(start); waited > 0 {
+		metrics.NodeGroupBudgetWait.WithLabelValues(ng.Config.ID).Add(waited.Seconds())
+	}
+	return nil
+}
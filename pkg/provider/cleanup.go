@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+)
+
+const (
+	// CleanupModeOrphans selects the servers created for node groups that are no longer configured
+	CleanupModeOrphans = "orphans"
+	// CleanupModeAll selects every server the autoscaler created for the cluster
+	CleanupModeAll = "all"
+)
+
+// CleanupServer is a server selected by a cleanup
+type CleanupServer struct {
+	ID          string
+	Name        string
+	NodeGroupID string
+	// Deleted is set once the server was deleted, Err when deleting it failed
+	Deleted bool
+	Err     error
+}
+
+// Cleanup runs when the cluster autoscaler shuts down. It only reports the servers the autoscaler
+// created for node groups that are no longer configured, deleting them is left to an operator
+// through the admin service.
+func (p *OpenStackProvider) Cleanup() error {
+	klog.Info("Cleaning up OpenStack provider")
+
+	if p.config.Load().Cloud.ClusterName == "" {
+		klog.V(2).Info("No cluster name configured, not looking for orphaned servers")
+		return nil
+	}
+	orphans, err := p.CleanupServers(CleanupModeOrphans, false, "")
+	if err != nil {
+		return fmt.Errorf("failed to look for orphaned servers: %w", err)
+	}
+	if len(orphans) > 0 {
+		klog.Infof("Found %d autoscaler-created servers of node groups that are no longer configured, delete them with the admin cleanup-servers command", len(orphans))
+	}
+	return nil
+}
+
+// CleanupServers selects the servers the autoscaler created for this cluster, those of node
+// groups that are no longer configured or, in CleanupModeAll, every one. Only servers stamped
+// with the configured cluster name are selected. With execute set they are deleted along with
+// their floating IPs and pre-created ports, otherwise, and always in dry-run mode, they are only
+// reported. The reason the operator gave is logged with every deletion.
+func (p *OpenStackProvider) CleanupServers(mode string, execute bool, reason string) ([]CleanupServer, error) {
+	if mode != CleanupModeOrphans && mode != CleanupModeAll {
+		return nil, configErrorf("cleanup mode must be %s or %s, got %q", CleanupModeOrphans, CleanupModeAll, mode)
+	}
+	clusterName := p.config.Load().Cloud.ClusterName
+	if clusterName == "" {
+		return nil, configErrorf("cluster_name must be set to tell the servers of this cluster apart")
+	}
+	configured := make(map[string]bool)
+	for _, ng := range p.GetNodeGroups() {
+		configured[ng.Config.ID] = true
+	}
+
+	allPages, err := servers.List(p.computeClient(), servers.ListOpts{}).AllPages(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers: %w", wrapOpenStackError(err))
+	}
+	allServers, err := servers.ExtractServers(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract servers: %w", err)
+	}
+
+	var selected []CleanupServer
+	for _, server := range allServers {
+		if server.Metadata[membership.CreatedByMetadataKey] != membership.CreatedByMetadataValue || server.Metadata[membership.ClusterMetadataKey] != clusterName {
+			continue
+		}
+		if mode == CleanupModeOrphans && configured[server.Metadata[membership.NodeGroupMetadataKey]] {
+			continue
+		}
+		selected = append(selected, CleanupServer{ID: server.ID, Name: server.Name, NodeGroupID: server.Metadata[membership.NodeGroupMetadataKey]})
+	}
+
+	if !execute || p.DryRun() {
+		for _, server := range selected {
+			klog.Infof("Cleanup would delete server %s (%s) of node group %q", server.Name, server.ID, server.NodeGroupID)
+		}
+		return selected, nil
+	}
+
+	reason = sanitizeReason(withOperatorReason(ReasonCleanup, reason))
+	for i := range selected {
+		server := &selected[i]
+		klog.Infof("Cleanup deleting server %s (%s) of node group %q (reason: %s)", server.Name, server.ID, server.NodeGroupID, reason)
+		if err := p.deleteCreatedServer(server.ID); err != nil {
+			klog.Errorf("Cleanup failed to delete server %s: %v", server.ID, err)
+			server.Err = err
+			continue
+		}
+		server.Deleted = true
+	}
+	return selected, nil
+}
+
+// deleteCreatedServer deletes a server outside any configured node group, releasing the floating
+// IPs the autoscaler allocated for it and the ports it pre-created
+func (p *OpenStackProvider) deleteCreatedServer(serverID string) error {
+	var portIDs []string
+	if p.networkClient() != nil {
+		if err := p.releaseFloatingIPs(serverID, false); err != nil {
+			klog.Errorf("Failed to release floating IPs of server %s: %v", serverID, err)
+		}
+
+		var err error
+		portIDs, err = p.listCreatedPorts(serverID)
+		if err != nil {
+			klog.Errorf("Failed to look up ports of server %s: %v", serverID, err)
+		}
+	}
+
+	if err := servers.Delete(context.TODO(), p.computeClient(), serverID).ExtractErr(); err != nil {
+		return fmt.Errorf("failed to delete server %s: %w", serverID, wrapOpenStackError(err))
+	}
+	p.forgetProvisioning(serverID)
+
+	for _, portID := range portIDs {
+		if err := p.deletePort(portID); err != nil {
+			klog.Errorf("Failed to delete port %s of server %s: %v", portID, serverID, err)
+		}
+	}
+	return nil
+}
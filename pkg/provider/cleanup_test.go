@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"sort"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// addCreatedServer adds a server the autoscaler created for a node group of a cluster
+func addCreatedServer(cloud *fakecloud.Cloud, name, groupID, clusterName string) string {
+	metadata := map[string]string{membership.NodeGroupMetadataKey: groupID, membership.CreatedByMetadataKey: membership.CreatedByMetadataValue}
+	if clusterName != "" {
+		metadata[membership.ClusterMetadataKey] = clusterName
+	}
+	return cloud.AddServer(fakecloud.Server{Name: name, FlavorID: testFlavorID, ImageID: testImageID, Metadata: metadata})
+}
+
+func TestCleanupServers(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		execute  bool
+		dryRun   bool
+		selected []string
+		deleted  bool
+	}{
+		{name: "report orphans", mode: CleanupModeOrphans, selected: []string{"orphan"}},
+		{name: "delete orphans", mode: CleanupModeOrphans, execute: true, selected: []string{"orphan"}, deleted: true},
+		{name: "report all", mode: CleanupModeAll, selected: []string{"member", "orphan"}},
+		{name: "delete all", mode: CleanupModeAll, execute: true, selected: []string{"member", "orphan"}, deleted: true},
+		{name: "delete in dry-run mode", mode: CleanupModeOrphans, execute: true, dryRun: true, selected: []string{"orphan"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				cfg.Cloud.DryRun = tt.dryRun
+				cfg.NodeGroups = []config.NodeGroupConfig{*testNodeGroupConfig()}
+			})
+			addCreatedServer(cloud, "member", testGroupID, "test")
+			addCreatedServer(cloud, "orphan", "removed", "test")
+			addCreatedServer(cloud, "other-cluster", "removed", "other")
+			addCreatedServer(cloud, "unstamped", "removed", "")
+			cloud.AddServer(fakecloud.Server{Name: "bastion", FlavorID: testFlavorID, ImageID: testImageID})
+
+			selected, err := p.CleanupServers(tt.mode, tt.execute, "")
+			th.AssertNoErr(t, err)
+			var names []string
+			for _, server := range selected {
+				names = append(names, server.Name)
+				th.AssertEquals(t, tt.deleted, server.Deleted)
+				th.AssertNoErr(t, server.Err)
+			}
+			sort.Strings(names)
+			th.AssertDeepEquals(t, tt.selected, names)
+
+			remaining := len(cloud.Servers())
+			if tt.deleted {
+				th.AssertEquals(t, 5-len(tt.selected), remaining)
+			} else {
+				th.AssertEquals(t, 5, remaining)
+			}
+		})
+	}
+}
+
+func TestCleanupServersRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName string
+		mode        string
+	}{
+		{name: "unknown mode", clusterName: "test", mode: "everything"},
+		{name: "no cluster name", mode: CleanupModeOrphans},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) { cfg.Cloud.ClusterName = tt.clusterName })
+			addCreatedServer(cloud, "orphan", "removed", "")
+
+			_, err := p.CleanupServers(tt.mode, true, "")
+			if !IsConfigurationError(err) {
+				t.Fatalf("expected a configuration error, got %v", err)
+			}
+			th.AssertEquals(t, 1, len(cloud.Servers()))
+		})
+	}
+}
+
+func TestCleanupOnShutdownOnlyReports(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	addCreatedServer(cloud, "orphan", "removed", "test")
+
+	th.AssertNoErr(t, p.Cleanup())
+	th.AssertEquals(t, 1, len(cloud.Servers()))
+}
+
+func TestDeleteCreatedServerReleasesOnlyOwnFloatingIPs(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	poolID := cloud.AddNetwork(fakecloud.Network{Name: "public", External: true})
+	serverID := addCreatedServer(cloud, "orphan", "removed", "test")
+	ownPortID := cloud.AddPort(fakecloud.Port{NetworkID: "private", DeviceID: serverID, FixedIPs: []string{"10.0.0.5"}})
+	foreignPortID := cloud.AddPort(fakecloud.Port{NetworkID: "private", DeviceID: serverID, FixedIPs: []string{"10.0.0.6"}})
+	cloud.AddFloatingIP(fakecloud.FloatingIP{NetworkID: poolID, PortID: ownPortID, Description: floatingIPDescription})
+	foreignID := cloud.AddFloatingIP(fakecloud.FloatingIP{NetworkID: poolID, PortID: foreignPortID, Description: "bastion"})
+
+	th.AssertNoErr(t, p.deleteCreatedServer(serverID))
+
+	remaining := cloud.FloatingIPs()
+	th.AssertEquals(t, 1, len(remaining))
+	th.AssertEquals(t, foreignID, remaining[0].ID)
+}
+
+func TestCreatedServersCarryClusterName(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+
+	th.AssertNoErr(t, ng.IncreaseSize(1))
+
+	created := cloud.Servers()
+	th.AssertEquals(t, 1, len(created))
+	th.AssertEquals(t, "test", created[0].Metadata[membership.ClusterMetadataKey])
+
+	selected, err := p.CleanupServers(CleanupModeAll, false, "")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(selected))
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// providerClient returns the authenticated provider client shared by the current service clients
+func (p *OpenStackProvider) providerClient() *gophercloud.ProviderClient {
+	return p.clients.Load().Provider()
+}
+
+// computeClient returns the client of the compute API
+func (p *OpenStackProvider) computeClient() *gophercloud.ServiceClient {
+	return p.clients.Load().Compute()
+}
+
+// tagsClient returns the compute client requesting the tags microversion, nil when the cloud does not support it
+func (p *OpenStackProvider) tagsClient() *gophercloud.ServiceClient {
+	return p.clients.Load().Tags()
+}
+
+// imageClient returns the client of the image API
+func (p *OpenStackProvider) imageClient() *gophercloud.ServiceClient {
+	return p.clients.Load().Image()
+}
+
+// volumeClient returns the client of the block storage API, nil when the cloud offers none
+func (p *OpenStackProvider) volumeClient() *gophercloud.ServiceClient {
+	return p.clients.Load().Volume()
+}
+
+// networkClient returns the client of the network API, nil when the cloud offers none
+func (p *OpenStackProvider) networkClient() *gophercloud.ServiceClient {
+	return p.clients.Load().Network()
+}
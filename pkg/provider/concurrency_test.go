@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestIncreaseSizeBoundsConcurrentCreates(t *testing.T) {
+	previous := serverPollInterval
+	serverPollInterval = time.Millisecond
+	t.Cleanup(func() { serverPollInterval = previous })
+
+	tests := []struct {
+		name        string
+		concurrency int
+		delta       int
+		limit       int
+	}{
+		{name: "default", delta: 12, limit: defaultCreateConcurrency},
+		{name: "configured", concurrency: 2, delta: 6, limit: 2},
+		{name: "one at a time", concurrency: 1, delta: 3, limit: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.SetCreateStatus(fakecloud.StatusBuild)
+			// A create is in flight from the server being created until it is ACTIVE, the scale-up
+			// waits for it before starting the next one
+			var mutex sync.Mutex
+			created, building, maxBuilding := 0, 0, 0
+			cloud.OnCreate(func(*fakecloud.Server) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				created++
+				building++
+				maxBuilding = max(maxBuilding, building)
+			})
+			p := newTestProvider(t, cloud, func(cfg *config.Config) { cfg.Cloud.CreateConcurrency = tt.concurrency })
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.MaxSize = tt.delta
+				cfg.WaitForActive = true
+				cfg.ProvisionTimeout = 10 * time.Second
+			})
+
+			done := make(chan struct{})
+			var err error
+			go func() {
+				defer close(done)
+				err = ng.IncreaseSize(tt.delta)
+			}()
+
+			// Servers finish building only once every worker is busy, so a scale-up exceeding the
+			// limit is caught and one staying below it stalls
+			for finished := false; !finished; {
+				select {
+				case <-done:
+					finished = true
+				case <-time.After(time.Millisecond):
+				}
+				mutex.Lock()
+				saturated := building >= tt.limit || created == tt.delta
+				mutex.Unlock()
+				if !saturated {
+					continue
+				}
+				for _, server := range cloud.Servers() {
+					if server.Status != fakecloud.StatusBuild {
+						continue
+					}
+					cloud.UpdateServer(server.ID, func(server *fakecloud.Server) {
+						mutex.Lock()
+						defer mutex.Unlock()
+						server.Status, server.TaskState = fakecloud.StatusActive, ""
+						building--
+					})
+					break
+				}
+			}
+
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.delta, created)
+			th.AssertEquals(t, tt.limit, maxBuilding)
+			th.AssertEquals(t, tt.delta, len(cloud.Servers()))
+		})
+	}
+}
+
+func TestCancelledIncreaseSizeKeepsCreatedServers(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) { cfg.Cloud.CreateConcurrency = 1 })
+	ng := newTestNodeGroup(t, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	created := 0
+	cloud.OnCreate(func(*fakecloud.Server) {
+		// The caller gives up while the second server is being created
+		if created++; created == 2 {
+			cancel()
+		}
+	})
+
+	err := ng.IncreaseSizeWithReason(ctx, 5, ReasonClusterAutoscaler)
+	var scaleUpErr *ScaleUpError
+	if !errors.As(err, &scaleUpErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled scale-up error, got %v", err)
+	}
+	th.AssertEquals(t, 2, scaleUpErr.Created)
+
+	// The servers created are kept and the target counts them only
+	th.AssertEquals(t, 2, len(cloud.Servers()))
+	size, err := ng.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, size)
+	instances, err := ng.Nodes()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(instances))
+}
+
+func TestParallelIncreaseSizeStaysWithinMaxSize(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.MaxSize = 6
+	})
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ng.IncreaseSize(1) == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	th.AssertEquals(t, 6, succeeded)
+	th.AssertEquals(t, 6, len(cloud.Servers()))
+	size, err := ng.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 6, size)
+}
+
+func TestScalingOperationDoesNotBlockReads(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+	addMember(cloud, testGroupID, "workers-a")
+
+	// A scaling operation in progress holds the operation mutex
+	ng.operationMutex.Lock()
+	defer ng.operationMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if instances, err := ng.Nodes(); err != nil || len(instances) != 1 {
+			t.Errorf("expected the one member, got %d instances: %v", len(instances), err)
+		}
+		if _, err := ng.TargetSize(); err != nil {
+			t.Errorf("failed to read the target size: %v", err)
+		}
+		if err := ng.Refresh(); err != nil {
+			t.Errorf("failed to refresh: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected reads to proceed while a scaling operation runs")
+	}
+}
+
+func TestRacingIncreaseSizeRespectsMaxSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		update bool
+	}{
+		{name: "same node group"},
+		{name: "across a node group update", update: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.MaxSize = 5
+			})
+			for _, name := range []string{"workers-a", "workers-b", "workers-c"} {
+				addMember(cloud, testGroupID, name)
+			}
+			th.AssertNoErr(t, ng.Refresh())
+
+			// Operations already running on the replaced node group race the new one
+			groups := []*OpenStackNodeGroup{ng}
+			if tt.update {
+				cfg := *ng.Config
+				updated, err := p.UpdateNodeGroup(&cfg, ng.ResourceVersion())
+				th.AssertNoErr(t, err)
+				groups = append(groups, updated)
+			}
+
+			var wg sync.WaitGroup
+			for i := range 9 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					// Each request fits the headroom of two servers on its own, or exceeds it
+					_ = groups[i%len(groups)].IncreaseSize(i%3 + 1)
+				}()
+			}
+			wg.Wait()
+
+			th.AssertEquals(t, 5, len(cloud.Servers()))
+			size, err := p.GetNodeGroup(testGroupID).TargetSize()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 5, size)
+		})
+	}
+}
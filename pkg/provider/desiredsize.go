@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+)
+
+// pruneDesiredSizes drops the persisted desired sizes of node groups that are no longer configured
+func (p *OpenStackProvider) pruneDesiredSizes(nodeGroupIDs map[string]bool) {
+	p.sizeStore.Prune(nodeGroupIDs)
+}
+
+// observedSize counts the servers that are part of the target size
+func observedSize(instances []servers.Server) int {
+	count := 0
+	for i := range instances {
+		if countsTowardTargetSize(&instances[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// desiredSize returns the target size of the node group. Until a scaling operation sets it, it
+// is taken from the state file or, without a stored size, from the servers in the cloud.
+func (ng *OpenStackNodeGroup) desiredSize() (int, error) {
+	if size, known := ng.target.Get(); known {
+		return size, nil
+	}
+
+	if restored, exists := ng.target.Restore(); exists {
+		klog.Infof("Restored desired size %d of node group %s", restored, ng.Config.ID)
+		return restored, nil
+	}
+
+	instances, err := ng.getInstances()
+	if err != nil {
+		return 0, err
+	}
+	return ng.target.Init(observedSize(instances)), nil
+}
+
+// setDesiredSize records and persists the target size of the node group
+func (ng *OpenStackNodeGroup) setDesiredSize(size int) {
+	ng.target.Set(size)
+}
+
+// adjustDesiredSize changes a known target size by delta, never below zero
+func (ng *OpenStackNodeGroup) adjustDesiredSize(delta int) {
+	ng.target.Adjust(delta)
+}
+
+// reconcileDesiredSize compares the target size with the servers in the cloud. Servers beyond
+// the target were created outside the autoscaler and raise it, missing servers are left to the
+// cluster autoscaler, which decreases the target once they fail to register.
+func (ng *OpenStackNodeGroup) reconcileDesiredSize() error {
+	desired, err := ng.desiredSize()
+	if err != nil {
+		return err
+	}
+	instances, err := ng.getInstances()
+	if err != nil {
+		return err
+	}
+
+	observed := observedSize(instances)
+	switch {
+	case observed > desired && !ng.Provider.DryRun():
+		klog.Warningf("Node group %s has %d servers but a desired size of %d, raising the desired size", ng.Config.ID, observed, desired)
+		ng.setDesiredSize(observed)
+	case observed < desired:
+		klog.V(2).Infof("Node group %s has %d servers, %d short of its desired size", ng.Config.ID, observed, desired-observed)
+	}
+	return nil
+}
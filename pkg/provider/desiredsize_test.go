@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// readStateFile returns the desired sizes persisted in a state file
+func readStateFile(t *testing.T, path string) map[string]int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	th.AssertNoErr(t, err)
+	sizes := map[string]int{}
+	th.AssertNoErr(t, json.Unmarshal(data, &sizes))
+	return sizes
+}
+
+func TestDesiredSizePersistedInOrder(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.StateFile = stateFile
+	})
+	ng := newTestNodeGroup(t, p)
+	ng.setDesiredSize(0)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				ng.adjustDesiredSize(1)
+				return
+			}
+			ng.setDesiredSize(i)
+		}()
+	}
+	wg.Wait()
+
+	size, err := ng.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, size, readStateFile(t, stateFile)[testGroupID])
+}
+
+func TestDesiredSizesPrunedForRemovedNodeGroups(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	th.AssertNoErr(t, os.WriteFile(stateFile, []byte(`{"a": 3, "b": 2, "removed": 5}`), 0o600))
+
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.StateFile = stateFile
+		cfg.NodeGroups = []config.NodeGroupConfig{namedNodeGroupConfig("a"), namedNodeGroupConfig("b")}
+	})
+
+	tests := []struct {
+		name       string
+		nodeGroups []config.NodeGroupConfig
+		want       map[string]int
+	}{
+		{name: "startup", want: map[string]int{"a": 3, "b": 2}},
+		{name: "reload removing b", nodeGroups: []config.NodeGroupConfig{namedNodeGroupConfig("a")}, want: map[string]int{"a": 3}},
+		{name: "reload removing a", want: map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name != "startup" {
+				th.AssertNoErr(t, p.Reload(reloadConfig(p, tt.nodeGroups...)))
+			}
+			th.AssertDeepEquals(t, tt.want, readStateFile(t, stateFile))
+		})
+	}
+}
+
+func TestDesiredSizeRestoredFromStateFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	th.AssertNoErr(t, os.WriteFile(stateFile, []byte(`{"workers": 4}`), 0o600))
+
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.StateFile = stateFile
+		cfg.NodeGroups = []config.NodeGroupConfig{*testNodeGroupConfig()}
+	})
+	addMember(cloud, testGroupID, "workers-a")
+
+	size, err := p.GetNodeGroup(testGroupID).TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 4, size)
+}
@@ -0,0 +1,6 @@
+package provider
+
+// DryRun reports whether scaling operations are only logged instead of changing the cloud
+func (p *OpenStackProvider) DryRun() bool {
+	return p.config.Load().Cloud.DryRun
+}
@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// mutatingRequests returns the requests that changed the cloud, authentication aside
+func mutatingRequests(cloud *fakecloud.Cloud) []fakecloud.Request {
+	var mutating []fakecloud.Request
+	for _, request := range cloud.RequestLog() {
+		if request.Method == http.MethodGet || strings.HasPrefix(request.Path, fakecloud.IdentityPath) {
+			continue
+		}
+		mutating = append(mutating, request)
+	}
+	return mutating
+}
+
+func TestDryRunSimulatesTargetSize(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) { cfg.Cloud.DryRun = true })
+	th.AssertEquals(t, true, p.DryRun())
+	ng := newTestNodeGroup(t, p)
+	member := addMember(cloud, testGroupID, "workers-a")
+	addMember(cloud, testGroupID, "workers-b")
+	th.AssertNoErr(t, ng.Refresh())
+
+	steps := []struct {
+		name   string
+		change func() error
+		size   int
+	}{
+		{name: "increase", change: func() error { return ng.IncreaseSize(3) }, size: 5},
+		{name: "decrease", change: func() error { return ng.DecreaseTargetSize(-1) }, size: 4},
+		{name: "delete node", change: func() error {
+			return ng.DeleteNodes([]*apiv1.Node{testNode("workers-a", member)})
+		}, size: 3},
+		{name: "decrease below the servers", change: func() error { return ng.DecreaseTargetSize(-2) }, size: 1},
+	}
+	for _, step := range steps {
+		th.AssertNoErr(t, step.change())
+		th.AssertNoErr(t, ng.Refresh())
+		size, err := ng.TargetSize()
+		th.AssertNoErr(t, err)
+		if size != step.size {
+			t.Fatalf("%s: expected a simulated target size of %d, got %d", step.name, step.size, size)
+		}
+	}
+
+	th.AssertDeepEquals(t, []fakecloud.Request(nil), mutatingRequests(cloud))
+	th.AssertEquals(t, 2, len(cloud.Servers()))
+}
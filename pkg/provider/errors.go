@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/osclient"
+)
+
+// maxFailedRequestIDs is the number of failed request IDs remembered per node group
+const maxFailedRequestIDs = 5
+
+// ErrQuotaExceeded marks failures caused by an exhausted OpenStack quota or resource pool
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrQuarantined is returned for mutations of a node group quarantined after repeated failures
+var ErrQuarantined = errors.New("node group quarantined")
+
+// ErrPriceNotFound is returned when the price table has no price for a node
+var ErrPriceNotFound = errors.New("price not found")
+
+// ErrNotMember is returned when a node to delete is not backed by a server of the node group
+var ErrNotMember = errors.New("not a member of the node group")
+
+// ErrBelowMinSize is returned when deleting nodes would take a node group below its minimum size
+var ErrBelowMinSize = errors.New("node group would go below its minimum size")
+
+// ErrScaleDownBatchLimit marks nodes left in place because a DeleteNodes call exceeded maxScaleDownBatch
+var ErrScaleDownBatchLimit = errors.New("scale-down batch limit reached")
+
+// ErrEvacuationRefused is returned for evacuations the node groups involved cannot accommodate
+var ErrEvacuationRefused = errors.New("evacuation refused")
+
+// ErrNoEvacuation is returned when cancelling an evacuation that is not running
+var ErrNoEvacuation = errors.New("no evacuation running")
+
+// ResourceVersionConflictError is returned when a node group update was based on a stale resource version
+type ResourceVersionConflictError struct {
+	NodeGroupID     string
+	CurrentVersion  uint64
+	ExpectedVersion uint64
+}
+
+func (e *ResourceVersionConflictError) Error() string {
+	return fmt.Sprintf("node group %s has resource version %d, update was based on %d", e.NodeGroupID, e.CurrentVersion, e.ExpectedVersion)
+}
+
+// ScaleUpError reports a scale-up that created fewer servers than requested
+type ScaleUpError struct {
+	NodeGroupID string
+	Requested   int
+	Created     int
+	Err         error
+}
+
+func (e *ScaleUpError) Error() string {
+	return fmt.Sprintf("created %d of %d servers for node group %s: %v", e.Created, e.Requested, e.NodeGroupID, e.Err)
+}
+
+func (e *ScaleUpError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigurationError is a failure caused by the configuration, such as a reference to a
+// flavor or image that does not exist, rather than by the cloud being unavailable
+type ConfigurationError = osclient.ConfigurationError
+
+// configErrorf formats a ConfigurationError
+func configErrorf(format string, args ...interface{}) error {
+	return osclient.ConfigErrorf(format, args...)
+}
+
+// IsConfigurationError reports whether err is caused by the configuration. Other errors,
+// such as timeouts and server errors, are considered transient cloud errors.
+func IsConfigurationError(err error) bool {
+	var configErr *ConfigurationError
+	if errors.As(err, &configErr) {
+		return true
+	}
+	return gophercloud.ResponseCodeIs(err, http.StatusBadRequest) || gophercloud.ResponseCodeIs(err, http.StatusNotFound)
+}
+
+// OpenStackError is a failed OpenStack API call carrying the request ID reported by the cloud
+type OpenStackError = osclient.OpenStackError
+
+// wrapOpenStackError wraps err in an OpenStackError when the response carried a request ID
+func wrapOpenStackError(err error) error {
+	return osclient.WrapError(err)
+}
+
+// RequestIDFromError returns the OpenStack request ID carried by err, if any
+func RequestIDFromError(err error) string {
+	var osErr *OpenStackError
+	if errors.As(err, &osErr) {
+		return osErr.RequestID
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// evacuationBatchTimeout bounds the wait for a batch of replacements to become ACTIVE
+	evacuationBatchTimeout = 30 * time.Minute
+)
+
+// evacuationPollInterval is the interval between checks for replacement capacity
+var evacuationPollInterval = 10 * time.Second
+
+// NodeDrainer evicts the pods of the node backed by a server before the server is deleted
+type NodeDrainer interface {
+	DrainNode(ctx context.Context, providerID string) error
+}
+
+// EvacuationOptions describes an evacuation of all servers of a node group
+type EvacuationOptions struct {
+	// SourceGroupID is the node group whose servers are removed
+	SourceGroupID string
+	// TargetGroupID receives replacement capacity before each batch is removed
+	TargetGroupID string
+	// BatchSize is the number of servers replaced and removed at a time
+	BatchSize int
+	// DryRun only plans the batches without changing anything
+	DryRun bool
+	// Reason is why the operator evacuates, recorded with the servers created and deleted
+	Reason string
+}
+
+// SetNodeDrainer sets the drainer evacuations use to move workloads off the servers they delete
+func (p *OpenStackProvider) SetNodeDrainer(drainer NodeDrainer) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.drainer = drainer
+}
+
+// EvacuateNodeGroup replaces and removes all servers of a node group batch by batch: the target
+// group grows by the size of a batch, and once the replacements are ACTIVE the nodes of the batch
+// are drained and their servers deleted. The evacuation runs in the background, it returns the
+// server IDs of each batch. Evacuations the node groups cannot accommodate are refused with
+// ErrEvacuationRefused.
+func (p *OpenStackProvider) EvacuateNodeGroup(opts EvacuationOptions) ([][]string, error) {
+	source := p.GetNodeGroup(opts.SourceGroupID)
+	if source == nil {
+		return nil, fmt.Errorf("source node group %s not found", opts.SourceGroupID)
+	}
+	if opts.TargetGroupID == "" {
+		return nil, fmt.Errorf("%w: no target node group given", ErrEvacuationRefused)
+	}
+	if opts.TargetGroupID == opts.SourceGroupID {
+		return nil, fmt.Errorf("%w: node group %s cannot be its own target", ErrEvacuationRefused, opts.SourceGroupID)
+	}
+	target := p.GetNodeGroup(opts.TargetGroupID)
+	if target == nil {
+		return nil, fmt.Errorf("target node group %s not found", opts.TargetGroupID)
+	}
+
+	p.mutex.RLock()
+	drainer := p.drainer
+	p.mutex.RUnlock()
+	if drainer == nil {
+		return nil, fmt.Errorf("%w: nodes cannot be drained without a Kubernetes client", ErrEvacuationRefused)
+	}
+
+	// The evacuation removes every server, which a node group with a minimum size does not allow
+	if source.Config.MinSize > 0 {
+		return nil, fmt.Errorf("%w: node group %s has min size %d", ErrEvacuationRefused, opts.SourceGroupID, source.Config.MinSize)
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1
+	}
+
+	instances, err := source.getInstances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers of node group %s: %w", opts.SourceGroupID, err)
+	}
+
+	targetSize, err := target.TargetSize()
+	if err != nil {
+		return nil, err
+	}
+	if targetSize+len(instances) > target.Config.MaxSize {
+		return nil, fmt.Errorf("%w: node group %s cannot grow from %d by %d servers, max size is %d",
+			ErrEvacuationRefused, opts.TargetGroupID, targetSize, len(instances), target.Config.MaxSize)
+	}
+
+	var batches [][]string
+	for start := 0; start < len(instances); start += opts.BatchSize {
+		end := start + opts.BatchSize
+		if end > len(instances) {
+			end = len(instances)
+		}
+		batch := make([]string, 0, end-start)
+		for _, instance := range instances[start:end] {
+			batch = append(batch, instance.ID)
+		}
+		batches = append(batches, batch)
+	}
+
+	if opts.DryRun {
+		klog.Infof("Dry-run evacuation of node group %s to %s: %d servers in %d batches", opts.SourceGroupID, opts.TargetGroupID, len(instances), len(batches))
+		return batches, nil
+	}
+
+	ctx, err := p.startEvacuation(opts.SourceGroupID)
+	if err != nil {
+		return nil, err
+	}
+	reason := sanitizeReason(withOperatorReason(ReasonEvacuation, opts.Reason))
+	klog.Infof("Evacuating node group %s to %s: %d servers in %d batches (reason: %s)", opts.SourceGroupID, opts.TargetGroupID, len(instances), len(batches), reason)
+
+	p.RunBackground("evacuation-"+opts.SourceGroupID, func(context.Context) {
+		defer p.finishEvacuation(opts.SourceGroupID)
+		if err := evacuate(ctx, source, target, drainer, batches, reason); err != nil {
+			klog.Errorf("Evacuation of node group %s stopped: %v", opts.SourceGroupID, err)
+			return
+		}
+		klog.Infof("Evacuation of node group %s completed", opts.SourceGroupID)
+	})
+	return batches, nil
+}
+
+// evacuate replaces, drains and deletes the batches of servers one after the other
+func evacuate(ctx context.Context, source, target *OpenStackNodeGroup, drainer NodeDrainer, batches [][]string, reason string) error {
+	for i, batch := range batches {
+		klog.Infof("Evacuating batch %d/%d of node group %s: %v", i+1, len(batches), source.Config.ID, batch)
+
+		if err := waitForReplacements(ctx, target, len(batch), reason); err != nil {
+			return fmt.Errorf("batch %d/%d: %w", i+1, len(batches), err)
+		}
+
+		for _, serverID := range batch {
+			if ctx.Err() != nil {
+				return fmt.Errorf("batch %d/%d: evacuation cancelled", i+1, len(batches))
+			}
+			if err := drainer.DrainNode(ctx, ProviderID(serverID)); err != nil {
+				return fmt.Errorf("batch %d/%d: failed to drain server %s: %w", i+1, len(batches), serverID, err)
+			}
+			if err := source.deleteEvacuatedServer(serverID, reason); err != nil {
+				return fmt.Errorf("batch %d/%d: %w", i+1, len(batches), err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteEvacuatedServer deletes a drained server, taking it out of the target size
+func (ng *OpenStackNodeGroup) deleteEvacuatedServer(serverID, reason string) error {
+	ng.operationMutex.Lock()
+	defer ng.operationMutex.Unlock()
+	defer ng.Provider.invalidateServerSnapshot()
+
+	klog.Infof("Deleting evacuated server %s of node group %s (reason: %s)", serverID, ng.Config.ID, reason)
+	if err := ng.deleteServer(serverID); err != nil {
+		return err
+	}
+	ng.adjustDesiredSize(-1)
+	return nil
+}
+
+// CancelEvacuation aborts a running evacuation before its next step
+func (p *OpenStackProvider) CancelEvacuation(groupID string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	cancel, exists := p.evacuations[groupID]
+	if !exists {
+		return fmt.Errorf("%w: node group %s", ErrNoEvacuation, groupID)
+	}
+	cancel()
+	klog.Infof("Cancelling evacuation of node group %s", groupID)
+	return nil
+}
+
+func (p *OpenStackProvider) startEvacuation(groupID string) (context.Context, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, exists := p.evacuations[groupID]; exists {
+		return nil, fmt.Errorf("%w: evacuation of node group %s already running", ErrEvacuationRefused, groupID)
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.evacuations[groupID] = cancel
+	return ctx, nil
+}
+
+func (p *OpenStackProvider) finishEvacuation(groupID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if cancel, exists := p.evacuations[groupID]; exists {
+		cancel()
+		delete(p.evacuations, groupID)
+	}
+}
+
+// waitForReplacements grows the target group by count and waits until the new servers are ACTIVE
+func waitForReplacements(ctx context.Context, target *OpenStackNodeGroup, count int, reason string) error {
+	before, err := countActive(target)
+	if err != nil {
+		return err
+	}
+
+	if err := target.IncreaseSizeWithReason(ctx, count, reason); err != nil {
+		return fmt.Errorf("failed to add replacement capacity to node group %s: %w", target.Config.ID, err)
+	}
+
+	deadline := time.Now().Add(evacuationBatchTimeout)
+	for {
+		active, err := countActive(target)
+		if err != nil {
+			return err
+		}
+		if active >= before+count {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("replacement capacity in node group %s not ACTIVE after %s", target.Config.ID, evacuationBatchTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("evacuation cancelled")
+		case <-time.After(evacuationPollInterval):
+		}
+	}
+}
+
+// countActive returns the number of ACTIVE servers of a node group
+func countActive(ng *OpenStackNodeGroup) (int, error) {
+	instances, err := ng.getInstances()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list servers of node group %s: %w", ng.Config.ID, err)
+	}
+
+	active := 0
+	for _, instance := range instances {
+		if instance.Status == "ACTIVE" {
+			active++
+		}
+	}
+	return active, nil
+}
@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// recordingDrainer records the nodes it drains and how many servers the target group had by then
+type recordingDrainer struct {
+	cloud   *fakecloud.Cloud
+	groupID string
+	// blocked, when set, is closed by the first drain, which then waits until the evacuation is cancelled
+	blocked chan struct{}
+
+	mutex   sync.Mutex
+	drained []string
+	targets []int
+}
+
+func (d *recordingDrainer) DrainNode(ctx context.Context, providerID string) error {
+	if d.blocked != nil {
+		close(d.blocked)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	target := 0
+	for _, server := range d.cloud.Servers() {
+		if server.Metadata[membership.NodeGroupMetadataKey] == d.groupID {
+			target++
+		}
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.drained = append(d.drained, providerID)
+	d.targets = append(d.targets, target)
+	return nil
+}
+
+func (d *recordingDrainer) recorded() ([]string, []int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.drained...), append([]int(nil), d.targets...)
+}
+
+// evacuationRunning reports whether an evacuation of the node group is running
+func evacuationRunning(p *OpenStackProvider, groupID string) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	_, exists := p.evacuations[groupID]
+	return exists
+}
+
+// newEvacuationGroups adds a source node group with the members and an empty target node group
+func newEvacuationGroups(t *testing.T, cloud *fakecloud.Cloud, p *OpenStackProvider, members int) (*OpenStackNodeGroup, *OpenStackNodeGroup, []string) {
+	t.Helper()
+	source := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) { cfg.ID = "old" })
+	target := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.ID = "new"
+		cfg.MaxSize = 3
+	})
+	var serverIDs []string
+	for i := 0; i < members; i++ {
+		serverIDs = append(serverIDs, addMember(cloud, "old", "old-"+string(rune('a'+i))))
+	}
+	return source, target, serverIDs
+}
+
+func TestEvacuateNodeGroup(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	source, _, serverIDs := newEvacuationGroups(t, cloud, p, 3)
+	drainer := &recordingDrainer{cloud: cloud, groupID: "new"}
+	p.SetNodeDrainer(drainer)
+
+	batches, err := p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: "new", BatchSize: 2})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(batches))
+	th.AssertEquals(t, 2, len(batches[0]))
+	th.AssertEquals(t, 1, len(batches[1]))
+
+	waitFor(t, func() bool { return !evacuationRunning(p, "old") })
+
+	// Every node is drained once the replacements of its batch exist, then its server is deleted
+	drained, targets := drainer.recorded()
+	var expected []string
+	for _, batch := range batches {
+		for _, serverID := range batch {
+			expected = append(expected, ProviderID(serverID))
+		}
+	}
+	th.AssertDeepEquals(t, expected, drained)
+	th.AssertDeepEquals(t, []int{2, 2, 3}, targets)
+	for _, serverID := range serverIDs {
+		if _, exists := cloud.Server(serverID); exists {
+			t.Errorf("evacuated server %s was not deleted", serverID)
+		}
+	}
+	th.AssertEquals(t, 3, len(cloud.Servers()))
+	size, err := source.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, size)
+}
+
+func TestEvacuateNodeGroupDryRun(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	newEvacuationGroups(t, cloud, p, 3)
+	p.SetNodeDrainer(&recordingDrainer{cloud: cloud, groupID: "new"})
+	since := len(cloud.RequestLog())
+
+	batches, err := p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: "new", BatchSize: 2, DryRun: true})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(batches))
+	assertReadOnly(t, cloud, since)
+}
+
+func TestEvacuateNodeGroupRefusals(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		noDrainer bool
+		minSize   int
+		members   int
+	}{
+		{name: "no target", members: 1},
+		{name: "source as target", target: "old", members: 1},
+		{name: "no drainer", target: "new", noDrainer: true, members: 1},
+		{name: "source min size", target: "new", minSize: 1, members: 1},
+		{name: "target max size", target: "new", members: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			source, _, _ := newEvacuationGroups(t, cloud, p, tt.members)
+			source.Config.MinSize = tt.minSize
+			if !tt.noDrainer {
+				p.SetNodeDrainer(&recordingDrainer{cloud: cloud, groupID: "new"})
+			}
+			since := len(cloud.RequestLog())
+
+			_, err := p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: tt.target})
+			if !errors.Is(err, ErrEvacuationRefused) {
+				t.Fatalf("expected the evacuation to be refused, got %v", err)
+			}
+			assertReadOnly(t, cloud, since)
+		})
+	}
+}
+
+func TestCancelEvacuation(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	_, _, serverIDs := newEvacuationGroups(t, cloud, p, 2)
+	drainer := &recordingDrainer{cloud: cloud, groupID: "new", blocked: make(chan struct{})}
+	p.SetNodeDrainer(drainer)
+
+	_, err := p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: "new"})
+	th.AssertNoErr(t, err)
+
+	// A second evacuation of the node group is refused while the first runs
+	_, err = p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: "new"})
+	if !errors.Is(err, ErrEvacuationRefused) {
+		t.Fatalf("expected the second evacuation to be refused, got %v", err)
+	}
+
+	<-drainer.blocked
+	th.AssertNoErr(t, p.CancelEvacuation("old"))
+	waitFor(t, func() bool { return !evacuationRunning(p, "old") })
+
+	// The node being drained keeps its server, the next batch is never replaced
+	for _, serverID := range serverIDs {
+		if _, exists := cloud.Server(serverID); !exists {
+			t.Errorf("server %s was deleted despite the cancellation", serverID)
+		}
+	}
+	th.AssertEquals(t, 3, len(cloud.Servers()))
+
+	// Once stopped the node group can be evacuated again
+	_, err = p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: "new", DryRun: true})
+	th.AssertNoErr(t, err)
+}
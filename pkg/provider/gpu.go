@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultGPUResourceName is the extended resource GPU nodes advertise
+	defaultGPUResourceName = "nvidia.com/gpu"
+	// defaultGPULabel is the node label marking GPU nodes for the cluster autoscaler
+	defaultGPULabel = "node.kubernetes.io/gpu"
+	// defaultGPULabelValue is the GPU label value when the GPU type is unknown
+	defaultGPULabelValue = "true"
+
+	// vgpuExtraSpec requests virtual GPUs through placement, e.g. "resources:VGPU": "1"
+	vgpuExtraSpec = "resources:VGPU"
+	// pciPassthroughExtraSpec requests PCI devices by alias, e.g. "pci_passthrough:alias": "a100:2"
+	pciPassthroughExtraSpec = "pci_passthrough:alias"
+	// vgpuType is the GPU type reported for flavors requesting virtual GPUs
+	vgpuType = "vgpu"
+)
+
+// knownGPUAliasPattern matches PCI passthrough aliases named after GPUs, such as "gpu", "nvidia-a100",
+// "a100", "h100" or "l40s". Aliases of other devices need to be listed in gpu_pci_aliases.
+var knownGPUAliasPattern = regexp.MustCompile(`(?i)gpu|nvidia|tesla|quadro|radeon|instinct|^rtx|^[ahlpvt][0-9]+[a-z]*$`)
+
+// flavorExtraSpecs returns the extra specs of a flavor, fetching them when the flavor was
+// retrieved with a microversion that does not embed them
+func (p *OpenStackProvider) flavorExtraSpecs(flavor *flavors.Flavor) (map[string]string, error) {
+	if len(flavor.ExtraSpecs) > 0 {
+		return flavor.ExtraSpecs, nil
+	}
+
+	extraSpecs, err := flavors.ListExtraSpecs(context.TODO(), p.computeClient(), flavor.ID).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extra specs of flavor %s: %w", flavor.Name, wrapOpenStackError(err))
+	}
+	return extraSpecs, nil
+}
+
+// isGPUAlias reports whether a PCI passthrough alias passes through GPUs
+func isGPUAlias(alias string, gpuAliases []string) bool {
+	return slices.Contains(gpuAliases, alias) || knownGPUAliasPattern.MatchString(alias)
+}
+
+// gpusFromExtraSpecs returns the number of GPUs and the GPU type requested by flavor extra specs.
+// Only PCI passthrough aliases that are configured or known to be GPUs are counted.
+func gpusFromExtraSpecs(extraSpecs map[string]string, gpuAliases []string) (int, string) {
+	if value, exists := extraSpecs[vgpuExtraSpec]; exists {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s extra spec %q", vgpuExtraSpec, value)
+		} else if count > 0 {
+			return count, vgpuType
+		}
+	}
+
+	value, exists := extraSpecs[pciPassthroughExtraSpec]
+	if !exists {
+		return 0, ""
+	}
+
+	// Aliases are given as "alias:count" pairs separated by commas, the count defaults to 1
+	total, gpuType := 0, ""
+	for _, entry := range strings.Split(value, ",") {
+		alias, countValue, hasCount := strings.Cut(strings.TrimSpace(entry), ":")
+		if alias == "" || !isGPUAlias(alias, gpuAliases) {
+			continue
+		}
+
+		count := 1
+		if hasCount {
+			parsed, err := strconv.Atoi(countValue)
+			if err != nil {
+				klog.Warningf("Ignoring invalid %s entry %q", pciPassthroughExtraSpec, entry)
+				continue
+			}
+			count = parsed
+		}
+
+		total += count
+		if gpuType == "" {
+			gpuType = alias
+		}
+	}
+	return total, gpuType
+}
+
+// gpuResourceName returns the extended resource name GPUs of the node group are advertised as
+func (ng *OpenStackNodeGroup) gpuResourceName(flavor *flavors.Flavor) string {
+	if ng.Config.GPUResourceName != "" {
+		return ng.Config.GPUResourceName
+	}
+	if override, exists := ng.Provider.config.Load().Cloud.GPUFlavors[flavor.Name]; exists && override.ResourceName != "" {
+		return override.ResourceName
+	}
+	return defaultGPUResourceName
+}
+
+// gpus returns the number of GPUs per node and their type. The node group count wins over
+// the flavor override table, which wins over the flavor extra specs.
+func (ng *OpenStackNodeGroup) gpus(flavor *flavors.Flavor) (int, string, error) {
+	if ng.Config.GPUCount > 0 {
+		return ng.Config.GPUCount, "", nil
+	}
+	if override, exists := ng.Provider.config.Load().Cloud.GPUFlavors[flavor.Name]; exists {
+		return override.Count, override.Type, nil
+	}
+
+	extraSpecs, err := ng.Provider.flavorExtraSpecs(flavor)
+	if err != nil {
+		return 0, "", err
+	}
+
+	count, gpuType := gpusFromExtraSpecs(extraSpecs, ng.Provider.config.Load().Cloud.GPUPCIAliases)
+	return count, gpuType, nil
+}
+
+// GPULabel returns the node label marking GPU nodes, empty when no node group has GPUs. Node
+// groups whose GPUs cannot be determined count as having GPUs, so an unavailable cloud does not
+// hide the label.
+func (p *OpenStackProvider) GPULabel() string {
+	for _, ng := range p.GetNodeGroups() {
+		flavor, err := ng.getFlavor()
+		if err != nil {
+			klog.Warningf("Failed to get flavor of node group %s, assuming it has GPUs: %v", ng.Config.ID, err)
+			return p.gpuLabel()
+		}
+		count, _, err := ng.gpus(flavor)
+		if err != nil {
+			klog.Warningf("Failed to get GPUs of node group %s, assuming it has some: %v", ng.Config.ID, err)
+			return p.gpuLabel()
+		}
+		if count > 0 {
+			return p.gpuLabel()
+		}
+	}
+	return ""
+}
+
+// gpuLabel returns the configured node label marking GPU nodes
+func (p *OpenStackProvider) gpuLabel() string {
+	if p.config.Load().Cloud.GPULabel != "" {
+		return p.config.Load().Cloud.GPULabel
+	}
+	return defaultGPULabel
+}
+
+// nodeLabels returns the labels nodes of the group register with, including the GPU label
+func (ng *OpenStackNodeGroup) nodeLabels(flavor *flavors.Flavor) (map[string]string, error) {
+	count, gpuType, err := ng.gpus(flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(ng.Config.Labels)+1)
+	if count > 0 {
+		if gpuType == "" {
+			gpuType = defaultGPULabelValue
+		}
+		labels[ng.Provider.gpuLabel()] = gpuType
+	}
+	for k, v := range ng.Config.Labels {
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// AvailableGPUTypes returns the distinct GPU types offered by the flavors of all node groups
+func (p *OpenStackProvider) AvailableGPUTypes() (map[string]struct{}, error) {
+	gpuTypes := make(map[string]struct{})
+	for _, ng := range p.GetNodeGroups() {
+		flavor, err := ng.getFlavor()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get flavor of node group %s: %w", ng.Config.ID, err)
+		}
+
+		count, gpuType, err := ng.gpus(flavor)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			if gpuType == "" {
+				gpuType = defaultGPULabelValue
+			}
+			gpuTypes[gpuType] = struct{}{}
+		}
+	}
+	return gpuTypes, nil
+}
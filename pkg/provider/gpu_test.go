@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestGPUsFromExtraSpecs(t *testing.T) {
+	tests := []struct {
+		name       string
+		extraSpecs map[string]string
+		gpuAliases []string
+		count      int
+		gpuType    string
+	}{
+		{name: "no accelerators", extraSpecs: map[string]string{"hw:cpu_policy": "dedicated"}},
+		{name: "virtual GPUs", extraSpecs: map[string]string{"resources:VGPU": "2"}, count: 2, gpuType: "vgpu"},
+		{name: "known alias", extraSpecs: map[string]string{"pci_passthrough:alias": "a100:2"}, count: 2, gpuType: "a100"},
+		{name: "known alias without count", extraSpecs: map[string]string{"pci_passthrough:alias": "nvidia-l40s"}, count: 1, gpuType: "nvidia-l40s"},
+		{name: "network card", extraSpecs: map[string]string{"pci_passthrough:alias": "sriov-nic:1"}},
+		{name: "network card and GPUs", extraSpecs: map[string]string{"pci_passthrough:alias": "sriov-nic:1, gpu:4"}, count: 4, gpuType: "gpu"},
+		{name: "configured alias", extraSpecs: map[string]string{"pci_passthrough:alias": "accel:2"}, gpuAliases: []string{"accel"}, count: 2, gpuType: "accel"},
+		{name: "unconfigured alias", extraSpecs: map[string]string{"pci_passthrough:alias": "accel:2"}},
+		{name: "invalid count", extraSpecs: map[string]string{"pci_passthrough:alias": "a100:two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, gpuType := gpusFromExtraSpecs(tt.extraSpecs, tt.gpuAliases)
+			th.AssertEquals(t, tt.count, count)
+			th.AssertEquals(t, tt.gpuType, gpuType)
+		})
+	}
+}
+
+func TestGPULabel(t *testing.T) {
+	tests := []struct {
+		name       string
+		extraSpecs map[string]string
+		gpuCount   int
+		label      string
+	}{
+		{name: "no GPU node groups"},
+		{name: "network card node group", extraSpecs: map[string]string{"pci_passthrough:alias": "sriov-nic:1"}},
+		{name: "GPU flavor", extraSpecs: map[string]string{"pci_passthrough:alias": "a100:1"}, label: defaultGPULabel},
+		{name: "GPU count override", gpuCount: 1, label: defaultGPULabel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "g1.large", VCPUs: 8, RAM: 16384, ExtraSpecs: tt.extraSpecs})
+			p := newTestProvider(t, cloud)
+			newTestNodeGroup(t, p)
+			newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.ID = "accelerated"
+				cfg.FlavorID = flavorID
+				cfg.GPUCount = tt.gpuCount
+			})
+
+			th.AssertEquals(t, tt.label, p.GPULabel())
+		})
+	}
+}
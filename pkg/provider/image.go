@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultImageFallbackMaxAge bounds how long a resolved image name is reused while Glance fails
+	defaultImageFallbackMaxAge = 24 * time.Hour
+)
+
+// imageInfo holds the image attributes the autoscaler needs, images do not change once uploaded
+type imageInfo struct {
+	name         string
+	architecture string
+	osType       string
+	minRAMMB     int
+	minDiskGB    int
+	// used is when the image was last looked up, guarded by imagesMutex
+	used time.Time
+}
+
+// getImage returns the name and architecture of an image, caching them per image ID
+func (p *OpenStackProvider) getImage(imageID string) (*imageInfo, error) {
+	p.imagesMutex.Lock()
+	info, exists := p.images[imageID]
+	if exists {
+		info.used = time.Now()
+	}
+	p.imagesMutex.Unlock()
+	if exists {
+		return info, nil
+	}
+
+	image, err := images.Get(context.TODO(), p.imageClient(), imageID).Extract()
+	if err != nil {
+		err = fmt.Errorf("failed to get image %s: %w", imageID, wrapOpenStackError(err))
+		p.observeImageService(err)
+		return nil, err
+	}
+	p.observeImageService(nil)
+
+	info = &imageInfo{name: image.Name, minRAMMB: image.MinRAMMegabytes, minDiskGB: image.MinDiskGigabytes, used: time.Now()}
+	info.architecture, _ = image.Properties[imageArchitectureProperty].(string)
+	info.osType, _ = image.Properties[imageOSTypeProperty].(string)
+
+	p.imagesMutex.Lock()
+	p.images[imageID] = info
+	p.imagesMutex.Unlock()
+	return info, nil
+}
+
+// expireImages forgets the images not looked up within the retention period, such as those of
+// node groups that moved on to a newer image, and returns the number of cached images
+func (p *OpenStackProvider) expireImages(retention time.Duration) int {
+	p.imagesMutex.Lock()
+	defer p.imagesMutex.Unlock()
+
+	for imageID, info := range p.images {
+		if time.Since(info.used) > retention {
+			delete(p.images, imageID)
+		}
+	}
+	return len(p.images)
+}
+
+// observeImageService tracks whether Glance is reachable from the outcome of an image call
+func (p *OpenStackProvider) observeImageService(err error) {
+	degraded := err != nil && !IsConfigurationError(err)
+	if p.imageDegraded.Swap(degraded) != degraded {
+		if degraded {
+			klog.Warningf("Image service is degraded, node groups with pinned image IDs keep scaling: %v", err)
+		} else {
+			klog.Info("Image service recovered")
+		}
+	}
+}
+
+// ImageServiceDegraded reports whether the last Glance call failed for reasons other than the request itself
+func (p *OpenStackProvider) ImageServiceDegraded() bool {
+	return p.imageDegraded.Load()
+}
+
+// imageFallbackMaxAge returns how long a resolved image name may be reused while Glance fails
+func (p *OpenStackProvider) imageFallbackMaxAge() time.Duration {
+	if maxAge := p.config.Load().Cloud.ImageFallbackMaxAge; maxAge > 0 {
+		return maxAge
+	}
+	return defaultImageFallbackMaxAge
+}
+
+// getImageID returns the image ID for this node group. An image name is resolved through the
+// provider lookup cache, and to its last known ID while Glance is unavailable unless that
+// resolution is older than the fallback bound.
+func (ng *OpenStackNodeGroup) getImageID() (string, error) {
+	if ng.Config.ImageID != "" {
+		return ng.Config.ImageID, nil
+	}
+
+	ng.imageMutex.Lock()
+	defer ng.imageMutex.Unlock()
+
+	cached := true
+	imageID, err := ng.Provider.cachedLookup("image:"+ng.imageSelector(), func() (interface{}, error) {
+		cached = false
+		return ng.lookupImageID()
+	})
+	if !cached {
+		ng.Provider.observeImageService(err)
+	}
+	if err == nil {
+		if imageID != ng.resolvedImageID || !cached {
+			ng.resolvedImageID, ng.resolvedImageAt = imageID.(string), time.Now()
+		}
+		return ng.resolvedImageID, nil
+	}
+	if IsConfigurationError(err) {
+		return "", err
+	}
+
+	if ng.resolvedImageID == "" || time.Since(ng.resolvedImageAt) > ng.Provider.imageFallbackMaxAge() {
+		return "", err
+	}
+	klog.Warningf("Using image %s resolved %s ago for node group %s: %v", ng.resolvedImageID, time.Since(ng.resolvedImageAt).Round(time.Second), ng.Config.ID, err)
+	return ng.resolvedImageID, nil
+}
+
+// lookupImageID resolves the configured image name and tags through Glance to the most
+// recently created active image, so uploading a new version rolls the node group forward
+func (ng *OpenStackNodeGroup) lookupImageID() (string, error) {
+	listOpts := images.ListOpts{
+		Status: images.ImageStatusActive,
+		Tags:   ng.Config.ImageTags,
+	}
+	if !ng.Config.ImageNameIsPrefix {
+		listOpts.Name = ng.Config.ImageName
+	}
+
+	allPages, err := images.List(ng.Provider.imageClient(), listOpts).AllPages(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to list images: %w", wrapOpenStackError(err))
+	}
+
+	allImages, err := images.ExtractImages(allPages)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract images: %w", err)
+	}
+
+	image, matches := newestActiveImage(allImages, ng.Config.ImageName, ng.Config.ImageNameIsPrefix)
+	if image == nil {
+		return "", configErrorf("no active image matching %s", ng.imageSelector())
+	}
+	if matches > 1 && ng.Config.ImageRequireUnique {
+		return "", configErrorf("%d active images match %s", matches, ng.imageSelector())
+	}
+
+	klog.V(2).Infof("Node group %s resolved image %s to %s (%s), newest of %d matching images", ng.Config.ID, ng.imageSelector(), image.ID, image.Name, matches)
+	return image.ID, nil
+}
+
+// newestActiveImage returns the most recently created active image whose name matches, or nil,
+// and the number of matching active images
+func newestActiveImage(allImages []images.Image, name string, prefix bool) (*images.Image, int) {
+	var newest *images.Image
+	matches := 0
+	for i := range allImages {
+		image := &allImages[i]
+		if image.Status != images.ImageStatusActive {
+			continue
+		}
+		if prefix && !strings.HasPrefix(image.Name, name) {
+			continue
+		}
+		if !prefix && name != "" && image.Name != name {
+			continue
+		}
+		matches++
+		if newest == nil || image.CreatedAt.After(newest.CreatedAt) {
+			newest = image
+		}
+	}
+	return newest, matches
+}
+
+// imageSelector describes the configured image selection for error messages
+func (ng *OpenStackNodeGroup) imageSelector() string {
+	var parts []string
+	if ng.Config.ImageNameIsPrefix {
+		parts = append(parts, fmt.Sprintf("name prefix %q", ng.Config.ImageName))
+	} else if ng.Config.ImageName != "" {
+		parts = append(parts, fmt.Sprintf("name %q", ng.Config.ImageName))
+	}
+	if len(ng.Config.ImageTags) > 0 {
+		parts = append(parts, fmt.Sprintf("tags %s", strings.Join(ng.Config.ImageTags, ",")))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// checkImageRequirements validates the image requirements of a node group. Only incompatibilities
+// fail, the requirements are not checked while Glance cannot return the image.
+func (ng *OpenStackNodeGroup) checkImageRequirements() error {
+	err := ng.validateImageRequirements()
+	if err != nil && !IsConfigurationError(err) {
+		klog.Warningf("Could not check image requirements of node group %s: %v", ng.Config.ID, err)
+		return nil
+	}
+	return err
+}
+
+// validateImageRequirements checks the flavor meets the minimum RAM and disk the image declares.
+// Node groups booting from a volume compare the root volume size instead of the flavor disk.
+func (ng *OpenStackNodeGroup) validateImageRequirements() error {
+	flavor, err := ng.getFlavor()
+	if err != nil {
+		return fmt.Errorf("failed to get flavor: %w", err)
+	}
+	imageID, err := ng.getImageID()
+	if err != nil {
+		return fmt.Errorf("failed to get image ID: %w", err)
+	}
+	image, err := ng.Provider.getImage(imageID)
+	if err != nil {
+		return err
+	}
+
+	if image.minRAMMB > flavor.RAM {
+		return configErrorf("flavor %s has %d MB of RAM but image %s requires min_ram %d MB", flavor.Name, flavor.RAM, image.name, image.minRAMMB)
+	}
+
+	if rootVolume := ng.Config.RootVolume; rootVolume != nil {
+		if image.minDiskGB > rootVolume.SizeGiB {
+			return configErrorf("root volume has %d GB but image %s requires min_disk %d GB", rootVolume.SizeGiB, image.name, image.minDiskGB)
+		}
+		return nil
+	}
+	// A flavor without a disk gets a root disk sized to the image
+	if flavor.Disk > 0 && image.minDiskGB > flavor.Disk {
+		return configErrorf("flavor %s has a %d GB disk but image %s requires min_disk %d GB", flavor.Name, flavor.Disk, image.name, image.minDiskGB)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestScaleUpResolvesImageAndFlavorOnce(t *testing.T) {
+	tests := []struct {
+		name       string
+		configure  func(cfg *config.NodeGroupConfig)
+		imageLists int
+	}{
+		{name: "image ID", configure: func(cfg *config.NodeGroupConfig) {}},
+		{name: "image name", configure: func(cfg *config.NodeGroupConfig) {
+			cfg.ImageID = ""
+			cfg.ImageName = "node-image"
+		}, imageLists: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.FlavorID = ""
+				cfg.FlavorName = "m1.small"
+				tt.configure(cfg)
+			})
+
+			p.invalidateLookups()
+			imageListPath := fakecloud.ImagePath + "/v2/images"
+			flavorListPath := fakecloud.ComputePath + "/flavors/detail"
+			imageLists, flavorLists := cloud.Requests(http.MethodGet, imageListPath), cloud.Requests(http.MethodGet, flavorListPath)
+
+			for range 3 {
+				th.AssertNoErr(t, ng.IncreaseSize(1))
+			}
+			th.AssertEquals(t, 3, len(cloud.Servers()))
+			th.AssertEquals(t, tt.imageLists, cloud.Requests(http.MethodGet, imageListPath)-imageLists)
+			th.AssertEquals(t, 1, cloud.Requests(http.MethodGet, flavorListPath)-flavorLists)
+
+			// Refresh resolves the names again for the next scale-up
+			th.AssertNoErr(t, ng.Refresh())
+			for range 2 {
+				th.AssertNoErr(t, ng.IncreaseSize(1))
+			}
+			th.AssertEquals(t, 2*tt.imageLists, cloud.Requests(http.MethodGet, imageListPath)-imageLists)
+			th.AssertEquals(t, 2, cloud.Requests(http.MethodGet, flavorListPath)-flavorLists)
+		})
+	}
+}
+
+func TestRefreshResolvesRenamedFlavorAndImage(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.FlavorID, cfg.FlavorName = "", "m1.small"
+		cfg.ImageID, cfg.ImageName = "", "node-image"
+	})
+	th.AssertNoErr(t, ng.IncreaseSize(1))
+
+	// The operator replaces the flavor and the image under the same names
+	cloud.UpdateFlavor(testFlavorID, func(flavor *fakecloud.Flavor) { flavor.Name = "m1.small-old" })
+	flavorID := cloud.AddFlavor(fakecloud.Flavor{Name: "m1.small", VCPUs: 4, RAM: 8192, Disk: 20})
+	cloud.UpdateImage(testImageID, func(image *fakecloud.Image) { image.Name = "node-image-old" })
+	imageID := cloud.AddImage(fakecloud.Image{Name: "node-image"})
+
+	th.AssertNoErr(t, ng.Refresh())
+	th.AssertNoErr(t, ng.IncreaseSize(1))
+	node, err := ng.TemplateNodeInfo()
+	th.AssertNoErr(t, err)
+
+	servers := cloud.Servers()
+	th.AssertEquals(t, 2, len(servers))
+	created := map[string]string{}
+	for _, server := range servers {
+		created[server.FlavorID] = server.ImageID
+	}
+	th.AssertDeepEquals(t, map[string]string{testFlavorID: testImageID, flavorID: imageID}, created)
+	th.AssertEquals(t, int64(4), node.Status.Capacity.Cpu().Value())
+}
+
+func TestLookupImageIDPicksNewest(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.NodeGroupConfig)
+		image     string
+		err       string
+	}{
+		{name: "newest active of the name", configure: func(cfg *config.NodeGroupConfig) {
+			cfg.ImageName = "ubuntu"
+		}, image: "ubuntu-v2"},
+		{name: "name prefix", configure: func(cfg *config.NodeGroupConfig) {
+			cfg.ImageName = "ubuntu"
+			cfg.ImageNameIsPrefix = true
+		}, image: "ubuntu-24.04"},
+		{name: "tags", configure: func(cfg *config.NodeGroupConfig) {
+			cfg.ImageName = "ubuntu"
+			cfg.ImageTags = []string{"stable"}
+		}, image: "ubuntu-v1"},
+		{name: "unique name required", configure: func(cfg *config.NodeGroupConfig) {
+			cfg.ImageName = "ubuntu"
+			cfg.ImageRequireUnique = true
+		}, err: "2 active images match"},
+		{name: "no match", configure: func(cfg *config.NodeGroupConfig) {
+			cfg.ImageName = "debian"
+		}, err: "no active image matching"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			now := time.Now()
+			// Glance returns the images in the order they were uploaded here, not by age
+			ids := map[string]string{
+				cloud.AddImage(fakecloud.Image{Name: "ubuntu", Tags: []string{"stable"}, Created: now.Add(-3 * time.Hour)}): "ubuntu-v1",
+				cloud.AddImage(fakecloud.Image{Name: "ubuntu-24.04", Created: now}):                                         "ubuntu-24.04",
+				cloud.AddImage(fakecloud.Image{Name: "ubuntu", Created: now.Add(-2 * time.Hour)}):                           "ubuntu-v2",
+				cloud.AddImage(fakecloud.Image{Name: "ubuntu", Status: "queued", Created: now.Add(-time.Hour)}):             "ubuntu-v3",
+			}
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p)
+			cfg := *ng.Config
+			cfg.ImageID = ""
+			tt.configure(&cfg)
+			ng.Config = &cfg
+
+			imageID, err := ng.lookupImageID()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				th.AssertEquals(t, true, IsConfigurationError(err))
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.image, ids[imageID])
+		})
+	}
+}
+
+func TestPinnedImageWithoutGlance(t *testing.T) {
+	tests := []struct {
+		name       string
+		flavorArch string
+		configured string
+		arch       string
+	}{
+		{name: "flavor architecture", flavorArch: "aarch64", arch: "arm64"},
+		{name: "configured architecture", flavorArch: "x86_64", configured: "arm64", arch: "arm64"},
+		{name: "neither declares one", arch: defaultArchitecture},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			flavor := fakecloud.Flavor{ID: "flavor-arch", Name: "m1.arch", VCPUs: 2, RAM: 4096, Disk: 20}
+			if tt.flavorArch != "" {
+				flavor.ExtraSpecs = map[string]string{flavorArchitectureExtraSpec: tt.flavorArch}
+			}
+			cloud.AddFlavor(flavor)
+			// The image declares a requirement the flavor does not meet, Glance cannot say so
+			imageID := cloud.AddImage(fakecloud.Image{Name: "node-image-v2", MinRAM: 8192, Properties: map[string]string{imageArchitectureProperty: "s390x"}})
+			cloud.Fail(http.MethodGet, fakecloud.ImagePath+"/v2/images", http.StatusServiceUnavailable, -1)
+			cloud.Fail(http.MethodGet, fakecloud.ImagePath+"/v2/images/"+imageID, http.StatusServiceUnavailable, -1)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.FlavorID, cfg.ImageID, cfg.Architecture = "flavor-arch", imageID, tt.configured
+			})
+
+			th.AssertNoErr(t, p.ValidateConfiguration(t.Context()))
+			node, err := ng.TemplateNodeInfo()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.arch, node.Labels[apiv1.LabelArchStable])
+			th.AssertNoErr(t, ng.IncreaseSize(1))
+			th.AssertEquals(t, 1, len(cloud.Servers()))
+			th.AssertEquals(t, true, p.ImageServiceDegraded())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+const (
+	// defaultJanitorInterval is how often tracking state is garbage collected
+	defaultJanitorInterval = 10 * time.Minute
+	// defaultTrackingRetention is how long tracking entries are kept without being refreshed
+	defaultTrackingRetention = 24 * time.Hour
+)
+
+// janitorLoop periodically expires stale tracking state until ctx is cancelled
+func (p *OpenStackProvider) janitorLoop(ctx context.Context) {
+	interval := p.config.Load().Cloud.JanitorInterval
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.collectGarbage(ctx)
+		}
+	}
+}
+
+// collectGarbage expires tracking entries past their retention, drops state of
+// node groups and servers that no longer exist and reports the remaining sizes
+func (p *OpenStackProvider) collectGarbage(ctx context.Context) {
+	retention := p.config.Load().Cloud.TrackingRetention
+	if retention <= 0 {
+		retention = defaultTrackingRetention
+	}
+
+	p.mutex.Lock()
+	for groupID, cancel := range p.evacuations {
+		if _, exists := p.nodeGroups[groupID]; !exists {
+			klog.V(2).Infof("Dropping evacuation state of removed node group %s", groupID)
+			cancel()
+			delete(p.evacuations, groupID)
+		}
+	}
+	evacuations := len(p.evacuations)
+	p.mutex.Unlock()
+
+	provisioning := p.expireProvisioning(retention)
+
+	members, failures := 0, 0
+	for _, ng := range p.GetNodeGroups() {
+		ng.members.Expire(retention)
+		members += ng.members.Len()
+		failures += ng.expireFailures(retention)
+	}
+
+	images := p.expireImages(retention)
+	lookups := p.expireLookups()
+	flavorNames := p.expireFlavorNames()
+	nodes, err := p.pruneNodes(ctx)
+	if err != nil {
+		klog.Warningf("Failed to prune registered nodes: %v", err)
+	}
+
+	metrics.TrackedEntries.WithLabelValues("known_members").Set(float64(members))
+	metrics.TrackedEntries.WithLabelValues("failed_request_ids").Set(float64(failures))
+	metrics.TrackedEntries.WithLabelValues("evacuations").Set(float64(evacuations))
+	metrics.TrackedEntries.WithLabelValues("provisioning_failures").Set(float64(provisioning))
+	metrics.TrackedEntries.WithLabelValues("node_group_cache").Set(float64(p.nodeGroupCacheSize()))
+	metrics.TrackedEntries.WithLabelValues("images").Set(float64(images))
+	metrics.TrackedEntries.WithLabelValues("lookups").Set(float64(lookups))
+	metrics.TrackedEntries.WithLabelValues("flavor_names").Set(float64(flavorNames))
+	metrics.TrackedEntries.WithLabelValues("registered_nodes").Set(float64(nodes))
+	klog.V(4).Infof("Tracking state: %d known members, %d failed request IDs, %d evacuations, %d images, %d lookups, %d registered nodes",
+		members, failures, evacuations, images, lookups, nodes)
+}
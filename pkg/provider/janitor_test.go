@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestCollectGarbageKeepsTrackingBounded(t *testing.T) {
+	const retention = 10 * time.Millisecond
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.TrackingRetention = retention
+		cfg.Cloud.LookupCacheTTL = retention
+		cfg.Cloud.TemplateCacheTTL = retention
+	})
+	ng := newTestNodeGroup(t, p)
+
+	for cycle := range 30 {
+		before := map[string]bool{}
+		for _, server := range cloud.Servers() {
+			before[server.ID] = true
+		}
+		th.AssertNoErr(t, ng.IncreaseSize(1))
+		for _, server := range cloud.Servers() {
+			if before[server.ID] {
+				continue
+			}
+			// The node is marked for deletion and deleted, the watcher misses the node deletion
+			node := testNode(server.Name, server.ID)
+			node.Spec.Taints = []apiv1.Taint{{Key: toBeDeletedTaint, Effect: apiv1.TaintEffectNoSchedule}}
+			p.NodeChanged(node)
+			th.AssertNoErr(t, ng.DeleteNodes([]*apiv1.Node{node}))
+		}
+		th.AssertEquals(t, 0, len(cloud.Servers()))
+
+		// Every cycle rolls the node group to a new image name
+		imageID := cloud.AddImage(fakecloud.Image{Name: fmt.Sprintf("node-image-%d", cycle)})
+		_, err := p.getImage(imageID)
+		th.AssertNoErr(t, err)
+		_, err = p.cachedLookup("image:"+imageID, func() (interface{}, error) { return imageID, nil })
+		th.AssertNoErr(t, err)
+		_, err = p.flavorName(testFlavorID)
+		th.AssertNoErr(t, err)
+
+		th.AssertEquals(t, 1, p.nodes.Len())
+		p.imagesMutex.Lock()
+		_, cached := p.images[imageID]
+		p.imagesMutex.Unlock()
+		th.AssertEquals(t, true, cached)
+
+		time.Sleep(2 * retention)
+		p.collectGarbage(context.Background())
+
+		th.AssertEquals(t, 0, p.nodes.Len())
+		th.AssertEquals(t, 0, p.expireImages(retention))
+		th.AssertEquals(t, 0, p.expireLookups())
+		th.AssertEquals(t, 0, p.expireFlavorNames())
+		th.AssertEquals(t, 0, ng.members.Len())
+		th.AssertEquals(t, 0, p.expireProvisioning(retention))
+	}
+}
+
+func TestCollectGarbageKeepsLiveState(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+	serverID := addMember(cloud, testGroupID, "workers-a")
+	p.NodeChanged(testNode("workers-a", serverID))
+	_, err := ng.Nodes()
+	th.AssertNoErr(t, err)
+	_, err = p.getImage(testImageID)
+	th.AssertNoErr(t, err)
+	_, err = p.flavorName(testFlavorID)
+	th.AssertNoErr(t, err)
+
+	p.collectGarbage(context.Background())
+
+	registered, _, _ := p.nodeState(serverID)
+	th.AssertEquals(t, true, registered)
+	th.AssertEquals(t, true, ng.members.Known(serverID))
+	th.AssertEquals(t, 1, p.expireImages(defaultTrackingRetention))
+	if p.expireFlavorNames() == 0 {
+		t.Error("expected the flavor names to be kept within the template cache TTL")
+	}
+}
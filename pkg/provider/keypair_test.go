@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestWithKeyPair(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyName string
+	}{
+		{name: "key pair", keyName: "nodes"},
+		{name: "no key pair"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ng := &OpenStackNodeGroup{Config: &config.NodeGroupConfig{ID: testGroupID, KeyName: tt.keyName}}
+
+			body, err := ng.withKeyPair(servers.CreateOpts{Name: "workers-a", FlavorRef: testFlavorID}).ToServerCreateMap()
+			th.AssertNoErr(t, err)
+			server := body["server"].(map[string]interface{})
+			keyName, exists := server["key_name"]
+			th.AssertEquals(t, tt.keyName != "", exists)
+			if exists {
+				th.AssertEquals(t, tt.keyName, keyName)
+			}
+		})
+	}
+}
+
+func TestCreateServerInjectsKeyPair(t *testing.T) {
+	tests := []struct {
+		name        string
+		multiCreate bool
+		requests    int
+	}{
+		{name: "one request per server", requests: 3},
+		{name: "multi create", multiCreate: true, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.AddKeypair(fakecloud.Keypair{Name: "nodes"})
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.KeyName = "nodes"
+				cfg.MultiCreate = tt.multiCreate
+			})
+
+			th.AssertNoErr(t, ng.IncreaseSize(3))
+			th.AssertEquals(t, tt.requests, cloud.Requests(http.MethodPost, fakecloud.ComputePath+"/servers"))
+			created := cloud.Servers()
+			th.AssertEquals(t, 3, len(created))
+			for _, server := range created {
+				th.AssertEquals(t, "nodes", server.KeyName)
+			}
+		})
+	}
+}
+
+func TestValidateKeyPair(t *testing.T) {
+	cloud := newTestCloud(t)
+	cloud.AddKeypair(fakecloud.Keypair{Name: "nodes"})
+	p := newTestProvider(t, cloud)
+
+	cfg := testNodeGroupConfig()
+	cfg.KeyName = "missing"
+	_, err := p.AddNodeGroup(cfg)
+	if err == nil || !strings.Contains(err.Error(), "key pair missing not found") {
+		t.Fatalf("expected a missing key pair error, got %v", err)
+	}
+
+	newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.KeyName = "nodes"
+	})
+	th.AssertNoErr(t, p.ValidateConfiguration(context.Background()))
+
+	// The key pair is deleted while the autoscaler runs
+	cloud.Fail(http.MethodGet, fakecloud.ComputePath+"/os-keypairs/nodes", http.StatusNotFound, -1)
+	err = p.ValidateConfiguration(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "key pair nodes not found") || !IsConfigurationError(err) {
+		t.Fatalf("expected a missing key pair configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// deletionCandidateTaint is the soft taint the cluster autoscaler puts on nodes it considers removing
+	deletionCandidateTaint = "DeletionCandidateOfClusterAutoscaler"
+)
+
+// rememberLiveNode keeps a ready member node as the model for template nodes, since the kubelet
+// registers labels, taints and extended resources the configuration cannot know about
+func (ng *OpenStackNodeGroup) rememberLiveNode(serverID string, node *apiv1.Node) {
+	ng.liveNodeMutex.Lock()
+	defer ng.liveNodeMutex.Unlock()
+
+	if !isNodeReady(node) || isMarkedForDeletion(node) {
+		if ng.liveNodeServerID == serverID {
+			ng.liveNode, ng.liveNodeServerID = nil, ""
+		}
+		return
+	}
+	ng.liveNode, ng.liveNodeServerID = node.DeepCopy(), serverID
+}
+
+// liveTemplateNode returns the remembered live node while its server is still a member, or nil
+func (ng *OpenStackNodeGroup) liveTemplateNode() *apiv1.Node {
+	ng.liveNodeMutex.Lock()
+	defer ng.liveNodeMutex.Unlock()
+
+	if ng.liveNode == nil {
+		return nil
+	}
+	if !ng.members.Known(ng.liveNodeServerID) {
+		ng.liveNode, ng.liveNodeServerID = nil, ""
+		return nil
+	}
+	return ng.liveNode
+}
+
+// applyLiveNode copies the labels, taints and allocatable resources of a live node onto a template
+// node built from the flavor. Labels derived from OpenStack keep their template values and a node
+// running another flavor, left over from a configuration change, is ignored.
+func (ng *OpenStackNodeGroup) applyLiveNode(template, live *apiv1.Node, flavor *flavors.Flavor) {
+	if instanceType := live.Labels[apiv1.LabelInstanceTypeStable]; instanceType != "" && instanceType != flavor.Name {
+		klog.V(2).Infof("Live node %s of node group %s runs flavor %s instead of %s, using the synthetic template", live.Name, ng.Config.ID, instanceType, flavor.Name)
+		return
+	}
+
+	for k, v := range live.Labels {
+		if k == apiv1.LabelHostname || isComputedLabel(k) {
+			continue
+		}
+		template.Labels[k] = v
+	}
+
+	for _, taint := range live.Spec.Taints {
+		if isTransientTaint(taint) || hasTaint(template.Spec.Taints, taint) {
+			continue
+		}
+		template.Spec.Taints = append(template.Spec.Taints, apiv1.Taint{Key: taint.Key, Value: taint.Value, Effect: taint.Effect})
+	}
+
+	// Resources the flavor does not describe are taken as they are, the allocatable share of
+	// flavor resources reflects the actual kubelet reservations
+	for name, quantity := range live.Status.Capacity {
+		if _, exists := template.Status.Capacity[name]; !exists {
+			template.Status.Capacity[name] = quantity.DeepCopy()
+		}
+	}
+	for name, quantity := range live.Status.Allocatable {
+		template.Status.Allocatable[name] = quantity.DeepCopy()
+	}
+
+	klog.V(4).Infof("Built template node of node group %s from live node %s", ng.Config.ID, live.Name)
+}
+
+// isNodeReady reports whether the node has a true Ready condition
+func isNodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isTransientTaint reports whether a taint reflects the current state of a node rather than its role
+func isTransientTaint(taint apiv1.Taint) bool {
+	return taint.Key == toBeDeletedTaint || taint.Key == deletionCandidateTaint ||
+		strings.HasPrefix(taint.Key, "node.kubernetes.io/") ||
+		strings.HasPrefix(taint.Key, "node.cloudprovider.kubernetes.io/")
+}
+
+// hasTaint reports whether a taint with the same key and effect is in the list
+func hasTaint(taints []apiv1.Taint, taint apiv1.Taint) bool {
+	for _, existing := range taints {
+		if existing.Key == taint.Key && existing.Effect == taint.Effect {
+			return true
+		}
+	}
+	return false
+}
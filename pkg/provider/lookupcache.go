@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLookupCacheTTL is how long flavor and image names resolve from the cache
+	defaultLookupCacheTTL = time.Hour
+	// negativeLookupTTL is how long a name that resolved to nothing is remembered
+	negativeLookupTTL = time.Minute
+)
+
+// lookupEntry is the cached outcome of resolving a flavor or image name
+type lookupEntry struct {
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+// lookupCache caches flavor and image name resolution shared by all node groups
+type lookupCache struct {
+	mutex   sync.Mutex
+	entries map[string]lookupEntry
+}
+
+// lookupCacheTTL returns how long resolved flavor and image names are cached
+func (p *OpenStackProvider) lookupCacheTTL() time.Duration {
+	if ttl := p.config.Load().Cloud.LookupCacheTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultLookupCacheTTL
+}
+
+// cachedLookup returns the cached outcome for key, calling lookup when there is none or it expired.
+// Names that resolve to nothing are cached briefly, other errors are not cached so outages are retried.
+func (p *OpenStackProvider) cachedLookup(key string, lookup func() (interface{}, error)) (interface{}, error) {
+	p.lookupCache.mutex.Lock()
+	entry, exists := p.lookupCache.entries[key]
+	p.lookupCache.mutex.Unlock()
+	if exists && time.Now().Before(entry.expires) {
+		return entry.value, entry.err
+	}
+
+	value, err := lookup()
+	switch {
+	case err == nil:
+		entry = lookupEntry{value: value, expires: time.Now().Add(p.lookupCacheTTL())}
+	case IsConfigurationError(err):
+		entry = lookupEntry{err: err, expires: time.Now().Add(negativeLookupTTL)}
+	default:
+		return nil, err
+	}
+
+	p.lookupCache.mutex.Lock()
+	if p.lookupCache.entries == nil {
+		p.lookupCache.entries = make(map[string]lookupEntry)
+	}
+	p.lookupCache.entries[key] = entry
+	p.lookupCache.mutex.Unlock()
+	return entry.value, entry.err
+}
+
+// invalidateLookups drops all cached flavor and image resolutions
+func (p *OpenStackProvider) invalidateLookups() {
+	p.lookupCache.mutex.Lock()
+	p.lookupCache.entries = nil
+	p.lookupCache.mutex.Unlock()
+}
+
+// expireLookups drops the cached resolutions past their expiry, such as those of names no node
+// group uses anymore, and returns the number of cached resolutions
+func (p *OpenStackProvider) expireLookups() int {
+	p.lookupCache.mutex.Lock()
+	defer p.lookupCache.mutex.Unlock()
+
+	for key, entry := range p.lookupCache.entries {
+		if !time.Now().Before(entry.expires) {
+			delete(p.lookupCache.entries, key)
+		}
+	}
+	return len(p.lookupCache.entries)
+}
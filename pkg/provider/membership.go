@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+// ownershipRepairInterval is the minimum time between metadata repairs of the same server
+const ownershipRepairInterval = 5 * time.Minute
+
+// canDecreaseTarget reports whether a server may be deleted to decrease the target size. Servers
+// still building never registered as nodes. Other servers only qualify once the node watcher
+// synced and reports no node for them, or their node is marked for deletion by the cluster
+// autoscaler. Servers attributed to the node group by their name alone are never deleted.
+func (ng *OpenStackNodeGroup) canDecreaseTarget(server *servers.Server) bool {
+	if !ng.ownsServer(server) {
+		return false
+	}
+	if ServerInstanceState(server) == InstanceStateCreating {
+		return true
+	}
+
+	registered, markedForDeletion, synced := ng.Provider.nodeState(server.ID)
+	if registered {
+		return markedForDeletion
+	}
+	return synced
+}
+
+// ownsServer reports whether a server carries the membership tag or node group metadata of the
+// node group, servers attributed by their name alone are not owned
+func (ng *OpenStackNodeGroup) ownsServer(server *servers.Server) bool {
+	return membership.Owns(server, ng.Config.ID)
+}
+
+// ProviderIDForNodeName returns the provider ID of the member server named exactly like a node.
+// Only servers carrying full ownership metadata qualify, several matches are refused as ambiguous.
+func (p *OpenStackProvider) ProviderIDForNodeName(name string) (string, error) {
+	var matches []string
+	for _, ng := range p.GetNodeGroups() {
+		instances, err := ng.getInstances()
+		if err != nil {
+			return "", fmt.Errorf("failed to get instances of node group %s: %w", ng.Config.ID, err)
+		}
+
+		for _, instance := range instances {
+			if instance.Name != name || !membership.CreatedFor(&instance, ng.Config.ID) {
+				continue
+			}
+			matches = append(matches, instance.ID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return ProviderID(matches[0]), nil
+	default:
+		return "", fmt.Errorf("%d member servers are named %s", len(matches), name)
+	}
+}
+
+// adoptServer decides whether a server missing ownership metadata is still a member,
+// re-applying the metadata when it is
+func (ng *OpenStackNodeGroup) adoptServer(server *servers.Server) bool {
+	if !ng.members.Known(server.ID) {
+		return false
+	}
+
+	if ng.Provider.config.Load().Cloud.StrictOwnershipMetadata {
+		klog.Warningf("Server %s lost its ownership metadata, forgetting it as member of node group %s", server.ID, ng.Config.ID)
+		ng.members.Forget(server.ID)
+		return false
+	}
+
+	if ng.members.ShouldRepair(server.ID, ownershipRepairInterval) {
+		if err := ng.repairOwnership(server.ID); err != nil {
+			klog.Errorf("Failed to restore ownership metadata of server %s in node group %s: %v", server.ID, ng.Config.ID, err)
+		} else {
+			metrics.OwnershipRepairs.WithLabelValues(ng.Config.ID).Inc()
+			klog.Infof("Restored ownership metadata of server %s in node group %s", server.ID, ng.Config.ID)
+		}
+	}
+
+	return true
+}
+
+// wouldAdopt reports whether adoptServer keeps a server missing ownership metadata as a member,
+// without forgetting or repairing it
+func (ng *OpenStackNodeGroup) wouldAdopt(server *servers.Server) bool {
+	return ng.members.Known(server.ID) && !ng.Provider.config.Load().Cloud.StrictOwnershipMetadata
+}
+
+// repairOwnership re-applies the ownership metadata to a server
+func (ng *OpenStackNodeGroup) repairOwnership(serverID string) error {
+	opts := servers.MetadataOpts(membership.Metadata(ng.Config.ID, ng.Provider.config.Load().Cloud.ClusterName))
+	_, err := servers.UpdateMetadata(context.TODO(), ng.Provider.computeClient(), serverID, opts).Extract()
+	if err != nil {
+		return fmt.Errorf("failed to update metadata of server %s: %w", serverID, wrapOpenStackError(err))
+	}
+	return nil
+}
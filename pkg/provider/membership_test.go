@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// captureLogs redirects klog output into a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	_ = flags.Set("logtostderr", "false")
+	_ = flags.Set("alsologtostderr", "false")
+	_ = flags.Set("stderrthreshold", "FATAL")
+
+	var buf bytes.Buffer
+	// Every severity is also written to the lower ones, the INFO output sees each line once
+	klog.SetOutput(io.Discard)
+	klog.SetOutputBySeverity("INFO", &buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		_ = flags.Set("logtostderr", "true")
+		klog.SetOutput(nil)
+	})
+	return &buf
+}
+
+func TestContainsNodeWarnsOncePerNameMatch(t *testing.T) {
+	logs := captureLogs(t)
+	ng := &OpenStackNodeGroup{Config: testNodeGroupConfig(), members: membership.NewRegistry()}
+	server := &servers.Server{ID: "server-1", Name: "cluster-" + testGroupID + "-1"}
+
+	for i := 0; i < 3; i++ {
+		if !ng.ContainsNode(server) {
+			t.Fatalf("expected the server to be attributed by its name")
+		}
+	}
+	klog.Flush()
+	if count := strings.Count(logs.String(), "attributing it to node group"); count != 1 {
+		t.Errorf("expected one warning, got %d:\n%s", count, logs.String())
+	}
+
+	ng.members.Prune(map[string]bool{})
+	ng.ContainsNode(server)
+	klog.Flush()
+	if count := strings.Count(logs.String(), "attributing it to node group"); count != 2 {
+		t.Errorf("expected a new warning once the server was pruned, got %d", count)
+	}
+}
+
+func TestContainsNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   servers.Server
+		expected bool
+	}{
+		{
+			name:     "membership tag",
+			server:   servers.Server{Name: "node", Tags: &[]string{membership.NodeGroupTagPrefix + testGroupID}},
+			expected: true,
+		},
+		{
+			name:     "metadata",
+			server:   servers.Server{Name: "node", Metadata: map[string]string{membership.NodeGroupMetadataKey: testGroupID}},
+			expected: true,
+		},
+		{
+			name:     "metadata of another group wins over the name",
+			server:   servers.Server{Name: testGroupID + "-1", Metadata: map[string]string{membership.NodeGroupMetadataKey: "other"}},
+			expected: false,
+		},
+		{
+			name:     "tag of another group wins over the name",
+			server:   servers.Server{Name: testGroupID + "-1", Tags: &[]string{membership.NodeGroupTagPrefix + "other"}},
+			expected: false,
+		},
+		{
+			name:     "name only",
+			server:   servers.Server{Name: testGroupID + "-1"},
+			expected: true,
+		},
+		{
+			name:     "unrelated",
+			server:   servers.Server{Name: "database"},
+			expected: false,
+		},
+	}
+
+	ng := &OpenStackNodeGroup{Config: testNodeGroupConfig(), members: membership.NewRegistry()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ng.ContainsNode(&tt.server); got != tt.expected {
+				t.Errorf("ContainsNode() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCanDecreaseTarget(t *testing.T) {
+	const serverID = "00000000-0000-4000-8000-000000000001"
+	owned := map[string]string{membership.NodeGroupMetadataKey: testGroupID}
+	markedNode := testNode("workers-a", serverID)
+	markedNode.Spec.Taints = []apiv1.Taint{{Key: toBeDeletedTaint, Effect: apiv1.TaintEffectNoSchedule}}
+
+	tests := []struct {
+		name     string
+		server   servers.Server
+		node     *apiv1.Node
+		synced   bool
+		expected bool
+	}{
+		{
+			name:     "building before the node state synced",
+			server:   servers.Server{ID: serverID, Status: "BUILD", Metadata: owned},
+			expected: true,
+		},
+		{
+			name:     "active before the node state synced",
+			server:   servers.Server{ID: serverID, Status: "ACTIVE", Metadata: owned},
+			expected: false,
+		},
+		{
+			name:     "registered",
+			server:   servers.Server{ID: serverID, Status: "ACTIVE", Metadata: owned},
+			node:     testNode("workers-a", serverID),
+			synced:   true,
+			expected: false,
+		},
+		{
+			name:     "registered and marked for deletion",
+			server:   servers.Server{ID: serverID, Status: "ACTIVE", Metadata: owned},
+			node:     markedNode,
+			synced:   true,
+			expected: true,
+		},
+		{
+			name:     "unregistered after the node state synced",
+			server:   servers.Server{ID: serverID, Status: "ACTIVE", Metadata: owned},
+			synced:   true,
+			expected: true,
+		},
+		{
+			name:     "failed and unregistered after the node state synced",
+			server:   servers.Server{ID: serverID, Status: "ERROR", Tags: &[]string{membership.NodeGroupTagPrefix + testGroupID}},
+			synced:   true,
+			expected: true,
+		},
+		{
+			name:     "name only",
+			server:   servers.Server{ID: serverID, Name: testGroupID + "-1", Status: "BUILD"},
+			synced:   true,
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &OpenStackProvider{}
+			ng := &OpenStackNodeGroup{Config: testNodeGroupConfig(), Provider: p, members: membership.NewRegistry()}
+			if tt.node != nil {
+				p.NodeChanged(tt.node)
+			}
+			if tt.synced {
+				p.NodesSynced()
+			}
+
+			if got := ng.canDecreaseTarget(&tt.server); got != tt.expected {
+				t.Errorf("canDecreaseTarget() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNodeDeletedForgetsNode(t *testing.T) {
+	const serverID = "00000000-0000-4000-8000-000000000001"
+	p := &OpenStackProvider{}
+	ng := &OpenStackNodeGroup{Config: testNodeGroupConfig(), Provider: p, members: membership.NewRegistry()}
+	server := &servers.Server{ID: serverID, Status: "ACTIVE", Metadata: map[string]string{membership.NodeGroupMetadataKey: testGroupID}}
+	node := testNode("workers-a", server.ID)
+
+	p.NodeChanged(node)
+	p.NodesSynced()
+	if ng.canDecreaseTarget(server) {
+		t.Fatal("expected a registered server to be kept")
+	}
+	p.NodeDeleted(node)
+	if !ng.canDecreaseTarget(server) {
+		t.Error("expected the server to qualify once its node was deleted")
+	}
+}
+
+func TestRestartAdoptsExistingServers(t *testing.T) {
+	logs := captureLogs(t)
+	cloud := newTestCloud(t)
+
+	// The previous run scaled the node group up
+	previous := newTestProvider(t, cloud)
+	th.AssertNoErr(t, newTestNodeGroup(t, previous).IncreaseSize(2))
+	th.AssertNoErr(t, previous.Shutdown(5*time.Second))
+
+	metadataOnly := cloud.AddServer(fakecloud.Server{Name: "node-1", FlavorID: testFlavorID, ImageID: testImageID,
+		Metadata: map[string]string{membership.NodeGroupMetadataKey: testGroupID}})
+	nameOnly := cloud.AddServer(fakecloud.Server{Name: "legacy-" + testGroupID + "-1", FlavorID: testFlavorID, ImageID: testImageID})
+	foreign := cloud.AddServer(fakecloud.Server{Name: "backup-" + testGroupID, FlavorID: testFlavorID, ImageID: testImageID,
+		Metadata: map[string]string{membership.NodeGroupMetadataKey: "databases"}})
+
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+
+	instances, err := ng.Nodes()
+	th.AssertNoErr(t, err)
+	adopted := map[string]bool{}
+	for _, instance := range instances {
+		adopted[instance.ID] = true
+	}
+	th.AssertEquals(t, 4, len(adopted))
+	th.AssertEquals(t, true, adopted[metadataOnly])
+	th.AssertEquals(t, true, adopted[nameOnly])
+	th.AssertEquals(t, false, adopted[foreign])
+
+	size, err := ng.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 4, size)
+
+	klog.Flush()
+	if !strings.Contains(logs.String(), nameOnly) || strings.Contains(logs.String(), foreign) {
+		t.Errorf("expected a warning about the server attributed by its name only, got:\n%s", logs.String())
+	}
+}
+
+func TestAdoptServerWithoutOwnershipMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		adopted  bool
+		repaired bool
+	}{
+		{name: "known member is adopted and repaired", adopted: true, repaired: true},
+		{name: "strict ownership forgets the member", strict: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				cfg.Cloud.StrictOwnershipMetadata = tt.strict
+			})
+			ng := newTestNodeGroup(t, p)
+			// Named unlike the node group, so it is not attributed by its name once stripped
+			serverID := addMember(cloud, testGroupID, "node-1")
+			instances, err := ng.Nodes()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 1, len(instances))
+
+			// Other tooling strips the metadata and tags
+			cloud.UpdateServer(serverID, func(server *fakecloud.Server) {
+				server.Metadata = nil
+				server.Tags = nil
+			})
+			p.invalidateServerSnapshot()
+
+			instances, err = ng.Nodes()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.adopted, len(instances) == 1)
+			th.AssertEquals(t, tt.adopted, ng.members.Known(serverID))
+			server, _ := cloud.Server(serverID)
+			th.AssertEquals(t, tt.repaired, server.Metadata[membership.NodeGroupMetadataKey] == testGroupID)
+
+			// Scaling to one node creates no duplicate of an adopted member
+			size, err := ng.TargetSize()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, len(instances), size)
+			if size < 1 {
+				th.AssertNoErr(t, ng.IncreaseSize(1-size))
+			}
+			if tt.adopted {
+				th.AssertEquals(t, 1, len(cloud.Servers()))
+			} else {
+				th.AssertEquals(t, 2, len(cloud.Servers()))
+			}
+		})
+	}
+}
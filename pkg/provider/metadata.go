@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"fmt"
+)
+
+const (
+	// labelMetadataPrefix prefixes the node labels of a node group in the server metadata
+	labelMetadataPrefix = "k8s-label-"
+
+	// maxMetadataItems is the default Nova quota of metadata items per server
+	maxMetadataItems = 128
+	// maxMetadataLength is the maximum length of a Nova metadata key or value
+	maxMetadataLength = 255
+	// reservedMetadataItems is the number of metadata items the autoscaler may add itself:
+	// ownership, cluster, creation reason, scale-up operation, key pair and the four topology labels
+	reservedMetadataItems = 10
+)
+
+// labelMetadata returns the node labels of the node group as server metadata items, so the
+// labels can be reconciled onto the node from the server
+func (ng *OpenStackNodeGroup) labelMetadata() map[string]string {
+	metadata := make(map[string]string, len(ng.Config.Labels))
+	for k, v := range ng.Config.Labels {
+		metadata[labelMetadataPrefix+k] = v
+	}
+	return metadata
+}
+
+// validateMetadata checks that the server metadata fits the Nova limits
+func (ng *OpenStackNodeGroup) validateMetadata() error {
+	items := len(ng.Config.Metadata) + len(ng.Config.Labels) + reservedMetadataItems
+	if items > maxMetadataItems {
+		return fmt.Errorf("metadata and labels need %d server metadata items, Nova allows %d", items, maxMetadataItems)
+	}
+
+	for k, v := range ng.Config.Metadata {
+		if len(k) > maxMetadataLength || len(v) > maxMetadataLength {
+			return fmt.Errorf("metadata %s exceeds the Nova limit of %d characters", k, maxMetadataLength)
+		}
+	}
+	for k, v := range ng.labelMetadata() {
+		if len(k) > maxMetadataLength || len(v) > maxMetadataLength {
+			return fmt.Errorf("label %s exceeds the Nova metadata limit of %d characters", k[len(labelMetadataPrefix):], maxMetadataLength)
+		}
+	}
+	return nil
+}
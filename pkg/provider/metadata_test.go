@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestCreateServerStampsLabelMetadata(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.Labels = map[string]string{"role": "worker", "example.com/tier": "batch"}
+		cfg.Metadata = map[string]string{"owner": "platform"}
+	})
+
+	th.AssertNoErr(t, ng.IncreaseSize(1))
+	created := cloud.Servers()
+	th.AssertEquals(t, 1, len(created))
+
+	metadata := created[0].Metadata
+	th.AssertEquals(t, "worker", metadata[labelMetadataPrefix+"role"])
+	th.AssertEquals(t, "batch", metadata[labelMetadataPrefix+"example.com/tier"])
+	th.AssertEquals(t, "platform", metadata["owner"])
+	th.AssertEquals(t, testGroupID, metadata[membership.NodeGroupMetadataKey])
+	if _, exists := metadata["role"]; exists {
+		t.Error("expected labels to be written with the label prefix only")
+	}
+}
+
+func TestValidateMetadataLimits(t *testing.T) {
+	manyLabels := make(map[string]string)
+	for i := range maxMetadataItems - reservedMetadataItems + 1 {
+		manyLabels[fmt.Sprintf("label-%d", i)] = "value"
+	}
+	fittingLabels := make(map[string]string)
+	for i := range maxMetadataItems - reservedMetadataItems {
+		fittingLabels[fmt.Sprintf("label-%d", i)] = "value"
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		metadata map[string]string
+		err      string
+	}{
+		{name: "within the limits", labels: fittingLabels},
+		{name: "too many labels", labels: manyLabels, err: "Nova allows 128"},
+		{name: "labels and metadata together exceed the items", labels: fittingLabels, metadata: map[string]string{"owner": "platform"}, err: "need 129 server metadata items"},
+		{name: "label value too long", labels: map[string]string{"role": strings.Repeat("x", maxMetadataLength+1)}, err: "label role exceeds"},
+		{name: "prefixed label key too long", labels: map[string]string{strings.Repeat("k", maxMetadataLength-len(labelMetadataPrefix)+1): "value"}, err: "exceeds the Nova metadata limit"},
+		{name: "metadata value too long", metadata: map[string]string{"owner": strings.Repeat("x", maxMetadataLength+1)}, err: "metadata owner exceeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			cfg := testNodeGroupConfig()
+			cfg.Labels = tt.labels
+			cfg.Metadata = tt.metadata
+
+			_, err := p.AddNodeGroup(cfg)
+			if tt.err == "" {
+				th.AssertNoErr(t, err)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
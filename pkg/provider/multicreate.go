@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/nodegroup"
+)
+
+// useMultiCreate reports whether a scale-up by delta requests its servers with one multi-create
+// request per availability zone instead of one request per server
+func (ng *OpenStackNodeGroup) useMultiCreate(delta int) bool {
+	if !ng.Config.MultiCreate || delta < 2 {
+		return false
+	}
+	if conflict := ng.multiCreateConflict(); conflict != "" {
+		klog.V(2).Infof("Creating the servers of node group %s one per request: %s", ng.Config.ID, conflict)
+		return false
+	}
+	return true
+}
+
+// multiCreateConflict returns why the servers of the node group need one create request each,
+// empty when servers of one availability zone can share a request
+func (ng *OpenStackNodeGroup) multiCreateConflict() string {
+	switch {
+	case ng.Provider.DryRun():
+		return "dry-run logs each server"
+	case ng.Config.RootVolume != nil && ng.Config.RootVolume.AvailabilityZone != "":
+		return "root volumes pinned to an availability zone are created per server"
+	case ng.Config.FloatingIPPool != "":
+		return "floating IPs are attached per server"
+	case ng.Config.WaitForActive:
+		return "waitForActive deletes failed servers one by one"
+	case ng.Config.UserDataTemplate:
+		return "user data templates are rendered per server name"
+	}
+	for _, network := range ng.networkConfigs() {
+		if network.SubnetID != "" {
+			return "ports pinned to a subnet are created per server"
+		}
+	}
+	return ""
+}
+
+// multiCreateServers creates the planned servers with one multi-create request per availability
+// zone and returns how many servers Nova launched before ctx was cancelled, how many of those were
+// created and the errors of the others. Nova launching fewer servers than requested is reported as
+// exhausted quota, the missing servers count as never launched. Servers finding no valid host in
+// their zone are created again one at a time in the remaining zones.
+func (ng *OpenStackNodeGroup) multiCreateServers(ctx context.Context, op *scaleUpOperation, reason string, planned []PlannedServer, placement *nodegroup.Placement) (int, int, []error) {
+	// One request per zone, in the order of the plan
+	var zones []string
+	counts := make(map[string]int)
+	for _, server := range planned {
+		if counts[server.AvailabilityZone] == 0 {
+			zones = append(zones, server.AvailabilityZone)
+		}
+		counts[server.AvailabilityZone]++
+	}
+
+	var (
+		launched, created, index int
+		errs                     []error
+	)
+	for _, zone := range zones {
+		if ctx.Err() != nil {
+			break
+		}
+		count := counts[zone]
+		first := index
+		index += count
+
+		serverIDs, err := ng.multiCreate(op, reason, first, count, zone)
+		switch {
+		case len(serverIDs) == 0:
+			// Nova rejected the whole request, its servers failed to create like single requests do
+			launched += count
+			ng.recordOutcome(err)
+			ng.recordFailure(err)
+			klog.Errorf("Failed to create %d servers for node group %s in availability zone %q: %v", count, ng.Config.ID, zone, err)
+			errs = append(errs, err)
+			continue
+		case err != nil:
+			// Nova launched the servers but not all of them were found, the others are adopted
+			// by their metadata on the next listing
+			klog.Errorf("Failed to look up the servers of node group %s created in availability zone %q: %v", ng.Config.ID, zone, err)
+			launched += count
+			errs = append(errs, err)
+		case len(serverIDs) < count:
+			klog.Warningf("Nova created %d of %d servers for node group %s in availability zone %q", len(serverIDs), count, ng.Config.ID, zone)
+			launched += len(serverIDs)
+			errs = append(errs, fmt.Errorf("%w: Nova created %d of %d servers for node group %s in availability zone %q", ErrQuotaExceeded, len(serverIDs), count, ng.Config.ID, zone))
+			for range count - len(serverIDs) {
+				placement.Release(zone)
+			}
+		default:
+			launched += count
+		}
+
+		for i, serverID := range serverIDs {
+			err := ng.settleMultiCreatedServer(op, reason, first+i, serverID, zone, placement)
+			ng.recordOutcome(err)
+			if err != nil {
+				ng.recordFailure(err)
+				klog.Errorf("Failed to create server %d/%d for node group %s: %v", first+i+1, len(planned), ng.Config.ID, err)
+				errs = append(errs, err)
+				continue
+			}
+			created++
+		}
+	}
+	return launched, created, errs
+}
+
+// multiCreate requests count servers in the availability zone with one create request and
+// returns the IDs of the servers Nova launched, at least one unless an error is returned
+func (ng *OpenStackNodeGroup) multiCreate(op *scaleUpOperation, reason string, index, count int, zone string) ([]string, error) {
+	imageID, err := ng.getImageID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image ID: %w", err)
+	}
+	flavor, err := ng.getFlavor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flavor: %w", err)
+	}
+	serverName, err := ng.serverName(index)
+	if err != nil {
+		return nil, err
+	}
+
+	createOpts, err := ng.serverCreateOpts(op, reason, serverName, imageID, flavor, zone)
+	if err != nil {
+		return nil, err
+	}
+	createOpts.Min = 1
+	createOpts.Max = count
+
+	// Without subnets no ports are created up front, which multiCreateConflict makes sure of
+	networks, _, err := ng.buildNetworks(serverName, createOpts.SecurityGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare networks: %w", err)
+	}
+	if len(networks) > 0 {
+		createOpts.Networks = networks
+	}
+
+	schedulerHints, err := ng.schedulerHints()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare server group: %w", err)
+	}
+
+	if err := ng.waitForBudget(op.ctx); err != nil {
+		return nil, err
+	}
+	klog.Infof("Creating %d servers %s for node group %s in availability zone %q", count, serverName, ng.Config.ID, zone)
+	server, err := servers.Create(op.ctx, ng.Provider.computeClient(), ng.withKeyPair(createOpts), schedulerHints).Extract()
+	if err != nil {
+		return nil, ng.createError(err)
+	}
+	if count == 1 {
+		return []string{server.ID}, nil
+	}
+	return ng.multiCreatedServers(op, serverName, server.ID)
+}
+
+// multiCreatedServers returns the IDs of the servers a multi-create request of the scale-up
+// launched, firstID being the one Nova returned. Nova names the servers of a request launching
+// more than one after the request with a "-<n>" suffix.
+func (ng *OpenStackNodeGroup) multiCreatedServers(op *scaleUpOperation, serverName, firstID string) ([]string, error) {
+	// Nova matches the name filter as a regular expression
+	listOpts := servers.ListOpts{Name: "^" + regexp.QuoteMeta(serverName) + "(-[0-9]+)?$"}
+	allPages, err := servers.List(ng.Provider.computeClient(), listOpts).AllPages(op.ctx)
+	if err != nil {
+		return []string{firstID}, fmt.Errorf("failed to list servers named %s: %w", serverName, wrapOpenStackError(err))
+	}
+	allServers, err := servers.ExtractServers(allPages)
+	if err != nil {
+		return []string{firstID}, fmt.Errorf("failed to extract servers: %w", err)
+	}
+
+	sort.Slice(allServers, func(i, j int) bool { return allServers[i].Name < allServers[j].Name })
+	var serverIDs []string
+	for _, server := range allServers {
+		if server.Metadata[scaleUpOperationMetadataKey] == op.id {
+			klog.Infof("Server %s (%s) created successfully for node group %s", server.Name, server.ID, ng.Config.ID)
+			serverIDs = append(serverIDs, server.ID)
+		}
+	}
+	if len(serverIDs) == 0 {
+		return []string{firstID}, fmt.Errorf("no server named %s of scale-up %s found", serverName, op.id)
+	}
+	return serverIDs, nil
+}
+
+// settleMultiCreatedServer tracks a server launched by a multi-create request. A server without
+// a valid host in its zone has been deleted, it is created again one at a time in the remaining
+// zones.
+func (ng *OpenStackNodeGroup) settleMultiCreatedServer(op *scaleUpOperation, reason string, index int, serverID, zone string, placement *nodegroup.Placement) error {
+	ng.members.Remember(serverID)
+	err := ng.settleNewServer(serverID, zone)
+	if err == nil {
+		op.record(serverID)
+		return nil
+	}
+	if !isNoValidHost(err) || placement.Size() < 2 {
+		return err
+	}
+
+	klog.Warningf("No valid host for node group %s in availability zone %s, trying the next zone: %v", ng.Config.ID, zone, err)
+	placement.Release(zone)
+	tried := map[string]bool{zone: true}
+	next, _ := placement.Reserve(tried)
+	return ng.createServerInZones(op, reason, index, next, placement, tried)
+}
@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// createRequests returns how many create server requests the cloud received
+func createRequests(cloud *fakecloud.Cloud) int {
+	count := 0
+	for _, request := range cloud.RequestLog() {
+		if request.Method == http.MethodPost && request.Path == fakecloud.ComputePath+"/servers" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMultiCreate(t *testing.T) {
+	multiCreatedName := regexp.MustCompile(`^workers-[a-z0-9]{5}-[0-9]+$`)
+	tests := []struct {
+		name      string
+		zones     []string
+		full      []string
+		delta     int
+		limits    fakecloud.Limits
+		configure func(*config.NodeGroupConfig)
+		requests  int
+		created   []string
+		err       error
+		target    int
+	}{
+		{name: "one request per zone", zones: []string{"az1", "az2"}, delta: 4, requests: 2, created: []string{"az1", "az1", "az2", "az2"}, target: 4},
+		{name: "without zones", delta: 3, requests: 1, created: []string{"", "", ""}, target: 3},
+		{
+			name: "partial scheduling", delta: 5, limits: fakecloud.Limits{MaxInstances: 3},
+			configure: func(cfg *config.NodeGroupConfig) { cfg.RollbackOnFailure = new(bool) },
+			requests:  1, created: []string{"", "", ""}, err: ErrQuotaExceeded, target: 3,
+		},
+		{name: "partial scheduling rolled back", delta: 5, limits: fakecloud.Limits{MaxInstances: 3}, requests: 1, err: ErrQuotaExceeded, target: 0},
+		{
+			// Servers without a host in their zone are created again one per request
+			name: "no valid host in a zone", zones: []string{"az1", "az2"}, full: []string{"az1"}, delta: 4,
+			requests: 4, created: []string{"az2", "az2", "az2", "az2"}, target: 4,
+		},
+		{
+			name: "user data template", delta: 3,
+			configure: func(cfg *config.NodeGroupConfig) {
+				cfg.UserData = "#cloud-config\nhostname: {{.ServerName}}\n"
+				cfg.UserDataTemplate = true
+			},
+			requests: 3, created: []string{"", "", ""}, target: 3,
+		},
+		{name: "single server", delta: 1, requests: 1, created: []string{""}, target: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.SetLimits(tt.limits)
+			cloud.OnCreate(func(server *fakecloud.Server) {
+				for _, zone := range tt.full {
+					if server.AvailabilityZone == zone {
+						server.Status = fakecloud.StatusError
+						server.Fault = "No valid host was found. There are not enough hosts available."
+					}
+				}
+			})
+			// Nova enforces the quota, the scale-up is not refused up front
+			p := newTestProvider(t, cloud, func(cfg *config.Config) { cfg.Cloud.SkipQuotaCheck = true })
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.AvailabilityZones = tt.zones
+				cfg.MultiCreate = true
+				if tt.configure != nil {
+					tt.configure(cfg)
+				}
+			})
+
+			err := ng.IncreaseSize(tt.delta)
+			if tt.err == nil {
+				th.AssertNoErr(t, err)
+			} else if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			th.AssertEquals(t, tt.requests, createRequests(cloud))
+
+			var created []string
+			for _, server := range cloud.Servers() {
+				created = append(created, server.AvailabilityZone)
+				if tt.requests < tt.delta && len(tt.full) == 0 && !multiCreatedName.MatchString(server.Name) {
+					t.Errorf("server %s is not named after its multi-create request", server.Name)
+				}
+			}
+			sort.Strings(created)
+			th.AssertDeepEquals(t, tt.created, created)
+
+			// Every created server is a member and the target counts only them
+			nodes, err := ng.Nodes()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, len(tt.created), len(nodes))
+			target, err := ng.TargetSize()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.target, target)
+		})
+	}
+}
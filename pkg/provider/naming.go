@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// defaultNameTemplate names servers after the node group with a random suffix
+	defaultNameTemplate = "{{.NodeGroupID}}-{{.Suffix}}"
+	// nameSuffixLength is the length of the random suffix available to name templates
+	nameSuffixLength = 5
+	// maxServerNameLength is the maximum length of a DNS-1123 label
+	maxServerNameLength = 63
+)
+
+// ServerNameTemplateData holds the variables available to server name templates:
+//
+//	{{.NodeGroupID}}  ID of the node group
+//	{{.Suffix}}       random 5 character suffix
+//	{{.Index}}        index of the server within the scale-up
+type ServerNameTemplateData struct {
+	NodeGroupID string
+	Suffix      string
+	Index       int
+}
+
+// parseNameTemplate parses the configured server name template, or the default one
+func (ng *OpenStackNodeGroup) parseNameTemplate() (*template.Template, error) {
+	nameTemplate := ng.Config.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = defaultNameTemplate
+	}
+
+	tmpl, err := template.New(ng.Config.ID + "-name").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// serverName renders a DNS-1123 compliant name for a new server
+func (ng *OpenStackNodeGroup) serverName(index int) (string, error) {
+	data := ServerNameTemplateData{
+		NodeGroupID: ng.Config.ID,
+		Suffix:      utilrand.String(nameSuffixLength),
+		Index:       index,
+	}
+
+	var rendered bytes.Buffer
+	if err := ng.nameTemplate.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render server name: %w", err)
+	}
+
+	name := sanitizeServerName(rendered.String())
+	if len(name) > maxServerNameLength {
+		// Keep the random suffix so truncated names stay unique
+		name = strings.TrimRight(name[:maxServerNameLength-nameSuffixLength-1], "-") + "-" + data.Suffix
+	}
+	if name == "" {
+		return "", fmt.Errorf("name template renders an empty server name")
+	}
+	return name, nil
+}
+
+// sanitizeServerName lowercases a name and replaces characters invalid in DNS-1123 labels
+func sanitizeServerName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, name)
+
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	return strings.Trim(name, "-")
+}
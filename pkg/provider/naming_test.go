@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// dns1123Label matches the server names Nova and the kubelet accept as node names
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+func TestIncreaseSizeNamesServersUniquely(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.MaxSize = 50
+	})
+
+	th.AssertNoErr(t, ng.IncreaseSize(50))
+
+	created := cloud.Servers()
+	th.AssertEquals(t, 50, len(created))
+	names := make(map[string]bool, len(created))
+	for _, server := range created {
+		if names[server.Name] {
+			t.Errorf("server name %s used twice", server.Name)
+		}
+		names[server.Name] = true
+		if !strings.HasPrefix(server.Name, testGroupID+"-") || !dns1123Label.MatchString(server.Name) {
+			t.Errorf("unexpected server name %s", server.Name)
+		}
+	}
+}
+
+func TestServerName(t *testing.T) {
+	tests := []struct {
+		name         string
+		groupID      string
+		nameTemplate string
+		index        int
+		pattern      string
+		err          string
+	}{
+		{name: "default template", groupID: "workers", pattern: `^workers-[a-z0-9]{5}$`},
+		{name: "index", groupID: "workers", nameTemplate: "{{.NodeGroupID}}-{{.Index}}-{{.Suffix}}", index: 7, pattern: `^workers-7-[a-z0-9]{5}$`},
+		{name: "sanitized", groupID: "GPU_Workers", nameTemplate: "k8s.{{.NodeGroupID}}--{{.Suffix}}", pattern: `^k8s-gpu-workers-[a-z0-9]{5}$`},
+		{name: "truncated keeping the suffix", groupID: strings.Repeat("a", 70), pattern: `^a{57}-[a-z0-9]{5}$`},
+		{name: "empty name", groupID: "workers", nameTemplate: "{{/* nothing */}}", err: "renders an empty server name"},
+		{name: "unknown variable", groupID: "workers", nameTemplate: "{{.Zone}}", err: "failed to render server name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ng := &OpenStackNodeGroup{Config: &config.NodeGroupConfig{ID: tt.groupID, NameTemplate: tt.nameTemplate}}
+			nameTemplate, err := ng.parseNameTemplate()
+			th.AssertNoErr(t, err)
+			ng.nameTemplate = nameTemplate
+
+			name, err := ng.serverName(tt.index)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			if !regexp.MustCompile(tt.pattern).MatchString(name) {
+				t.Errorf("expected name matching %s, got %s", tt.pattern, name)
+			}
+			if len(name) > maxServerNameLength || !dns1123Label.MatchString(name) {
+				t.Errorf("expected a DNS-1123 label, got %s", name)
+			}
+		})
+	}
+}
+
+func TestAddNodeGroupRejectsInvalidNameTemplate(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	cfg := testNodeGroupConfig()
+	cfg.NameTemplate = "{{.NodeGroupID"
+
+	_, err := p.AddNodeGroup(cfg)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse name template") {
+		t.Fatalf("expected a name template error, got %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/subnets"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// portWaitTimeout bounds how long we wait for Nova to plug a port into a new server
+	portWaitTimeout = 2 * time.Minute
+	// portPollInterval is the interval between port lookups while waiting
+	portPollInterval = 5 * time.Second
+	// portDescription marks ports pre-created by the autoscaler, Nova leaves them behind on server deletion
+	portDescription = "created by openstack-autoscaler"
+	// floatingIPDescription marks floating IPs allocated by the autoscaler, only those are reused and released
+	floatingIPDescription = "created by openstack-autoscaler"
+	// legacyFloatingIPDescriptionPrefix starts the description of floating IPs allocated by earlier versions
+	legacyFloatingIPDescriptionPrefix = "openstack-autoscaler node group "
+)
+
+// resolveNetworkID resolves a Neutron network by ID or name
+func (p *OpenStackProvider) resolveNetworkID(nameOrID string) (string, error) {
+	if p.networkClient() == nil {
+		return "", fmt.Errorf("network service is not available")
+	}
+
+	network, err := networks.Get(context.TODO(), p.networkClient(), nameOrID).Extract()
+	if err == nil {
+		return network.ID, nil
+	}
+	if !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return "", fmt.Errorf("failed to get network %s: %w", nameOrID, wrapOpenStackError(err))
+	}
+
+	allPages, err := networks.List(p.networkClient(), networks.ListOpts{Name: nameOrID}).AllPages(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to list networks: %w", wrapOpenStackError(err))
+	}
+
+	allNetworks, err := networks.ExtractNetworks(allPages)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract networks: %w", err)
+	}
+
+	if len(allNetworks) == 0 {
+		return "", configErrorf("network %s not found", nameOrID)
+	}
+	if len(allNetworks) > 1 {
+		return "", configErrorf("network name %s is ambiguous, %d networks match", nameOrID, len(allNetworks))
+	}
+
+	return allNetworks[0].ID, nil
+}
+
+// waitForServerPort waits up to timeout until Nova has plugged a port into the server and returns it
+func (p *OpenStackProvider) waitForServerPort(ctx context.Context, serverID string, timeout time.Duration) (*ports.Port, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		allPages, err := ports.List(p.networkClient(), ports.ListOpts{DeviceID: serverID}).AllPages(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ports of server %s: %w", serverID, wrapOpenStackError(err))
+		}
+
+		serverPorts, err := ports.ExtractPorts(allPages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract ports: %w", err)
+		}
+
+		for _, port := range serverPorts {
+			if len(port.FixedIPs) > 0 {
+				return &port, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("server %s has no port with a fixed IP after %s", serverID, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(portPollInterval):
+		}
+	}
+}
+
+// isAutoscalerFloatingIP reports whether the autoscaler allocated a floating IP
+func isAutoscalerFloatingIP(fip *floatingips.FloatingIP) bool {
+	return fip.Description == floatingIPDescription || strings.HasPrefix(fip.Description, legacyFloatingIPDescriptionPrefix)
+}
+
+// associateFloatingIP associates a free floating IP the autoscaler allocated earlier, or a newly
+// allocated one, from pool with the port. Floating IPs allocated by anyone else are never taken.
+func (p *OpenStackProvider) associateFloatingIP(pool string, port *ports.Port) (*floatingips.FloatingIP, error) {
+	poolNetworkID, err := p.resolveNetworkID(pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve floating IP pool: %w", err)
+	}
+
+	// Reuse an unassociated floating IP before allocating a new one
+	listOpts := floatingips.ListOpts{FloatingNetworkID: poolNetworkID, Description: floatingIPDescription}
+	allPages, err := floatingips.List(p.networkClient(), listOpts).AllPages(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list floating IPs: %w", wrapOpenStackError(err))
+	}
+
+	allFloatingIPs, err := floatingips.ExtractFloatingIPs(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract floating IPs: %w", err)
+	}
+
+	for _, fip := range allFloatingIPs {
+		if fip.PortID != "" || !isAutoscalerFloatingIP(&fip) {
+			continue
+		}
+
+		portID := port.ID
+		updated, err := floatingips.Update(context.TODO(), p.networkClient(), fip.ID, floatingips.UpdateOpts{PortID: &portID}).Extract()
+		if err != nil {
+			klog.Warningf("Failed to reuse floating IP %s, trying next: %v", fip.FloatingIP, wrapOpenStackError(err))
+			continue
+		}
+		return updated, nil
+	}
+
+	createOpts := floatingips.CreateOpts{
+		Description:       floatingIPDescription,
+		FloatingNetworkID: poolNetworkID,
+		PortID:            port.ID,
+	}
+
+	fip, err := floatingips.Create(context.TODO(), p.networkClient(), createOpts).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusConflict) {
+			return nil, fmt.Errorf("%w: floating IP pool %s is exhausted: %w", ErrQuotaExceeded, pool, wrapOpenStackError(err))
+		}
+		return nil, fmt.Errorf("failed to allocate floating IP from pool %s: %w", pool, wrapOpenStackError(err))
+	}
+
+	return fip, nil
+}
+
+// releaseFloatingIPs releases the floating IPs the autoscaler associated with the ports of a
+// server. With keep set they are only disassociated and stay allocated to the project. Floating
+// IPs allocated by anyone else are left alone, Neutron disassociates them with the port.
+func (p *OpenStackProvider) releaseFloatingIPs(serverID string, keep bool) error {
+	allPages, err := ports.List(p.networkClient(), ports.ListOpts{DeviceID: serverID}).AllPages(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to list ports of server %s: %w", serverID, wrapOpenStackError(err))
+	}
+
+	serverPorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		return fmt.Errorf("failed to extract ports: %w", err)
+	}
+
+	for _, port := range serverPorts {
+		allPages, err := floatingips.List(p.networkClient(), floatingips.ListOpts{PortID: port.ID}).AllPages(context.TODO())
+		if err != nil {
+			return fmt.Errorf("failed to list floating IPs of port %s: %w", port.ID, wrapOpenStackError(err))
+		}
+
+		portFloatingIPs, err := floatingips.ExtractFloatingIPs(allPages)
+		if err != nil {
+			return fmt.Errorf("failed to extract floating IPs: %w", err)
+		}
+
+		for _, fip := range portFloatingIPs {
+			if !isAutoscalerFloatingIP(&fip) {
+				klog.Infof("Leaving floating IP %s of server %s alone, it was not allocated by the autoscaler", fip.FloatingIP, serverID)
+				continue
+			}
+			if keep {
+				noPort := ""
+				if _, err := floatingips.Update(context.TODO(), p.networkClient(), fip.ID, floatingips.UpdateOpts{PortID: &noPort}).Extract(); err != nil {
+					return fmt.Errorf("failed to disassociate floating IP %s: %w", fip.FloatingIP, wrapOpenStackError(err))
+				}
+				klog.Infof("Disassociated floating IP %s from server %s", fip.FloatingIP, serverID)
+				continue
+			}
+
+			if err := floatingips.Delete(context.TODO(), p.networkClient(), fip.ID).ExtractErr(); err != nil {
+				return fmt.Errorf("failed to release floating IP %s: %w", fip.FloatingIP, wrapOpenStackError(err))
+			}
+			klog.Infof("Released floating IP %s of server %s", fip.FloatingIP, serverID)
+		}
+	}
+
+	return nil
+}
+
+// resolveSecurityGroupIDs resolves security groups given by name or ID to their IDs
+func (p *OpenStackProvider) resolveSecurityGroupIDs(namesOrIDs []string) ([]string, error) {
+	ids := make([]string, 0, len(namesOrIDs))
+	for _, nameOrID := range namesOrIDs {
+		allPages, err := groups.List(p.networkClient(), groups.ListOpts{Name: nameOrID}).AllPages(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list security groups: %w", wrapOpenStackError(err))
+		}
+
+		allGroups, err := groups.ExtractGroups(allPages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract security groups: %w", err)
+		}
+
+		switch len(allGroups) {
+		case 0:
+			group, err := groups.Get(context.TODO(), p.networkClient(), nameOrID).Extract()
+			if err != nil {
+				return nil, fmt.Errorf("security group %s not found: %w", nameOrID, wrapOpenStackError(err))
+			}
+			ids = append(ids, group.ID)
+		case 1:
+			ids = append(ids, allGroups[0].ID)
+		default:
+			return nil, configErrorf("security group name %s is ambiguous, %d groups match", nameOrID, len(allGroups))
+		}
+	}
+	return ids, nil
+}
+
+// createPort creates a port with a fixed IP on the given subnet
+func (p *OpenStackProvider) createPort(name, networkID, subnetID, fixedIP string, securityGroups []string) (*ports.Port, error) {
+	if p.networkClient() == nil {
+		return nil, fmt.Errorf("network service is not available")
+	}
+
+	createOpts := ports.CreateOpts{
+		Name:        name,
+		Description: portDescription,
+		NetworkID:   networkID,
+		FixedIPs:    []ports.IP{{SubnetID: subnetID, IPAddress: fixedIP}},
+	}
+
+	if len(securityGroups) > 0 {
+		securityGroupIDs, err := p.resolveSecurityGroupIDs(securityGroups)
+		if err != nil {
+			return nil, err
+		}
+		createOpts.SecurityGroups = &securityGroupIDs
+	}
+
+	port, err := ports.Create(context.TODO(), p.networkClient(), createOpts).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create port on subnet %s: %w", subnetID, wrapOpenStackError(err))
+	}
+
+	return port, nil
+}
+
+// listCreatedPorts returns the IDs of ports pre-created by the autoscaler that are attached to a server
+func (p *OpenStackProvider) listCreatedPorts(serverID string) ([]string, error) {
+	allPages, err := ports.List(p.networkClient(), ports.ListOpts{DeviceID: serverID, Description: portDescription}).AllPages(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ports of server %s: %w", serverID, wrapOpenStackError(err))
+	}
+
+	serverPorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract ports: %w", err)
+	}
+
+	portIDs := make([]string, 0, len(serverPorts))
+	for _, port := range serverPorts {
+		portIDs = append(portIDs, port.ID)
+	}
+	return portIDs, nil
+}
+
+// deletePort deletes a port, treating an already deleted port as success
+func (p *OpenStackProvider) deletePort(portID string) error {
+	err := ports.Delete(context.TODO(), p.networkClient(), portID).ExtractErr()
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete port %s: %w", portID, wrapOpenStackError(err))
+	}
+	return nil
+}
+
+// validateNetworks checks that the networks, subnets, security groups and floating IP pool
+// of the node group exist, skipping the ones that are not configured
+func (ng *OpenStackNodeGroup) validateNetworks() error {
+	networkConfigs := ng.networkConfigs()
+	if len(networkConfigs) == 0 && len(ng.Config.SecurityGroups) == 0 && ng.Config.FloatingIPPool == "" {
+		return nil
+	}
+	if ng.Provider.networkClient() == nil {
+		return fmt.Errorf("network service is not available")
+	}
+
+	for _, network := range networkConfigs {
+		networkID, err := ng.Provider.resolveNetworkID(network.NetworkID)
+		if err != nil {
+			return err
+		}
+		// Nova and port creation take the configured value verbatim
+		if networkID != network.NetworkID {
+			return configErrorf("network %s must be given by ID, use %s", network.NetworkID, networkID)
+		}
+
+		if network.SubnetID == "" {
+			continue
+		}
+		subnet, err := subnets.Get(context.TODO(), ng.Provider.networkClient(), network.SubnetID).Extract()
+		if err != nil {
+			if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return configErrorf("subnet %s not found", network.SubnetID)
+			}
+			return fmt.Errorf("failed to get subnet %s: %w", network.SubnetID, wrapOpenStackError(err))
+		}
+		if subnet.NetworkID != networkID {
+			return configErrorf("subnet %s belongs to network %s, not %s", network.SubnetID, subnet.NetworkID, networkID)
+		}
+	}
+
+	if len(ng.Config.SecurityGroups) > 0 {
+		if _, err := ng.Provider.resolveSecurityGroupIDs(ng.Config.SecurityGroups); err != nil {
+			return err
+		}
+	}
+
+	if ng.Config.FloatingIPPool != "" {
+		if _, err := ng.Provider.resolveNetworkID(ng.Config.FloatingIPPool); err != nil {
+			return fmt.Errorf("floating IP pool: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestAssociateFloatingIPReusesOnlyOwnFloatingIPs(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []fakecloud.FloatingIP
+		reused    int
+		allocated bool
+	}{
+		{
+			name:      "no free floating IP",
+			allocated: true,
+		},
+		{
+			name:     "free floating IP of the autoscaler",
+			existing: []fakecloud.FloatingIP{{Description: floatingIPDescription}},
+			reused:   0,
+		},
+		{
+			name:      "free floating IP allocated by someone else",
+			existing:  []fakecloud.FloatingIP{{Description: "bastion"}},
+			allocated: true,
+		},
+		{
+			name:     "free floating IPs of the autoscaler and someone else",
+			existing: []fakecloud.FloatingIP{{}, {Description: floatingIPDescription}},
+			reused:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			poolID := cloud.AddNetwork(fakecloud.Network{Name: "public", External: true})
+			portID := cloud.AddPort(fakecloud.Port{NetworkID: "private", DeviceID: "server", FixedIPs: []string{"10.0.0.5"}})
+			existingIDs := make([]string, len(tt.existing))
+			for i, fip := range tt.existing {
+				fip.NetworkID = poolID
+				existingIDs[i] = cloud.AddFloatingIP(fip)
+			}
+
+			fip, err := p.associateFloatingIP("public", &ports.Port{ID: portID})
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, portID, fip.PortID)
+			th.AssertEquals(t, floatingIPDescription, fip.Description)
+			if tt.allocated {
+				th.AssertEquals(t, len(tt.existing)+1, len(cloud.FloatingIPs()))
+				return
+			}
+			th.AssertEquals(t, existingIDs[tt.reused], fip.ID)
+			th.AssertEquals(t, len(tt.existing), len(cloud.FloatingIPs()))
+		})
+	}
+}
+
+func TestReleaseFloatingIPsLeavesForeignFloatingIPs(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		keep        bool
+		remaining   int
+		associated  bool
+	}{
+		{name: "own floating IP", description: floatingIPDescription},
+		{name: "own floating IP of an earlier version", description: legacyFloatingIPDescriptionPrefix + testGroupID},
+		{name: "own floating IP kept", description: floatingIPDescription, keep: true, remaining: 1},
+		{name: "foreign floating IP", description: "bastion", remaining: 1, associated: true},
+		{name: "foreign floating IP with keep", description: "", keep: true, remaining: 1, associated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			poolID := cloud.AddNetwork(fakecloud.Network{Name: "public", External: true})
+			portID := cloud.AddPort(fakecloud.Port{NetworkID: "private", DeviceID: "server", FixedIPs: []string{"10.0.0.5"}})
+			cloud.AddFloatingIP(fakecloud.FloatingIP{NetworkID: poolID, PortID: portID, Description: tt.description})
+
+			th.AssertNoErr(t, p.releaseFloatingIPs("server", tt.keep))
+
+			remaining := cloud.FloatingIPs()
+			th.AssertEquals(t, tt.remaining, len(remaining))
+			if len(remaining) > 0 {
+				th.AssertEquals(t, tt.associated, remaining[0].PortID == portID)
+			}
+		})
+	}
+}
+
+func TestCreateServerAttachesFloatingIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		wait      bool
+		exhausted bool
+		err       string
+	}{
+		{name: "in the background"},
+		{name: "in the background from an exhausted pool", exhausted: true},
+		{name: "waiting for the server", wait: true},
+		{name: "waiting for the server from an exhausted pool", wait: true, exhausted: true, err: "failed to attach floating IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			cloud.AddNetwork(fakecloud.Network{Name: "private"})
+			poolID := cloud.AddNetwork(fakecloud.Network{Name: "public", External: true})
+			if tt.exhausted {
+				cloud.AddFloatingIP(fakecloud.FloatingIP{NetworkID: poolID, Description: "bastion", PortID: "bastion"})
+				cloud.SetLimits(fakecloud.Limits{MaxFloatingIPs: 1})
+			}
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.FloatingIPPool = "public"
+				cfg.ProvisionTimeout = time.Minute
+				cfg.WaitForActive = tt.wait
+			})
+
+			err := ng.IncreaseSize(1)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				th.AssertEquals(t, 0, len(cloud.Servers()))
+				return
+			}
+			th.AssertNoErr(t, err)
+			created := cloud.Servers()
+			th.AssertEquals(t, 1, len(created))
+			serverID := created[0].ID
+
+			if tt.exhausted {
+				// The server is left to the cluster autoscaler, which backs off the node group
+				waitFor(t, func() bool { return ng.ProvisioningFailure(serverID) != nil })
+				failure := ng.ProvisioningFailure(serverID)
+				th.AssertEquals(t, true, failure.OutOfResources)
+				th.AssertEquals(t, 1, len(cloud.Servers()))
+				return
+			}
+
+			waitFor(t, func() bool {
+				for _, fip := range cloud.FloatingIPs() {
+					if fip.Description == floatingIPDescription && fip.PortID != "" {
+						return true
+					}
+				}
+				return false
+			})
+			th.AssertEquals(t, true, ng.ProvisioningFailure(serverID) == nil)
+		})
+	}
+}
+
+// waitFor polls until condition holds
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeleteNodesReleasesFloatingIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		keep      bool
+		remaining int
+	}{
+		{name: "released"},
+		{name: "kept in the project", keep: true, remaining: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			cloud.AddNetwork(fakecloud.Network{Name: "private"})
+			cloud.AddNetwork(fakecloud.Network{Name: "public", External: true})
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.FloatingIPPool = "public"
+				cfg.KeepFloatingIPOnDelete = tt.keep
+				cfg.ProvisionTimeout = time.Minute
+				cfg.WaitForActive = true
+			})
+
+			th.AssertNoErr(t, ng.IncreaseSize(1))
+			created := cloud.Servers()
+			th.AssertEquals(t, 1, len(created))
+			th.AssertEquals(t, 1, len(cloud.FloatingIPs()))
+
+			th.AssertNoErr(t, ng.DeleteNodes([]*apiv1.Node{testNode(created[0].Name, created[0].ID)}))
+
+			th.AssertEquals(t, 0, len(cloud.Servers()))
+			remaining := cloud.FloatingIPs()
+			th.AssertEquals(t, tt.remaining, len(remaining))
+			for _, fip := range remaining {
+				th.AssertEquals(t, "", fip.PortID)
+			}
+		})
+	}
+}
+
+func TestValidateNetworks(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.NodeGroupConfig, ids map[string]string)
+		err       string
+	}{
+		{name: "nothing configured", configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {}},
+		{
+			name: "present network, subnet and security groups",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.NetworkID = ids["private"]
+				cfg.SubnetID = ids["private-subnet"]
+				cfg.SecurityGroups = []string{"nodes", ids["ssh"]}
+				cfg.FloatingIPPool = "public"
+			},
+		},
+		{
+			name: "missing network",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.NetworkID = "99999999-9999-4999-8999-999999999999"
+			},
+			err: "network 99999999-9999-4999-8999-999999999999 not found",
+		},
+		{
+			name: "network given by name",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.NetworkID = "private"
+			},
+			err: "network private must be given by ID",
+		},
+		{
+			name: "missing subnet",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.NetworkID = ids["private"]
+				cfg.SubnetID = "99999999-9999-4999-8999-999999999999"
+			},
+			err: "subnet 99999999-9999-4999-8999-999999999999 not found",
+		},
+		{
+			name: "subnet of another network",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.Networks = []config.NetworkConfig{{NetworkID: ids["public"], SubnetID: ids["private-subnet"]}}
+			},
+			err: "belongs to network",
+		},
+		{
+			name: "missing security group",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.SecurityGroups = []string{"nodes", "typo"}
+			},
+			err: "security group typo not found",
+		},
+		{
+			name: "missing floating IP pool",
+			configure: func(cfg *config.NodeGroupConfig, ids map[string]string) {
+				cfg.FloatingIPPool = "external"
+			},
+			err: "floating IP pool: network external not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ids := map[string]string{
+				"private": cloud.AddNetwork(fakecloud.Network{Name: "private"}),
+				"public":  cloud.AddNetwork(fakecloud.Network{Name: "public", External: true}),
+				"nodes":   cloud.AddSecurityGroup(fakecloud.SecurityGroup{Name: "nodes"}),
+				"ssh":     cloud.AddSecurityGroup(fakecloud.SecurityGroup{Name: "ssh"}),
+			}
+			ids["private-subnet"] = cloud.AddSubnet(fakecloud.Subnet{NetworkID: ids["private"], CIDR: "10.0.0.0/24"})
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) { tt.configure(cfg, ids) })
+
+			err := ng.validateNetworks()
+			if tt.err == "" {
+				th.AssertNoErr(t, err)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+			th.AssertEquals(t, true, IsConfigurationError(err))
+		})
+	}
+}
@@ -2,39 +2,124 @@ package provider
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
+	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/keypairs"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
-	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"golang.org/x/time/rate"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/nodegroup"
 	"github.com/bucher-brothers/openstack-autoscaler/internal/utils"
 	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
 )
 
+const (
+	// defaultMaxPods is the pods capacity of template nodes, the kubelet default
+	defaultMaxPods = 110
+
+	// defaultTemplateCacheTTL is how long a template node is reused before it is rebuilt
+	defaultTemplateCacheTTL = 10 * time.Minute
+
+	// scaleDownTrimPolicyPartial fails DeleteNodes for nodes beyond maxScaleDownBatch
+	scaleDownTrimPolicyPartial = "partial"
+	// scaleDownTrimPolicyTrim silently ignores nodes beyond maxScaleDownBatch
+	scaleDownTrimPolicyTrim = "trim"
+)
+
 // OpenStackNodeGroup represents a node group in OpenStack
 type OpenStackNodeGroup struct {
 	Config   *config.NodeGroupConfig
 	Provider *OpenStackProvider
 	mutex    sync.RWMutex
 
+	// Serializes scaling operations so each one computes sizes from the servers the previous left,
+	// shared with the node group replacing this one on update
+	operationMutex *sync.Mutex
+
+	// User data resolved from UserData or UserDataFile
+	userData         string
+	userDataTemplate *template.Template
+
+	// Template rendering the names of new servers
+	nameTemplate *template.Template
+
+	// Last image ID the image name resolved to, reused while Glance is unavailable
+	resolvedImageID string
+	resolvedImageAt time.Time
+	imageMutex      sync.Mutex
+
+	// Server group created for the node group
+	serverGroupID string
+
+	// Per availability zone server counts of the last listing, reported in the debug string
+	lastZoneDistribution map[string]int
+	zonesMutex           sync.Mutex
+
+	// Target size maintained by scaling operations, unknown until first restored or observed
+	target *nodegroup.Target
+
+	// Ready member node the template node is modelled on
+	liveNode         *apiv1.Node
+	liveNodeServerID string
+	liveNodeMutex    sync.Mutex
+
 	// Cache for template node info
 	templateNodeInfo *apiv1.Node
 	lastRefresh      time.Time
+
+	// Servers known to be members and servers attributed to the node group by their name
+	members *membership.Registry
+
+	// Set once every member carries the node group tag, listings then use the tags filter
+	tagsMigrated atomic.Bool
+
+	// Recent server operations and the quarantine they caused, shared with the node group
+	// replacing this one on update
+	quarantine *nodegroup.Quarantine
+
+	// Share of the server operation rate, shared with the node group replacing this one on update
+	budget *rate.Limiter
+
+	// Request IDs of the most recent failed OpenStack calls
+	failedRequestIDs []string
+	lastFailure      time.Time
+	failuresMutex    sync.Mutex
+
+	// Bumped on every configuration update, guarded by the provider mutex
+	resourceVersion uint64
+}
+
+// ResourceVersion returns the version of the node group configuration
+func (ng *OpenStackNodeGroup) ResourceVersion() uint64 {
+	ng.Provider.mutex.RLock()
+	defer ng.Provider.mutex.RUnlock()
+	return ng.resourceVersion
 }
 
 // NewOpenStackNodeGroup creates a new OpenStack node group
 func NewOpenStackNodeGroup(cfg *config.NodeGroupConfig, provider *OpenStackProvider) (*OpenStackNodeGroup, error) {
 	ng := &OpenStackNodeGroup{
-		Config:   cfg,
-		Provider: provider,
+		Config:          cfg,
+		Provider:        provider,
+		members:         membership.NewRegistry(),
+		target:          nodegroup.NewTarget(cfg.ID, provider.sizeStore),
+		resourceVersion: 1,
+		operationMutex:  &sync.Mutex{},
+		quarantine:      &nodegroup.Quarantine{},
+		budget:          rate.NewLimiter(rate.Inf, 1),
 	}
 
 	// Validate configuration
@@ -42,6 +127,20 @@ func NewOpenStackNodeGroup(cfg *config.NodeGroupConfig, provider *OpenStackProvi
 		return nil, fmt.Errorf("invalid node group configuration: %w", err)
 	}
 
+	// Load user data
+	userData, userDataTemplate, err := ng.loadUserData()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user data: %w", err)
+	}
+	ng.userData = userData
+	ng.userDataTemplate = userDataTemplate
+
+	nameTemplate, err := ng.parseNameTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid node group configuration: %w", err)
+	}
+	ng.nameTemplate = nameTemplate
+
 	return ng, nil
 }
 
@@ -56,11 +155,92 @@ func (ng *OpenStackNodeGroup) validateConfig() error {
 	if ng.Config.MaxSize < ng.Config.MinSize {
 		return fmt.Errorf("maxSize (%d) must be >= minSize (%d)", ng.Config.MaxSize, ng.Config.MinSize)
 	}
-	if ng.Config.FlavorName == "" {
-		return fmt.Errorf("flavorName is required")
+	if ng.Config.FlavorName == "" && ng.Config.FlavorID == "" {
+		return fmt.Errorf("either flavorName or flavorId is required")
+	}
+	if ng.Config.ImageName == "" && ng.Config.ImageID == "" && len(ng.Config.ImageTags) == 0 {
+		return fmt.Errorf("either imageName, imageTags or imageId is required")
+	}
+	if ng.Config.ImageNameIsPrefix && ng.Config.ImageName == "" {
+		return fmt.Errorf("imageNameIsPrefix requires imageName")
+	}
+	if ng.Config.EphemeralStorageGB < 0 {
+		return fmt.Errorf("ephemeralStorageGB cannot be negative")
+	}
+	if ng.Config.EphemeralStorageReservedGB < 0 {
+		return fmt.Errorf("ephemeralStorageReservedGB cannot be negative")
+	}
+	if ng.Config.GPUCount < 0 {
+		return fmt.Errorf("gpuCount cannot be negative")
+	}
+	if _, err := ng.reservedResources(); err != nil {
+		return err
+	}
+	if err := ng.validateMetadata(); err != nil {
+		return err
+	}
+	if err := ng.validateTags(); err != nil {
+		return err
+	}
+	if ng.Config.ProvisionTimeout < 0 {
+		return fmt.Errorf("provisionTimeout cannot be negative")
+	}
+	if ng.Config.WaitForActive && ng.Config.ProvisionTimeout == 0 {
+		return fmt.Errorf("waitForActive requires a provisionTimeout")
+	}
+	if ng.Config.MaxPods < 0 {
+		return fmt.Errorf("maxPods cannot be negative")
+	}
+	for i, taint := range ng.Config.ExpectedTaints {
+		switch apiv1.TaintEffect(taint.Effect) {
+		case apiv1.TaintEffectNoSchedule, apiv1.TaintEffectPreferNoSchedule, apiv1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("expectedTaints[%d]: invalid effect %q", i, taint.Effect)
+		}
+		if taint.Key == "" {
+			return fmt.Errorf("expectedTaints[%d]: key is required", i)
+		}
+	}
+	if sg := ng.Config.ServerGroup; sg != nil && (sg.ID == "") == (sg.Policy == "") {
+		return fmt.Errorf("serverGroup requires exactly one of id or policy")
+	}
+	if ng.Config.AvailabilityZone != "" && len(ng.Config.AvailabilityZones) > 0 {
+		return fmt.Errorf("availabilityZone and availabilityZones cannot be combined")
+	}
+	if options := ng.Config.AutoscalingOptions; options != nil {
+		if err := options.Validate(); err != nil {
+			return fmt.Errorf("autoscalingOptions: %w", err)
+		}
+	}
+	if ng.Config.MaxScaleDownBatch < 0 {
+		return fmt.Errorf("maxScaleDownBatch cannot be negative")
 	}
-	if ng.Config.ImageName == "" && ng.Config.ImageID == "" {
-		return fmt.Errorf("either imageName or imageId is required")
+	switch ng.Config.ScaleDownTrimPolicy {
+	case "", scaleDownTrimPolicyPartial, scaleDownTrimPolicyTrim:
+	default:
+		return fmt.Errorf("scaleDownTrimPolicy must be %q or %q, got %q", scaleDownTrimPolicyPartial, scaleDownTrimPolicyTrim, ng.Config.ScaleDownTrimPolicy)
+	}
+	if ng.Config.SubnetID != "" && ng.Config.NetworkID == "" {
+		return fmt.Errorf("networkId is required when subnetId is set")
+	}
+	if len(ng.Config.Networks) > 0 && (ng.Config.NetworkID != "" || ng.Config.SubnetID != "") {
+		return fmt.Errorf("networkId and subnetId cannot be combined with networks")
+	}
+	for i, network := range ng.Config.Networks {
+		if network.NetworkID == "" {
+			return fmt.Errorf("networks[%d]: networkId is required", i)
+		}
+	}
+	if ng.Config.RootVolume != nil && ng.Config.RootVolume.SizeGiB <= 0 {
+		return fmt.Errorf("rootVolume.sizeGiB must be positive")
+	}
+	for key, value := range ng.Config.ExpectedNodeLabels {
+		if isComputedLabel(key) {
+			return fmt.Errorf("expectedNodeLabels cannot override computed label %s", key)
+		}
+		if labelValue, exists := ng.Config.Labels[key]; exists && labelValue != value {
+			return fmt.Errorf("expectedNodeLabels %s=%s conflicts with labels %s=%s", key, value, key, labelValue)
+		}
 	}
 	return nil
 }
@@ -82,49 +262,137 @@ func (ng *OpenStackNodeGroup) MaxSize() int {
 
 // TargetSize returns the current target size of the node group
 func (ng *OpenStackNodeGroup) TargetSize() (int, error) {
-	instances, err := ng.getInstances()
+	size, err := ng.desiredSize()
 	if err != nil {
+		ng.recordFailure(err)
 		return 0, fmt.Errorf("failed to get instances: %w", err)
 	}
-
-	// Count only running and creating instances
-	count := 0
-	for _, instance := range instances {
-		if instance.Status == "ACTIVE" || instance.Status == "BUILD" {
-			count++
-		}
-	}
-
-	return count, nil
+	return size, nil
 }
 
-// IncreaseSize increases the size of the node group
+// IncreaseSize increases the size of the node group on behalf of the cluster autoscaler
 func (ng *OpenStackNodeGroup) IncreaseSize(delta int) error {
-	if delta <= 0 {
-		return fmt.Errorf("delta must be positive, got %d", delta)
+	return ng.IncreaseSizeWithReason(context.Background(), delta, ReasonClusterAutoscaler)
+}
+
+// IncreaseSizeWithReason increases the size of the node group, recording the reason on the new servers.
+// Once ctx is cancelled no further servers are launched, servers being created are completed.
+// A scale-up creating fewer servers than requested returns a *ScaleUpError.
+func (ng *OpenStackNodeGroup) IncreaseSizeWithReason(ctx context.Context, delta int, reason string) error {
+	reason = sanitizeReason(reason)
+	if err := ng.checkQuarantine(); err != nil {
+		return err
 	}
 
-	currentSize, err := ng.TargetSize()
+	ng.operationMutex.Lock()
+	defer ng.operationMutex.Unlock()
+
+	plan, err := ng.planIncrease(delta, true)
 	if err != nil {
-		return fmt.Errorf("failed to get current size: %w", err)
+		return err
+	}
+	if plan.Refusal != nil {
+		return plan.Refusal
+	}
+	placement := plan.placement(ng.availabilityZones())
+	defer ng.Provider.invalidateServerSnapshot()
+
+	// Without rollback failed creations keep the target, the cluster autoscaler decreases it when
+	// they do not register
+	ng.setDesiredSize(plan.CurrentSize + delta)
+
+	klog.Infof("Increasing node group %s from %d to %d nodes (reason: %s)", ng.Config.ID, plan.CurrentSize, plan.CurrentSize+delta, reason)
+
+	op := newScaleUpOperation(ctx)
+	var launched, created int
+	var errs []error
+	if ng.useMultiCreate(delta) {
+		launched, created, errs = ng.multiCreateServers(ctx, op, reason, plan.Create, placement)
+	} else {
+		launched, created, errs = ng.createServers(ctx, op, reason, plan.Create, placement)
 	}
 
-	newSize := currentSize + delta
-	if newSize > ng.Config.MaxSize {
-		return fmt.Errorf("cannot increase size to %d, max size is %d", newSize, ng.Config.MaxSize)
+	var cancelErr error
+	if launched < delta && ctx.Err() != nil {
+		klog.Warningf("Scale-up of node group %s cancelled after launching %d of %d servers", ng.Config.ID, launched, delta)
+		cancelErr = fmt.Errorf("scale-up cancelled: %w", ctx.Err())
+	}
+	if len(errs) == 0 && cancelErr == nil {
+		return nil
 	}
 
-	klog.Infof("Increasing node group %s from %d to %d nodes", ng.Config.ID, currentSize, newSize)
+	// A cancelled scale-up keeps the servers it created, only failed creations roll it back
+	if len(errs) > 0 && created > 0 && ng.rollbackOnFailure() {
+		// The cluster autoscaler retries the whole increase, so the target returns to where it was
+		orphans := ng.rollbackScaleUp(op)
+		ng.setDesiredSize(plan.CurrentSize + orphans)
+		created = orphans
+	} else if launched < delta {
+		// Servers never launched are not part of the target, unlike servers that failed to create
+		ng.adjustDesiredSize(launched - delta)
+	}
+	return &ScaleUpError{NodeGroupID: ng.Config.ID, Requested: delta, Created: created, Err: errors.Join(append(errs, cancelErr)...)}
+}
 
-	// Create new servers
-	for i := 0; i < delta; i++ {
-		if err := ng.createServer(); err != nil {
-			klog.Errorf("Failed to create server %d/%d for node group %s: %v", i+1, delta, ng.Config.ID, err)
-			return fmt.Errorf("failed to create server: %w", err)
+// createServers creates the planned servers one per request, a bounded number at a time, and
+// returns how many were launched before ctx was cancelled, how many of those were created and
+// the errors of the others
+func (ng *OpenStackNodeGroup) createServers(ctx context.Context, op *scaleUpOperation, reason string, planned []PlannedServer, placement *nodegroup.Placement) (int, int, []error) {
+	var (
+		wg       sync.WaitGroup
+		errsLock sync.Mutex
+		errs     []error
+	)
+	workers := make(chan struct{}, ng.Provider.createConcurrency())
+	launched := 0
+	for i, server := range planned {
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
 		}
+		launched++
+		wg.Add(1)
+		go func(index int, zone string) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			err := ng.createServerInZones(op, reason, index, zone, placement, make(map[string]bool))
+			ng.recordOutcome(err)
+			if err != nil {
+				ng.recordFailure(err)
+				klog.Errorf("Failed to create server %d/%d for node group %s: %v", index+1, len(planned), ng.Config.ID, err)
+				errsLock.Lock()
+				errs = append(errs, err)
+				errsLock.Unlock()
+			}
+		}(i, server.AvailabilityZone)
 	}
+	wg.Wait()
 
-	return nil
+	return launched, launched - len(errs), errs
+}
+
+// createServerInZones creates a server in the availability zone reserved for it by the plan,
+// moving on to the least populated remaining zone not yet tried when Nova finds no valid host in one
+func (ng *OpenStackNodeGroup) createServerInZones(op *scaleUpOperation, reason string, index int, zone string, placement *nodegroup.Placement, tried map[string]bool) error {
+	for {
+		err := ng.createServer(op, reason, index, zone)
+		if err == nil || zone == "" {
+			return err
+		}
+		placement.Release(zone)
+
+		tried[zone] = true
+		if !isNoValidHost(err) || len(tried) == placement.Size() {
+			return err
+		}
+		klog.Warningf("No valid host for node group %s in availability zone %s, trying the next zone: %v", ng.Config.ID, zone, err)
+
+		zone, _ = placement.Reserve(tried)
+	}
 }
 
 // DecreaseTargetSize decreases the target size of the node group
@@ -132,10 +400,24 @@ func (ng *OpenStackNodeGroup) DecreaseTargetSize(delta int) error {
 	if delta >= 0 {
 		return fmt.Errorf("delta must be negative, got %d", delta)
 	}
+	if err := ng.checkQuarantine(); err != nil {
+		return err
+	}
+
+	ng.operationMutex.Lock()
+	defer ng.operationMutex.Unlock()
+
+	instances, err := ng.getInstances()
+	if err != nil {
+		ng.recordFailure(err)
+		return fmt.Errorf("failed to get instances: %w", err)
+	}
+	defer ng.Provider.invalidateServerSnapshot()
 
-	currentSize, err := ng.TargetSize()
+	currentSize, err := ng.desiredSize()
 	if err != nil {
-		return fmt.Errorf("failed to get current size: %w", err)
+		ng.recordFailure(err)
+		return fmt.Errorf("failed to get target size: %w", err)
 	}
 
 	newSize := currentSize + delta // delta is negative
@@ -145,33 +427,130 @@ func (ng *OpenStackNodeGroup) DecreaseTargetSize(delta int) error {
 
 	klog.Infof("Decreasing node group %s from %d to %d nodes", ng.Config.ID, currentSize, newSize)
 
-	// We don't actually delete nodes here, just reduce the target size
-	// The cluster autoscaler will handle the actual node deletion
+	// Capacity that never materialized goes first, servers are only deleted beyond that
+	excess := observedSize(instances) - newSize
+	if excess <= 0 || ng.Provider.DryRun() {
+		ng.setDesiredSize(newSize)
+		return nil
+	}
+
+	candidates := ng.decreaseCandidates(instances, excess)
+	ng.setDesiredSize(newSize + excess - len(candidates))
+	for _, server := range candidates {
+		klog.Infof("Deleting server %s (%s) to decrease the target size of node group %s", server.Name, server.ID, ng.Config.ID)
+		err := ng.deleteServer(server.ID)
+		ng.recordOutcome(err)
+		if err != nil {
+			ng.recordFailure(err)
+			return fmt.Errorf("failed to delete server %s: %w", server.ID, err)
+		}
+		ng.members.Forget(server.ID)
+	}
+
+	if err := ng.releaseServerGroup(); err != nil {
+		klog.Errorf("Failed to release server group of node group %s: %v", ng.Config.ID, err)
+	}
+
+	if len(candidates) < excess {
+		return fmt.Errorf("only %d of %d servers of node group %s can be deleted, the others are protected or registered nodes not marked for deletion", len(candidates), excess, ng.Config.ID)
+	}
 	return nil
 }
 
-// DeleteNodes deletes the specified nodes from the group
+// DeleteNodes deletes the specified nodes from the group on behalf of the cluster autoscaler
 func (ng *OpenStackNodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	return ng.DeleteNodesWithReason(nodes, ReasonClusterAutoscaler)
+}
+
+// DeleteNodesWithReason deletes the specified nodes from the group, logging the reason with every deletion
+func (ng *OpenStackNodeGroup) DeleteNodesWithReason(nodes []*apiv1.Node, reason string) error {
 	if len(nodes) == 0 {
 		return nil
 	}
+	reason = sanitizeReason(reason)
+
+	if err := ng.checkQuarantine(); err != nil {
+		return err
+	}
 
-	klog.Infof("Deleting %d nodes from node group %s", len(nodes), ng.Config.ID)
+	ng.operationMutex.Lock()
+	defer ng.operationMutex.Unlock()
 
-	for _, node := range nodes {
-		if err := ng.deleteNode(node); err != nil {
-			klog.Errorf("Failed to delete node %s: %v", node.Name, err)
-			return fmt.Errorf("failed to delete node %s: %w", node.Name, err)
+	plan := ng.planDelete(nodes, true)
+	if plan.Refusal != nil {
+		return plan.Refusal
+	}
+	defer ng.Provider.invalidateServerSnapshot()
+
+	// Nodes that cannot be resolved to a server fail on their own without blocking the others
+	var unresolved []error
+	var limited []string
+	for _, rejected := range plan.Rejected {
+		if rejected.err != nil {
+			klog.Errorf("Failed to delete node %s: %v", rejected.Name, rejected.err)
+			unresolved = append(unresolved, fmt.Errorf("failed to delete node %s: %w", rejected.Name, rejected.err))
+			continue
 		}
+		limited = append(limited, rejected.Name)
+	}
+	if len(limited) > 0 {
+		klog.Infof("Limiting scale-down of node group %s to %d of %d nodes", ng.Config.ID, len(plan.Delete), len(plan.Delete)+len(limited))
 	}
 
-	return nil
+	klog.Infof("Deleting %d nodes from node group %s (reason: %s)", len(plan.Delete), ng.Config.ID, reason)
+
+	var (
+		wg       sync.WaitGroup
+		errsLock sync.Mutex
+		errs     []error
+	)
+	workers := make(chan struct{}, ng.Provider.deleteConcurrency())
+	for _, server := range plan.Delete {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(server PlannedServer) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			klog.Infof("Deleting server %s for node %s in node group %s (reason: %s)", server.ID, server.Name, ng.Config.ID, reason)
+			err := ng.deleteServer(server.ID)
+			ng.recordOutcome(err)
+			if err == nil {
+				ng.adjustDesiredSize(-1)
+			}
+			if err != nil {
+				ng.recordFailure(err)
+				klog.Errorf("Failed to delete node %s: %v", server.Name, err)
+				errsLock.Lock()
+				errs = append(errs, fmt.Errorf("failed to delete node %s: %w", server.Name, err))
+				errsLock.Unlock()
+			}
+		}(server)
+	}
+	wg.Wait()
+
+	if err := ng.releaseServerGroup(); err != nil {
+		klog.Errorf("Failed to release server group of node group %s: %v", ng.Config.ID, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete %d of %d nodes from node group %s: %w", len(errs), len(plan.Delete), ng.Config.ID, errors.Join(append(unresolved, errs...)...))
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("failed to resolve %d nodes of node group %s: %w", len(unresolved), ng.Config.ID, errors.Join(unresolved...))
+	}
+
+	if len(limited) == 0 || ng.Config.ScaleDownTrimPolicy == scaleDownTrimPolicyTrim {
+		return nil
+	}
+	return fmt.Errorf("%w: maxScaleDownBatch is %d, not deleted: %s", ErrScaleDownBatchLimit, ng.Config.MaxScaleDownBatch, strings.Join(limited, ", "))
 }
 
 // Nodes returns a list of all nodes in the group
 func (ng *OpenStackNodeGroup) Nodes() ([]servers.Server, error) {
 	instances, err := ng.getInstances()
 	if err != nil {
+		ng.recordFailure(err)
 		return nil, fmt.Errorf("failed to get instances: %w", err)
 	}
 
@@ -184,13 +563,14 @@ func (ng *OpenStackNodeGroup) TemplateNodeInfo() (*apiv1.Node, error) {
 	defer ng.mutex.Unlock()
 
 	// Use cached template if available and not too old
-	if ng.templateNodeInfo != nil && time.Since(ng.lastRefresh) < 10*time.Minute {
+	if ng.templateNodeInfo != nil && time.Since(ng.lastRefresh) < ng.templateCacheTTL() {
 		return ng.templateNodeInfo.DeepCopy(), nil
 	}
 
 	// Create template node info
 	node, err := ng.buildTemplateNodeInfo()
 	if err != nil {
+		ng.recordFailure(err)
 		return nil, fmt.Errorf("failed to build template node info: %w", err)
 	}
 
@@ -200,6 +580,14 @@ func (ng *OpenStackNodeGroup) TemplateNodeInfo() (*apiv1.Node, error) {
 	return node.DeepCopy(), nil
 }
 
+// templateCacheTTL returns how long the template node is cached
+func (ng *OpenStackNodeGroup) templateCacheTTL() time.Duration {
+	if ttl := ng.Provider.config.Load().Cloud.TemplateCacheTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultTemplateCacheTTL
+}
+
 // buildTemplateNodeInfo builds a template node info based on the node group configuration
 func (ng *OpenStackNodeGroup) buildTemplateNodeInfo() (*apiv1.Node, error) {
 	// Get flavor information
@@ -208,18 +596,31 @@ func (ng *OpenStackNodeGroup) buildTemplateNodeInfo() (*apiv1.Node, error) {
 		return nil, fmt.Errorf("failed to get flavor: %w", err)
 	}
 
+	// The arch label must never contradict the image
+	arch, err := ng.resolveArchitecture(flavor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve architecture: %w", err)
+	}
+	if arch == "" {
+		arch = defaultArchitecture
+	}
+	operatingSystem, err := ng.resolveOperatingSystem()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve operating system: %w", err)
+	}
+
 	// Create node template
 	node := &apiv1.Node{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: fmt.Sprintf("%s-template", ng.Config.ID),
 			Labels: map[string]string{
-				"kubernetes.io/arch":               "amd64",
-				"kubernetes.io/os":                 "linux",
+				"kubernetes.io/arch":               arch,
+				"kubernetes.io/os":                 operatingSystem,
 				"node.kubernetes.io/instance-type": flavor.Name,
 			},
 		},
 		Spec: apiv1.NodeSpec{
-			ProviderID: fmt.Sprintf("%s://template-%s", ProviderName, ng.Config.ID),
+			ProviderID: templateProviderID(ng.Config.ID),
 		},
 		Status: apiv1.NodeStatus{
 			Capacity: apiv1.ResourceList{
@@ -239,27 +640,118 @@ func (ng *OpenStackNodeGroup) buildTemplateNodeInfo() (*apiv1.Node, error) {
 		},
 	}
 
-	// Add custom labels from config
-	for k, v := range ng.Config.Labels {
+	gpus, _, err := ng.gpus(flavor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GPU count: %w", err)
+	}
+	if gpus > 0 {
+		gpuResource := apiv1.ResourceName(ng.gpuResourceName(flavor))
+		node.Status.Capacity[gpuResource] = *utils.ResourceQuantity(gpus)
+		node.Status.Allocatable[gpuResource] = *utils.ResourceQuantity(gpus)
+	}
+
+	maxPods := ng.Config.MaxPods
+	if maxPods <= 0 {
+		maxPods = defaultMaxPods
+	}
+	node.Status.Capacity[apiv1.ResourcePods] = *utils.ResourceQuantity(maxPods)
+	node.Status.Allocatable[apiv1.ResourcePods] = *utils.ResourceQuantity(maxPods)
+
+	// An explicit size wins, then the boot volume, which leaves the flavor disk unused, then the flavor disks
+	ephemeralStorageGB := ng.Config.EphemeralStorageGB
+	if ephemeralStorageGB == 0 {
+		if rootVolume := ng.Config.RootVolume; rootVolume != nil {
+			ephemeralStorageGB = rootVolume.SizeGiB
+		} else {
+			ephemeralStorageGB = flavor.Disk + flavor.Ephemeral
+		}
+	}
+	if ephemeralStorageGB > 0 {
+		allocatableGB := ephemeralStorageGB - ng.Config.EphemeralStorageReservedGB
+		if allocatableGB < 0 {
+			klog.Warningf("ephemeralStorageReservedGB of node group %s exceeds its %dGB of ephemeral storage", ng.Config.ID, ephemeralStorageGB)
+			allocatableGB = 0
+		}
+		node.Status.Capacity[apiv1.ResourceEphemeralStorage] = *utils.ResourceQuantityFromBytes(ephemeralStorageGB * 1024 * 1024 * 1024)
+		node.Status.Allocatable[apiv1.ResourceEphemeralStorage] = *utils.ResourceQuantityFromBytes(allocatableGB * 1024 * 1024 * 1024)
+	}
+
+	reserved, err := ng.reservedResources()
+	if err != nil {
+		return nil, err
+	}
+	ng.subtractReserved(node.Status.Allocatable, reserved)
+
+	node.Spec.Taints = ng.expectedTaints()
+
+	// Add custom labels from config, together with the GPU label
+	labels, err := ng.nodeLabels(flavor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node labels: %w", err)
+	}
+	for k, v := range labels {
+		node.Labels[k] = v
+	}
+
+	// Simulate the zone the next server would be created in
+	zone := ""
+	if zones := ng.availabilityZones(); len(zones) == 1 {
+		zone = zones[0]
+	} else if len(zones) > 1 {
+		distribution, err := ng.zoneDistribution()
+		if err != nil {
+			klog.Warningf("Failed to get availability zone distribution of node group %s: %v", ng.Config.ID, err)
+		}
+		zone = ng.zonesByPopulation(distribution)[0]
+	}
+	for k, v := range ng.topologyLabels(zone) {
+		node.Labels[k] = v
+	}
+
+	if live := ng.liveTemplateNode(); live != nil {
+		ng.applyLiveNode(node, live, flavor)
+	}
+
+	// Add labels the kubelet is expected to register so similar node groups compare equal
+	for k, v := range ng.Config.ExpectedNodeLabels {
 		node.Labels[k] = v
 	}
 
 	return node, nil
 }
 
-// ContainsNode checks if a server belongs to this node group
-func (ng *OpenStackNodeGroup) ContainsNode(server *servers.Server) bool {
-	// Check if server has the node group metadata
-	if nodeGroupID, exists := server.Metadata["nodegroup"]; exists {
-		return nodeGroupID == ng.Config.ID
+// expectedTaints returns the taints the kubelet registers nodes of the group with
+func (ng *OpenStackNodeGroup) expectedTaints() []apiv1.Taint {
+	var taints []apiv1.Taint
+	for _, taint := range ng.Config.ExpectedTaints {
+		taints = append(taints, apiv1.Taint{
+			Key:    taint.Key,
+			Value:  taint.Value,
+			Effect: apiv1.TaintEffect(taint.Effect),
+		})
 	}
+	return taints
+}
 
-	// Fallback: check if server name contains node group ID
-	return strings.Contains(server.Name, ng.Config.ID)
+// ContainsNode checks if a server belongs to this node group. Membership tags and the node
+// group metadata decide on their own, the server name is only consulted for servers carrying
+// neither, which predate the metadata.
+func (ng *OpenStackNodeGroup) ContainsNode(server *servers.Server) bool {
+	switch membership.Classify(server, ng.Config.ID) {
+	case membership.MatchedByTag, membership.MatchedByMetadata:
+		return true
+	case membership.MatchedByName:
+		if ng.members.FirstNameMatch(server.ID) {
+			klog.Warningf("Server %s (%s) has no %s metadata, attributing it to node group %s by its name", server.Name, server.ID, membership.NodeGroupMetadataKey, ng.Config.ID)
+		}
+		return true
+	default:
+		return false
+	}
 }
 
 // createServer creates a new server in OpenStack
-func (ng *OpenStackNodeGroup) createServer() error {
+func (ng *OpenStackNodeGroup) createServer(op *scaleUpOperation, reason string, index int, zone string) (err error) {
 	// Get image ID
 	imageID, err := ng.getImageID()
 	if err != nil {
@@ -272,10 +764,47 @@ func (ng *OpenStackNodeGroup) createServer() error {
 		return fmt.Errorf("failed to get flavor: %w", err)
 	}
 
+	serverName, err := ng.serverName(index)
+	if err != nil {
+		return err
+	}
+
+	if ng.Provider.DryRun() {
+		klog.Infof("Dry run: would create server %s for node group %s with flavor %s and image %s in availability zone %q (reason: %s)", serverName, ng.Config.ID, flavor.Name, imageID, zone, reason)
+		return nil
+	}
+
+	createOpts, err := ng.serverCreateOpts(op, reason, serverName, imageID, flavor, zone)
+	if err != nil {
+		return err
+	}
+
+	rootVolume := ng.Config.RootVolume
+	if rootVolume != nil && rootVolume.AvailabilityZone != "" {
+		return ng.createServerFromVolume(op, createOpts, rootVolume, imageID, zone)
+	}
+	return ng.launchServer(op, createOpts, zone)
+}
+
+// serverCreateOpts returns the create options of a server of a scale-up in the availability zone,
+// without its networks
+func (ng *OpenStackNodeGroup) serverCreateOpts(op *scaleUpOperation, reason, serverName, imageID string, flavor *flavors.Flavor, zone string) (servers.CreateOpts, error) {
 	// Prepare user data
-	userData := ng.Config.UserData
-	if userData != "" {
-		userData = base64.StdEncoding.EncodeToString([]byte(userData))
+	labels, err := ng.nodeLabels(flavor)
+	if err != nil {
+		return servers.CreateOpts{}, fmt.Errorf("failed to get node labels: %w", err)
+	}
+	topologyLabels := ng.topologyLabels(zone)
+	for k, v := range topologyLabels {
+		labels[k] = v
+	}
+	userData, err := ng.renderUserData(serverName, labels)
+	if err != nil {
+		return servers.CreateOpts{}, err
+	}
+	userData, err = encodeUserData(userData)
+	if err != nil {
+		return servers.CreateOpts{}, err
 	}
 
 	// Prepare metadata
@@ -283,64 +812,352 @@ func (ng *OpenStackNodeGroup) createServer() error {
 	for k, v := range ng.Config.Metadata {
 		metadata[k] = v
 	}
-	metadata["nodegroup"] = ng.Config.ID
-	metadata["created_by"] = "openstack-autoscaler"
+	for k, v := range ng.labelMetadata() {
+		metadata[k] = v
+	}
+	for k, v := range topologyLabels {
+		metadata[k] = v
+	}
+	metadata[membership.NodeGroupMetadataKey] = ng.Config.ID
+	metadata[membership.CreatedByMetadataKey] = membership.CreatedByMetadataValue
+	if clusterName := ng.Provider.config.Load().Cloud.ClusterName; clusterName != "" {
+		metadata[membership.ClusterMetadataKey] = clusterName
+	}
+	metadata[createdByReasonMetadataKey] = reason
+	metadata[scaleUpOperationMetadataKey] = op.id
 
 	// Prepare security groups
 	securityGroups := make([]string, len(ng.Config.SecurityGroups))
 	copy(securityGroups, ng.Config.SecurityGroups)
 
 	// Create server options
-	serverName := fmt.Sprintf("%s-%d", ng.Config.ID, time.Now().Unix())
 	createOpts := servers.CreateOpts{
 		Name:           serverName,
-		ImageRef:       imageID,
 		FlavorRef:      flavor.ID,
 		UserData:       []byte(userData),
 		Metadata:       metadata,
 		SecurityGroups: securityGroups,
 	}
 
+	rootVolume := ng.Config.RootVolume
+	if rootVolume == nil {
+		createOpts.ImageRef = imageID
+	} else if rootVolume.AvailabilityZone == "" {
+		createOpts.BlockDevice = imageVolumeMapping(imageID, rootVolume)
+	}
+
 	if ng.Config.KeyName != "" {
-		// SSH key will be handled in user data or metadata
+		// The metadata entry is informational only, the key pair is set on the request below
 		metadata["key_name"] = ng.Config.KeyName
 	}
 
-	if ng.Config.AvailabilityZone != "" {
-		createOpts.AvailabilityZone = ng.Config.AvailabilityZone
+	if zone != "" {
+		createOpts.AvailabilityZone = zone
 	}
 
-	// Add networks if specified
-	if ng.Config.NetworkID != "" {
-		createOpts.Networks = []servers.Network{
-			{UUID: ng.Config.NetworkID},
+	if ng.useConfigDrive() {
+		configDrive := true
+		createOpts.ConfigDrive = &configDrive
+	}
+	return createOpts, nil
+}
+
+// createServerFromVolume creates a server booting from a root volume created up front in the
+// availability zone of the root volume. Cinder may take minutes to build the volume, unless the
+// scale-up waits for its servers the server is created in the background once the volume is
+// available, a failure then takes the server back out of the target size.
+func (ng *OpenStackNodeGroup) createServerFromVolume(op *scaleUpOperation, createOpts servers.CreateOpts, rootVolume *config.RootVolumeConfig, imageID, zone string) error {
+	volumeID, err := ng.Provider.createBootVolume(createOpts.Name, imageID, rootVolume)
+	if err != nil {
+		return fmt.Errorf("failed to prepare root volume: %w", err)
+	}
+	createOpts.BlockDevice = bootVolumeMapping(volumeID, rootVolume)
+
+	launch := func(ctx context.Context) error {
+		err := ng.Provider.waitForVolume(ctx, volumeID)
+		if err == nil {
+			err = ng.launchServer(op, createOpts, zone)
+		}
+		if err != nil {
+			if cleanupErr := ng.Provider.deleteVolume(volumeID); cleanupErr != nil {
+				klog.Errorf("Failed to clean up volume %s after failed server creation: %v", volumeID, cleanupErr)
+			}
 		}
+		return err
+	}
+
+	if ng.Config.WaitForActive {
+		return launch(ng.Provider.ctx)
 	}
+	ng.Provider.RunBackground("root-volume-"+volumeID, func(ctx context.Context) {
+		err := launch(ctx)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		ng.recordFailure(err)
+		klog.Errorf("Failed to create server %s for node group %s: %v", createOpts.Name, ng.Config.ID, err)
+		ng.adjustDesiredSize(-1)
+	})
+	return nil
+}
 
+// launchServer creates the server of a scale-up with its networks and waits for what the node
+// group configuration requires before the server counts as created
+func (ng *OpenStackNodeGroup) launchServer(op *scaleUpOperation, createOpts servers.CreateOpts, zone string) (err error) {
+	serverName, securityGroups := createOpts.Name, createOpts.SecurityGroups
+
+	// Add networks if specified, pinning a subnet requires a pre-created port
+	networks, portIDs, err := ng.buildNetworks(serverName, securityGroups)
+	defer func() {
+		if err != nil {
+			for _, portID := range portIDs {
+				if cleanupErr := ng.Provider.deletePort(portID); cleanupErr != nil {
+					klog.Errorf("Failed to clean up port %s after failed server creation: %v", portID, cleanupErr)
+				}
+			}
+		}
+	}()
+	if err != nil {
+		return fmt.Errorf("failed to prepare networks: %w", err)
+	}
+	if len(networks) > 0 {
+		createOpts.Networks = networks
+	}
+	if len(portIDs) > 0 && len(portIDs) == len(networks) {
+		// Security groups are applied to the ports, there is no NIC left for Nova to apply them to
+		createOpts.SecurityGroups = nil
+	}
+
+	schedulerHints, err := ng.schedulerHints()
+	if err != nil {
+		return fmt.Errorf("failed to prepare server group: %w", err)
+	}
+
+	if err = ng.waitForBudget(op.ctx); err != nil {
+		return err
+	}
 	klog.Infof("Creating server %s for node group %s", serverName, ng.Config.ID)
-	server, err := servers.Create(context.TODO(), ng.Provider.computeClient, createOpts, nil).Extract()
+	server, err := servers.Create(op.ctx, ng.Provider.computeClient(), ng.withKeyPair(createOpts), schedulerHints).Extract()
 	if err != nil {
-		return fmt.Errorf("failed to create server: %w", err)
+		return ng.createError(err)
 	}
 
 	klog.Infof("Server %s (%s) created successfully for node group %s", server.Name, server.ID, ng.Config.ID)
+	ng.members.Remember(server.ID)
+	defer func() {
+		if err == nil {
+			op.record(server.ID)
+		}
+	}()
+
+	if err := ng.settleNewServer(server.ID, zone); err != nil {
+		return err
+	}
+
+	// Floating IPs are best-effort, the node is usable through its fixed IP. Unless the scale-up
+	// waits for its servers they are attached in the background once Nova plugged the port.
+	if ng.Config.FloatingIPPool != "" {
+		if !ng.Config.WaitForActive {
+			ng.attachFloatingIPInBackground(server.ID)
+			return nil
+		}
+
+		if fipErr := ng.attachFloatingIP(ng.Provider.ctx, server.ID, portWaitTimeout); fipErr != nil {
+			if !errors.Is(fipErr, ErrQuotaExceeded) {
+				klog.Errorf("Failed to attach floating IP to server %s in node group %s: %v", server.ID, ng.Config.ID, fipErr)
+				return nil
+			}
+
+			// An exhausted pool fails the scale-up so the autoscaler backs off
+			if deleteErr := ng.deleteServer(server.ID); deleteErr != nil {
+				klog.Errorf("Failed to delete server %s after floating IP allocation failed: %v", server.ID, deleteErr)
+			} else {
+				ng.members.Forget(server.ID)
+			}
+			return fmt.Errorf("failed to attach floating IP to server %s: %w", server.ID, fipErr)
+		}
+	}
+
+	return nil
+}
+
+// createError wraps the error of a create request, a full server group exhausts the quota of the
+// node group
+func (ng *OpenStackNodeGroup) createError(err error) error {
+	if isServerGroupFull(err) {
+		return fmt.Errorf("%w: server group of node group %s has reached its member limit: %w", ErrQuotaExceeded, ng.Config.ID, wrapOpenStackError(err))
+	}
+	return fmt.Errorf("failed to create server: %w", wrapOpenStackError(err))
+}
+
+// settleNewServer tags a new server and waits for what the node group configuration requires
+// before the server counts as created
+func (ng *OpenStackNodeGroup) settleNewServer(serverID, zone string) error {
+	if ng.Provider.tagsClient() != nil {
+		if tagErr := ng.tagNewServer(serverID); tagErr != nil {
+			// Fall back to metadata scanning until the next refresh tags the server
+			klog.Warningf("Failed to tag server %s of node group %s: %v", serverID, ng.Config.ID, tagErr)
+			ng.tagsMigrated.Store(false)
+		}
+	}
+
+	if ng.Config.WaitForActive {
+		return ng.awaitProvisioning(serverID)
+	}
+
+	// Nova reports a zone without capacity through the server fault, the zone fallback
+	// needs it before the scale-up moves on
+	if zone != "" && len(ng.availabilityZones()) > 1 {
+		if err := ng.awaitScheduling(serverID); err != nil {
+			return err
+		}
+	}
+	if ng.Config.ProvisionTimeout > 0 {
+		ng.watchProvisioning(serverID)
+	}
 	return nil
 }
 
-// deleteNode deletes a node from OpenStack
-func (ng *OpenStackNodeGroup) deleteNode(node *apiv1.Node) error {
-	// Extract server ID from provider ID
-	providerID := node.Spec.ProviderID
-	serverID := strings.TrimPrefix(providerID, ProviderName+"://")
-	if serverID == providerID {
-		return fmt.Errorf("invalid provider ID format: %s", providerID)
+// attachFloatingIPInBackground attaches a floating IP to a new server without holding up the
+// scale-up. An exhausted pool is recorded as an out-of-resources provisioning failure, the cluster
+// autoscaler then backs off from the node group and deletes the server itself.
+func (ng *OpenStackNodeGroup) attachFloatingIPInBackground(serverID string) {
+	// The port is plugged while the server builds, which may take as long as provisioning
+	timeout := max(portWaitTimeout, ng.Config.ProvisionTimeout)
+
+	ng.Provider.RunBackground("floating-ip-"+serverID, func(ctx context.Context) {
+		err := ng.attachFloatingIP(ctx, serverID, timeout)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrQuotaExceeded):
+			klog.Errorf("Failed to attach floating IP to server %s in node group %s: %v", serverID, ng.Config.ID, err)
+			ng.Provider.recordProvisioningFailure(serverID, ProvisioningFailure{
+				Reason:         fmt.Sprintf("failed to attach floating IP to server %s: %v", serverID, err),
+				OutOfResources: true,
+			})
+		case ctx.Err() == nil:
+			klog.Errorf("Failed to attach floating IP to server %s in node group %s: %v", serverID, ng.Config.ID, err)
+		}
+	})
+}
+
+// attachFloatingIP associates a floating IP from the configured pool with the server, waiting up
+// to portTimeout for its port
+func (ng *OpenStackNodeGroup) attachFloatingIP(ctx context.Context, serverID string, portTimeout time.Duration) error {
+	if ng.Provider.networkClient() == nil {
+		return fmt.Errorf("network service is not available")
+	}
+
+	port, err := ng.Provider.waitForServerPort(ctx, serverID, portTimeout)
+	if err != nil {
+		return err
+	}
+
+	fip, err := ng.Provider.associateFloatingIP(ng.Config.FloatingIPPool, port)
+	if err != nil {
+		return err
+	}
+
+	klog.Infof("Associated floating IP %s with server %s", fip.FloatingIP, serverID)
+	return nil
+}
+
+// withKeyPair adds the configured SSH key pair to the server create options
+func (ng *OpenStackNodeGroup) withKeyPair(createOpts servers.CreateOpts) servers.CreateOptsBuilder {
+	if ng.Config.KeyName == "" {
+		return createOpts
+	}
+	return keypairs.CreateOptsExt{
+		CreateOptsBuilder: createOpts,
+		KeyName:           ng.Config.KeyName,
+	}
+}
+
+// validateKeyPair checks that the configured SSH key pair exists
+func (ng *OpenStackNodeGroup) validateKeyPair() error {
+	if ng.Config.KeyName == "" {
+		return nil
+	}
+
+	_, err := keypairs.Get(context.TODO(), ng.Provider.computeClient(), ng.Config.KeyName, nil).Extract()
+	if err != nil {
+		return fmt.Errorf("key pair %s not found: %w", ng.Config.KeyName, wrapOpenStackError(err))
+	}
+	return nil
+}
+
+// networkConfigs returns the configured network attachments, including the legacy single network
+func (ng *OpenStackNodeGroup) networkConfigs() []config.NetworkConfig {
+	if len(ng.Config.Networks) > 0 {
+		return ng.Config.Networks
+	}
+	if ng.Config.NetworkID != "" {
+		return []config.NetworkConfig{{NetworkID: ng.Config.NetworkID, SubnetID: ng.Config.SubnetID}}
+	}
+	return nil
+}
+
+// buildNetworks returns the server network attachments in configuration order, creating ports
+// for attachments pinned to a subnet. The IDs of created ports are returned even on error.
+func (ng *OpenStackNodeGroup) buildNetworks(serverName string, securityGroups []string) ([]servers.Network, []string, error) {
+	var networks []servers.Network
+	var portIDs []string
+
+	for i, network := range ng.networkConfigs() {
+		if network.SubnetID == "" {
+			networks = append(networks, servers.Network{UUID: network.NetworkID, FixedIP: network.FixedIP})
+			continue
+		}
+
+		portName := fmt.Sprintf("%s-%d", serverName, i)
+		port, err := ng.Provider.createPort(portName, network.NetworkID, network.SubnetID, network.FixedIP, securityGroups)
+		if err != nil {
+			return nil, portIDs, err
+		}
+		portIDs = append(portIDs, port.ID)
+		networks = append(networks, servers.Network{Port: port.ID})
+	}
+
+	return networks, portIDs, nil
+}
+
+// deleteServer deletes a server of this node group and the resources attached to it
+func (ng *OpenStackNodeGroup) deleteServer(serverID string) error {
+	if ng.Provider.DryRun() {
+		klog.Infof("Dry run: would delete server %s of node group %s", serverID, ng.Config.ID)
+		return nil
+	}
+
+	// Release floating IPs first, Neutron only disassociates them when the server goes away
+	if ng.Config.FloatingIPPool != "" && ng.Provider.networkClient() != nil {
+		if err := ng.Provider.releaseFloatingIPs(serverID, ng.Config.KeepFloatingIPOnDelete); err != nil {
+			klog.Errorf("Failed to release floating IPs of server %s: %v", serverID, err)
+		}
 	}
 
-	klog.Infof("Deleting server %s for node %s in node group %s", serverID, node.Name, ng.Config.ID)
+	// Look up pre-created ports before the server goes away and detaches them
+	var portIDs []string
+	if ng.Provider.networkClient() != nil {
+		var err error
+		portIDs, err = ng.Provider.listCreatedPorts(serverID)
+		if err != nil {
+			klog.Errorf("Failed to look up ports of server %s: %v", serverID, err)
+		}
+	}
 
-	err := servers.Delete(context.TODO(), ng.Provider.computeClient, serverID).ExtractErr()
+	if err := ng.waitForBudget(ng.Provider.ctx); err != nil {
+		return err
+	}
+	err := servers.Delete(context.TODO(), ng.Provider.computeClient(), serverID).ExtractErr()
 	if err != nil {
-		return fmt.Errorf("failed to delete server %s: %w", serverID, err)
+		return fmt.Errorf("failed to delete server %s: %w", serverID, wrapOpenStackError(err))
+	}
+	ng.Provider.forgetProvisioning(serverID)
+
+	for _, portID := range portIDs {
+		if err := ng.Provider.deletePort(portID); err != nil {
+			klog.Errorf("Failed to delete port %s of server %s: %v", portID, serverID, err)
+		}
 	}
 
 	klog.Infof("Server %s deleted successfully", serverID)
@@ -349,87 +1166,125 @@ func (ng *OpenStackNodeGroup) deleteNode(node *apiv1.Node) error {
 
 // getInstances returns all instances belonging to this node group
 func (ng *OpenStackNodeGroup) getInstances() ([]servers.Server, error) {
-	// List all servers
-	allPages, err := servers.List(ng.Provider.computeClient, servers.ListOpts{}).AllPages(context.TODO())
-	if err != nil {
-		return nil, fmt.Errorf("failed to list servers: %w", err)
+	return ng.listInstances(true)
+}
+
+// listInstances returns all servers belonging to this node group. With track set the listing
+// updates the known members and restores the ownership metadata of adopted members, without it
+// nothing is changed, for plans that must not act.
+func (ng *OpenStackNodeGroup) listInstances(track bool) ([]servers.Server, error) {
+	if ng.Provider.tagsClient() != nil && ng.tagsMigrated.Load() {
+		return ng.getTaggedInstances(track)
 	}
 
-	allServers, err := servers.ExtractServers(allPages)
+	// All servers, with tags when supported so untagged members can be migrated
+	allServers, err := ng.Provider.listServers()
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract servers: %w", err)
+		return nil, err
 	}
 
 	// Filter servers belonging to this node group
 	var groupServers []servers.Server
+	existing := make(map[string]bool, len(allServers))
 	for _, server := range allServers {
+		existing[server.ID] = true
+
+		if membership.HasTag(&server, ng.Config.ID) {
+			groupServers = append(groupServers, server)
+			if track {
+				ng.members.Remember(server.ID)
+			}
+			continue
+		}
+
+		if _, hasOwnership := server.Metadata[membership.NodeGroupMetadataKey]; !hasOwnership {
+			// Metadata may have been stripped by other tooling, keep known members
+			adopted := ng.wouldAdopt(&server)
+			if track {
+				adopted = ng.adoptServer(&server)
+			}
+			if adopted {
+				groupServers = append(groupServers, server)
+				continue
+			}
+		}
+
 		if ng.ContainsNode(&server) {
 			groupServers = append(groupServers, server)
+			if track && server.Metadata[membership.NodeGroupMetadataKey] == ng.Config.ID {
+				ng.members.Remember(server.ID)
+			}
 		}
 	}
+	if track {
+		ng.members.Prune(existing)
+	}
 
 	return groupServers, nil
 }
 
-// getFlavor returns the flavor for this node group
+// getFlavor returns the flavor for this node group, resolved through the provider lookup cache.
+// The returned flavor is shared with other node groups and must not be modified.
 func (ng *OpenStackNodeGroup) getFlavor() (*flavors.Flavor, error) {
-	flavor, err := flavors.Get(context.TODO(), ng.Provider.computeClient, ng.Config.FlavorName).Extract()
+	key := "flavor-name:" + ng.Config.FlavorName
+	if ng.Config.FlavorID != "" {
+		key = "flavor-id:" + ng.Config.FlavorID
+	}
+
+	flavor, err := ng.Provider.cachedLookup(key, func() (interface{}, error) {
+		return ng.lookupFlavor()
+	})
 	if err != nil {
-		// Try to find flavor by name
-		allPages, err := flavors.ListDetail(ng.Provider.computeClient, flavors.ListOpts{}).AllPages(context.TODO())
-		if err != nil {
-			return nil, fmt.Errorf("failed to list flavors: %w", err)
-		}
+		return nil, err
+	}
+	return flavor.(*flavors.Flavor), nil
+}
 
-		allFlavors, err := flavors.ExtractFlavors(allPages)
+// lookupFlavor resolves the configured flavor ID or name through Nova
+func (ng *OpenStackNodeGroup) lookupFlavor() (*flavors.Flavor, error) {
+	if ng.Config.FlavorID != "" {
+		flavor, err := flavors.Get(context.TODO(), ng.Provider.computeClient(), ng.Config.FlavorID).Extract()
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract flavors: %w", err)
-		}
-
-		for _, f := range allFlavors {
-			if f.Name == ng.Config.FlavorName {
-				return &f, nil
+			if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return nil, configErrorf("flavor %s not found", ng.Config.FlavorID)
 			}
+			return nil, fmt.Errorf("failed to get flavor %s: %w", ng.Config.FlavorID, wrapOpenStackError(err))
 		}
-
-		return nil, fmt.Errorf("flavor %s not found", ng.Config.FlavorName)
-	}
-
-	return flavor, nil
-}
-
-// getImageID returns the image ID for this node group
-func (ng *OpenStackNodeGroup) getImageID() (string, error) {
-	if ng.Config.ImageID != "" {
-		return ng.Config.ImageID, nil
+		return flavor, nil
 	}
 
-	// Find image by name
-	listOpts := images.ListOpts{
-		Name: ng.Config.ImageName,
-	}
-
-	allPages, err := images.List(ng.Provider.imageClient, listOpts).AllPages(context.TODO())
+	// Find flavor by name, an ID given as the name is still accepted
+	allPages, err := flavors.ListDetail(ng.Provider.computeClient(), flavors.ListOpts{AccessType: flavors.AllAccess}).AllPages(context.TODO())
 	if err != nil {
-		return "", fmt.Errorf("failed to list images: %w", err)
+		return nil, fmt.Errorf("failed to list flavors: %w", wrapOpenStackError(err))
 	}
 
-	allImages, err := images.ExtractImages(allPages)
+	allFlavors, err := flavors.ExtractFlavors(allPages)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract images: %w", err)
+		return nil, fmt.Errorf("failed to extract flavors: %w", err)
 	}
 
-	if len(allImages) == 0 {
-		return "", fmt.Errorf("image %s not found", ng.Config.ImageName)
+	for i := range allFlavors {
+		if allFlavors[i].Name == ng.Config.FlavorName || allFlavors[i].ID == ng.Config.FlavorName {
+			return &allFlavors[i], nil
+		}
 	}
 
-	return allImages[0].ID, nil
+	return nil, configErrorf("flavor %s not found", ng.Config.FlavorName)
+}
+
+// useConfigDrive reports whether servers get a config drive, falling back to the cloud default
+func (ng *OpenStackNodeGroup) useConfigDrive() bool {
+	if ng.Config.ConfigDrive != nil {
+		return *ng.Config.ConfigDrive
+	}
+	return ng.Provider.config.Load().Cloud.ConfigDrive
 }
 
 // ValidateConfiguration validates the node group configuration against OpenStack
 func (ng *OpenStackNodeGroup) ValidateConfiguration(ctx context.Context) error {
 	// Validate flavor
-	_, err := ng.getFlavor()
+	flavor, err := ng.getFlavor()
 	if err != nil {
 		return fmt.Errorf("flavor validation failed: %w", err)
 	}
@@ -440,18 +1295,125 @@ func (ng *OpenStackNodeGroup) ValidateConfiguration(ctx context.Context) error {
 		return fmt.Errorf("image validation failed: %w", err)
 	}
 
+	// Validate that the flavor fits the image and the image runs on the flavor, a pinned image
+	// Glance cannot return is not checked
+	if err := ng.checkImageRequirements(); err != nil {
+		return fmt.Errorf("image requirements validation failed: %w", err)
+	}
+	arch, err := ng.resolveArchitecture(flavor)
+	if err != nil {
+		return fmt.Errorf("architecture validation failed: %w", err)
+	}
+	if arch == "" {
+		klog.Warningf("Neither the image nor the flavor of node group %s declares an architecture, assuming %s", ng.Config.ID, defaultArchitecture)
+	}
+
+	// Validate user data size, oversized user data fails illegibly with config drive
+	userData, err := ng.renderUserData(ng.Config.ID+"-validate", ng.Config.Labels)
+	if err != nil {
+		return fmt.Errorf("user data validation failed: %w", err)
+	}
+	if _, err := encodeUserData(userData); err != nil {
+		return fmt.Errorf("user data validation failed: %w", err)
+	}
+
+	// Validate networks and security groups, typos otherwise surface as Nova errors at scale-up
+	if err := ng.validateNetworks(); err != nil {
+		return fmt.Errorf("network validation failed: %w", err)
+	}
+
+	// Validate key pair
+	if err := ng.validateKeyPair(); err != nil {
+		return fmt.Errorf("key pair validation failed: %w", err)
+	}
+
+	// Validate volume type
+	if rootVolume := ng.Config.RootVolume; rootVolume != nil && rootVolume.VolumeType != "" {
+		if _, err := ng.Provider.getVolumeTypeID(rootVolume.VolumeType); err != nil {
+			return fmt.Errorf("volume type validation failed: %w", err)
+		}
+	}
+
 	klog.V(2).Infof("Node group %s configuration is valid", ng.Config.ID)
 	return nil
 }
 
 // Refresh refreshes the node group state
 func (ng *OpenStackNodeGroup) Refresh() error {
+	// Clear cached template node info to force refresh
+	ng.InvalidateTemplateNodeInfo()
+
+	// Resolve flavor and image names again, a renamed flavor or a new image version applies to
+	// the next scale-up instead of after the lookup cache TTL
+	ng.Provider.invalidateLookups()
+
+	// Re-read user data so updated bootstrap scripts apply to new servers
+	userData, userDataTemplate, err := ng.loadUserData()
+	if err != nil {
+		return fmt.Errorf("failed to reload user data: %w", err)
+	}
+	ng.mutex.Lock()
+	ng.userData = userData
+	ng.userDataTemplate = userDataTemplate
+	ng.mutex.Unlock()
+
+	// A running scaling operation changes the servers under us, compare on the next Refresh
+	if ng.operationMutex.TryLock() {
+		defer ng.operationMutex.Unlock()
+		if err := ng.reconcileDesiredSize(); err != nil {
+			return fmt.Errorf("failed to reconcile desired size: %w", err)
+		}
+		if err := ng.reconcileMembershipTags(); err != nil {
+			return fmt.Errorf("failed to reconcile membership tags: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// InvalidateTemplateNodeInfo drops the cached template node so the next request rebuilds it
+func (ng *OpenStackNodeGroup) InvalidateTemplateNodeInfo() {
 	ng.mutex.Lock()
 	defer ng.mutex.Unlock()
 
-	// Clear cached template node info to force refresh
 	ng.templateNodeInfo = nil
 	ng.lastRefresh = time.Time{}
+}
 
-	return nil
+// recordFailure remembers the request ID of a failed OpenStack call for debugging
+func (ng *OpenStackNodeGroup) recordFailure(err error) {
+	requestID := RequestIDFromError(err)
+	if requestID == "" {
+		return
+	}
+
+	ng.failuresMutex.Lock()
+	defer ng.failuresMutex.Unlock()
+
+	ng.lastFailure = time.Now()
+	ng.failedRequestIDs = append(ng.failedRequestIDs, requestID)
+	if len(ng.failedRequestIDs) > maxFailedRequestIDs {
+		ng.failedRequestIDs = ng.failedRequestIDs[len(ng.failedRequestIDs)-maxFailedRequestIDs:]
+	}
+}
+
+// expireFailures forgets the failed request IDs once no failure happened within the retention period
+func (ng *OpenStackNodeGroup) expireFailures(retention time.Duration) int {
+	ng.failuresMutex.Lock()
+	defer ng.failuresMutex.Unlock()
+
+	if len(ng.failedRequestIDs) > 0 && time.Since(ng.lastFailure) > retention {
+		ng.failedRequestIDs = nil
+	}
+	return len(ng.failedRequestIDs)
+}
+
+// FailedRequestIDs returns the request IDs of the most recent failed OpenStack calls
+func (ng *OpenStackNodeGroup) FailedRequestIDs() []string {
+	ng.failuresMutex.Lock()
+	defer ng.failuresMutex.Unlock()
+
+	requestIDs := make([]string, len(ng.failedRequestIDs))
+	copy(requestIDs, ng.failedRequestIDs)
+	return requestIDs
 }
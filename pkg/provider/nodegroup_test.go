@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestDeleteNodesCountsFailuresAgainstPlannedNodes(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+
+	ids := []string{
+		addMember(cloud, testGroupID, "workers-a"),
+		addMember(cloud, testGroupID, "workers-b"),
+		addMember(cloud, testGroupID, "workers-c"),
+	}
+	cloud.Fail(http.MethodDelete, fakecloud.ComputePath+"/servers/"+ids[1], http.StatusInternalServerError, -1)
+
+	err := ng.DeleteNodes([]*apiv1.Node{
+		testNode("workers-a", ids[0]),
+		testNode("workers-b", ids[1]),
+		testNode("workers-c", ids[2]),
+	})
+	if err == nil {
+		t.Fatal("expected an error for the failed deletion")
+	}
+	if !strings.Contains(err.Error(), "failed to delete 1 of 3 nodes") {
+		t.Errorf("expected 1 of 3 nodes to be reported as failed, got: %v", err)
+	}
+
+	remaining := cloud.Servers()
+	th.AssertEquals(t, 1, len(remaining))
+	th.AssertEquals(t, ids[1], remaining[0].ID)
+
+	size, err := ng.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, size)
+}
+
+func TestDeleteNodesChecksMembershipAndMinSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		minSize    int
+		foreign    fakecloud.Server
+		registered []int
+		synced     bool
+		// Index into the members, -1 for the foreign server
+		delete   int
+		expected error
+	}{
+		{
+			name:     "foreign server named like the node group",
+			foreign:  fakecloud.Server{Name: testGroupID + "-foreign"},
+			delete:   -1,
+			expected: ErrNotMember,
+		},
+		{
+			name:     "server of another node group",
+			foreign:  fakecloud.Server{Name: testGroupID + "-other", Metadata: map[string]string{membership.NodeGroupMetadataKey: "other"}},
+			delete:   -1,
+			expected: ErrNotMember,
+		},
+		{
+			name:       "registered node at min size",
+			minSize:    2,
+			registered: []int{0, 1},
+			synced:     true,
+			delete:     1,
+			expected:   ErrBelowMinSize,
+		},
+		{
+			name:     "node at min size before the node state synced",
+			minSize:  2,
+			delete:   1,
+			expected: ErrBelowMinSize,
+		},
+		{
+			name:       "unregistered server at min size",
+			minSize:    2,
+			registered: []int{0},
+			synced:     true,
+			delete:     1,
+		},
+		{
+			name:       "registered node above min size",
+			minSize:    1,
+			registered: []int{0, 1},
+			synced:     true,
+			delete:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) { cfg.MinSize = tt.minSize })
+
+			members := []string{
+				addMember(cloud, testGroupID, "workers-a"),
+				addMember(cloud, testGroupID, "workers-b"),
+			}
+			for _, i := range tt.registered {
+				p.NodeChanged(testNode("node-"+members[i], members[i]))
+			}
+			if tt.synced {
+				p.NodesSynced()
+			}
+			var target *apiv1.Node
+			if tt.delete >= 0 {
+				target = testNode("node-"+members[tt.delete], members[tt.delete])
+			} else {
+				tt.foreign.FlavorID, tt.foreign.ImageID = testFlavorID, testImageID
+				target = testNode(tt.foreign.Name, cloud.AddServer(tt.foreign))
+			}
+			before := len(cloud.Servers())
+
+			err := ng.DeleteNodes([]*apiv1.Node{target})
+			if tt.expected == nil {
+				th.AssertNoErr(t, err)
+				th.AssertEquals(t, before-1, len(cloud.Servers()))
+				return
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			th.AssertEquals(t, before, len(cloud.Servers()))
+		})
+	}
+}
+
+func TestGetFlavor(t *testing.T) {
+	tests := []struct {
+		name       string
+		flavorID   string
+		flavorName string
+		gets       int
+		lists      int
+		err        string
+	}{
+		{name: "by ID", flavorID: testFlavorID, gets: 1},
+		{name: "by name", flavorName: "m1.small", lists: 1},
+		{name: "ID given as the name", flavorName: testFlavorID, lists: 1},
+		{name: "missing ID", flavorID: "missing", err: "flavor missing not found"},
+		{name: "missing name", flavorName: "m1.huge", err: "flavor m1.huge not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			cfg := testNodeGroupConfig()
+			cfg.FlavorID = tt.flavorID
+			cfg.FlavorName = tt.flavorName
+
+			ng, err := p.AddNodeGroup(cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				th.AssertEquals(t, true, IsConfigurationError(err))
+				return
+			}
+			th.AssertNoErr(t, err)
+
+			// Resolve from scratch, the node group resolved its flavor when it was added
+			p.invalidateLookups()
+			getPath := fakecloud.ComputePath + "/flavors/" + testFlavorID
+			listPath := fakecloud.ComputePath + "/flavors/detail"
+			gets, lists := cloud.Requests(http.MethodGet, getPath), cloud.Requests(http.MethodGet, listPath)
+			for range 3 {
+				flavor, err := ng.getFlavor()
+				th.AssertNoErr(t, err)
+				th.AssertEquals(t, testFlavorID, flavor.ID)
+			}
+			th.AssertEquals(t, tt.gets, cloud.Requests(http.MethodGet, getPath)-gets)
+			th.AssertEquals(t, tt.lists, cloud.Requests(http.MethodGet, listPath)-lists)
+		})
+	}
+}
+
+func TestCreateServerConfigDrive(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name         string
+		cloudDefault bool
+		nodeGroup    *bool
+		configDrive  bool
+	}{
+		{name: "default"},
+		{name: "cloud default", cloudDefault: true, configDrive: true},
+		{name: "node group enables", nodeGroup: &enabled, configDrive: true},
+		{name: "node group disables the cloud default", cloudDefault: true, nodeGroup: &disabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				cfg.Cloud.ConfigDrive = tt.cloudDefault
+			})
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.ConfigDrive = tt.nodeGroup
+			})
+
+			th.AssertNoErr(t, ng.IncreaseSize(1))
+			created := cloud.Servers()
+			th.AssertEquals(t, 1, len(created))
+			th.AssertEquals(t, tt.configDrive, created[0].ConfigDrive)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+const (
+	// defaultNodeGroupCacheTTL is how long the server to node group mapping is trusted without a refresh
+	defaultNodeGroupCacheTTL = time.Minute
+)
+
+// nodeGroupCacheTTL returns how long the server to node group mapping is trusted
+func (p *OpenStackProvider) nodeGroupCacheTTL() time.Duration {
+	if ttl := p.config.Load().Cloud.NodeGroupCacheTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultNodeGroupCacheTTL
+}
+
+// refreshNodeGroupCache rebuilds the server to node group mapping from the shared server listing
+func (p *OpenStackProvider) refreshNodeGroupCache() error {
+	allServers, err := p.listServers()
+	if err != nil {
+		return err
+	}
+
+	nodeGroups := p.GetNodeGroups()
+	size := p.nodeGroupCache.Rebuild(allServers, func(server *servers.Server) string {
+		for _, ng := range nodeGroups {
+			if ng.ContainsNode(server) {
+				return ng.Config.ID
+			}
+		}
+		return ""
+	})
+
+	klog.V(4).Infof("Refreshed node group mapping of %d servers", size)
+	return nil
+}
+
+// cachedNodeGroup looks a server up in the mapping, refreshing it first when it is stale.
+// It reports false when the server is unknown and has to be fetched.
+func (p *OpenStackProvider) cachedNodeGroup(serverID string) (*OpenStackNodeGroup, bool) {
+	if p.nodeGroupCache.Stale(p.nodeGroupCacheTTL()) {
+		if err := p.refreshNodeGroupCache(); err != nil {
+			klog.Warningf("Failed to refresh node group mapping: %v", err)
+		}
+	}
+
+	groupID, exists := p.nodeGroupCache.Lookup(serverID)
+	if !exists {
+		metrics.NodeGroupCacheLookups.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	if groupID == "" {
+		metrics.NodeGroupCacheLookups.WithLabelValues("hit").Inc()
+		return nil, true
+	}
+
+	// The node group may have been removed since the mapping was built
+	ng := p.GetNodeGroup(groupID)
+	if ng == nil {
+		metrics.NodeGroupCacheLookups.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	metrics.NodeGroupCacheLookups.WithLabelValues("hit").Inc()
+	return ng, true
+}
+
+// cacheNodeGroup records the node group of a server fetched after a cache miss
+func (p *OpenStackProvider) cacheNodeGroup(serverID string, ng *OpenStackNodeGroup) {
+	if ng == nil {
+		p.nodeGroupCache.Record(serverID, "")
+		return
+	}
+	p.nodeGroupCache.Record(serverID, ng.Config.ID)
+}
+
+// invalidateNodeGroupCache forces a refresh of the mapping after node groups changed
+func (p *OpenStackProvider) invalidateNodeGroupCache() {
+	p.nodeGroupCache.Invalidate()
+}
+
+// nodeGroupCacheSize returns the number of servers in the mapping
+func (p *OpenStackProvider) nodeGroupCacheSize() int {
+	return p.nodeGroupCache.Len()
+}
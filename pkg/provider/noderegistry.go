@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// recordNode records the Kubernetes node of a server
+func (p *OpenStackProvider) recordNode(serverID string, node *apiv1.Node) {
+	p.nodes.Record(serverID, isMarkedForDeletion(node))
+}
+
+// NodeDeleted forgets a Kubernetes node that was removed from the cluster
+func (p *OpenStackProvider) NodeDeleted(node *apiv1.Node) {
+	serverID, err := parseProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return
+	}
+	p.nodes.Forget(serverID)
+}
+
+// NodesSynced marks the registry complete, called once the node watcher saw every existing node
+func (p *OpenStackProvider) NodesSynced() {
+	registered := p.nodes.MarkSynced()
+	klog.Infof("Kubernetes node state synced, %d nodes registered", registered)
+}
+
+// nodeState returns whether a server is registered as a node, whether that node is marked for
+// deletion and whether the registry is complete
+func (p *OpenStackProvider) nodeState(serverID string) (registered, markedForDeletion, synced bool) {
+	return p.nodes.State(serverID)
+}
+
+// isRegisteredServer reports whether a server is registered as a node. Until the node state synced
+// every server counted in the target size is assumed to be.
+func (p *OpenStackProvider) isRegisteredServer(server *servers.Server) bool {
+	registered, _, synced := p.nodeState(server.ID)
+	if !synced {
+		return countsTowardTargetSize(server)
+	}
+	return registered
+}
+
+// pruneNodes forgets the registered nodes of servers that no longer exist in Nova, in case the
+// node watcher missed their deletion, and returns the number of registered nodes. Every server
+// of the project is listed, a node group added later may claim any of them.
+func (p *OpenStackProvider) pruneNodes(ctx context.Context) (int, error) {
+	listedAt := time.Now()
+	existing := make(map[string]bool)
+	err := p.clients.Load().EachServer(ctx, servers.ListOpts{}, func(server *servers.Server) {
+		existing[server.ID] = true
+	})
+	if err != nil {
+		return p.nodes.Len(), fmt.Errorf("failed to list servers: %w", wrapOpenStackError(err))
+	}
+	return p.nodes.Prune(existing, listedAt), nil
+}
@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/nodegroup"
+)
+
+// PlannedServer is a server a scaling plan creates or deletes
+type PlannedServer struct {
+	ID               string
+	Name             string
+	AvailabilityZone string
+}
+
+// PlanRejection is a node a scaling plan leaves in place
+type PlanRejection struct {
+	Name   string
+	Reason string
+
+	// err is set when the node cannot be deleted at all, as opposed to being held back
+	err error
+}
+
+// ScalePlan describes the actions a scaling operation takes, computed without changing anything
+type ScalePlan struct {
+	NodeGroupID string
+	CurrentSize int
+	Create      []PlannedServer
+	Delete      []PlannedServer
+	Rejected    []PlanRejection
+	// Refusal is why the operation is refused as a whole, nil when it goes ahead
+	Refusal error
+
+	// zoneCounts are the servers per availability zone including the planned ones
+	zoneCounts map[string]int
+}
+
+// PlanIncrease returns the servers IncreaseSize would create for delta, without changing anything
+func (ng *OpenStackNodeGroup) PlanIncrease(delta int) (*ScalePlan, error) {
+	return ng.planIncrease(delta, false)
+}
+
+// PlanDelete returns the servers DeleteNodes would delete for nodes, without changing anything
+func (ng *OpenStackNodeGroup) PlanDelete(nodes []*apiv1.Node) *ScalePlan {
+	return ng.planDelete(nodes, false)
+}
+
+// planIncrease checks a scale-up against the node group limits and the project quota and places
+// the new servers in availability zones. With track set the servers listed for the placement
+// update the known members, as for every listing of a scaling operation.
+func (ng *OpenStackNodeGroup) planIncrease(delta int, track bool) (*ScalePlan, error) {
+	if delta <= 0 {
+		return nil, fmt.Errorf("delta must be positive, got %d", delta)
+	}
+
+	currentSize, err := ng.TargetSize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current size: %w", err)
+	}
+
+	plan := &ScalePlan{NodeGroupID: ng.Config.ID, CurrentSize: currentSize}
+	newSize := currentSize + delta
+	if newSize > ng.Config.MaxSize {
+		plan.Refusal = fmt.Errorf("cannot increase size to %d, max size is %d", newSize, ng.Config.MaxSize)
+		return plan, nil
+	}
+
+	distribution := make(map[string]int)
+	if len(ng.availabilityZones()) > 1 {
+		instances, err := ng.listInstances(track)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get availability zone distribution: %w", err)
+		}
+		distribution = ng.countZones(instances)
+		if track {
+			ng.recordZoneDistribution(distribution)
+		}
+	}
+
+	placement := nodegroup.NewPlacement(ng.availabilityZones(), distribution)
+	for i := 0; i < delta; i++ {
+		zone, _ := placement.Reserve(nil)
+		plan.Create = append(plan.Create, PlannedServer{AvailabilityZone: zone})
+	}
+	plan.zoneCounts = placement.Distribution()
+
+	plan.Refusal = ng.checkComputeQuota(delta)
+	return plan, nil
+}
+
+// placement returns the zone placement of the servers the plan creates, holding their zones
+func (plan *ScalePlan) placement(zones []string) *nodegroup.Placement {
+	return nodegroup.NewPlacement(zones, plan.zoneCounts)
+}
+
+// serverIDForNode returns the server ID of a node to delete, looking the server up by the node
+// name when the node has no usable provider ID and making sure it belongs to the node group
+func (ng *OpenStackNodeGroup) serverIDForNode(node *apiv1.Node) (string, error) {
+	serverID, err := parseProviderID(node.Spec.ProviderID)
+	if err == nil || node.Name == "" {
+		return serverID, err
+	}
+
+	server, err := ng.Provider.serverByName(node.Name)
+	if err != nil {
+		return "", err
+	}
+	if server == nil {
+		return "", fmt.Errorf("node %s has no provider ID and no server is named like it", node.Name)
+	}
+	if !ng.ownsServer(server) {
+		return "", fmt.Errorf("%w: server %s named like node %s is not in node group %s", ErrNotMember, server.ID, node.Name, ng.Config.ID)
+	}
+	klog.V(2).Infof("Resolved node %s without provider ID to server %s by name", node.Name, server.ID)
+	return server.ID, nil
+}
+
+// planDelete resolves the servers of nodes, rejecting those that are not members of the node
+// group, applies the scale-down batch limit and checks the minimum size. With track set the
+// member listing updates the known members, as for every listing of a scaling operation.
+func (ng *OpenStackNodeGroup) planDelete(nodes []*apiv1.Node, track bool) *ScalePlan {
+	plan := &ScalePlan{NodeGroupID: ng.Config.ID}
+
+	members, membersErr := ng.memberIDs(track)
+	for _, node := range nodes {
+		serverID, err := ng.serverIDForNode(node)
+		if err == nil && membersErr != nil {
+			err = fmt.Errorf("failed to check membership of server %s: %w", serverID, membersErr)
+		} else if err == nil && members[serverID] == nil {
+			err = fmt.Errorf("%w: server %s of node %s is not in node group %s", ErrNotMember, serverID, node.Name, ng.Config.ID)
+		}
+		if err != nil {
+			plan.Rejected = append(plan.Rejected, PlanRejection{Name: node.Name, Reason: err.Error(), err: err})
+			continue
+		}
+
+		if limit := ng.Config.MaxScaleDownBatch; limit > 0 && len(plan.Delete) >= limit {
+			plan.Rejected = append(plan.Rejected, PlanRejection{
+				Name:   node.Name,
+				Reason: fmt.Sprintf("beyond maxScaleDownBatch %d", limit),
+			})
+			continue
+		}
+
+		zone := node.Labels[apiv1.LabelTopologyZone]
+		if zone == "" {
+			zone = members[serverID].AvailabilityZone
+		}
+		plan.Delete = append(plan.Delete, PlannedServer{ID: serverID, Name: node.Name, AvailabilityZone: zone})
+	}
+
+	// A node outside the node group means the request was meant for another one, nothing is deleted
+	if notMembers := plan.notMembers(); len(notMembers) > 0 {
+		plan.Refusal = fmt.Errorf("refusing to delete nodes from node group %s: %w", ng.Config.ID, errors.Join(notMembers...))
+	} else if membersErr == nil {
+		plan.Refusal = ng.checkMinSize(plan, members)
+	}
+	return plan
+}
+
+// notMembers returns the rejections of a plan for nodes outside the node group
+func (plan *ScalePlan) notMembers() []error {
+	var errs []error
+	for _, rejected := range plan.Rejected {
+		if errors.Is(rejected.err, ErrNotMember) {
+			errs = append(errs, rejected.err)
+		}
+	}
+	return errs
+}
+
+// memberIDs returns the servers carrying the membership tag or node group metadata of the node
+// group by ID. Servers attributed by their name alone are left out, they may belong to anything.
+func (ng *OpenStackNodeGroup) memberIDs(track bool) (map[string]*servers.Server, error) {
+	instances, err := ng.listInstances(track)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]*servers.Server, len(instances))
+	for i := range instances {
+		if ng.ownsServer(&instances[i]) {
+			members[instances[i].ID] = &instances[i]
+		}
+	}
+	return members, nil
+}
+
+// checkMinSize rejects a plan that deletes registered nodes while that leaves fewer registered
+// members than the minimum size. Servers that never registered can always be deleted, replacing
+// them is what keeps the node group at its minimum size. Until the node state synced every server
+// counted in the target size is taken as registered.
+func (ng *OpenStackNodeGroup) checkMinSize(plan *ScalePlan, members map[string]*servers.Server) error {
+	deleted := make(map[string]bool, len(plan.Delete))
+	for _, server := range plan.Delete {
+		deleted[server.ID] = true
+	}
+	remaining, deletedRegistered := 0, 0
+	for id, member := range members {
+		if !ng.Provider.isRegisteredServer(member) {
+			continue
+		}
+		if deleted[id] {
+			deletedRegistered++
+		} else {
+			remaining++
+		}
+	}
+	if deletedRegistered > 0 && remaining < ng.Config.MinSize {
+		return fmt.Errorf("%w: deleting %d registered nodes leaves node group %s with %d, min size is %d", ErrBelowMinSize, deletedRegistered, ng.Config.ID, remaining, ng.Config.MinSize)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// assertReadOnly fails when the cloud received anything but reads after the first since requests
+func assertReadOnly(t *testing.T, cloud *fakecloud.Cloud, since int) {
+	t.Helper()
+	for _, request := range cloud.RequestLog()[since:] {
+		if request.Method != http.MethodGet {
+			t.Errorf("planning sent %s %s", request.Method, request.Path)
+		}
+	}
+}
+
+func TestPlanIncreaseMatchesIncreaseSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		delta    int
+		limits   fakecloud.Limits
+		zones    []string
+		refusal  error
+	}{
+		{name: "spread across zones", existing: []string{"az1"}, delta: 3, zones: []string{"az2", "az1", "az2"}},
+		{name: "quota exhausted", delta: 3, limits: fakecloud.Limits{MaxInstances: 2}, refusal: ErrQuotaExceeded},
+		{name: "beyond max size", existing: []string{"az1", "az2"}, delta: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.SetLimits(tt.limits)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.AvailabilityZones = []string{"az1", "az2"}
+				cfg.MaxSize = 4
+			})
+			existing := make(map[string]bool)
+			for _, zone := range tt.existing {
+				serverID := addMember(cloud, testGroupID, "workers-"+zone)
+				cloud.UpdateServer(serverID, func(server *fakecloud.Server) { server.AvailabilityZone = zone })
+				existing[serverID] = true
+			}
+			since := len(cloud.RequestLog())
+
+			plan, err := ng.PlanIncrease(tt.delta)
+			th.AssertNoErr(t, err)
+			assertReadOnly(t, cloud, since)
+			th.AssertEquals(t, len(tt.existing), plan.CurrentSize)
+			if tt.refusal != nil && !errors.Is(plan.Refusal, tt.refusal) {
+				t.Fatalf("expected the plan to be refused with %v, got %v", tt.refusal, plan.Refusal)
+			}
+
+			// Executing the plan creates exactly the planned servers, or is refused the same way
+			err = ng.IncreaseSize(tt.delta)
+			if plan.Refusal != nil {
+				th.AssertEquals(t, plan.Refusal.Error(), err.Error())
+				th.AssertEquals(t, len(tt.existing), len(cloud.Servers()))
+				return
+			}
+			th.AssertNoErr(t, err)
+
+			var planned, created []string
+			for _, server := range plan.Create {
+				planned = append(planned, server.AvailabilityZone)
+			}
+			for _, server := range cloud.Servers() {
+				if !existing[server.ID] {
+					created = append(created, server.AvailabilityZone)
+				}
+			}
+			th.AssertDeepEquals(t, tt.zones, planned)
+			sort.Strings(planned)
+			sort.Strings(created)
+			th.AssertDeepEquals(t, planned, created)
+		})
+	}
+}
+
+func TestPlanDeleteMatchesDeleteNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		minSize  int
+		batch    int
+		foreign  bool
+		delete   []int
+		planned  []int
+		rejected int
+		refusal  error
+	}{
+		{name: "members", delete: []int{0, 1}, planned: []int{0, 1}},
+		{name: "batch limit", batch: 1, delete: []int{0, 1}, planned: []int{0}, rejected: 1},
+		{name: "foreign node", foreign: true, delete: []int{0}, planned: []int{0}, rejected: 1, refusal: ErrNotMember},
+		{name: "min size", minSize: 3, delete: []int{2}, planned: []int{2}, refusal: ErrBelowMinSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.MinSize = tt.minSize
+				cfg.MaxScaleDownBatch = tt.batch
+				cfg.ScaleDownTrimPolicy = scaleDownTrimPolicyTrim
+			})
+			members := []string{
+				addMember(cloud, testGroupID, "workers-a"),
+				addMember(cloud, testGroupID, "workers-b"),
+				addMember(cloud, testGroupID, "workers-c"),
+			}
+			var nodes []*apiv1.Node
+			for _, serverID := range members {
+				p.NodeChanged(testNode("node-"+serverID, serverID))
+			}
+			p.NodesSynced()
+			for _, i := range tt.delete {
+				nodes = append(nodes, testNode("node-"+members[i], members[i]))
+			}
+			if tt.foreign {
+				foreignID := addMember(cloud, "other", "other-a")
+				nodes = append(nodes, testNode("node-"+foreignID, foreignID))
+			}
+			since := len(cloud.RequestLog())
+
+			plan := ng.PlanDelete(nodes)
+			assertReadOnly(t, cloud, since)
+			var planned []string
+			for _, server := range plan.Delete {
+				planned = append(planned, server.ID)
+			}
+			var expected []string
+			for _, i := range tt.planned {
+				expected = append(expected, members[i])
+			}
+			th.AssertDeepEquals(t, expected, planned)
+			th.AssertEquals(t, tt.rejected, len(plan.Rejected))
+			if tt.refusal != nil && !errors.Is(plan.Refusal, tt.refusal) {
+				t.Fatalf("expected the plan to be refused with %v, got %v", tt.refusal, plan.Refusal)
+			}
+
+			// Executing the plan deletes exactly the planned servers, or is refused the same way
+			before := len(cloud.Servers())
+			err := ng.DeleteNodes(nodes)
+			if plan.Refusal != nil {
+				th.AssertEquals(t, plan.Refusal.Error(), err.Error())
+				th.AssertEquals(t, before, len(cloud.Servers()))
+				return
+			}
+			th.AssertNoErr(t, err)
+			for _, serverID := range planned {
+				if _, exists := cloud.Server(serverID); exists {
+					t.Errorf("planned server %s was not deleted", serverID)
+				}
+			}
+			th.AssertEquals(t, before-len(planned), len(cloud.Servers()))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// flavorNameCache maps flavor IDs to names, refreshed at most once per template cache TTL
+type flavorNameCache struct {
+	mutex   sync.Mutex
+	names   map[string]string
+	fetched time.Time
+}
+
+// PricingEnabled reports whether a price table is configured
+func (p *OpenStackProvider) PricingEnabled() bool {
+	return p.config.Load().Pricing != nil
+}
+
+// NodePrice returns the price of running a node between start and end based on the configured price table
+func (p *OpenStackProvider) NodePrice(providerID string, labels map[string]string, start, end time.Time) (float64, error) {
+	hourlyPrice, err := p.hourlyPrice(providerID, labels)
+	if err != nil {
+		return 0, err
+	}
+	return hourlyPrice * end.Sub(start).Hours(), nil
+}
+
+// hourlyPrice looks up the hourly price of a node by node group, then by flavor, then the default.
+// Template nodes of node groups are priced without looking up a server.
+func (p *OpenStackProvider) hourlyPrice(providerID string, labels map[string]string) (float64, error) {
+	pricing := p.config.Load().Pricing
+	if pricing == nil {
+		return 0, fmt.Errorf("%w: no pricing configured", ErrPriceNotFound)
+	}
+
+	groupID, isTemplate := parseTemplateProviderID(providerID)
+	if len(pricing.NodeGroups) > 0 {
+		if !isTemplate {
+			ng, err := p.NodeGroupForNode(providerID, "")
+			if err != nil {
+				return 0, fmt.Errorf("failed to find node group: %w", err)
+			}
+			if ng != nil {
+				groupID = ng.Config.ID
+			}
+		}
+		if price, exists := pricing.NodeGroups[groupID]; exists && groupID != "" {
+			return price, nil
+		}
+	}
+
+	// The instance type label saves a server lookup for registered nodes
+	flavorName := labels[apiv1.LabelInstanceTypeStable]
+	if flavorName == "" {
+		var err error
+		if isTemplate {
+			flavorName, err = p.nodeGroupFlavorName(groupID)
+		} else {
+			flavorName, err = p.serverFlavorName(providerID)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if price, exists := pricing.Flavors[flavorName]; exists {
+		return price, nil
+	}
+
+	if pricing.DefaultHourlyPrice != nil {
+		return *pricing.DefaultHourlyPrice, nil
+	}
+	return 0, fmt.Errorf("%w: no price for flavor %s", ErrPriceNotFound, flavorName)
+}
+
+// nodeGroupFlavorName returns the flavor name of the servers of a node group
+func (p *OpenStackProvider) nodeGroupFlavorName(groupID string) (string, error) {
+	ng := p.GetNodeGroup(groupID)
+	if ng == nil {
+		return "", fmt.Errorf("%w: node group %s not found", ErrPriceNotFound, groupID)
+	}
+	if ng.Config.FlavorName != "" {
+		return ng.Config.FlavorName, nil
+	}
+	return p.flavorName(ng.Config.FlavorID)
+}
+
+// serverFlavorName returns the flavor name of the server behind a provider ID
+func (p *OpenStackProvider) serverFlavorName(providerID string) (string, error) {
+	serverID, err := parseProviderID(providerID)
+	if err != nil {
+		return "", err
+	}
+
+	server, err := servers.Get(context.TODO(), p.computeClient(), serverID).Extract()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server %s: %w", serverID, wrapOpenStackError(err))
+	}
+
+	// Microversion 2.47 and later embed the flavor name, earlier versions only reference it
+	if name, ok := server.Flavor["original_name"].(string); ok {
+		return name, nil
+	}
+	flavorID, _ := server.Flavor["id"].(string)
+	return p.flavorName(flavorID)
+}
+
+// flavorNameTTL returns how long the flavor list resolving flavor names is reused
+func (p *OpenStackProvider) flavorNameTTL() time.Duration {
+	if ttl := p.config.Load().Cloud.TemplateCacheTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultTemplateCacheTTL
+}
+
+// flavorName resolves a flavor ID to its name from the cached flavor list
+func (p *OpenStackProvider) flavorName(flavorID string) (string, error) {
+	p.flavorNames.mutex.Lock()
+	defer p.flavorNames.mutex.Unlock()
+
+	name, exists := p.flavorNames.names[flavorID]
+	if exists && time.Since(p.flavorNames.fetched) < p.flavorNameTTL() {
+		return name, nil
+	}
+
+	allPages, err := flavors.ListDetail(p.computeClient(), flavors.ListOpts{AccessType: flavors.AllAccess}).AllPages(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to list flavors: %w", wrapOpenStackError(err))
+	}
+
+	allFlavors, err := flavors.ExtractFlavors(allPages)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract flavors: %w", err)
+	}
+
+	p.flavorNames.names = make(map[string]string, len(allFlavors))
+	for _, flavor := range allFlavors {
+		p.flavorNames.names[flavor.ID] = flavor.Name
+	}
+	p.flavorNames.fetched = time.Now()
+
+	name, exists = p.flavorNames.names[flavorID]
+	if !exists {
+		return "", fmt.Errorf("%w: flavor %s not found", ErrPriceNotFound, flavorID)
+	}
+	return name, nil
+}
+
+// expireFlavorNames drops the flavor list once it is too old to be reused, and returns the
+// number of cached flavor names
+func (p *OpenStackProvider) expireFlavorNames() int {
+	p.flavorNames.mutex.Lock()
+	defer p.flavorNames.mutex.Unlock()
+
+	if time.Since(p.flavorNames.fetched) >= p.flavorNameTTL() {
+		p.flavorNames.names = nil
+	}
+	return len(p.flavorNames.names)
+}
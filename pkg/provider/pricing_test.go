@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestNodePrice(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodeGroups map[string]float64
+		template   string
+		labels     map[string]string
+		price      float64
+		err        error
+	}{
+		{name: "template of a priced node group", nodeGroups: map[string]float64{testGroupID: 3}, template: testGroupID, price: 3},
+		{name: "template by flavor label", nodeGroups: map[string]float64{"other": 3}, template: testGroupID, labels: map[string]string{apiv1.LabelInstanceTypeStable: "m1.large"}, price: 2},
+		{name: "template by node group flavor", nodeGroups: map[string]float64{"other": 3}, template: testGroupID, price: 1},
+		{name: "template of an unknown node group", template: "missing", err: ErrPriceNotFound},
+		{name: "member of a priced node group", nodeGroups: map[string]float64{testGroupID: 3}, price: 3},
+		{name: "member by server flavor", price: 1},
+		{name: "unpriced flavor", labels: map[string]string{apiv1.LabelInstanceTypeStable: "m1.tiny"}, err: ErrPriceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				cfg.Pricing = &config.PricingConfig{
+					Flavors:    map[string]float64{"m1.small": 1, "m1.large": 2},
+					NodeGroups: tt.nodeGroups,
+				}
+			})
+			newTestNodeGroup(t, p)
+			providerID := ProviderID(addMember(cloud, testGroupID, "workers-a"))
+			if tt.template != "" {
+				providerID = templateProviderID(tt.template)
+			}
+			since := len(cloud.RequestLog())
+
+			start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			price, err := p.NodePrice(providerID, tt.labels, start, start.Add(2*time.Hour))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 2*tt.price, price)
+
+			// Template nodes have no server to look up
+			if tt.template != "" {
+				for _, request := range cloud.RequestLog()[since:] {
+					if strings.HasPrefix(request.Path, fakecloud.ComputePath+"/servers") {
+						t.Errorf("pricing a template node sent %s %s", request.Method, request.Path)
+					}
+				}
+			}
+		})
+	}
+}
@@ -2,120 +2,101 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/gophercloud/gophercloud/v2"
-	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumetypes"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
+	"github.com/bucher-brothers/openstack-autoscaler/internal/inventory"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/nodegroup"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/osclient"
 	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
 )
 
 const (
 	ProviderName = "openstack"
+
+	// defaultCreateConcurrency is how many servers a scale-up creates in parallel by default
+	defaultCreateConcurrency = 5
+	// defaultDeleteConcurrency is how many servers a scale-down deletes in parallel by default
+	defaultDeleteConcurrency = 5
 )
 
 // OpenStackProvider implements the cloud provider interface for OpenStack
 type OpenStackProvider struct {
-	config        *config.Config
-	computeClient *gophercloud.ServiceClient
-	imageClient   *gophercloud.ServiceClient
-	nodeGroups    map[string]*OpenStackNodeGroup
-	mutex         sync.RWMutex
+	config         atomic.Pointer[config.Config]
+	clients        atomic.Pointer[osclient.Clients]
+	nodeGroups     map[string]*OpenStackNodeGroup
+	mutex          sync.RWMutex
+	degraded       atomic.Bool
+	evacuations    map[string]context.CancelFunc
+	drainer        NodeDrainer
+	flavorNames    flavorNameCache
+	images         map[string]*imageInfo
+	imagesMutex    sync.Mutex
+	imageDegraded  atomic.Bool
+	nodeGroupCache inventory.GroupIndex
+	nodes          inventory.NodeRegistry
+	serverSnapshot inventory.Snapshot
+	lookupCache    lookupCache
+	sizeStore      *nodegroup.FileSizeStore
+	provisioning   provisionTracker
+	// Set once the compute API was found returning servers the tags filter should have excluded
+	serverFiltersIgnored atomic.Bool
+
+	// Background loops share a context cancelled on shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	start  sync.Once
 }
 
 // NewOpenStackProvider creates a new OpenStack provider
 func NewOpenStackProvider(cfg *config.Config) (*OpenStackProvider, error) {
 	provider := &OpenStackProvider{
-		config:     cfg,
-		nodeGroups: make(map[string]*OpenStackNodeGroup),
+		nodeGroups:  make(map[string]*OpenStackNodeGroup),
+		evacuations: make(map[string]context.CancelFunc),
+		images:      make(map[string]*imageInfo),
 	}
+	provider.config.Store(cfg)
+	provider.sizeStore = nodegroup.NewFileSizeStore(func() string { return provider.config.Load().Cloud.StateFile })
+	provider.ctx, provider.cancel = context.WithCancel(context.Background())
 
 	// Initialize OpenStack clients
-	if err := provider.initializeClients(); err != nil {
+	clients, err := osclient.New(context.TODO(), cfg)
+	if err != nil {
 		return nil, fmt.Errorf("failed to initialize OpenStack clients: %w", err)
 	}
+	provider.clients.Store(clients)
 
-	// NodeGroups are created dynamically via external-grpc protocol
-	// No static initialization needed
+	if err := provider.syncNodeGroups(cfg.NodeGroups); err != nil {
+		return nil, err
+	}
 
 	return provider, nil
 }
 
-// initializeClients initializes the OpenStack service clients
-func (p *OpenStackProvider) initializeClients() error {
-	// Validate authentication configuration
-	if err := p.config.Cloud.ValidateAuth(); err != nil {
-		return fmt.Errorf("authentication validation failed: %w", err)
-	}
-
-	// Create provider client
-	authOptions := gophercloud.AuthOptions{
-		IdentityEndpoint: p.config.Cloud.AuthURL,
-	}
-
-	// Use application credentials if available, otherwise fall back to username/password
-	if p.config.Cloud.ApplicationCredentialID != "" && p.config.Cloud.ApplicationCredentialSecret != "" {
-		klog.V(2).Info("Using OpenStack application credentials for authentication")
-		authOptions.ApplicationCredentialID = p.config.Cloud.ApplicationCredentialID
-		authOptions.ApplicationCredentialSecret = p.config.Cloud.ApplicationCredentialSecret
-		// When using application credentials, we don't need username/password or domain info
-	} else if p.config.Cloud.ApplicationCredentialName != "" && p.config.Cloud.ApplicationCredentialSecret != "" {
-		klog.V(2).Info("Using OpenStack application credentials with name for authentication")
-		authOptions.ApplicationCredentialName = p.config.Cloud.ApplicationCredentialName
-		authOptions.ApplicationCredentialSecret = p.config.Cloud.ApplicationCredentialSecret
-		// For application credential name, we need username and user domain
-		authOptions.Username = p.config.Cloud.Username
-		authOptions.DomainName = p.config.Cloud.UserDomainName
-	} else {
-		klog.V(2).Info("Using OpenStack username/password authentication")
-		authOptions.Username = p.config.Cloud.Username
-		authOptions.Password = p.config.Cloud.Password
-		authOptions.TenantName = p.config.Cloud.ProjectName
-		authOptions.TenantID = p.config.Cloud.ProjectID
-		authOptions.DomainName = p.config.Cloud.UserDomainName
-		authOptions.DomainID = p.config.Cloud.ProjectDomainName
-	}
+// Start starts the background loops renewing the token, collecting garbage and deleting stuck
+// servers. It is only called when serving, so validating a configuration never changes the cloud.
+func (p *OpenStackProvider) Start() {
+	p.start.Do(func() {
+		// Renew the token before it expires
+		p.RunBackground("token-renewal", p.tokenRenewalLoop)
 
-	providerClient, err := openstack.AuthenticatedClient(context.TODO(), authOptions)
-	if err != nil {
-		return fmt.Errorf("failed to create authenticated client: %w", err)
-	}
+		// Keep internal tracking state bounded
+		p.RunBackground("janitor", p.janitorLoop)
 
-	// Create compute client
-	endpointOpts := gophercloud.EndpointOpts{
-		Region:       p.config.Cloud.Region,
-		Availability: gophercloud.AvailabilityPublic,
-	}
-
-	if p.config.Cloud.Interface != "" {
-		switch strings.ToLower(p.config.Cloud.Interface) {
-		case "public":
-			endpointOpts.Availability = gophercloud.AvailabilityPublic
-		case "internal":
-			endpointOpts.Availability = gophercloud.AvailabilityInternal
-		case "admin":
-			endpointOpts.Availability = gophercloud.AvailabilityAdmin
-		}
-	}
-
-	p.computeClient, err = openstack.NewComputeV2(providerClient, endpointOpts)
-	if err != nil {
-		return fmt.Errorf("failed to create compute client: %w", err)
-	}
-
-	// Create image client
-	p.imageClient, err = openstack.NewImageV2(providerClient, endpointOpts)
-	if err != nil {
-		return fmt.Errorf("failed to create image client: %w", err)
-	}
-
-	return nil
+		// Delete servers that never finished provisioning
+		p.RunBackground("stuck-servers", p.stuckServerLoop)
+	})
 }
 
 // GetNodeGroups returns all node groups
@@ -137,6 +118,22 @@ func (p *OpenStackProvider) GetNodeGroup(id string) *OpenStackNodeGroup {
 	return p.nodeGroups[id]
 }
 
+// createConcurrency returns how many servers a scale-up creates in parallel
+func (p *OpenStackProvider) createConcurrency() int {
+	if p.config.Load().Cloud.CreateConcurrency > 0 {
+		return p.config.Load().Cloud.CreateConcurrency
+	}
+	return defaultCreateConcurrency
+}
+
+// deleteConcurrency returns how many servers a scale-down deletes in parallel
+func (p *OpenStackProvider) deleteConcurrency() int {
+	if p.config.Load().Cloud.DeleteConcurrency > 0 {
+		return p.config.Load().Cloud.DeleteConcurrency
+	}
+	return defaultDeleteConcurrency
+}
+
 // AddNodeGroup adds a new node group dynamically
 func (p *OpenStackProvider) AddNodeGroup(ngConfig *config.NodeGroupConfig) (*OpenStackNodeGroup, error) {
 	p.mutex.Lock()
@@ -148,95 +145,299 @@ func (p *OpenStackProvider) AddNodeGroup(ngConfig *config.NodeGroupConfig) (*Ope
 	}
 
 	// Create new node group
-	nodeGroup, err := NewOpenStackNodeGroup(ngConfig, p)
+	nodeGroup, err := p.newValidatedNodeGroup(ngConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create node group %s: %w", ngConfig.ID, err)
+		return nil, err
 	}
 
 	p.nodeGroups[ngConfig.ID] = nodeGroup
+	p.invalidateNodeGroupCache()
+	p.invalidateServerSnapshot()
+	p.invalidateLookups()
 	klog.Infof("Added node group: %s", ngConfig.ID)
 	return nodeGroup, nil
 }
 
-// NodeGroupForNode returns the node group for a given node
-func (p *OpenStackProvider) NodeGroupForNode(nodeProviderID string) (*OpenStackNodeGroup, error) {
-	// Extract server ID from provider ID (format: openstack://server-id)
-	serverID := strings.TrimPrefix(nodeProviderID, ProviderName+"://")
-	if serverID == nodeProviderID {
-		return nil, fmt.Errorf("invalid provider ID format: %s", nodeProviderID)
+// newValidatedNodeGroup creates a node group and checks its key pair and image against the cloud,
+// failing up front rather than at scale-up time
+func (p *OpenStackProvider) newValidatedNodeGroup(ngConfig *config.NodeGroupConfig) (*OpenStackNodeGroup, error) {
+	nodeGroup, err := NewOpenStackNodeGroup(ngConfig, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node group %s: %w", ngConfig.ID, err)
 	}
+	if err := nodeGroup.validateKeyPair(); err != nil {
+		return nil, fmt.Errorf("invalid node group %s: %w", ngConfig.ID, err)
+	}
+	if err := nodeGroup.checkImageRequirements(); err != nil {
+		return nil, fmt.Errorf("invalid node group %s: %w", ngConfig.ID, err)
+	}
+	return nodeGroup, nil
+}
+
+// UpdateNodeGroup replaces the configuration of an existing node group. The update
+// must be based on the current resource version, otherwise a
+// ResourceVersionConflictError carrying the current version is returned.
+func (p *OpenStackProvider) UpdateNodeGroup(ngConfig *config.NodeGroupConfig, resourceVersion uint64) (*OpenStackNodeGroup, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 
-	// Get server details
-	server, err := servers.Get(context.TODO(), p.computeClient, serverID).Extract()
+	existing, exists := p.nodeGroups[ngConfig.ID]
+	if !exists {
+		return nil, fmt.Errorf("node group %s not found", ngConfig.ID)
+	}
+	if existing.resourceVersion != resourceVersion {
+		return nil, &ResourceVersionConflictError{
+			NodeGroupID:     ngConfig.ID,
+			CurrentVersion:  existing.resourceVersion,
+			ExpectedVersion: resourceVersion,
+		}
+	}
+
+	nodeGroup, err := p.newValidatedNodeGroup(ngConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get server %s: %w", serverID, err)
+		return nil, err
+	}
+	p.replaceNodeGroupLocked(existing, nodeGroup)
+	return nodeGroup, nil
+}
+
+// replaceNodeGroupLocked registers a node group in place of the existing one with the same ID,
+// taking over its state and bumping the resource version. The provider mutex must be held.
+func (p *OpenStackProvider) replaceNodeGroupLocked(existing, nodeGroup *OpenStackNodeGroup) {
+	// Keep the membership state, the servers did not change. The states are copied, the existing
+	// node group may still be observing nodes until its operations finish.
+	nodeGroup.members = existing.members.Clone()
+
+	// Scaling operations still running on the existing node group finish before new ones start,
+	// and the target size they change is the one of the replacement
+	nodeGroup.operationMutex = existing.operationMutex
+	nodeGroup.target = existing.target
+
+	// A quarantine or its manual override outlives configuration updates, as does the budget
+	nodeGroup.quarantine = existing.quarantine
+	nodeGroup.budget = existing.budget
+
+	nodeGroup.resourceVersion = existing.resourceVersion + 1
+	p.nodeGroups[nodeGroup.Config.ID] = nodeGroup
+	p.invalidateNodeGroupCache()
+	p.invalidateServerSnapshot()
+	p.invalidateLookups()
+	klog.Infof("Updated node group %s to resource version %d", nodeGroup.Config.ID, nodeGroup.resourceVersion)
+}
+
+// NodeGroupForNode returns the node group for a given node. Nodes without a usable provider ID
+// are matched to a server by name when the node name is known.
+func (p *OpenStackProvider) NodeGroupForNode(nodeProviderID, nodeName string) (*OpenStackNodeGroup, error) {
+	var server *servers.Server
+	serverID, err := parseProviderID(nodeProviderID)
+	switch {
+	case err == nil:
+		if ng, found := p.cachedNodeGroup(serverID); found {
+			return ng, nil
+		}
+		server, err = servers.Get(context.TODO(), p.computeClient(), serverID).Extract()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server %s: %w", serverID, wrapOpenStackError(err))
+		}
+	case nodeName != "":
+		server, err = p.serverByName(nodeName)
+		if err != nil {
+			return nil, err
+		}
+		if server == nil {
+			return nil, nil
+		}
+	default:
+		return nil, err
 	}
 
 	// Find the node group based on server metadata or other attributes
-	for _, ng := range p.nodeGroups {
+	for _, ng := range p.GetNodeGroups() {
 		if ng.ContainsNode(server) {
+			p.cacheNodeGroup(server.ID, ng)
 			return ng, nil
 		}
 	}
 
+	p.cacheNodeGroup(server.ID, nil)
 	return nil, nil // No node group found for this node
 }
 
-// ValidateConfiguration validates the OpenStack configuration
+// listedNodeGroup returns the node group of a server from the mapping or the shared server listing,
+// without fetching the server. Node events arrive for every node of the cluster, a server missing
+// from the listing belongs to no node group.
+func (p *OpenStackProvider) listedNodeGroup(serverID string) (*OpenStackNodeGroup, error) {
+	if ng, found := p.cachedNodeGroup(serverID); found {
+		return ng, nil
+	}
+
+	allServers, err := p.listServers()
+	if err != nil {
+		return nil, err
+	}
+	for i := range allServers {
+		if allServers[i].ID != serverID {
+			continue
+		}
+		for _, ng := range p.GetNodeGroups() {
+			if ng.ContainsNode(&allServers[i]) {
+				p.cacheNodeGroup(serverID, ng)
+				return ng, nil
+			}
+		}
+		break
+	}
+	return nil, nil
+}
+
+// NodeChanged invalidates the cached template of the node group owning a changed Kubernetes node
+func (p *OpenStackProvider) NodeChanged(node *apiv1.Node) {
+	serverID, err := parseProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return
+	}
+	p.recordNode(serverID, node)
+	if len(p.GetNodeGroups()) == 0 {
+		return
+	}
+
+	ng, err := p.listedNodeGroup(serverID)
+	if err != nil {
+		klog.V(4).Infof("Failed to find node group for changed node %s: %v", node.Name, err)
+		return
+	}
+	if ng == nil {
+		return
+	}
+
+	ng.members.Remember(serverID)
+	ng.rememberLiveNode(serverID, node)
+
+	klog.V(2).Infof("Node %s of node group %s changed, invalidating template node info", node.Name, ng.Config.ID)
+	ng.InvalidateTemplateNodeInfo()
+}
+
+// ValidateConfiguration validates the OpenStack configuration and all node groups
 func (p *OpenStackProvider) ValidateConfiguration(ctx context.Context) error {
+	return errors.Join(p.ValidationErrors(ctx)...)
+}
+
+// ValidationErrors validates the OpenStack configuration and all node groups, returning
+// every problem found. Use IsConfigurationError to tell configuration mistakes from cloud errors.
+func (p *OpenStackProvider) ValidationErrors(ctx context.Context) []error {
 	klog.V(2).Info("Validating OpenStack configuration")
 
 	// Test compute client by listing flavors
-	allPages, err := flavors.ListDetail(p.computeClient, flavors.ListOpts{}).AllPages(context.TODO())
+	allPages, err := flavors.ListDetail(p.computeClient(), flavors.ListOpts{}).AllPages(context.TODO())
 	if err != nil {
-		return fmt.Errorf("failed to validate compute client: %w", err)
+		return []error{fmt.Errorf("failed to validate compute client: %w", wrapOpenStackError(err))}
 	}
 	flavorList, err := flavors.ExtractFlavors(allPages)
 	if err != nil {
-		return fmt.Errorf("failed to extract flavors: %w", err)
+		return []error{fmt.Errorf("failed to extract flavors: %w", err)}
 	}
 	klog.V(2).Infof("Found %d flavors in OpenStack", len(flavorList))
 
-	// Test image client by listing images
-	allPages, err = images.List(p.imageClient, images.ListOpts{}).AllPages(context.TODO())
-	if err != nil {
-		return fmt.Errorf("failed to validate image client: %w", err)
+	if err := p.clients.Load().ValidateComputeMicroversion(ctx); err != nil {
+		return []error{err}
 	}
-	imageList, err := images.ExtractImages(allPages)
+
+	// Test image client by listing images, node groups with pinned image IDs work without it
+	allPages, err = images.List(p.imageClient(), images.ListOpts{}).AllPages(context.TODO())
 	if err != nil {
-		return fmt.Errorf("failed to extract images: %w", err)
+		err = fmt.Errorf("failed to validate image client: %w", wrapOpenStackError(err))
+		p.observeImageService(err)
+		klog.Warning(err)
+	} else if imageList, err := images.ExtractImages(allPages); err != nil {
+		return []error{fmt.Errorf("failed to extract images: %w", err)}
+	} else {
+		p.observeImageService(nil)
+		klog.V(2).Infof("Found %d images in OpenStack", len(imageList))
 	}
-	klog.V(2).Infof("Found %d images in OpenStack", len(imageList))
 
 	// Validate node group configurations
-	for _, ng := range p.nodeGroups {
+	var errs []error
+	for _, ng := range p.GetNodeGroups() {
 		if err := ng.ValidateConfiguration(ctx); err != nil {
-			return fmt.Errorf("node group %s validation failed: %w", ng.Config.ID, err)
+			errs = append(errs, fmt.Errorf("node group %s validation failed: %w", ng.Config.ID, err))
 		}
 	}
+	if len(errs) > 0 {
+		return errs
+	}
 
 	klog.Info("OpenStack configuration validation successful")
 	return nil
 }
 
+// getVolumeTypeID resolves a Cinder volume type by ID or name
+func (p *OpenStackProvider) getVolumeTypeID(nameOrID string) (string, error) {
+	if p.volumeClient() == nil {
+		return "", fmt.Errorf("block storage service is not available")
+	}
+
+	allPages, err := volumetypes.List(p.volumeClient(), volumetypes.ListOpts{}).AllPages(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to list volume types: %w", wrapOpenStackError(err))
+	}
+
+	allTypes, err := volumetypes.ExtractVolumeTypes(allPages)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract volume types: %w", err)
+	}
+
+	for _, vt := range allTypes {
+		if vt.ID == nameOrID || vt.Name == nameOrID {
+			return vt.ID, nil
+		}
+	}
+
+	return "", configErrorf("volume type %s not found", nameOrID)
+}
+
 // Refresh refreshes the provider state
 func (p *OpenStackProvider) Refresh() error {
 	klog.V(2).Info("Refreshing OpenStack provider state")
+	p.invalidateServerSnapshot()
 
-	p.mutex.RLock()
-	defer p.mutex.RUnlock()
-
-	for _, ng := range p.nodeGroups {
+	for _, ng := range p.GetNodeGroups() {
 		if err := ng.Refresh(); err != nil {
 			klog.Errorf("Failed to refresh node group %s: %v", ng.Config.ID, err)
 		}
 	}
 
+	if err := p.refreshNodeGroupCache(); err != nil {
+		klog.Errorf("Failed to refresh node group mapping: %v", err)
+	}
+
 	return nil
 }
 
-// Cleanup performs cleanup operations
-func (p *OpenStackProvider) Cleanup() error {
-	klog.Info("Cleaning up OpenStack provider")
-	return nil
+// RunBackground starts a background loop that is stopped by Shutdown
+func (p *OpenStackProvider) RunBackground(name string, loop func(ctx context.Context)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		klog.V(2).Infof("Starting background loop %s", name)
+		loop(p.ctx)
+		klog.V(2).Infof("Background loop %s stopped", name)
+	}()
+}
+
+// Shutdown stops all background loops and waits up to timeout for them to exit
+func (p *OpenStackProvider) Shutdown(timeout time.Duration) error {
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("background loops did not stop within %s", timeout)
+	}
 }
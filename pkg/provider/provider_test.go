@@ -0,0 +1,225 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/osclient"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+const (
+	testFlavorID = "flavor-small"
+	testImageID  = "11111111-1111-4111-8111-111111111111"
+	testGroupID  = "workers"
+)
+
+// newTestCloud starts a fake cloud with the flavor and image test node groups use
+func newTestCloud(t *testing.T) *fakecloud.Cloud {
+	t.Helper()
+	cloud := fakecloud.New()
+	t.Cleanup(cloud.Close)
+	cloud.AddFlavor(fakecloud.Flavor{ID: testFlavorID, Name: "m1.small", VCPUs: 2, RAM: 4096, Disk: 20})
+	cloud.AddImage(fakecloud.Image{ID: testImageID, Name: "node-image"})
+	return cloud
+}
+
+// newTestProvider returns a provider authenticated against the fake cloud, configure adjusts
+// the configuration before the provider is created
+func newTestProvider(t *testing.T, cloud *fakecloud.Cloud, configure ...func(*config.Config)) *OpenStackProvider {
+	t.Helper()
+	cfg := &config.Config{Cloud: config.CloudConfig{
+		AuthURL:            cloud.AuthURL(),
+		Username:           "autoscaler",
+		Password:           "secret",
+		ProjectName:        fakecloud.ProjectID,
+		UserDomainName:     "Default",
+		Region:             fakecloud.Region,
+		ClusterName:        "test",
+		IdentityAPIVersion: "3",
+	}}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	p, err := NewOpenStackProvider(cfg)
+	th.AssertNoErr(t, err)
+	t.Cleanup(func() { _ = p.Shutdown(5 * time.Second) })
+	return p
+}
+
+// testNodeGroupConfig returns the configuration of a node group of the test flavor and image
+func testNodeGroupConfig() *config.NodeGroupConfig {
+	return &config.NodeGroupConfig{
+		ID:       testGroupID,
+		MinSize:  0,
+		MaxSize:  10,
+		FlavorID: testFlavorID,
+		ImageID:  testImageID,
+	}
+}
+
+// newTestNodeGroup adds a node group of the test flavor and image, configure adjusts its configuration
+func newTestNodeGroup(t *testing.T, p *OpenStackProvider, configure ...func(*config.NodeGroupConfig)) *OpenStackNodeGroup {
+	t.Helper()
+	cfg := testNodeGroupConfig()
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	ng, err := p.AddNodeGroup(cfg)
+	th.AssertNoErr(t, err)
+	return ng
+}
+
+// addMember adds a server the node group created to the cloud and returns its ID
+func addMember(cloud *fakecloud.Cloud, groupID, name string) string {
+	return cloud.AddServer(fakecloud.Server{
+		Name:     name,
+		FlavorID: testFlavorID,
+		ImageID:  testImageID,
+		Metadata: map[string]string{
+			membership.NodeGroupMetadataKey: groupID,
+			membership.CreatedByMetadataKey: membership.CreatedByMetadataValue,
+		},
+		Tags: []string{membership.NodeGroupTagPrefix + groupID, membership.ManagedByTag},
+	})
+}
+
+// testNode returns a Kubernetes node of a server
+func testNode(name, serverID string) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       apiv1.NodeSpec{ProviderID: ProviderID(serverID)},
+	}
+}
+
+func TestNewOpenStackProvider(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+
+	th.AssertEquals(t, 1, cloud.Authentications())
+	if p.tagsClient() == nil {
+		t.Fatalf("expected a tags client for a cloud supporting microversion %s", osclient.TagsMicroversion)
+	}
+}
+
+func TestNewOpenStackProviderWithoutMicroversions(t *testing.T) {
+	cloud := newTestCloud(t)
+	cloud.SetComputeMicroversion("")
+	p := newTestProvider(t, cloud)
+
+	if p.tagsClient() != nil {
+		t.Fatalf("expected no tags client for a cloud without microversions")
+	}
+}
+
+func TestNodeChangedServedFromListing(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+
+	memberID := addMember(cloud, testGroupID, "workers-a")
+	foreignID := cloud.AddServer(fakecloud.Server{Name: "bastion", FlavorID: testFlavorID, ImageID: testImageID})
+
+	tests := []struct {
+		name     string
+		serverID string
+		member   bool
+	}{
+		{name: "member", serverID: memberID, member: true},
+		{name: "foreign server", serverID: foreignID},
+		{name: "unknown server", serverID: "99999999-9999-4999-8999-999999999999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 3 {
+				p.NodeChanged(testNode("node-"+tt.name, tt.serverID))
+			}
+
+			th.AssertEquals(t, 0, cloud.Requests(http.MethodGet, fakecloud.ComputePath+"/servers/"+tt.serverID))
+			th.AssertEquals(t, tt.member, ng.members.Known(tt.serverID))
+		})
+	}
+	if lists := cloud.Requests(http.MethodGet, fakecloud.ComputePath+"/servers/detail"); lists > 1 {
+		t.Errorf("expected node events to share one server listing, got %d listings", lists)
+	}
+}
+
+func TestUpdateNodeGroupCopiesMemberState(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	existing := newTestNodeGroup(t, p)
+	memberID := addMember(cloud, testGroupID, "workers-a")
+	existing.members.Remember(memberID)
+
+	cfg := *existing.Config
+	cfg.MaxSize = 20
+	updated, err := p.UpdateNodeGroup(&cfg, existing.ResourceVersion())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, uint64(2), updated.ResourceVersion())
+
+	// The replaced node group may still repair its members, that must not leak into the new one
+	th.AssertEquals(t, true, existing.members.ShouldRepair(memberID, time.Hour))
+
+	if !updated.members.Known(memberID) {
+		t.Fatal("expected the member to be carried over")
+	}
+	if !updated.members.ShouldRepair(memberID, time.Hour) {
+		t.Error("expected the member state to be copied, not shared")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		loops   int
+		ignore  bool
+		wantErr bool
+	}{
+		{name: "no loops"},
+		{name: "loops honouring the context", loops: 3},
+		{name: "loop ignoring the context", loops: 1, ignore: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			p.Start()
+
+			release := make(chan struct{})
+			defer close(release)
+			var exited atomic.Int32
+			for i := range tt.loops {
+				p.RunBackground(fmt.Sprintf("test-%d", i), func(ctx context.Context) {
+					defer exited.Add(1)
+					if tt.ignore {
+						<-release
+						return
+					}
+					<-ctx.Done()
+				})
+			}
+
+			err := p.Shutdown(100 * time.Millisecond)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected shutdown to time out")
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, int32(tt.loops), exited.Load())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// serverIDPattern matches the UUID form of Nova server IDs
+var serverIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ProviderID returns the provider ID of a server in the canonical openstack:///<id> form
+// registered by the OpenStack cloud controller manager
+func ProviderID(serverID string) string {
+	return fmt.Sprintf("%s:///%s", ProviderName, serverID)
+}
+
+// templateProviderIDPrefix precedes the node group ID in the provider ID of template nodes
+const templateProviderIDPrefix = "template-"
+
+// templateProviderID returns the provider ID of the template node of a node group
+func templateProviderID(groupID string) string {
+	return ProviderID(templateProviderIDPrefix + groupID)
+}
+
+// parseTemplateProviderID returns the node group ID of a template node provider ID, the cluster
+// autoscaler passes those for nodes that do not exist yet
+func parseTemplateProviderID(providerID string) (string, bool) {
+	return strings.CutPrefix(providerID, ProviderID(templateProviderIDPrefix))
+}
+
+// parseProviderID returns the server ID of a provider ID. Besides the canonical form it accepts
+// openstack://<id> and openstack://<region>/<id>, which older nodes still carry. Provider IDs of
+// other providers and server IDs that are not UUIDs fail before any API call is made.
+func parseProviderID(providerID string) (string, error) {
+	rest, found := strings.CutPrefix(providerID, ProviderName+"://")
+	if !found {
+		return "", fmt.Errorf("provider ID %q does not start with %s://", providerID, ProviderName)
+	}
+
+	serverID := rest[strings.LastIndex(rest, "/")+1:]
+	if !serverIDPattern.MatchString(serverID) {
+		return "", fmt.Errorf("provider ID %q does not end in a server UUID", providerID)
+	}
+	return serverID, nil
+}
+
+// serverByName returns the server named exactly like a node, or nil when there is none. Nodes
+// the cloud controller manager has not initialized yet have no provider ID to go by, several
+// servers with the name are an error rather than a guess.
+func (p *OpenStackProvider) serverByName(name string) (*servers.Server, error) {
+	// Nova matches the name filter as a regular expression
+	listOpts := servers.ListOpts{Name: "^" + regexp.QuoteMeta(name) + "$"}
+	allPages, err := servers.List(p.computeClient(), listOpts).AllPages(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers named %s: %w", name, wrapOpenStackError(err))
+	}
+
+	allServers, err := servers.ExtractServers(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract servers: %w", err)
+	}
+
+	var matches []servers.Server
+	for _, server := range allServers {
+		if server.Name == name {
+			matches = append(matches, server)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d servers are named %s", len(matches), name)
+	}
+}
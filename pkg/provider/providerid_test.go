@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+)
+
+func TestParseProviderID(t *testing.T) {
+	const serverID = "5f3c7a1e-2b4d-4c6e-8f90-1a2b3c4d5e6f"
+	tests := []struct {
+		name       string
+		providerID string
+		serverID   string
+	}{
+		{name: "canonical", providerID: "openstack:///" + serverID, serverID: serverID},
+		{name: "two slashes", providerID: "openstack://" + serverID, serverID: serverID},
+		{name: "region", providerID: "openstack://RegionOne/" + serverID, serverID: serverID},
+		{name: "upper case UUID", providerID: "openstack:///" + strings.ToUpper(serverID), serverID: strings.ToUpper(serverID)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseProviderID(tt.providerID)
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.serverID, parsed)
+		})
+	}
+
+	errorTests := []struct {
+		name       string
+		providerID string
+		err        string
+	}{
+		{name: "empty", providerID: "", err: "does not start with openstack://"},
+		{name: "other provider", providerID: "aws:///us-east-1a/i-0123456789abcdef0", err: "does not start with openstack://"},
+		{name: "prefix without scheme separator", providerID: "openstack:" + serverID, err: "does not start with openstack://"},
+		{name: "not a UUID", providerID: "openstack:///workers-a", err: "does not end in a server UUID"},
+		{name: "truncated UUID", providerID: "openstack:///" + serverID[:30], err: "does not end in a server UUID"},
+		{name: "missing server ID", providerID: "openstack://RegionOne/", err: "does not end in a server UUID"},
+		{name: "template node", providerID: "openstack:///template-" + testGroupID, err: "does not end in a server UUID"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProviderID(tt.providerID)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	// Generated provider IDs take the canonical form and parse back
+	th.AssertEquals(t, "openstack:///"+serverID, ProviderID(serverID))
+	parsed, err := parseProviderID(ProviderID(serverID))
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, serverID, parsed)
+}
+
+func TestNodeGroupForNodeProviderIDFormats(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+	memberID := addMember(cloud, testGroupID, "workers-a")
+
+	tests := []struct {
+		name       string
+		providerID string
+	}{
+		{name: "canonical", providerID: "openstack:///" + memberID},
+		{name: "two slashes", providerID: "openstack://" + memberID},
+		{name: "region", providerID: "openstack://RegionOne/" + memberID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.invalidateNodeGroupCache()
+
+			found, err := p.NodeGroupForNode(tt.providerID, "workers-a")
+			th.AssertNoErr(t, err)
+			if found != ng {
+				t.Fatalf("expected node group %s, got %v", ng.Config.ID, found)
+			}
+		})
+	}
+
+	// Template nodes carry the canonical form too
+	template, err := ng.TemplateNodeInfo()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "openstack:///template-"+testGroupID, template.Spec.ProviderID)
+}
+
+func TestMalformedProviderIDSkipsCompute(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+	addMember(cloud, testGroupID, "workers-a")
+	th.AssertNoErr(t, ng.Refresh())
+
+	tests := []struct {
+		name       string
+		providerID string
+		err        string
+	}{
+		{name: "other provider", providerID: "aws:///us-east-1a/i-0123456789abcdef0", err: "does not start with openstack://"},
+		{name: "not a UUID", providerID: "openstack:///workers-a", err: "does not end in a server UUID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(cloud.RequestLog())
+
+			// Without a node name to fall back on, the provider ID alone decides
+			found, err := p.NodeGroupForNode(tt.providerID, "")
+			if err == nil || !strings.Contains(err.Error(), tt.err) || found != nil {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+			err = ng.DeleteNodes([]*apiv1.Node{{Spec: apiv1.NodeSpec{ProviderID: tt.providerID}}})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+
+			// The servers of the node group may be listed, no single server is fetched or deleted
+			for _, request := range cloud.RequestLog()[before:] {
+				if strings.HasPrefix(request.Path, fakecloud.ComputePath+"/servers/") && request.Path != fakecloud.ComputePath+"/servers/detail" {
+					t.Errorf("unexpected request %s %s", request.Method, request.Path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+const (
+	// schedulingTimeout bounds how long a scale-up waits for Nova to place a server on a host
+	schedulingTimeout = 2 * time.Minute
+	// taskStateScheduling is the Nova task state of a server waiting for a host
+	taskStateScheduling = "scheduling"
+)
+
+// serverPollInterval is the interval between server status checks while waiting for provisioning
+var serverPollInterval = 5 * time.Second
+
+// ProvisioningFailure records why a new server did not become a usable node
+type ProvisioningFailure struct {
+	Reason string
+	// TimedOut is set for servers still building after the provision timeout
+	TimedOut bool
+	// OutOfResources is set when the cloud ran out of a resource the server needs
+	OutOfResources bool
+	at             time.Time
+}
+
+// provisionTracker holds the servers being watched and the provisioning failures they ended in
+type provisionTracker struct {
+	mutex    sync.Mutex
+	watching map[string]bool
+	failures map[string]ProvisioningFailure
+}
+
+// watchProvisioning polls a new server in the background until it is ACTIVE, recording a failure
+// when Nova puts it in ERROR or the provision timeout expires. The watch outlives the scale-up
+// request and stops on provider shutdown.
+func (ng *OpenStackNodeGroup) watchProvisioning(serverID string) {
+	tracker := &ng.Provider.provisioning
+	tracker.mutex.Lock()
+	if tracker.watching[serverID] {
+		tracker.mutex.Unlock()
+		return
+	}
+	if tracker.watching == nil {
+		tracker.watching = make(map[string]bool)
+	}
+	tracker.watching[serverID] = true
+	tracker.mutex.Unlock()
+
+	ng.Provider.RunBackground("provision-watch-"+serverID, func(ctx context.Context) {
+		defer func() {
+			tracker.mutex.Lock()
+			delete(tracker.watching, serverID)
+			tracker.mutex.Unlock()
+		}()
+
+		failure, err := ng.waitForServerActive(ctx, serverID)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				klog.Errorf("Stopped watching provisioning of server %s of node group %s: %v", serverID, ng.Config.ID, err)
+			}
+		case failure != nil:
+			klog.Errorf("Server %s of node group %s failed to provision: %s", serverID, ng.Config.ID, failure.Reason)
+			ng.Provider.recordProvisioningFailure(serverID, *failure)
+		}
+		ng.recordProvisioningOutcome(failure, err)
+	})
+}
+
+// awaitProvisioning waits until a new server is ACTIVE, for node groups whose scale-ups wait for
+// their servers. A server ending in ERROR or still building after the provision timeout is
+// deleted and its failure returned.
+func (ng *OpenStackNodeGroup) awaitProvisioning(serverID string) error {
+	failure, err := ng.waitForServerActive(ng.Provider.ctx, serverID)
+	ng.recordProvisioningOutcome(failure, err)
+	if err == nil && failure == nil {
+		return nil
+	}
+	if failure != nil {
+		err = fmt.Errorf("%s", failure.Reason)
+	}
+	ng.discardServer(serverID)
+	return err
+}
+
+// awaitScheduling waits until Nova placed a new server on a host, so a zone without capacity is
+// noticed while the scale-up can still try other zones. A server Nova found no valid host for is
+// deleted and its fault returned, other failures are left to the provisioning watch.
+func (ng *OpenStackNodeGroup) awaitScheduling(serverID string) error {
+	deadline := time.Now().Add(schedulingTimeout)
+	for {
+		server, err := servers.Get(ng.Provider.ctx, ng.Provider.computeClient(), serverID).Extract()
+		if err != nil {
+			return fmt.Errorf("failed to get server %s: %w", serverID, wrapOpenStackError(err))
+		}
+
+		switch ServerInstanceState(server) {
+		case InstanceStateFailed:
+			err := fmt.Errorf("server %s failed to build: %s", serverID, server.Fault.Message)
+			if !isNoValidHost(err) {
+				return nil
+			}
+			ng.discardServer(serverID)
+			return err
+		case InstanceStateCreating:
+			if server.TaskState == taskStateScheduling && time.Now().Before(deadline) {
+				break
+			}
+			return nil
+		default:
+			return nil
+		}
+
+		select {
+		case <-ng.Provider.ctx.Done():
+			return ng.Provider.ctx.Err()
+		case <-time.After(serverPollInterval):
+		}
+	}
+}
+
+// discardServer deletes a new server that failed to provision
+func (ng *OpenStackNodeGroup) discardServer(serverID string) {
+	if err := ng.deleteServer(serverID); err != nil {
+		klog.Errorf("Failed to delete server %s after it failed to provision: %v", serverID, err)
+		return
+	}
+	ng.members.Forget(serverID)
+}
+
+// recordProvisioningOutcome counts how a watched server ended provisioning, servers that could not
+// be polled to the end are not counted
+func (ng *OpenStackNodeGroup) recordProvisioningOutcome(failure *ProvisioningFailure, err error) {
+	switch {
+	case err != nil:
+	case failure == nil:
+		metrics.ProvisioningOutcomes.WithLabelValues(ng.Config.ID, "active").Inc()
+	case failure.TimedOut:
+		metrics.ProvisioningOutcomes.WithLabelValues(ng.Config.ID, "timeout").Inc()
+	default:
+		metrics.ProvisioningOutcomes.WithLabelValues(ng.Config.ID, "error").Inc()
+	}
+}
+
+// waitForServerActive polls a new server until it is ACTIVE. It returns a failure when Nova puts
+// the server in ERROR, carrying the fault message, or when the provision timeout expires, and an
+// error when the server cannot be polled or ctx is cancelled.
+func (ng *OpenStackNodeGroup) waitForServerActive(ctx context.Context, serverID string) (*ProvisioningFailure, error) {
+	timeout := ng.Config.ProvisionTimeout
+	klog.V(2).Infof("Watching server %s of node group %s for up to %s until it is ACTIVE", serverID, ng.Config.ID, timeout)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		server, err := servers.Get(ctx, ng.Provider.computeClient(), serverID).Extract()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server %s: %w", serverID, wrapOpenStackError(err))
+		}
+
+		switch ServerInstanceState(server) {
+		case InstanceStateRunning:
+			klog.V(2).Infof("Server %s of node group %s is ACTIVE", serverID, ng.Config.ID)
+			return nil, nil
+		case InstanceStateFailed:
+			reason := fmt.Sprintf("server %s failed to build", serverID)
+			if server.Fault.Message != "" {
+				reason = fmt.Sprintf("server %s failed to build: %s", serverID, server.Fault.Message)
+			}
+			return &ProvisioningFailure{Reason: reason, at: time.Now()}, nil
+		case InstanceStateDeleting:
+			return nil, fmt.Errorf("server %s is being deleted", serverID)
+		}
+
+		if time.Now().After(deadline) {
+			reason := fmt.Sprintf("server %s is still %s after %s", serverID, server.Status, timeout)
+			return &ProvisioningFailure{Reason: reason, TimedOut: true, at: time.Now()}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(serverPollInterval):
+		}
+	}
+}
+
+// ProvisioningFailure returns why a server of the node group did not become a usable node, or nil
+// when it did not fail
+func (ng *OpenStackNodeGroup) ProvisioningFailure(serverID string) *ProvisioningFailure {
+	tracker := &ng.Provider.provisioning
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	failure, exists := tracker.failures[serverID]
+	if !exists {
+		return nil
+	}
+	return &failure
+}
+
+// recordProvisioningFailure records why a new server failed to provision
+func (p *OpenStackProvider) recordProvisioningFailure(serverID string, failure ProvisioningFailure) {
+	if failure.at.IsZero() {
+		failure.at = time.Now()
+	}
+
+	p.provisioning.mutex.Lock()
+	defer p.provisioning.mutex.Unlock()
+	if p.provisioning.failures == nil {
+		p.provisioning.failures = make(map[string]ProvisioningFailure)
+	}
+	p.provisioning.failures[serverID] = failure
+}
+
+// forgetProvisioning drops the provisioning failure of a deleted server
+func (p *OpenStackProvider) forgetProvisioning(serverID string) {
+	p.provisioning.mutex.Lock()
+	defer p.provisioning.mutex.Unlock()
+	delete(p.provisioning.failures, serverID)
+}
+
+// expireProvisioning drops provisioning failures older than retention and returns how many remain
+func (p *OpenStackProvider) expireProvisioning(retention time.Duration) int {
+	p.provisioning.mutex.Lock()
+	defer p.provisioning.mutex.Unlock()
+
+	for serverID, failure := range p.provisioning.failures {
+		if time.Since(failure.at) > retention {
+			delete(p.provisioning.failures, serverID)
+		}
+	}
+	return len(p.provisioning.failures)
+}
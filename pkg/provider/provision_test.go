@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestProvisioning(t *testing.T) {
+	tests := []struct {
+		name    string
+		wait    bool
+		status  string
+		fault   string
+		timeout time.Duration
+		// Expected error of the scale-up, empty when it succeeds
+		err string
+		// Expected recorded failure of a watched server, empty when none is recorded
+		failure  string
+		timedOut bool
+	}{
+		{name: "wait until active", wait: true, status: fakecloud.StatusActive},
+		{name: "wait until error", wait: true, status: "ERROR", fault: "No valid host was found.", err: "No valid host was found."},
+		{name: "wait until timeout", wait: true, status: "BUILD", timeout: time.Nanosecond, err: "still BUILD"},
+		{name: "watch until active", status: fakecloud.StatusActive},
+		{name: "watch until error", status: "ERROR", fault: "No valid host was found.", failure: "No valid host was found."},
+		{name: "watch until timeout", status: "BUILD", timeout: time.Nanosecond, failure: "still BUILD", timedOut: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.OnCreate(func(server *fakecloud.Server) {
+				server.Status, server.Fault = tt.status, tt.fault
+			})
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.ProvisionTimeout = time.Minute
+				if tt.timeout > 0 {
+					cfg.ProvisionTimeout = tt.timeout
+				}
+				cfg.WaitForActive = tt.wait
+			})
+
+			err := ng.IncreaseSize(1)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				th.AssertEquals(t, 0, len(cloud.Servers()))
+				return
+			}
+			th.AssertNoErr(t, err)
+			created := cloud.Servers()
+			th.AssertEquals(t, 1, len(created))
+			if tt.wait {
+				return
+			}
+
+			// The watch runs in the background, the server is left to the cluster autoscaler
+			waitForWatches(t, p)
+			failure := ng.ProvisioningFailure(created[0].ID)
+			th.AssertEquals(t, tt.failure != "", failure != nil)
+			if failure != nil {
+				if !strings.Contains(failure.Reason, tt.failure) {
+					t.Errorf("expected a failure containing %q, got %q", tt.failure, failure.Reason)
+				}
+				th.AssertEquals(t, tt.timedOut, failure.TimedOut)
+			}
+			th.AssertEquals(t, 1, len(cloud.Servers()))
+		})
+	}
+}
+
+func TestWaitForActiveRequiresProvisionTimeout(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	cfg := testNodeGroupConfig()
+	cfg.WaitForActive = true
+
+	if _, err := p.AddNodeGroup(cfg); err == nil {
+		t.Fatal("expected waitForActive without provisionTimeout to be rejected")
+	}
+}
+
+// waitForWatches waits until no provisioning watch is running
+func waitForWatches(t *testing.T, p *OpenStackProvider) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.provisioning.mutex.Lock()
+		watching := len(p.provisioning.watching)
+		p.provisioning.mutex.Unlock()
+		if watching == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d provisioning watches still running", watching)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWaitForActiveDeletesOnlyFailedServers(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		fault  string
+		err    string
+	}{
+		{name: "error", status: fakecloud.StatusError, fault: "Exceeded maximum number of retries.", err: "Exceeded maximum number of retries."},
+		{name: "deleted while building", status: "DELETED", err: "is being deleted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			created := 0
+			cloud.OnCreate(func(server *fakecloud.Server) {
+				// The second of three servers fails
+				if created++; created == 2 {
+					server.Status, server.Fault = tt.status, tt.fault
+				}
+			})
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.ProvisionTimeout = time.Minute
+				cfg.WaitForActive = true
+				cfg.RollbackOnFailure = new(bool)
+			})
+
+			err := ng.IncreaseSize(3)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+			remaining := cloud.Servers()
+			th.AssertEquals(t, 2, len(remaining))
+			for _, server := range remaining {
+				th.AssertEquals(t, fakecloud.StatusActive, server.Status)
+			}
+
+			// Without rollback the target keeps the failed server until the cluster autoscaler
+			// decreases it for the node that never registers
+			size, err := ng.TargetSize()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 3, size)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/nodegroup"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/metrics"
+)
+
+// QuarantineOverride manually controls the quarantine of a node group
+type QuarantineOverride = nodegroup.QuarantineOverride
+
+const (
+	// QuarantineAuto quarantines the node group based on its failure rate
+	QuarantineAuto = nodegroup.QuarantineAuto
+	// QuarantineForced keeps the node group quarantined until the override is reset
+	QuarantineForced = nodegroup.QuarantineForced
+	// QuarantineDisabled never quarantines the node group
+	QuarantineDisabled = nodegroup.QuarantineDisabled
+)
+
+// ParseQuarantineOverride returns the quarantine override named auto, forced or disabled
+func ParseQuarantineOverride(name string) (QuarantineOverride, error) {
+	for _, override := range []QuarantineOverride{QuarantineAuto, QuarantineForced, QuarantineDisabled} {
+		if override.String() == name {
+			return override, nil
+		}
+	}
+	return QuarantineAuto, configErrorf("unknown quarantine override %q, expected auto, forced or disabled", name)
+}
+
+// recordOutcome records the result of a server operation and quarantines the node group
+// when the failure rate over the window exceeds the threshold
+func (ng *OpenStackNodeGroup) recordOutcome(err error) {
+	cloud := ng.Provider.config.Load().Cloud
+	policy := nodegroup.QuarantinePolicy{
+		Window:      cloud.QuarantineWindow,
+		FailureRate: cloud.QuarantineFailureRate,
+		Cooldown:    cloud.QuarantineCooldown,
+	}.WithDefaults()
+
+	quarantined, failures, operations := ng.quarantine.Record(err != nil, policy)
+	if !quarantined {
+		return
+	}
+	metrics.NodeGroupQuarantined.WithLabelValues(ng.Config.ID).Set(1)
+	klog.Warningf("Quarantining node group %s for %s, %d of %d server operations failed within %s: %v", ng.Config.ID, policy.Cooldown, failures, operations, policy.Window, err)
+}
+
+// checkQuarantine returns ErrQuarantined while mutations of the node group are paused,
+// lifting an automatic quarantine once its cool-down has passed
+func (ng *OpenStackNodeGroup) checkQuarantine() error {
+	state, until := ng.quarantine.Check()
+	switch state {
+	case nodegroup.QuarantineManual:
+		return fmt.Errorf("%w: node group %s is quarantined manually", ErrQuarantined, ng.Config.ID)
+	case nodegroup.QuarantineActive:
+		return fmt.Errorf("%w: node group %s is quarantined until %s", ErrQuarantined, ng.Config.ID, until.Format(time.RFC3339))
+	case nodegroup.QuarantineLifted:
+		metrics.NodeGroupQuarantined.WithLabelValues(ng.Config.ID).Set(0)
+		klog.Infof("Lifting quarantine of node group %s", ng.Config.ID)
+	}
+	return nil
+}
+
+// SetQuarantineOverride manually quarantines the node group, exempts it from quarantine or
+// returns it to automatic quarantine. Any automatic quarantine in effect is lifted.
+func (ng *OpenStackNodeGroup) SetQuarantineOverride(override QuarantineOverride) {
+	ng.quarantine.SetOverride(override)
+
+	quarantined := 0.0
+	if override == QuarantineForced {
+		quarantined = 1
+	}
+	metrics.NodeGroupQuarantined.WithLabelValues(ng.Config.ID).Set(quarantined)
+	klog.Infof("Quarantine override of node group %s set to %s", ng.Config.ID, override)
+}
+
+// QuarantineStatus describes the quarantine of the node group, empty when mutations are allowed
+func (ng *OpenStackNodeGroup) QuarantineStatus() string {
+	return ng.quarantine.Status()
+}
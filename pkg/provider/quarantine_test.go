@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestFailingNodeGroupDoesNotImpedeHealthyOne(t *testing.T) {
+	previous := serverPollInterval
+	serverPollInterval = time.Millisecond
+	t.Cleanup(func() { serverPollInterval = previous })
+
+	// Two node groups share the rate equally, five failed scale-ups quarantine a node group
+	const operationRate, share, quarantineOperations = 40.0, 20.0, 5
+	cloud := newTestCloud(t)
+	brokenImageID := cloud.AddImage(fakecloud.Image{Name: "broken-image"})
+	var mutex sync.Mutex
+	brokenCreates := 0
+	cloud.OnCreate(func(server *fakecloud.Server) {
+		if server.ImageID == brokenImageID {
+			mutex.Lock()
+			brokenCreates++
+			mutex.Unlock()
+			server.Status, server.Fault = fakecloud.StatusError, "Image is corrupt."
+		}
+	})
+	p := newTestProvider(t, cloud, func(cfg *config.Config) { cfg.Cloud.ServerOperationRate = operationRate })
+	broken := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+		cfg.ID, cfg.ImageID = "broken", brokenImageID
+		cfg.WaitForActive = true
+		cfg.ProvisionTimeout = time.Minute
+	})
+	healthy := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) { cfg.ID = "healthy" })
+
+	// The broken node group retries its scale-up until it is quarantined
+	start := time.Now()
+	var brokenErr error
+	var brokenElapsed time.Duration
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if err := broken.IncreaseSize(1); errors.Is(err, ErrQuarantined) {
+				brokenErr, brokenElapsed = err, time.Since(start)
+				return
+			}
+		}
+	}()
+	for i := range 10 {
+		if err := healthy.IncreaseSize(1); err != nil {
+			t.Fatalf("scale-up %d of the healthy node group failed: %v", i+1, err)
+		}
+	}
+	<-done
+
+	if brokenErr == nil {
+		t.Fatal("expected the broken node group to be quarantined")
+	}
+	if broken.QuarantineStatus() == "" || healthy.QuarantineStatus() != "" {
+		t.Fatalf("expected only the broken node group to be quarantined, got %q and %q", broken.QuarantineStatus(), healthy.QuarantineStatus())
+	}
+	servers := cloud.Servers()
+	th.AssertEquals(t, 10, len(servers))
+	for _, server := range servers {
+		th.AssertEquals(t, testImageID, server.ImageID)
+	}
+
+	// The broken node group created and deleted its servers within its share of the rate
+	mutex.Lock()
+	operations := brokenCreates + cloud.Requests(http.MethodDelete, fakecloud.ComputePath+"/servers/")
+	mutex.Unlock()
+	if allowed := int(share*brokenElapsed.Seconds()) + 1; operations < 2*quarantineOperations || operations > allowed {
+		t.Fatalf("expected between %d and %d server operations of the broken node group in %s, got %d", 2*quarantineOperations, allowed, brokenElapsed, operations)
+	}
+}
@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/limits"
+	"k8s.io/klog/v2"
+)
+
+// checkComputeQuota returns ErrQuotaExceeded naming the exhausted resources when count more
+// servers of the node group flavor do not fit the instance, core or RAM quota of the project.
+// A cloud whose limits cannot be read is not blocked, Nova still enforces the quota itself.
+func (ng *OpenStackNodeGroup) checkComputeQuota(count int) error {
+	if ng.Provider.config.Load().Cloud.SkipQuotaCheck {
+		return nil
+	}
+
+	flavor, err := ng.getFlavor()
+	if err != nil {
+		return fmt.Errorf("failed to get flavor: %w", err)
+	}
+
+	result, err := limits.Get(context.TODO(), ng.Provider.computeClient(), nil).Extract()
+	if err != nil {
+		klog.Warningf("Failed to get compute limits, skipping the quota check of node group %s: %v", ng.Config.ID, wrapOpenStackError(err))
+		return nil
+	}
+	absolute := result.Absolute
+
+	var exhausted []string
+	check := func(resource string, max, used, needed int) {
+		// Nova reports unlimited quota as -1
+		if max < 0 || used+needed <= max {
+			return
+		}
+		exhausted = append(exhausted, fmt.Sprintf("%s (%d of %d used, %d needed)", resource, used, max, needed))
+	}
+	check("instances", absolute.MaxTotalInstances, absolute.TotalInstancesUsed, count)
+	check("cores", absolute.MaxTotalCores, absolute.TotalCoresUsed, count*flavor.VCPUs)
+	check("ram", absolute.MaxTotalRAMSize, absolute.TotalRAMUsed, count*flavor.RAM)
+
+	if len(exhausted) > 0 {
+		return fmt.Errorf("%w: %d servers of flavor %s for node group %s exceed the project quota: %s", ErrQuotaExceeded, count, flavor.Name, ng.Config.ID, strings.Join(exhausted, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"strings"
+)
+
+const (
+	// ReasonClusterAutoscaler is the reason of scale operations requested through the external-grpc protocol
+	ReasonClusterAutoscaler = "cluster-autoscaler"
+	// ReasonEvacuation is the reason of scale operations performed by a node group evacuation
+	ReasonEvacuation = "evacuation"
+	// ReasonCleanup is the reason of server deletions performed by an admin cleanup
+	ReasonCleanup = "cleanup"
+
+	// createdByReasonMetadataKey is the server metadata key recording why a server was created
+	createdByReasonMetadataKey = "created_by_reason"
+	// maxReasonLength bounds the length of a scale operation reason
+	maxReasonLength = 64
+)
+
+// sanitizeReason limits a scale operation reason to a short string of safe characters
+func sanitizeReason(reason string) string {
+	reason = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-', r == '_', r == '.', r == ':', r == ' ':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(reason))
+
+	if len(reason) > maxReasonLength {
+		reason = reason[:maxReasonLength]
+	}
+	if reason == "" {
+		return "unknown"
+	}
+	return reason
+}
+
+// withOperatorReason appends the reason an operator gave to the reason of an operation
+func withOperatorReason(reason, operatorReason string) string {
+	if strings.TrimSpace(operatorReason) == "" {
+		return reason
+	}
+	return reason + ": " + operatorReason
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"k8s.io/klog/v2"
+)
+
+func TestSanitizeReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		want   string
+	}{
+		{name: "allowed characters", reason: "canary: rollout-2.1_b", want: "canary: rollout-2.1_b"},
+		{name: "surrounding whitespace", reason: "  evacuation \n", want: "evacuation"},
+		{name: "control characters", reason: "drain\nnode\t1\x00", want: "drain_node_1_"},
+		{name: "log injection", reason: "ok\r\nI1015 fake entry", want: "ok__I1015 fake entry"},
+		{name: "non-ASCII", reason: "wartung-ü", want: "wartung-_"},
+		{name: "length limit", reason: strings.Repeat("a", 100), want: strings.Repeat("a", maxReasonLength)},
+		{name: "exact length", reason: strings.Repeat("b", maxReasonLength), want: strings.Repeat("b", maxReasonLength)},
+		{name: "empty", reason: "", want: "unknown"},
+		{name: "only whitespace", reason: " \t ", want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th.AssertEquals(t, tt.want, sanitizeReason(tt.reason))
+		})
+	}
+}
+
+func TestWithOperatorReason(t *testing.T) {
+	th.AssertEquals(t, ReasonEvacuation, withOperatorReason(ReasonEvacuation, ""))
+	th.AssertEquals(t, ReasonEvacuation, withOperatorReason(ReasonEvacuation, "  "))
+	th.AssertEquals(t, "evacuation: retire az1", withOperatorReason(ReasonEvacuation, "retire az1"))
+}
+
+func TestScaleOperationsRecordReason(t *testing.T) {
+	logs := captureLogs(t)
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	_, _, serverIDs := newEvacuationGroups(t, cloud, p, 1)
+	p.SetNodeDrainer(&recordingDrainer{cloud: cloud, groupID: "new"})
+	canary := newTestNodeGroup(t, p)
+
+	// Protocol-initiated scale-ups record the cluster autoscaler, others the sanitized reason
+	th.AssertNoErr(t, canary.IncreaseSize(1))
+	th.AssertNoErr(t, canary.IncreaseSizeWithReason(t.Context(), 1, "canary\nrollout"))
+	_, err := p.EvacuateNodeGroup(EvacuationOptions{SourceGroupID: "old", TargetGroupID: "new", Reason: "retire az1"})
+	th.AssertNoErr(t, err)
+	waitFor(t, func() bool { return !evacuationRunning(p, "old") })
+
+	reasons := map[string]int{}
+	for _, server := range cloud.Servers() {
+		reasons[server.Metadata[createdByReasonMetadataKey]]++
+	}
+	th.AssertDeepEquals(t, map[string]int{
+		ReasonClusterAutoscaler:  1,
+		"canary_rollout":         1,
+		"evacuation: retire az1": 1,
+	}, reasons)
+
+	klog.Flush()
+	if !strings.Contains(logs.String(), "Deleting evacuated server "+serverIDs[0]+" of node group old (reason: evacuation: retire az1)") {
+		t.Errorf("expected the deletion to log the evacuation reason, got:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/osclient"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// connectionSettings returns the cloud settings the OpenStack clients are built from
+func connectionSettings(cloud *config.CloudConfig) []string {
+	return []string{
+		cloud.AuthURL, cloud.Username, cloud.Password, cloud.ProjectName, cloud.ProjectID,
+		cloud.UserDomainName, cloud.ProjectDomainName, cloud.ApplicationCredentialID,
+		cloud.ApplicationCredentialName, cloud.ApplicationCredentialSecret, cloud.Region,
+		cloud.Interface, cloud.IdentityAPIVersion, cloud.ComputeAPIVersion,
+		cloud.NetworkAPIVersion, cloud.VolumeAPIVersion, cloud.ComputeInterface, cloud.ImageInterface,
+		cloud.NetworkInterface, cloud.VolumeInterface, cloud.CACertFile, strconv.FormatBool(cloud.Insecure),
+	}
+}
+
+// Reload applies a re-read configuration. The cluster name cannot change. Tunables and pricing take effect immediately and the
+// caches built from the previous configuration are dropped. Changed credentials or endpoints
+// authenticate a new set of OpenStack clients that replaces the previous one, and node groups are
+// added, updated and removed to match the configuration. When any of this fails the provider keeps
+// running with the previous configuration and clients.
+func (p *OpenStackProvider) Reload(cfg *config.Config) error {
+	// Servers are stamped with the cluster name at creation and cleanup only selects servers
+	// carrying the configured one, a new name would disown every existing server
+	if cfg.Cloud.ClusterName != p.config.Load().Cloud.ClusterName {
+		return configErrorf("cluster_name cannot change while running, restart with the new name instead")
+	}
+
+	previousClients := p.clients.Load()
+	if !slices.Equal(connectionSettings(&p.config.Load().Cloud), connectionSettings(&cfg.Cloud)) {
+		clients, err := osclient.New(context.TODO(), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild OpenStack clients for the changed cloud settings: %w", err)
+		}
+		p.clients.Store(clients)
+		klog.Info("Cloud settings changed, switched to newly authenticated OpenStack clients")
+	}
+
+	if err := p.syncNodeGroups(cfg.NodeGroups); err != nil {
+		p.clients.Store(previousClients)
+		return err
+	}
+
+	p.config.Store(cfg)
+	p.invalidateLookups()
+	p.invalidateServerSnapshot()
+	p.invalidateNodeGroupCache()
+	for _, ng := range p.GetNodeGroups() {
+		ng.InvalidateTemplateNodeInfo()
+	}
+
+	klog.Info("Configuration reloaded")
+	return nil
+}
+
+// syncNodeGroups adds, updates and removes node groups to match the configured ones. Every added
+// or changed node group is validated before any is applied. Removed node groups are forgotten,
+// their servers are left in place.
+func (p *OpenStackProvider) syncNodeGroups(configs []config.NodeGroupConfig) error {
+	configured := make(map[string]*OpenStackNodeGroup, len(configs))
+	for i := range configs {
+		ngConfig := configs[i]
+		if _, duplicate := configured[ngConfig.ID]; duplicate {
+			return configErrorf("node group %s is configured twice", ngConfig.ID)
+		}
+		configured[ngConfig.ID] = nil
+
+		if existing := p.GetNodeGroup(ngConfig.ID); existing != nil && reflect.DeepEqual(*existing.Config, ngConfig) {
+			continue
+		}
+		nodeGroup, err := p.newValidatedNodeGroup(&ngConfig)
+		if err != nil {
+			return err
+		}
+		configured[ngConfig.ID] = nodeGroup
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for id, nodeGroup := range configured {
+		if nodeGroup == nil {
+			continue
+		}
+		if existing, exists := p.nodeGroups[id]; exists {
+			p.replaceNodeGroupLocked(existing, nodeGroup)
+			continue
+		}
+		p.nodeGroups[id] = nodeGroup
+		klog.Infof("Added node group: %s", id)
+	}
+	nodeGroupIDs := make(map[string]bool, len(configured))
+	for id := range p.nodeGroups {
+		if _, exists := configured[id]; !exists {
+			delete(p.nodeGroups, id)
+			klog.Infof("Removed node group %s, its servers are left in place", id)
+			continue
+		}
+		nodeGroupIDs[id] = true
+	}
+	p.pruneDesiredSizes(nodeGroupIDs)
+
+	p.invalidateNodeGroupCache()
+	p.invalidateServerSnapshot()
+	p.invalidateLookups()
+	return nil
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+// reloadConfig returns a copy of the configuration of the provider with the node groups replaced
+func reloadConfig(p *OpenStackProvider, nodeGroups ...config.NodeGroupConfig) *config.Config {
+	cfg := *p.config.Load()
+	cfg.NodeGroups = nodeGroups
+	return &cfg
+}
+
+// namedNodeGroupConfig returns the test node group configuration under another ID
+func namedNodeGroupConfig(id string) config.NodeGroupConfig {
+	cfg := *testNodeGroupConfig()
+	cfg.ID = id
+	return cfg
+}
+
+func TestReloadNodeGroups(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.NodeGroups = []config.NodeGroupConfig{namedNodeGroupConfig("a"), namedNodeGroupConfig("b"), namedNodeGroupConfig("c")}
+	})
+	memberID := addMember(cloud, "b", "b-1")
+
+	updated := namedNodeGroupConfig("a")
+	updated.MinSize = 1
+	updated.MaxSize = 20
+	updated.Labels = map[string]string{"pool": "a"}
+	th.AssertNoErr(t, p.Reload(reloadConfig(p, updated, namedNodeGroupConfig("c"), namedNodeGroupConfig("d"))))
+
+	tests := []struct {
+		id      string
+		present bool
+		version uint64
+		maxSize int
+	}{
+		{id: "a", present: true, version: 2, maxSize: 20},
+		{id: "b", present: false},
+		{id: "c", present: true, version: 1, maxSize: 10},
+		{id: "d", present: true, version: 1, maxSize: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			ng := p.GetNodeGroup(tt.id)
+			th.AssertEquals(t, tt.present, ng != nil)
+			if ng == nil {
+				return
+			}
+			th.AssertEquals(t, tt.version, ng.ResourceVersion())
+			th.AssertEquals(t, tt.maxSize, ng.MaxSize())
+		})
+	}
+
+	th.AssertEquals(t, 1, p.GetNodeGroup("a").MinSize())
+	th.AssertEquals(t, "a", p.GetNodeGroup("a").Config.Labels["pool"])
+	if _, exists := cloud.Server(memberID); !exists {
+		t.Error("expected the servers of a removed node group to be left in place")
+	}
+
+	// Reloading the same configuration leaves every node group untouched
+	th.AssertNoErr(t, p.Reload(reloadConfig(p, updated, namedNodeGroupConfig("c"), namedNodeGroupConfig("d"))))
+	th.AssertEquals(t, uint64(2), p.GetNodeGroup("a").ResourceVersion())
+}
+
+func TestReloadRejectsInvalidNodeGroup(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.NodeGroups = []config.NodeGroupConfig{namedNodeGroupConfig("a")}
+	})
+	previous := p.config.Load()
+
+	invalid := namedNodeGroupConfig("b")
+	invalid.KeyName = "missing-key"
+	if err := p.Reload(reloadConfig(p, invalid)); err == nil {
+		t.Fatal("expected a node group with a missing key pair to be rejected")
+	}
+
+	th.AssertEquals(t, previous, p.config.Load())
+	if p.GetNodeGroup("a") == nil || p.GetNodeGroup("b") != nil {
+		t.Error("expected the node groups to stay unchanged after a rejected reload")
+	}
+}
+
+func TestReloadRebuildsClients(t *testing.T) {
+	cloud := newTestCloud(t)
+	cloud.SetPassword("secret")
+	p := newTestProvider(t, cloud)
+	previous := p.clients.Load()
+
+	tests := []struct {
+		name     string
+		password string
+		swapped  bool
+	}{
+		{name: "unchanged credentials", password: "secret"},
+		{name: "rejected credentials", password: "wrong"},
+		{name: "rotated credentials", password: "rotated", swapped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.swapped {
+				cloud.SetPassword(tt.password)
+			}
+			cfg := reloadConfig(p)
+			cfg.Cloud.Password = tt.password
+
+			err := p.Reload(cfg)
+			th.AssertEquals(t, tt.password != "wrong", err == nil)
+			th.AssertEquals(t, tt.swapped, p.clients.Load() != previous)
+		})
+	}
+}
+
+func TestReloadRejectsClusterNameChange(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+
+	cfg := reloadConfig(p)
+	cfg.Cloud.ClusterName = "renamed"
+	err := p.Reload(cfg)
+	if !IsConfigurationError(err) {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+	th.AssertEquals(t, "test", p.config.Load().Cloud.ClusterName)
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// reservableResources are the resources kubeReserved and systemReserved may set
+var reservableResources = map[apiv1.ResourceName]bool{
+	apiv1.ResourceCPU:              true,
+	apiv1.ResourceMemory:           true,
+	apiv1.ResourceEphemeralStorage: true,
+}
+
+// reservedResources returns the sum of the kube and system reservations of the node group,
+// each resource taken from the node group when set there and from the cloud defaults otherwise
+func (ng *OpenStackNodeGroup) reservedResources() (apiv1.ResourceList, error) {
+	cloud := ng.Provider.config.Load().Cloud
+	reserved := apiv1.ResourceList{}
+	for _, reservation := range []struct {
+		name      string
+		defaults  map[string]string
+		overrides map[string]string
+	}{
+		{"kubeReserved", cloud.KubeReserved, ng.Config.KubeReserved},
+		{"systemReserved", cloud.SystemReserved, ng.Config.SystemReserved},
+	} {
+		values := make(map[string]string, len(reservation.defaults)+len(reservation.overrides))
+		for name, value := range reservation.defaults {
+			values[name] = value
+		}
+		for name, value := range reservation.overrides {
+			values[name] = value
+		}
+
+		for name, value := range values {
+			if !reservableResources[apiv1.ResourceName(name)] {
+				return nil, fmt.Errorf("%s: unsupported resource %q, expected cpu, memory or ephemeral-storage", reservation.name, name)
+			}
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s quantity %q: %w", reservation.name, name, value, err)
+			}
+			if quantity.Sign() < 0 {
+				return nil, fmt.Errorf("%s: %s cannot be negative", reservation.name, name)
+			}
+
+			total := reserved[apiv1.ResourceName(name)]
+			total.Add(quantity)
+			reserved[apiv1.ResourceName(name)] = total
+		}
+	}
+	return reserved, nil
+}
+
+// subtractReserved removes the reservations from the allocatable resources, clamping at zero
+func (ng *OpenStackNodeGroup) subtractReserved(allocatable, reserved apiv1.ResourceList) {
+	for name, quantity := range reserved {
+		current, exists := allocatable[name]
+		if !exists {
+			continue
+		}
+		available := current.DeepCopy()
+		available.Sub(quantity)
+		if available.Sign() < 0 {
+			klog.Warningf("Reserved %s of node group %s exceeds its allocatable %s, reporting none", name, ng.Config.ID, name)
+			available = *resource.NewQuantity(0, available.Format)
+		}
+		allocatable[name] = available
+	}
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"sync"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// scaleUpOperationMetadataKey marks the servers created by one scale-up
+	scaleUpOperationMetadataKey = "scale_up_operation"
+	// scaleUpOperationIDLength is the length of generated scale-up operation IDs
+	scaleUpOperationIDLength = 8
+)
+
+// scaleUpOperation records the servers a scale-up created so a failed scale-up can be rolled back
+type scaleUpOperation struct {
+	id string
+	// ctx carries the values of the caller's context, such as the gRPC request ID, to the
+	// create requests. Its cancellation is dropped, servers being created are completed.
+	ctx       context.Context
+	mutex     sync.Mutex
+	serverIDs []string
+}
+
+// newScaleUpOperation starts recording a scale-up on behalf of the caller of ctx
+func newScaleUpOperation(ctx context.Context) *scaleUpOperation {
+	return &scaleUpOperation{
+		id:  utilrand.String(scaleUpOperationIDLength),
+		ctx: context.WithoutCancel(ctx),
+	}
+}
+
+// record adds a server created by the scale-up
+func (op *scaleUpOperation) record(serverID string) {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	op.serverIDs = append(op.serverIDs, serverID)
+}
+
+// rollbackOnFailure reports whether a failed scale-up deletes the servers it created, on by default
+func (ng *OpenStackNodeGroup) rollbackOnFailure() bool {
+	return ng.Config.RollbackOnFailure == nil || *ng.Config.RollbackOnFailure
+}
+
+// rollbackScaleUp deletes the servers created by a failed scale-up and returns how many could not
+// be deleted. Those are logged and left to the orphan cleanup rather than failing the scale-up twice.
+func (ng *OpenStackNodeGroup) rollbackScaleUp(op *scaleUpOperation) int {
+	op.mutex.Lock()
+	serverIDs := append([]string(nil), op.serverIDs...)
+	op.mutex.Unlock()
+
+	klog.Warningf("Rolling back scale-up %s of node group %s, deleting %d servers", op.id, ng.Config.ID, len(serverIDs))
+	var orphans []string
+	for _, serverID := range serverIDs {
+		if err := ng.deleteServer(serverID); err != nil {
+			klog.Errorf("Failed to roll back server %s of node group %s: %v", serverID, ng.Config.ID, err)
+			orphans = append(orphans, serverID)
+			continue
+		}
+		ng.members.Forget(serverID)
+	}
+
+	if len(orphans) > 0 {
+		klog.Errorf("Rollback of scale-up %s of node group %s left servers behind, they carry %s=%s: %v", op.id, ng.Config.ID, scaleUpOperationMetadataKey, op.id, orphans)
+	}
+	return len(orphans)
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestIncreaseSizeRollsBackPartialScaleUp(t *testing.T) {
+	tests := []struct {
+		name         string
+		rollback     *bool
+		lockOne      bool
+		noFailure    bool
+		cancelAfter  int
+		created      int
+		servers      int
+		targetSize   int
+		orphansInLog bool
+	}{
+		{name: "rolled back by default", created: 0, servers: 1, targetSize: 1},
+		{name: "rollback disabled", rollback: new(bool), created: 4, servers: 5, targetSize: 6},
+		{name: "rollback leaves an undeletable server", lockOne: true, created: 1, servers: 2, targetSize: 2, orphansInLog: true},
+		// Servers are created one at a time, the failed first creation is followed by two more
+		{name: "cancelled after a failure", cancelAfter: 2, created: 0, servers: 1, targetSize: 1},
+		{name: "cancelled without a failure", noFailure: true, cancelAfter: 2, created: 2, servers: 3, targetSize: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				if tt.cancelAfter > 0 {
+					cfg.Cloud.CreateConcurrency = 1
+				}
+			})
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.RollbackOnFailure = tt.rollback
+			})
+			existing := addMember(cloud, testGroupID, "workers-existing")
+			th.AssertNoErr(t, ng.Refresh())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			created := 0
+			cloud.OnCreate(func(server *fakecloud.Server) {
+				created++
+				server.Locked = tt.lockOne && created == 1
+				if created == tt.cancelAfter {
+					cancel()
+				}
+			})
+			// One of the five creations fails
+			if !tt.noFailure {
+				cloud.Fail(http.MethodPost, fakecloud.ComputePath+"/servers", http.StatusInternalServerError, 1)
+			}
+
+			err := ng.IncreaseSizeWithReason(ctx, 5, ReasonClusterAutoscaler)
+			var scaleUpErr *ScaleUpError
+			if !errors.As(err, &scaleUpErr) {
+				t.Fatalf("expected a scale-up error, got %v", err)
+			}
+			th.AssertEquals(t, 5, scaleUpErr.Requested)
+			th.AssertEquals(t, tt.created, scaleUpErr.Created)
+			th.AssertEquals(t, tt.cancelAfter > 0, errors.Is(err, context.Canceled))
+
+			remaining := cloud.Servers()
+			th.AssertEquals(t, tt.servers, len(remaining))
+			if _, exists := cloud.Server(existing); !exists {
+				t.Error("expected the rollback to keep servers of earlier scale-ups")
+			}
+			for _, server := range remaining {
+				if server.ID != existing && server.Metadata[scaleUpOperationMetadataKey] == "" {
+					t.Errorf("expected server %s to carry the scale-up operation", server.Name)
+				}
+			}
+
+			size, err := ng.TargetSize()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.targetSize, size)
+			th.AssertEquals(t, tt.orphansInLog, strings.Contains(logs.String(), "left servers behind"))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"sort"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// protectedMetadataKey is the server metadata key excluding a server from target size decreases
+	protectedMetadataKey = "scale_down_protected"
+	// toBeDeletedTaint is the taint the cluster autoscaler puts on nodes it is about to delete
+	toBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
+)
+
+// isProtected reports whether a server is locked or explicitly protected from target size decreases
+func isProtected(server *servers.Server) bool {
+	if server.Locked != nil && *server.Locked {
+		return true
+	}
+	return server.Metadata[protectedMetadataKey] == "true"
+}
+
+// isMarkedForDeletion reports whether the cluster autoscaler marked the node for deletion
+func isMarkedForDeletion(node *apiv1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == toBeDeletedTaint {
+			return true
+		}
+	}
+	return false
+}
+
+// decreaseCandidates returns up to count servers counted in the target size to delete for a target size
+// decrease, most recently created first. Protected servers and servers canDecreaseTarget rejects
+// are skipped.
+func (ng *OpenStackNodeGroup) decreaseCandidates(instances []servers.Server, count int) []servers.Server {
+	var candidates []servers.Server
+	for _, instance := range instances {
+		if !countsTowardTargetSize(&instance) {
+			continue
+		}
+		if isProtected(&instance) || !ng.canDecreaseTarget(&instance) {
+			continue
+		}
+		candidates = append(candidates, instance)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Created.After(candidates[j].Created)
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+	return candidates
+}
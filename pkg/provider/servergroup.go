@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+)
+
+// softPolicyMicroversion is the first compute microversion accepting soft-affinity policies
+const softPolicyMicroversion = "2.15"
+
+// schedulerHints returns the scheduler hints placing new servers in the node group's server group
+func (ng *OpenStackNodeGroup) schedulerHints() (servers.SchedulerHintOptsBuilder, error) {
+	if ng.Config.ServerGroup == nil {
+		return nil, nil
+	}
+
+	groupID, err := ng.ensureServerGroup()
+	if err != nil {
+		return nil, err
+	}
+	return servers.SchedulerHintOpts{Group: groupID}, nil
+}
+
+// ensureServerGroup returns the configured server group, creating one owned by the node group
+// when only a policy is configured
+func (ng *OpenStackNodeGroup) ensureServerGroup() (string, error) {
+	if ng.Config.ServerGroup.ID != "" {
+		return ng.Config.ServerGroup.ID, nil
+	}
+
+	ng.mutex.Lock()
+	defer ng.mutex.Unlock()
+
+	if ng.serverGroupID != "" {
+		return ng.serverGroupID, nil
+	}
+
+	// Reuse the group created before a restart
+	allPages, err := servergroups.List(ng.Provider.computeClient(), servergroups.ListOpts{}).AllPages(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to list server groups: %w", wrapOpenStackError(err))
+	}
+	allGroups, err := servergroups.ExtractServerGroups(allPages)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract server groups: %w", err)
+	}
+	for _, group := range allGroups {
+		if group.Name != ng.serverGroupName() {
+			continue
+		}
+		if policy := serverGroupPolicy(&group); policy != ng.Config.ServerGroup.Policy {
+			return "", configErrorf("server group %s (%s) has policy %s, node group %s is configured with %s", group.Name, group.ID, policy, ng.Config.ID, ng.Config.ServerGroup.Policy)
+		}
+		ng.serverGroupID = group.ID
+		return group.ID, nil
+	}
+
+	client := ng.Provider.computeClient()
+	if strings.HasPrefix(ng.Config.ServerGroup.Policy, "soft-") {
+		microversionClient := *client
+		microversionClient.Microversion = softPolicyMicroversion
+		client = &microversionClient
+	}
+
+	createOpts := servergroups.CreateOpts{
+		Name:     ng.serverGroupName(),
+		Policies: []string{ng.Config.ServerGroup.Policy},
+	}
+	group, err := servergroups.Create(context.TODO(), client, createOpts).Extract()
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s server group: %w", ng.Config.ServerGroup.Policy, wrapOpenStackError(err))
+	}
+
+	klog.Infof("Created %s server group %s (%s) for node group %s", ng.Config.ServerGroup.Policy, group.Name, group.ID, ng.Config.ID)
+	ng.serverGroupID = group.ID
+	return group.ID, nil
+}
+
+// serverGroupName returns the name of the server group the autoscaler creates for the node group.
+// The name marks the group as owned, groups named otherwise are never deleted.
+func (ng *OpenStackNodeGroup) serverGroupName() string {
+	if clusterName := ng.Provider.config.Load().Cloud.ClusterName; clusterName != "" {
+		return fmt.Sprintf("%s-%s-%s", membership.CreatedByMetadataValue, clusterName, ng.Config.ID)
+	}
+	return fmt.Sprintf("%s-%s", membership.CreatedByMetadataValue, ng.Config.ID)
+}
+
+// serverGroupPolicy returns the policy of a server group, reported in policies before
+// compute microversion 2.64
+func serverGroupPolicy(group *servergroups.ServerGroup) string {
+	if group.Policy != nil {
+		return *group.Policy
+	}
+	if len(group.Policies) > 0 {
+		return group.Policies[0]
+	}
+	return ""
+}
+
+// releaseServerGroup deletes the server group the node group created once it has no members
+// left. Configured server groups and groups not named as owned by the node group are kept.
+func (ng *OpenStackNodeGroup) releaseServerGroup() error {
+	if ng.Config.ServerGroup == nil || ng.Config.ServerGroup.ID != "" {
+		return nil
+	}
+
+	ng.mutex.Lock()
+	groupID := ng.serverGroupID
+	ng.mutex.Unlock()
+	if groupID == "" {
+		return nil
+	}
+
+	group, err := servergroups.Get(context.TODO(), ng.Provider.computeClient(), groupID).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			ng.forgetServerGroup(groupID)
+			return nil
+		}
+		return fmt.Errorf("failed to get server group %s: %w", groupID, wrapOpenStackError(err))
+	}
+	if group.Name != ng.serverGroupName() {
+		klog.Warningf("Not deleting server group %s (%s) of node group %s, it is not named %s", group.Name, groupID, ng.Config.ID, ng.serverGroupName())
+		return nil
+	}
+	if len(group.Members) > 0 {
+		return nil
+	}
+
+	err = servergroups.Delete(context.TODO(), ng.Provider.computeClient(), groupID).ExtractErr()
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete server group %s: %w", groupID, wrapOpenStackError(err))
+	}
+	ng.forgetServerGroup(groupID)
+
+	klog.Infof("Deleted server group %s (%s) of empty node group %s", group.Name, groupID, ng.Config.ID)
+	return nil
+}
+
+// forgetServerGroup drops the remembered server group so the next scale-up looks it up again
+func (ng *OpenStackNodeGroup) forgetServerGroup(groupID string) {
+	ng.mutex.Lock()
+	defer ng.mutex.Unlock()
+	if ng.serverGroupID == groupID {
+		ng.serverGroupID = ""
+	}
+}
+
+// isServerGroupFull reports whether a create request failed because the server group member quota is exhausted
+func isServerGroupFull(err error) bool {
+	return gophercloud.ResponseCodeIs(err, http.StatusForbidden) && strings.Contains(strings.ToLower(err.Error()), "server group")
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+const ownedServerGroupName = "openstack-autoscaler-test-" + testGroupID
+
+func TestEnsureServerGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []fakecloud.ServerGroup
+		reused   bool
+		invalid  bool
+	}{
+		{name: "no server group", existing: nil},
+		{name: "owned server group", existing: []fakecloud.ServerGroup{{Name: ownedServerGroupName, Policy: "soft-anti-affinity"}}, reused: true},
+		{name: "owned server group with another policy", existing: []fakecloud.ServerGroup{{Name: ownedServerGroupName, Policy: "affinity"}}, invalid: true},
+		{name: "server group named like the node group", existing: []fakecloud.ServerGroup{{Name: testGroupID, Policy: "soft-anti-affinity"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			var existingIDs []string
+			for _, group := range tt.existing {
+				existingIDs = append(existingIDs, cloud.AddServerGroup(group))
+			}
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.ServerGroup = &config.ServerGroupConfig{Policy: "soft-anti-affinity"}
+			})
+
+			groupID, err := ng.ensureServerGroup()
+			if tt.invalid {
+				if !IsConfigurationError(err) {
+					t.Fatalf("expected a configuration error, got %v", err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			if tt.reused {
+				th.AssertEquals(t, existingIDs[0], groupID)
+				th.AssertEquals(t, len(tt.existing), len(cloud.ServerGroups()))
+				return
+			}
+			th.AssertEquals(t, len(tt.existing)+1, len(cloud.ServerGroups()))
+			for _, group := range cloud.ServerGroups() {
+				if group.ID == groupID {
+					th.AssertEquals(t, ownedServerGroupName, group.Name)
+					th.AssertEquals(t, "soft-anti-affinity", group.Policy)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseServerGroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured bool
+		group      fakecloud.ServerGroup
+		deleted    bool
+	}{
+		{name: "empty owned server group", group: fakecloud.ServerGroup{Name: ownedServerGroupName}, deleted: true},
+		{name: "owned server group with members", group: fakecloud.ServerGroup{Name: ownedServerGroupName, Members: []string{"server"}}},
+		{name: "server group renamed by someone else", group: fakecloud.ServerGroup{Name: "shared"}},
+		{name: "configured server group", configured: true, group: fakecloud.ServerGroup{Name: ownedServerGroupName}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			tt.group.Policy = "soft-anti-affinity"
+			groupID := cloud.AddServerGroup(tt.group)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.ServerGroup = &config.ServerGroupConfig{Policy: "soft-anti-affinity"}
+				if tt.configured {
+					cfg.ServerGroup = &config.ServerGroupConfig{ID: groupID}
+				}
+			})
+			ng.serverGroupID = groupID
+
+			th.AssertNoErr(t, ng.releaseServerGroup())
+			if tt.deleted {
+				th.AssertEquals(t, 0, len(cloud.ServerGroups()))
+				th.AssertEquals(t, "", ng.serverGroupID)
+				return
+			}
+			th.AssertEquals(t, 1, len(cloud.ServerGroups()))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/inventory"
+)
+
+const (
+	// defaultServerListTTL is how long a server listing is shared before the next call lists again
+	defaultServerListTTL = 10 * time.Second
+)
+
+// serverListTTL returns how long a server listing is shared
+func (p *OpenStackProvider) serverListTTL() time.Duration {
+	if ttl := p.config.Load().Cloud.ServerListTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultServerListTTL
+}
+
+// listServers returns the servers of the project a node group may claim, from the shared snapshot
+// while it is fresh. Servers carry their tags when the compute API supports them.
+func (p *OpenStackProvider) listServers() ([]servers.Server, error) {
+	return p.serverSnapshot.Servers(p.serverListTTL(), p.listCandidateServers)
+}
+
+// listCandidateServers lists the servers of the project a node group may claim
+func (p *OpenStackProvider) listCandidateServers() ([]servers.Server, error) {
+	nodeGroups := p.GetNodeGroups()
+	listOpts, filtered := p.serverListFilter(nodeGroups)
+
+	allServers, unfiltered, err := inventory.Collect(context.TODO(), p.clients.Load(), listOpts, func(server *servers.Server) bool {
+		return isCandidateServer(server, nodeGroups)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers: %w", err)
+	}
+
+	if filtered && unfiltered > 0 {
+		klog.Warningf("Compute API ignored the tags filter and returned %d servers without a node group tag, listing all servers from now on", unfiltered)
+		p.serverFiltersIgnored.Store(true)
+	}
+
+	klog.V(4).Infof("Listed %d servers", len(allServers))
+	return allServers, nil
+}
+
+// serverListFilter returns the list options restricting the listing to servers of the node groups.
+// Nova can only filter by tag, so servers are filtered once every node group selects its members
+// by tag and as long as the compute API honours the filter.
+func (p *OpenStackProvider) serverListFilter(nodeGroups []*OpenStackNodeGroup) (servers.ListOpts, bool) {
+	if p.tagsClient() == nil || p.serverFiltersIgnored.Load() || len(nodeGroups) == 0 {
+		return servers.ListOpts{}, false
+	}
+
+	tags := make([]string, 0, len(nodeGroups))
+	for _, ng := range nodeGroups {
+		if !ng.tagsMigrated.Load() {
+			return servers.ListOpts{}, false
+		}
+		tags = append(tags, ng.nodeGroupTag())
+	}
+	return servers.ListOpts{TagsAny: strings.Join(tags, ",")}, true
+}
+
+// isCandidateServer reports whether a node group may claim the server as a member
+func isCandidateServer(server *servers.Server, nodeGroups []*OpenStackNodeGroup) bool {
+	for _, ng := range nodeGroups {
+		if ng.ContainsNode(server) || ng.members.Known(server.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateServerSnapshot makes the next call list servers again, after servers were created
+// or deleted so node group sizes do not go stale
+func (p *OpenStackProvider) invalidateServerSnapshot() {
+	p.serverSnapshot.Invalidate()
+}
@@ -0,0 +1,63 @@
+package provider
+
+import "github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+
+// InstanceState is the lifecycle state of a server as the cluster autoscaler sees it
+type InstanceState int
+
+const (
+	// InstanceStateUnknown is a Nova status without a mapping
+	InstanceStateUnknown InstanceState = iota
+	// InstanceStateCreating is a server still being built
+	InstanceStateCreating
+	// InstanceStateRunning is a server that exists, including stopped, paused or servers under
+	// maintenance, which the autoscaler handles through the readiness of their node
+	InstanceStateRunning
+	// InstanceStateDeleting is a server being deleted or already gone
+	InstanceStateDeleting
+	// InstanceStateFailed is a server in ERROR, reported as a failed creation
+	InstanceStateFailed
+)
+
+// serverStatusStates maps Nova server statuses to instance states
+var serverStatusStates = map[string]InstanceState{
+	"BUILD":             InstanceStateCreating,
+	"ACTIVE":            InstanceStateRunning,
+	"REBUILD":           InstanceStateRunning,
+	"REBOOT":            InstanceStateRunning,
+	"HARD_REBOOT":       InstanceStateRunning,
+	"PASSWORD":          InstanceStateRunning,
+	"MIGRATING":         InstanceStateRunning,
+	"RESIZE":            InstanceStateRunning,
+	"VERIFY_RESIZE":     InstanceStateRunning,
+	"REVERT_RESIZE":     InstanceStateRunning,
+	"RESCUE":            InstanceStateRunning,
+	"SHUTOFF":           InstanceStateRunning,
+	"SUSPENDED":         InstanceStateRunning,
+	"PAUSED":            InstanceStateRunning,
+	"SHELVED":           InstanceStateRunning,
+	"SHELVED_OFFLOADED": InstanceStateRunning,
+	"UNKNOWN":           InstanceStateRunning,
+	"ERROR":             InstanceStateFailed,
+	"DELETED":           InstanceStateDeleting,
+	"SOFT_DELETED":      InstanceStateDeleting,
+}
+
+// ServerInstanceState returns the instance state of a server from its Nova status, a server
+// whose task state shows it being deleted is deleting whatever its status
+func ServerInstanceState(server *servers.Server) InstanceState {
+	if server.TaskState == "deleting" || server.TaskState == "soft-deleting" {
+		return InstanceStateDeleting
+	}
+	return serverStatusStates[server.Status]
+}
+
+// countsTowardTargetSize reports whether a server is part of the target size of its node group.
+// Failed servers count until the cluster autoscaler deletes them, which decreases the target.
+func countsTowardTargetSize(server *servers.Server) bool {
+	switch ServerInstanceState(server) {
+	case InstanceStateCreating, InstanceStateRunning, InstanceStateFailed:
+		return true
+	}
+	return false
+}
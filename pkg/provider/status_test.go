@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+)
+
+func TestServerInstanceState(t *testing.T) {
+	tests := []struct {
+		status    string
+		taskState string
+		state     InstanceState
+		counts    bool
+	}{
+		{status: "BUILD", state: InstanceStateCreating, counts: true},
+		{status: "ACTIVE", state: InstanceStateRunning, counts: true},
+		{status: "REBUILD", state: InstanceStateRunning, counts: true},
+		{status: "REBOOT", state: InstanceStateRunning, counts: true},
+		{status: "HARD_REBOOT", state: InstanceStateRunning, counts: true},
+		{status: "PASSWORD", state: InstanceStateRunning, counts: true},
+		{status: "MIGRATING", state: InstanceStateRunning, counts: true},
+		{status: "RESIZE", state: InstanceStateRunning, counts: true},
+		{status: "VERIFY_RESIZE", state: InstanceStateRunning, counts: true},
+		{status: "REVERT_RESIZE", state: InstanceStateRunning, counts: true},
+		{status: "RESCUE", state: InstanceStateRunning, counts: true},
+		{status: "SHUTOFF", state: InstanceStateRunning, counts: true},
+		{status: "SUSPENDED", state: InstanceStateRunning, counts: true},
+		{status: "PAUSED", state: InstanceStateRunning, counts: true},
+		{status: "SHELVED", state: InstanceStateRunning, counts: true},
+		{status: "SHELVED_OFFLOADED", state: InstanceStateRunning, counts: true},
+		{status: "UNKNOWN", state: InstanceStateRunning, counts: true},
+		{status: "ERROR", state: InstanceStateFailed, counts: true},
+		{status: "DELETED", state: InstanceStateDeleting},
+		{status: "SOFT_DELETED", state: InstanceStateDeleting},
+		{status: "ACTIVE", taskState: "deleting", state: InstanceStateDeleting},
+		{status: "SHUTOFF", taskState: "soft-deleting", state: InstanceStateDeleting},
+		{status: "ERROR", taskState: "deleting", state: InstanceStateDeleting},
+		{status: "ACTIVE", taskState: "rebooting", state: InstanceStateRunning, counts: true},
+		{status: "BUILD", taskState: "spawning", state: InstanceStateCreating, counts: true},
+		{status: "NEW_STATUS", state: InstanceStateUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status+"/"+tt.taskState, func(t *testing.T) {
+			server := &servers.Server{Status: tt.status, TaskState: tt.taskState}
+			th.AssertEquals(t, tt.state, ServerInstanceState(server))
+			th.AssertEquals(t, tt.counts, countsTowardTargetSize(server))
+		})
+	}
+}
+
+func TestTargetSizeCountsServersByState(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+	for name, status := range map[string]string{
+		"workers-active":  "ACTIVE",
+		"workers-shutoff": "SHUTOFF",
+		"workers-paused":  "PAUSED",
+		"workers-error":   "ERROR",
+		"workers-build":   "BUILD",
+		"workers-deleted": "DELETED",
+	} {
+		id := addMember(cloud, testGroupID, name)
+		cloud.UpdateServer(id, func(server *fakecloud.Server) { server.Status = status })
+	}
+	deleting := addMember(cloud, testGroupID, "workers-deleting")
+	cloud.UpdateServer(deleting, func(server *fakecloud.Server) { server.TaskState = "deleting" })
+
+	// Every server but the deleted and the deleting one is part of the target size
+	size, err := ng.TargetSize()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 5, size)
+}
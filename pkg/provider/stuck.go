@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultStuckServerInterval is how often node groups are checked for servers stuck provisioning
+	defaultStuckServerInterval = 5 * time.Minute
+	// defaultStuckServerMaxDeletes bounds how many stuck servers one pass deletes
+	defaultStuckServerMaxDeletes = 5
+	// defaultMaxNodeProvisionDuration matches the cluster autoscaler default for node groups
+	// without a maxNodeProvisionDuration override
+	defaultMaxNodeProvisionDuration = 15 * time.Minute
+)
+
+// stuckServerLoop periodically deletes servers stuck in BUILD or ERROR until ctx is cancelled
+func (p *OpenStackProvider) stuckServerLoop(ctx context.Context) {
+	interval := p.config.Load().Cloud.StuckServerInterval
+	if interval <= 0 {
+		interval = defaultStuckServerInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.collectStuckServers()
+		}
+	}
+}
+
+// collectStuckServers deletes servers that did not become ACTIVE within the maximum provision
+// duration of their node group, at most the configured number per pass
+func (p *OpenStackProvider) collectStuckServers() {
+	budget := p.config.Load().Cloud.StuckServerMaxDeletes
+	if budget <= 0 {
+		budget = defaultStuckServerMaxDeletes
+	}
+
+	for _, ng := range p.GetNodeGroups() {
+		if budget == 0 {
+			klog.V(2).Info("Stuck server deletion budget exhausted, continuing next pass")
+			return
+		}
+		if ng.Config.SkipStuckServerCollection {
+			continue
+		}
+		budget -= ng.collectStuckServers(budget)
+	}
+}
+
+// collectStuckServers deletes up to budget servers of the node group stuck in BUILD or ERROR and
+// returns how many it deleted. Node groups in the middle of a scale operation are left for the
+// next pass.
+func (ng *OpenStackNodeGroup) collectStuckServers(budget int) int {
+	if !ng.operationMutex.TryLock() {
+		return 0
+	}
+	defer ng.operationMutex.Unlock()
+
+	instances, err := ng.getInstances()
+	if err != nil {
+		klog.Errorf("Failed to list servers of node group %s for stuck server collection: %v", ng.Config.ID, err)
+		return 0
+	}
+
+	maxAge := ng.maxNodeProvisionDuration()
+	deleted := 0
+	for i := range instances {
+		if deleted == budget {
+			break
+		}
+		server := &instances[i]
+		if !isStuckServer(server, maxAge) {
+			continue
+		}
+
+		fault := server.Fault.Message
+		if fault == "" {
+			fault = "no fault recorded"
+		}
+		klog.Warningf("Deleting server %s (%s) of node group %s, %s for %s: %s", server.Name, server.ID, ng.Config.ID, server.Status, time.Since(server.Created).Round(time.Second), fault)
+		if err := ng.deleteServer(server.ID); err != nil {
+			klog.Errorf("Failed to delete stuck server %s of node group %s: %v", server.ID, ng.Config.ID, err)
+			continue
+		}
+		ng.adjustDesiredSize(-1)
+		deleted++
+	}
+	if deleted > 0 {
+		ng.Provider.invalidateServerSnapshot()
+	}
+	return deleted
+}
+
+// maxNodeProvisionDuration returns how long a server of the node group may take to become ACTIVE
+func (ng *OpenStackNodeGroup) maxNodeProvisionDuration() time.Duration {
+	if options := ng.Config.AutoscalingOptions; options != nil && options.MaxNodeProvisionDuration != nil && *options.MaxNodeProvisionDuration > 0 {
+		return *options.MaxNodeProvisionDuration
+	}
+	return defaultMaxNodeProvisionDuration
+}
+
+// isStuckServer reports whether a server is still building or failed and was created longer than maxAge ago
+func isStuckServer(server *servers.Server, maxAge time.Duration) bool {
+	switch ServerInstanceState(server) {
+	case InstanceStateCreating, InstanceStateFailed:
+		return !server.Created.IsZero() && time.Since(server.Created) > maxAge
+	}
+	return false
+}
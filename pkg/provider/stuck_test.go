@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestStuckServersCollectedOnlyAfterStart(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud, func(cfg *config.Config) {
+		cfg.Cloud.StuckServerInterval = 10 * time.Millisecond
+	})
+	newTestNodeGroup(t, p)
+
+	stuckID := addMember(cloud, testGroupID, "workers-stuck")
+	cloud.UpdateServer(stuckID, func(server *fakecloud.Server) {
+		server.Status = fakecloud.StatusError
+		server.Created = time.Now().Add(-24 * time.Hour)
+	})
+
+	// Validating a configuration creates a provider without serving, it must leave the cloud alone
+	time.Sleep(100 * time.Millisecond)
+	th.AssertEquals(t, 1, len(cloud.Servers()))
+
+	p.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(cloud.Servers()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stuck server was not deleted after Start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
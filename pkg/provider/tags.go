@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/tags"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+)
+
+// nodeGroupTag returns the server tag marking members of the node group
+func (ng *OpenStackNodeGroup) nodeGroupTag() string {
+	return membership.Tag(ng.Config.ID)
+}
+
+// tagServer adds the membership tag of the node group to a server
+func (ng *OpenStackNodeGroup) tagServer(serverID string) error {
+	err := tags.Add(context.TODO(), ng.Provider.tagsClient(), serverID, ng.nodeGroupTag()).ExtractErr()
+	if err != nil {
+		return fmt.Errorf("failed to tag server %s: %w", serverID, wrapOpenStackError(err))
+	}
+	return nil
+}
+
+// tagNewServer sets the membership tag, the managed-by tag and the configured tags of a server
+// the node group just created
+func (ng *OpenStackNodeGroup) tagNewServer(serverID string) error {
+	serverTags := append([]string{ng.nodeGroupTag(), membership.ManagedByTag}, ng.Config.Tags...)
+	_, err := tags.ReplaceAll(context.TODO(), ng.Provider.tagsClient(), serverID, tags.ReplaceAllOpts{Tags: serverTags}).Extract()
+	if err != nil {
+		return fmt.Errorf("failed to tag server %s: %w", serverID, wrapOpenStackError(err))
+	}
+	return nil
+}
+
+// validateTags checks the configured server tags against the Nova tag rules
+func (ng *OpenStackNodeGroup) validateTags() error {
+	return membership.ValidateTags(ng.Config.ID, ng.Config.Tags)
+}
+
+// getTaggedInstances returns the members of the node group, letting Nova filter by membership tag.
+// With track set the listed servers update the known members.
+func (ng *OpenStackNodeGroup) getTaggedInstances(track bool) ([]servers.Server, error) {
+	allPages, err := servers.List(ng.Provider.tagsClient(), servers.ListOpts{Tags: ng.nodeGroupTag()}).AllPages(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers tagged %s: %w", ng.nodeGroupTag(), wrapOpenStackError(err))
+	}
+	allServers, err := servers.ExtractServers(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract servers: %w", err)
+	}
+
+	// Servers are checked again in case the compute API ignored the filter
+	var groupServers []servers.Server
+	existing := make(map[string]bool, len(allServers))
+	for _, server := range allServers {
+		if membership.HasTag(&server, ng.Config.ID) {
+			existing[server.ID] = true
+			groupServers = append(groupServers, server)
+			if track {
+				ng.members.Remember(server.ID)
+			}
+		}
+	}
+	if track {
+		ng.members.Prune(existing)
+	}
+
+	return groupServers, nil
+}
+
+// reconcileMembershipTags tags the members whose node group metadata names the node group.
+// Members attributed by their name or adopted after their metadata was stripped stay untagged,
+// tagging them would turn a guess into ownership. Once every member carries the tag the node
+// group lets Nova select its members by tag.
+func (ng *OpenStackNodeGroup) reconcileMembershipTags() error {
+	if ng.Provider.tagsClient() == nil || ng.tagsMigrated.Load() {
+		return nil
+	}
+
+	instances, err := ng.getInstances()
+	if err != nil {
+		return err
+	}
+
+	migrated := true
+	for i := range instances {
+		server := &instances[i]
+		if membership.HasTag(server, ng.Config.ID) {
+			continue
+		}
+		if server.Metadata[membership.NodeGroupMetadataKey] != ng.Config.ID {
+			klog.V(2).Infof("Not tagging server %s (%s) of node group %s, its membership is not recorded in its metadata", server.Name, server.ID, ng.Config.ID)
+			migrated = false
+			continue
+		}
+		if ng.Provider.DryRun() {
+			klog.Infof("Dry run: would tag server %s (%s) as a member of node group %s", server.Name, server.ID, ng.Config.ID)
+			migrated = false
+			continue
+		}
+		if err := ng.tagServer(server.ID); err != nil {
+			klog.Warningf("Failed to migrate server %s of node group %s to tags: %v", server.ID, ng.Config.ID, err)
+			migrated = false
+		}
+	}
+
+	if migrated && !ng.tagsMigrated.Swap(true) {
+		klog.Infof("All members of node group %s are tagged, selecting members by tag from now on", ng.Config.ID)
+	}
+	return nil
+}
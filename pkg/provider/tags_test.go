@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestReconcileMembershipTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		servers  []fakecloud.Server
+		tagged   []bool
+		migrated bool
+	}{
+		{
+			name: "members recorded in metadata",
+			servers: []fakecloud.Server{
+				{Name: "workers-a", Metadata: map[string]string{membership.NodeGroupMetadataKey: testGroupID}},
+				{Name: "node-b", Metadata: map[string]string{membership.NodeGroupMetadataKey: testGroupID}},
+			},
+			tagged:   []bool{true, true},
+			migrated: true,
+		},
+		{
+			name: "member attributed by its name",
+			servers: []fakecloud.Server{
+				{Name: "workers-a", Metadata: map[string]string{membership.NodeGroupMetadataKey: testGroupID}},
+				{Name: "workers-b"},
+			},
+			tagged: []bool{true, false},
+		},
+		{
+			name: "server of another node group",
+			servers: []fakecloud.Server{
+				{Name: "workers-a", Metadata: map[string]string{membership.NodeGroupMetadataKey: "other"}},
+			},
+			tagged:   []bool{false},
+			migrated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p)
+
+			ids := make([]string, len(tt.servers))
+			for i, server := range tt.servers {
+				server.FlavorID, server.ImageID = testFlavorID, testImageID
+				ids[i] = cloud.AddServer(server)
+			}
+
+			// Listing members does not change them
+			_, err := ng.getInstances()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 0, cloud.Requests(http.MethodPut, fakecloud.ComputePath+"/servers/"))
+
+			th.AssertNoErr(t, ng.reconcileMembershipTags())
+			for i, id := range ids {
+				server, _ := cloud.Server(id)
+				th.AssertEquals(t, tt.tagged[i], slices.Contains(server.Tags, membership.NodeGroupTagPrefix+testGroupID))
+			}
+			th.AssertEquals(t, tt.migrated, ng.tagsMigrated.Load())
+		})
+	}
+}
+
+func TestTaggedInstancesFilteredByNova(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	ng := newTestNodeGroup(t, p)
+	memberID := addMember(cloud, testGroupID, "workers-a")
+	addMember(cloud, "other", "other-a")
+
+	th.AssertNoErr(t, ng.reconcileMembershipTags())
+	if !ng.tagsMigrated.Load() {
+		t.Fatal("expected the node group to select members by tag")
+	}
+
+	before := len(cloud.RequestLog())
+	instances, err := ng.getInstances()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(instances))
+	th.AssertEquals(t, memberID, instances[0].ID)
+
+	var filters []string
+	for _, request := range cloud.RequestLog()[before:] {
+		if request.Method == http.MethodGet && request.Path == fakecloud.ComputePath+"/servers/detail" {
+			query, err := url.ParseQuery(request.Query)
+			th.AssertNoErr(t, err)
+			filters = append(filters, query.Get("tags"))
+		}
+	}
+	th.AssertDeepEquals(t, []string{membership.NodeGroupTagPrefix + testGroupID}, filters)
+}
+
+func TestCreateServerSetsTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		microversion string
+		configured   []string
+		tags         []string
+	}{
+		{name: "membership and managed-by tags", tags: []string{membership.NodeGroupTagPrefix + testGroupID, membership.ManagedByTag}},
+		{name: "configured tags", configured: []string{"billing-team-a", "env-staging"},
+			tags: []string{membership.NodeGroupTagPrefix + testGroupID, membership.ManagedByTag, "billing-team-a", "env-staging"}},
+		{name: "tags unsupported", microversion: "2.20", configured: []string{"billing-team-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			if tt.microversion != "" {
+				cloud.SetComputeMicroversion(tt.microversion)
+			}
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.Tags = tt.configured
+			})
+
+			th.AssertNoErr(t, ng.IncreaseSize(1))
+			created := cloud.Servers()
+			th.AssertEquals(t, 1, len(created))
+			th.AssertDeepEquals(t, tt.tags, created[0].Tags)
+			th.AssertEquals(t, len(tt.tags) > 0, cloud.Requests(http.MethodPut, fakecloud.ComputePath+"/servers/"+created[0].ID+"/tags") == 1)
+
+			// Without tags the metadata still makes the server a member
+			instances, err := ng.Nodes()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, 1, len(instances))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// computedLabels are template node labels derived from OpenStack resources
+var computedLabels = []string{
+	apiv1.LabelArchStable,
+	apiv1.LabelOSStable,
+	apiv1.LabelInstanceTypeStable,
+	apiv1.LabelTopologyZone,
+	apiv1.LabelTopologyRegion,
+	apiv1.LabelFailureDomainBetaZone,
+	apiv1.LabelFailureDomainBetaRegion,
+}
+
+// isComputedLabel reports whether the label key is set from OpenStack resources
+func isComputedLabel(key string) bool {
+	for _, computed := range computedLabels {
+		if key == computed {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateNodeDifferences lists the label and resource differences between two template nodes,
+// as considered by the cluster autoscaler when balancing similar node groups
+func TemplateNodeDifferences(a, b *apiv1.Node) []string {
+	var differences []string
+
+	for _, key := range unionKeys(a.Labels, b.Labels) {
+		valueA, existsA := a.Labels[key]
+		valueB, existsB := b.Labels[key]
+		if existsA != existsB || valueA != valueB {
+			differences = append(differences, fmt.Sprintf("label %s: %q != %q", key, valueA, valueB))
+		}
+	}
+
+	for _, resourceName := range unionResourceNames(a.Status.Capacity, b.Status.Capacity) {
+		quantityA := a.Status.Capacity[resourceName]
+		quantityB := b.Status.Capacity[resourceName]
+		if quantityA.Cmp(quantityB) != 0 {
+			differences = append(differences, fmt.Sprintf("capacity %s: %s != %s", resourceName, quantityA.String(), quantityB.String()))
+		}
+	}
+
+	for _, resourceName := range unionResourceNames(a.Status.Allocatable, b.Status.Allocatable) {
+		quantityA := a.Status.Allocatable[resourceName]
+		quantityB := b.Status.Allocatable[resourceName]
+		if quantityA.Cmp(quantityB) != 0 {
+			differences = append(differences, fmt.Sprintf("allocatable %s: %s != %s", resourceName, quantityA.String(), quantityB.String()))
+		}
+	}
+
+	return differences
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	seen := make(map[string]bool)
+	for _, m := range []map[string]string{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unionResourceNames(a, b apiv1.ResourceList) []apiv1.ResourceName {
+	names := make([]apiv1.ResourceName, 0, len(a)+len(b))
+	seen := make(map[apiv1.ResourceName]bool)
+	for _, list := range []apiv1.ResourceList{a, b} {
+		for name := range list {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
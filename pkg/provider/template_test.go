@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestTemplateNodeInfoScaleFromZero(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(cfg *config.NodeGroupConfig)
+		taints      []apiv1.Taint
+		ephemeral   string
+		allocatable string
+		pods        int64
+	}{
+		{
+			name:        "flavor disk",
+			configure:   func(cfg *config.NodeGroupConfig) {},
+			ephemeral:   "20Gi",
+			allocatable: "20Gi",
+			pods:        defaultMaxPods,
+		},
+		{
+			name: "configured taints, storage and pods",
+			configure: func(cfg *config.NodeGroupConfig) {
+				cfg.ExpectedTaints = []config.TaintConfig{
+					{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"},
+					{Key: "spot", Effect: "PreferNoSchedule"},
+				}
+				cfg.EphemeralStorageGB = 100
+				cfg.EphemeralStorageReservedGB = 10
+				cfg.MaxPods = 250
+			},
+			taints: []apiv1.Taint{
+				{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule},
+				{Key: "spot", Effect: apiv1.TaintEffectPreferNoSchedule},
+			},
+			ephemeral:   "100Gi",
+			allocatable: "90Gi",
+			pods:        250,
+		},
+		{
+			name: "root volume",
+			configure: func(cfg *config.NodeGroupConfig) {
+				cfg.RootVolume = &config.RootVolumeConfig{SizeGiB: 50}
+			},
+			ephemeral:   "50Gi",
+			allocatable: "50Gi",
+			pods:        defaultMaxPods,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, tt.configure)
+
+			node, err := ng.TemplateNodeInfo()
+			th.AssertNoErr(t, err)
+			th.AssertDeepEquals(t, tt.taints, node.Spec.Taints)
+
+			capacity := node.Status.Capacity[apiv1.ResourceEphemeralStorage]
+			allocatable := node.Status.Allocatable[apiv1.ResourceEphemeralStorage]
+			th.AssertEquals(t, 0, capacity.Cmp(resource.MustParse(tt.ephemeral)))
+			th.AssertEquals(t, 0, allocatable.Cmp(resource.MustParse(tt.allocatable)))
+			pods := node.Status.Capacity[apiv1.ResourcePods]
+			th.AssertEquals(t, tt.pods, pods.Value())
+		})
+	}
+}
+
+func TestExpectedTaintsValidation(t *testing.T) {
+	cloud := newTestCloud(t)
+	p := newTestProvider(t, cloud)
+	cfg := testNodeGroupConfig()
+	cfg.ExpectedTaints = []config.TaintConfig{{Key: "dedicated", Effect: "Sometimes"}}
+
+	_, err := p.AddNodeGroup(cfg)
+	if err == nil {
+		t.Fatal("expected a taint with an invalid effect to be rejected")
+	}
+}
+
+func TestTemplateNodeInfoCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		refresh bool
+		rebuilt bool
+	}{
+		{name: "default TTL"},
+		{name: "expired TTL", ttl: time.Millisecond, rebuilt: true},
+		{name: "long TTL", ttl: time.Hour},
+		{name: "refresh within the TTL", ttl: time.Hour, refresh: true, rebuilt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				cfg.Cloud.TemplateCacheTTL = tt.ttl
+			})
+			ng := newTestNodeGroup(t, p)
+
+			_, err := ng.TemplateNodeInfo()
+			th.AssertNoErr(t, err)
+			cached := ng.templateNodeInfo
+
+			time.Sleep(5 * time.Millisecond)
+			if tt.refresh {
+				th.AssertNoErr(t, ng.Refresh())
+			}
+			_, err = ng.TemplateNodeInfo()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.rebuilt, ng.templateNodeInfo != cached)
+		})
+	}
+}
+
+func TestTemplateNodeDifferencesAcrossZones(t *testing.T) {
+	tests := []struct {
+		name        string
+		legacy      bool
+		differences []string
+	}{
+		{
+			name:        "topology labels",
+			differences: []string{`label topology.kubernetes.io/zone: "az1" != "az2"`},
+		},
+		{
+			name:   "legacy topology labels",
+			legacy: true,
+			differences: []string{
+				`label failure-domain.beta.kubernetes.io/zone: "az1" != "az2"`,
+				`label topology.kubernetes.io/zone: "az1" != "az2"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.SetZones("az1", "az2")
+			p := newTestProvider(t, cloud, func(cfg *config.Config) {
+				cfg.Cloud.LegacyTopologyLabels = tt.legacy
+			})
+			var templates []*apiv1.Node
+			for _, zone := range []string{"az1", "az2"} {
+				ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+					cfg.ID = "workers-" + zone
+					cfg.AvailabilityZone = zone
+				})
+				node, err := ng.TemplateNodeInfo()
+				th.AssertNoErr(t, err)
+				templates = append(templates, node)
+			}
+
+			th.AssertDeepEquals(t, tt.differences, TemplateNodeDifferences(templates[0], templates[1]))
+			th.AssertEquals(t, 0, len(TemplateNodeDifferences(templates[0], templates[0])))
+		})
+	}
+}
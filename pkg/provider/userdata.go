@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"text/template"
+
+	"k8s.io/klog/v2"
+)
+
+// maxUserDataSize is the largest base64 encoded user data Nova accepts
+const maxUserDataSize = 65535
+
+// UserDataTemplateData holds the variables available to user data templates:
+//
+//	{{.NodeGroupID}}  ID of the node group
+//	{{.ServerName}}   name of the server being created
+//	{{.ClusterName}}  cluster name from the cloud configuration
+//	{{.Labels}}       node labels as a map of key to value, including the GPU label
+//	{{.Taints}}       expected taints formatted as key=value:Effect
+type UserDataTemplateData struct {
+	NodeGroupID string
+	ServerName  string
+	ClusterName string
+	Labels      map[string]string
+	Taints      []string
+}
+
+// loadUserData returns the configured user data, reading UserDataFile when needed,
+// and the parsed template when templating is enabled
+func (ng *OpenStackNodeGroup) loadUserData() (string, *template.Template, error) {
+	userData := ng.Config.UserData
+	if userData != "" {
+		if ng.Config.UserDataFile != "" {
+			klog.Warningf("Node group %s sets both userData and userDataFile, using userData", ng.Config.ID)
+		}
+	} else if ng.Config.UserDataFile != "" {
+		data, err := os.ReadFile(ng.Config.UserDataFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read user data file %s: %w", ng.Config.UserDataFile, err)
+		}
+		userData = string(data)
+	}
+
+	if userData == "" || !ng.Config.UserDataTemplate {
+		return userData, nil, nil
+	}
+
+	tmpl, err := template.New(ng.Config.ID).Option("missingkey=error").Parse(userData)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse user data template: %w", err)
+	}
+	return userData, tmpl, nil
+}
+
+// renderUserData returns the user data for a new server, expanding the template if enabled
+func (ng *OpenStackNodeGroup) renderUserData(serverName string, labels map[string]string) (string, error) {
+	ng.mutex.RLock()
+	userData := ng.userData
+	tmpl := ng.userDataTemplate
+	ng.mutex.RUnlock()
+
+	if tmpl == nil {
+		return userData, nil
+	}
+
+	var taints []string
+	for _, taint := range ng.expectedTaints() {
+		taints = append(taints, taint.ToString())
+	}
+
+	data := UserDataTemplateData{
+		NodeGroupID: ng.Config.ID,
+		ServerName:  serverName,
+		ClusterName: ng.Provider.config.Load().Cloud.ClusterName,
+		Labels:      labels,
+		Taints:      taints,
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render user data template: %w", err)
+	}
+	return rendered.String(), nil
+}
+
+// encodeUserData base64 encodes user data for the create request, rejecting user data Nova would refuse
+func encodeUserData(userData string) (string, error) {
+	if userData == "" {
+		return "", nil
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(userData))
+	if len(encoded) > maxUserDataSize {
+		return "", configErrorf("user data is %d bytes after base64 encoding, Nova accepts at most %d", len(encoded), maxUserDataSize)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestUserDataTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		userData string
+		template bool
+		taints   bool
+		rendered string
+		err      string
+	}{
+		{name: "verbatim by default", userData: "echo {{.ServerName}}", rendered: "echo {{.ServerName}}"},
+		{name: "template", userData: "group={{.NodeGroupID}} cluster={{.ClusterName}}", template: true, rendered: "group=workers cluster=test"},
+		{name: "template with taints", userData: "{{range .Taints}}--register-with-taints={{.}} {{end}}", template: true, taints: true, rendered: "--register-with-taints=dedicated=gpu:NoSchedule "},
+		{name: "template referencing an unknown variable", userData: "{{.Zone}}", template: true, err: "failed to render user data template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.UserData = tt.userData
+				cfg.UserDataTemplate = tt.template
+				if tt.taints {
+					cfg.ExpectedTaints = []config.TaintConfig{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}}
+				}
+			})
+
+			err := ng.IncreaseSize(1)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				th.AssertEquals(t, 0, len(cloud.Servers()))
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.rendered, cloud.Servers()[0].UserData)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"k8s.io/klog/v2"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/membership"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+const (
+	// volumeWaitTimeout bounds how long we wait for Cinder to build a volume from an image
+	volumeWaitTimeout = 10 * time.Minute
+	// volumePollInterval is the interval between volume status checks while waiting
+	volumePollInterval = 5 * time.Second
+)
+
+// imageVolumeMapping returns the block device mapping booting a server from a volume Nova creates
+// from the image
+func imageVolumeMapping(imageID string, rootVolume *config.RootVolumeConfig) []servers.BlockDevice {
+	return []servers.BlockDevice{
+		{
+			SourceType:          servers.SourceImage,
+			UUID:                imageID,
+			BootIndex:           0,
+			DestinationType:     servers.DestinationVolume,
+			VolumeSize:          rootVolume.SizeGiB,
+			VolumeType:          rootVolume.VolumeType,
+			DeleteOnTermination: rootVolume.ShouldDeleteOnTermination(),
+		},
+	}
+}
+
+// bootVolumeMapping returns the block device mapping booting a server from a volume created up front
+func bootVolumeMapping(volumeID string, rootVolume *config.RootVolumeConfig) []servers.BlockDevice {
+	return []servers.BlockDevice{
+		{
+			SourceType:          servers.SourceVolume,
+			UUID:                volumeID,
+			BootIndex:           0,
+			DestinationType:     servers.DestinationVolume,
+			DeleteOnTermination: rootVolume.ShouldDeleteOnTermination(),
+		},
+	}
+}
+
+// createBootVolume requests a bootable volume built from an image and returns its ID, the volume
+// is usable once waitForVolume returns
+func (p *OpenStackProvider) createBootVolume(name, imageID string, rootVolume *config.RootVolumeConfig) (string, error) {
+	if p.volumeClient() == nil {
+		return "", fmt.Errorf("block storage service is not available")
+	}
+
+	createOpts := volumes.CreateOpts{
+		Name:             name,
+		Size:             rootVolume.SizeGiB,
+		VolumeType:       rootVolume.VolumeType,
+		AvailabilityZone: rootVolume.AvailabilityZone,
+		ImageID:          imageID,
+		Metadata:         map[string]string{membership.CreatedByMetadataKey: membership.CreatedByMetadataValue},
+	}
+
+	volume, err := volumes.Create(context.TODO(), p.volumeClient(), createOpts, nil).Extract()
+	if err != nil {
+		return "", fmt.Errorf("failed to create root volume: %w", wrapOpenStackError(err))
+	}
+	return volume.ID, nil
+}
+
+// waitForVolume waits until Cinder built a volume
+func (p *OpenStackProvider) waitForVolume(ctx context.Context, volumeID string) error {
+	klog.V(2).Infof("Waiting for root volume %s to become available", volumeID)
+	deadline := time.Now().Add(volumeWaitTimeout)
+	for {
+		volume, err := volumes.Get(ctx, p.volumeClient(), volumeID).Extract()
+		if err != nil {
+			return fmt.Errorf("failed to get root volume %s: %w", volumeID, wrapOpenStackError(err))
+		}
+
+		switch volume.Status {
+		case "available":
+			return nil
+		case "error":
+			return fmt.Errorf("root volume %s failed to build", volumeID)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("root volume %s is still %s after %s", volumeID, volume.Status, volumeWaitTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(volumePollInterval):
+		}
+	}
+}
+
+// deleteVolume deletes a volume, treating an already deleted volume as success
+func (p *OpenStackProvider) deleteVolume(volumeID string) error {
+	err := volumes.Delete(context.TODO(), p.volumeClient(), volumeID, nil).ExtractErr()
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete volume %s: %w", volumeID, wrapOpenStackError(err))
+	}
+	return nil
+}
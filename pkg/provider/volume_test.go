@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestCreateServerFromPinnedRootVolume(t *testing.T) {
+	tests := []struct {
+		name   string
+		wait   bool
+		fail   bool
+		err    bool
+		target int
+	}{
+		{name: "in the background", target: 1},
+		{name: "in the background failing", fail: true, target: 0},
+		{name: "waiting for the server", wait: true, target: 1},
+		{name: "waiting for the server failing", wait: true, fail: true, err: true, target: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.SetVolumeBuildPolls(1)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.RootVolume = &config.RootVolumeConfig{SizeGiB: 20, AvailabilityZone: "nova-storage"}
+				cfg.ProvisionTimeout = time.Minute
+				cfg.WaitForActive = tt.wait
+				cfg.RollbackOnFailure = new(bool)
+			})
+			if tt.fail {
+				cloud.Fail(http.MethodPost, fakecloud.ComputePath+"/servers", http.StatusInternalServerError, 1)
+			}
+
+			err := ng.IncreaseSize(1)
+			th.AssertEquals(t, tt.err, err != nil)
+
+			// A failed creation cleans up the volume created for it
+			waitFor(t, func() bool {
+				if tt.fail {
+					return len(cloud.Volumes()) == 0
+				}
+				return len(cloud.Servers()) == 1
+			})
+			if !tt.fail {
+				th.AssertEquals(t, "nova-storage", cloud.Volumes()[0].AvailabilityZone)
+			}
+
+			// Background failures take the server back out of the target size
+			waitFor(t, func() bool {
+				target, err := ng.TargetSize()
+				return err == nil && target == tt.target
+			})
+		})
+	}
+}
+
+func TestCreateServerBootsFromImageVolume(t *testing.T) {
+	keep := false
+	tests := []struct {
+		name       string
+		rootVolume config.RootVolumeConfig
+		invalid    bool
+		deleted    bool
+	}{
+		{name: "deleted with the server", rootVolume: config.RootVolumeConfig{SizeGiB: 40}, deleted: true},
+		{name: "kept after the server", rootVolume: config.RootVolumeConfig{SizeGiB: 40, DeleteOnTermination: &keep}},
+		{name: "volume type", rootVolume: config.RootVolumeConfig{SizeGiB: 80, VolumeType: "ssd"}, deleted: true},
+		{name: "missing size", rootVolume: config.RootVolumeConfig{VolumeType: "ssd"}, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			cfg := testNodeGroupConfig()
+			rootVolume := tt.rootVolume
+			cfg.RootVolume = &rootVolume
+
+			ng, err := p.AddNodeGroup(cfg)
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected a root volume without size to be rejected")
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertNoErr(t, ng.IncreaseSize(1))
+
+			servers := cloud.Servers()
+			th.AssertEquals(t, 1, len(servers))
+			// The image is only referenced by the block device mapping
+			th.AssertEquals(t, "", servers[0].ImageID)
+			th.AssertEquals(t, 1, len(servers[0].Volumes))
+			th.AssertEquals(t, tt.deleted, servers[0].Volumes[0].DeleteOnTermination)
+
+			volumes := cloud.Volumes()
+			th.AssertEquals(t, 1, len(volumes))
+			th.AssertEquals(t, tt.rootVolume.SizeGiB, volumes[0].Size)
+			th.AssertEquals(t, tt.rootVolume.VolumeType, volumes[0].VolumeType)
+			th.AssertEquals(t, testImageID, volumes[0].ImageID)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// availabilityZones returns the availability zones new servers are spread across, nil when Nova picks the zone
+func (ng *OpenStackNodeGroup) availabilityZones() []string {
+	if len(ng.Config.AvailabilityZones) > 0 {
+		return ng.Config.AvailabilityZones
+	}
+	if ng.Config.AvailabilityZone != "" {
+		return []string{ng.Config.AvailabilityZone}
+	}
+	return nil
+}
+
+// zoneDistribution counts the servers in the target size of the node group per configured availability zone
+func (ng *OpenStackNodeGroup) zoneDistribution() (map[string]int, error) {
+	instances, err := ng.getInstances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instances: %w", err)
+	}
+
+	distribution := ng.countZones(instances)
+	ng.recordZoneDistribution(distribution)
+	return distribution, nil
+}
+
+// countZones counts the servers in the target size per configured availability zone
+func (ng *OpenStackNodeGroup) countZones(instances []servers.Server) map[string]int {
+	distribution := make(map[string]int)
+	for _, zone := range ng.availabilityZones() {
+		distribution[zone] = 0
+	}
+	for _, instance := range instances {
+		if !countsTowardTargetSize(&instance) {
+			continue
+		}
+		if _, configured := distribution[instance.AvailabilityZone]; configured {
+			distribution[instance.AvailabilityZone]++
+		}
+	}
+	return distribution
+}
+
+// recordZoneDistribution keeps the per zone server counts for ZoneDistribution
+func (ng *OpenStackNodeGroup) recordZoneDistribution(distribution map[string]int) {
+	ng.zonesMutex.Lock()
+	ng.lastZoneDistribution = distribution
+	ng.zonesMutex.Unlock()
+}
+
+// zonesByPopulation returns the configured availability zones ordered from least to most populated,
+// zones with the same number of servers keep their configured order
+func (ng *OpenStackNodeGroup) zonesByPopulation(distribution map[string]int) []string {
+	zones := append([]string(nil), ng.availabilityZones()...)
+	sort.SliceStable(zones, func(i, j int) bool {
+		return distribution[zones[i]] < distribution[zones[j]]
+	})
+	return zones
+}
+
+// ZoneDistribution returns the per availability zone server counts observed by the last listing
+func (ng *OpenStackNodeGroup) ZoneDistribution() string {
+	ng.zonesMutex.Lock()
+	defer ng.zonesMutex.Unlock()
+
+	parts := make([]string, 0, len(ng.lastZoneDistribution))
+	for _, zone := range ng.availabilityZones() {
+		if count, exists := ng.lastZoneDistribution[zone]; exists {
+			parts = append(parts, fmt.Sprintf("%s:%d", zone, count))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// topologyLabels returns the region and zone labels of a server created in zone, the zone
+// labels are left out when Nova picks the zone
+func (ng *OpenStackNodeGroup) topologyLabels(zone string) map[string]string {
+	cloud := ng.Provider.config.Load().Cloud
+	labels := make(map[string]string)
+	if cloud.Region != "" {
+		labels[apiv1.LabelTopologyRegion] = cloud.Region
+		if cloud.LegacyTopologyLabels {
+			labels[apiv1.LabelFailureDomainBetaRegion] = cloud.Region
+		}
+	}
+	if zone != "" {
+		labels[apiv1.LabelTopologyZone] = zone
+		if cloud.LegacyTopologyLabels {
+			labels[apiv1.LabelFailureDomainBetaZone] = zone
+		}
+	}
+	return labels
+}
+
+// isNoValidHost reports whether Nova failed to schedule a server because no host could take it
+func isNoValidHost(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "no valid host")
+}
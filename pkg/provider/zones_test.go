@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+
+	"github.com/bucher-brothers/openstack-autoscaler/internal/fakecloud"
+	"github.com/bucher-brothers/openstack-autoscaler/pkg/config"
+)
+
+func TestZoneFallbackOnNoValidHost(t *testing.T) {
+	tests := []struct {
+		name  string
+		wait  bool
+		full  []string
+		zones []string
+		err   string
+	}{
+		{name: "zone without capacity", full: []string{"az1"}, zones: []string{"az2"}},
+		{name: "zone without capacity waiting for the server", wait: true, full: []string{"az1"}, zones: []string{"az2"}},
+		{name: "all zones without capacity", full: []string{"az1", "az2"}, err: "No valid host"},
+		{name: "capacity in every zone", zones: []string{"az1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			cloud.SetCreateStatus(fakecloud.StatusBuild)
+			cloud.OnCreate(func(server *fakecloud.Server) {
+				for _, zone := range tt.full {
+					if server.AvailabilityZone == zone {
+						server.Status = fakecloud.StatusError
+						server.TaskState = ""
+						server.Fault = "No valid host was found. There are not enough hosts available."
+					}
+				}
+			})
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.AvailabilityZones = []string{"az1", "az2"}
+				cfg.ProvisionTimeout = time.Minute
+				cfg.WaitForActive = tt.wait
+			})
+			if tt.wait {
+				// The server becomes ACTIVE on the next poll, unless its zone is full
+				cloud.SetCreateStatus(fakecloud.StatusActive)
+			}
+
+			err := ng.IncreaseSize(1)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+			} else {
+				th.AssertNoErr(t, err)
+			}
+
+			// Servers Nova found no host for are deleted
+			var zones []string
+			for _, server := range cloud.Servers() {
+				zones = append(zones, server.AvailabilityZone)
+			}
+			th.AssertDeepEquals(t, tt.zones, zones)
+		})
+	}
+}
+
+func TestIncreaseSizeSpreadsAcrossZones(t *testing.T) {
+	tests := []struct {
+		name      string
+		zones     []string
+		zone      string
+		existing  []string
+		delta     int
+		perZone   map[string]int
+		debugZone string
+	}{
+		{name: "even split", zones: []string{"az1", "az2"}, delta: 4, perZone: map[string]int{"az1": 2, "az2": 2}, debugZone: "az1:2,az2:2"},
+		{name: "fills the emptier zone", zones: []string{"az1", "az2"}, existing: []string{"az1", "az1"}, delta: 2, perZone: map[string]int{"az1": 2, "az2": 2}, debugZone: "az1:2,az2:2"},
+		{name: "uneven delta", zones: []string{"az1", "az2", "az3"}, existing: []string{"az2"}, delta: 2, perZone: map[string]int{"az1": 1, "az2": 1, "az3": 1}, debugZone: "az1:1,az2:1,az3:1"},
+		{name: "single zone", zone: "az1", delta: 2, perZone: map[string]int{"az1": 2}, debugZone: "az1:2"},
+		{name: "zone picked by Nova", delta: 2, perZone: map[string]int{"": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(t)
+			p := newTestProvider(t, cloud)
+			ng := newTestNodeGroup(t, p, func(cfg *config.NodeGroupConfig) {
+				cfg.AvailabilityZones = tt.zones
+				cfg.AvailabilityZone = tt.zone
+			})
+			for i, zone := range tt.existing {
+				id := addMember(cloud, testGroupID, fmt.Sprintf("workers-existing-%d", i))
+				cloud.UpdateServer(id, func(server *fakecloud.Server) { server.AvailabilityZone = zone })
+			}
+
+			th.AssertNoErr(t, ng.IncreaseSize(tt.delta))
+
+			perZone := make(map[string]int)
+			for _, server := range cloud.Servers() {
+				perZone[server.AvailabilityZone]++
+			}
+			th.AssertDeepEquals(t, tt.perZone, perZone)
+
+			// The debug string reports the distribution of the next listing
+			_, err := ng.zoneDistribution()
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.debugZone, ng.ZoneDistribution())
+		})
+	}
+}